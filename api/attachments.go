@@ -0,0 +1,126 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MaxAttachmentSize is the size of the biggest attachment which can be
+// created. Bigger attachments need an upload session, which isn't supported.
+const MaxAttachmentSize = 3 * 1024 * 1024
+
+// Attachment is a file attached to a task, based on https://docs.microsoft.com/en-us/graph/api/resources/taskfileattachment?view=graph-rest-1.0
+type Attachment struct {
+	Id                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	ContentType          string    `json:"contentType"`
+	Size                 int       `json:"size"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+
+	// ContentBytes is only got by GetAttachment
+	ContentBytes []byte `json:"contentBytes"`
+}
+
+type attachmentMarshal struct {
+	ODataType    string `json:"@odata.type"`
+	Name         string `json:"name"`
+	ContentType  string `json:"contentType"`
+	ContentBytes []byte `json:"contentBytes"`
+}
+
+// MarshalJSON only writes the fields which are sent to create an attachment
+func (a *Attachment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(attachmentMarshal{
+		ODataType:    "#microsoft.graph.taskFileAttachment",
+		Name:         a.Name,
+		ContentType:  a.ContentType,
+		ContentBytes: a.ContentBytes,
+	})
+}
+
+type attachmentsResponse struct {
+	Value    []Attachment `json:"value"`
+	NextLink string       `json:"@odata.nextLink"`
+}
+
+// GetAttachments gets the attachments of the task with the id taskId, without
+// their content
+func (c *Client) GetAttachments(ctx context.Context, listId string, taskId string) ([]Attachment, error) {
+	attachments := []Attachment{}
+
+	// Get each page
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/attachments", listId, taskId)
+	for url != "" {
+		resp, err := c.request(ctx).SetResult(&attachmentsResponse{}).Get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkResponse(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		page := resp.Result().(*attachmentsResponse)
+		attachments = append(attachments, page.Value...)
+		url = page.NextLink
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment gets the attachment with the id attachmentId, with its content
+func (c *Client) GetAttachment(ctx context.Context, listId string, taskId string, attachmentId string) (*Attachment, error) {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/attachments/%v", listId, taskId, attachmentId)
+
+	resp, err := c.request(ctx).SetResult(&Attachment{}).Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	return resp.Result().(*Attachment), nil
+}
+
+// CreateAttachment attaches the file to the task with the id taskId. It must be
+// no bigger than MaxAttachmentSize.
+func (c *Client) CreateAttachment(ctx context.Context, listId string, taskId string, attachment *Attachment) error {
+	if len(attachment.ContentBytes) > MaxAttachmentSize {
+		return fmt.Errorf("the attachment '%s' is bigger than 3 MB, which isn't supported", attachment.Name)
+	}
+
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/attachments", listId, taskId)
+	body, err := json.Marshal(attachment)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).Post(url)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(resp, http.StatusCreated)
+}
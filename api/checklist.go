@@ -0,0 +1,119 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ChecklistItem is a step of a task, based on https://docs.microsoft.com/en-us/graph/api/resources/checklistitem?view=graph-rest-1.0
+type ChecklistItem struct {
+	Id              string     `json:"id"`
+	DisplayName     string     `json:"displayName"`
+	IsChecked       bool       `json:"isChecked"`
+	CheckedDateTime *time.Time `json:"checkedDateTime"`
+	CreatedDateTime time.Time  `json:"createdDateTime"`
+}
+
+type checklistItemMarshal struct {
+	DisplayName string `json:"displayName"`
+	IsChecked   bool   `json:"isChecked"`
+}
+
+// MarshalJSON only writes the fields which can be changed
+func (item *ChecklistItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(checklistItemMarshal{DisplayName: item.DisplayName, IsChecked: item.IsChecked})
+}
+
+type checklistItemsResponse struct {
+	Value    []ChecklistItem `json:"value"`
+	NextLink string          `json:"@odata.nextLink"`
+}
+
+// GetChecklistItems gets the checklist items of the task with the id taskId
+func (c *Client) GetChecklistItems(ctx context.Context, listId string, taskId string) ([]ChecklistItem, error) {
+	items := []ChecklistItem{}
+
+	// Get each page
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/checklistItems", listId, taskId)
+	for url != "" {
+		resp, err := c.request(ctx).SetResult(&checklistItemsResponse{}).Get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkResponse(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		page := resp.Result().(*checklistItemsResponse)
+		items = append(items, page.Value...)
+		url = page.NextLink
+	}
+
+	return items, nil
+}
+
+// CreateChecklistItem adds item to the task with the id taskId
+func (c *Client) CreateChecklistItem(ctx context.Context, listId string, taskId string, item *ChecklistItem) error {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/checklistItems", listId, taskId)
+	body, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).Post(url)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(resp, http.StatusCreated)
+}
+
+// UpdateChecklistItem replaces the name and checked state of the checklist
+// item with the id itemId with item's
+func (c *Client) UpdateChecklistItem(ctx context.Context, listId string, taskId string, itemId string, item *ChecklistItem) error {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/checklistItems/%v", listId, taskId, itemId)
+	body, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).Patch(url)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(resp, http.StatusOK)
+}
+
+// DeleteChecklistItem deletes the checklist item with the id itemId
+func (c *Client) DeleteChecklistItem(ctx context.Context, listId string, taskId string, itemId string) error {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/checklistItems/%v", listId, taskId, itemId)
+
+	resp, err := c.request(ctx).Delete(url)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(resp, http.StatusNoContent)
+}
@@ -18,26 +18,80 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package api
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/dalyisaac/mstodo/auth"
+	"github.com/dalyisaac/mstodo/datetime"
 	"github.com/go-resty/resty/v2"
+	"golang.org/x/oauth2"
 )
 
-func GetClient() *resty.Client {
-	client := resty.New()
-	client.EnableTrace()
-	client.SetHostURL("https://graph.microsoft.com/v1.0")
+// Client is a Microsoft Graph client. It should be created once per command,
+// as the token is only loaded once and refreshed as needed.
+type Client struct {
+	rest *resty.Client
+
+	// timeZone is the Windows name of the time zone which Graph returns times
+	// in. If empty, Graph uses UTC.
+	timeZone string
+}
+
+// NewClient creates a client for the signed-in user of the configured cloud,
+// starting the login flow if required.
+func NewClient(ctx context.Context) (*Client, error) {
+	cloud, err := auth.CurrentCloud()
+	if err != nil {
+		return nil, err
+	}
+
+	tm, err := auth.GetTokenManager(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return client
+	return NewClientWithTokenSource(ctx, cloud.GraphURL, tm.TokenSource(ctx)), nil
 }
 
-func CreateRequest() (*resty.Request, error) {
-	client := GetClient()
+// NewClientWithSavedToken creates a client which uses the saved token, without
+// signing in. It returns auth.ErrNotSignedIn if there's no saved token.
+func NewClientWithSavedToken(ctx context.Context) (*Client, error) {
+	cloud, err := auth.CurrentCloud()
+	if err != nil {
+		return nil, err
+	}
 
-	token, err := auth.GetToken()
+	tm, err := auth.GetSavedTokenManager()
 	if err != nil {
 		return nil, err
 	}
 
-	request := client.R().SetAuthToken(token.AccessToken)
-	return request, nil
+	return NewClientWithTokenSource(ctx, cloud.GraphURL, tm.TokenSource(ctx)), nil
+}
+
+// NewClientWithTokenSource creates a client for the Graph API at baseURL (e.g.
+// https://graph.microsoft.com/v1.0), which authenticates using ts.
+func NewClientWithTokenSource(ctx context.Context, baseURL string, ts oauth2.TokenSource) *Client {
+	rest := resty.NewWithClient(oauth2.NewClient(ctx, ts))
+	rest.EnableTrace()
+	rest.SetHostURL(baseURL)
+
+	return &Client{rest: rest}
+}
+
+// SetTimeZone makes Graph return times in loc, using the Prefer:
+// outlook.timezone header. Zones without a Windows name are ignored, so times
+// are returned in UTC.
+func (c *Client) SetTimeZone(loc *time.Location) {
+	c.timeZone, _ = datetime.WindowsZone(loc)
+}
+
+// request creates a request which is cancelled when ctx is done
+func (c *Client) request(ctx context.Context) *resty.Request {
+	req := c.rest.R().SetContext(ctx)
+	if c.timeZone != "" {
+		req.SetHeader("Prefer", fmt.Sprintf("outlook.timezone=%q", c.timeZone))
+	}
+	return req
 }
@@ -0,0 +1,292 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"golang.org/x/oauth2"
+)
+
+func newTestClient(s *graphfake.Server) *Client {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})
+	return NewClientWithTokenSource(context.Background(), s.URL(), ts)
+}
+
+func TestClient_GetLists(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+	s.PageSize = 2
+
+	for i := 0; i < 4; i++ {
+		s.AddList(fmt.Sprintf("List %d", i))
+	}
+
+	lists, err := newTestClient(s).GetLists(context.Background())
+	if err != nil {
+		t.Fatalf("Client.GetLists() error = %v", err)
+	}
+
+	if len(*lists) != 5 {
+		t.Errorf("Client.GetLists() returned %v lists, want %v", len(*lists), 5)
+	}
+
+	if id, err := lists.GetListId("list 3"); err != nil || id == "" {
+		t.Errorf("GetListId() = %v, %v", id, err)
+	}
+}
+
+func TestClient_CreateList(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	list, err := newTestClient(s).CreateList(context.Background(), "Garden")
+	if err != nil {
+		t.Fatalf("Client.CreateList() error = %v", err)
+	}
+
+	if list.Id == "" || list.DisplayName != "Garden" || len(s.Lists()) != 2 {
+		t.Errorf("Client.CreateList() = %v, lists = %v", list, s.Lists())
+	}
+}
+
+func TestClient_CreateTask(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	task := &TodoTask{Title: "Pay rent", Importance: "high", Status: GraphStatus("not started")}
+	if err := client.CreateTask(ctx, s.DefaultListID(), task); err != nil {
+		t.Fatalf("Client.CreateTask() error = %v", err)
+	}
+	if task.Id == "" || task.CreatedDateTime.IsZero() {
+		t.Errorf("Client.CreateTask() should set the created task, got %+v", task)
+	}
+
+	tasks, err := client.GetTasks(ctx, s.DefaultListID())
+	if err != nil {
+		t.Fatalf("Client.GetTasks() error = %v", err)
+	}
+
+	if len(*tasks) != 1 || (*tasks)[0].Title != "Pay rent" || (*tasks)[0].Status != "not started" {
+		t.Errorf("Client.GetTasks() = %v", *tasks)
+	}
+}
+
+func TestClient_UpdateTask(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	id, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pay rent", "categories": []interface{}{"finance"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &TodoTask{Title: "Pay the rent", Importance: "high", Status: GraphStatus("completed")}
+	if err := client.UpdateTask(ctx, s.DefaultListID(), id, task); err != nil {
+		t.Fatalf("Client.UpdateTask() error = %v", err)
+	}
+
+	tasks, err := client.GetTasks(ctx, s.DefaultListID())
+	if err != nil {
+		t.Fatalf("Client.GetTasks() error = %v", err)
+	}
+
+	if len(*tasks) != 1 || (*tasks)[0].Title != "Pay the rent" || (*tasks)[0].Status != "completed" || (*tasks)[0].Completed == nil {
+		t.Errorf("Client.GetTasks() = %v", *tasks)
+	}
+	if len(*tasks) == 1 && len((*tasks)[0].Categories) != 0 {
+		t.Errorf("Client.UpdateTask() without categories kept %v, want them cleared", (*tasks)[0].Categories)
+	}
+
+	if err := client.DeleteTask(ctx, s.DefaultListID(), id); err != nil {
+		t.Fatalf("Client.DeleteTask() error = %v", err)
+	}
+
+	if tasks, err := client.GetTasks(ctx, s.DefaultListID()); err != nil || len(*tasks) != 0 {
+		t.Errorf("Client.GetTasks() after delete = %v, %v", tasks, err)
+	}
+
+	if err := client.DeleteTask(ctx, s.DefaultListID(), id); err == nil {
+		t.Errorf("Client.DeleteTask() of a deleted task should fail")
+	}
+}
+
+func TestClient_ChecklistItems(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	taskId, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Passport", "Tickets"} {
+		if err := client.CreateChecklistItem(ctx, s.DefaultListID(), taskId, &ChecklistItem{DisplayName: name}); err != nil {
+			t.Fatalf("Client.CreateChecklistItem() error = %v", err)
+		}
+	}
+
+	items, err := client.GetChecklistItems(ctx, s.DefaultListID(), taskId)
+	if err != nil || len(items) != 2 || items[0].DisplayName != "Passport" || items[0].IsChecked {
+		t.Fatalf("Client.GetChecklistItems() = %v, %v", items, err)
+	}
+
+	item := items[0]
+	item.IsChecked = true
+	if err := client.UpdateChecklistItem(ctx, s.DefaultListID(), taskId, item.Id, &item); err != nil {
+		t.Fatalf("Client.UpdateChecklistItem() error = %v", err)
+	}
+	if err := client.DeleteChecklistItem(ctx, s.DefaultListID(), taskId, items[1].Id); err != nil {
+		t.Fatalf("Client.DeleteChecklistItem() error = %v", err)
+	}
+
+	items, err = client.GetChecklistItems(ctx, s.DefaultListID(), taskId)
+	if err != nil || len(items) != 1 || !items[0].IsChecked || items[0].CheckedDateTime == nil {
+		t.Errorf("Client.GetChecklistItems() after update = %v, %v", items, err)
+	}
+}
+
+func TestClient_LinkedResources(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	taskId, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Reply to Sam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource := &LinkedResource{WebUrl: "https://outlook.office.com/mail/1", ApplicationName: "Outlook", DisplayName: "Re: invoice"}
+	if err := client.CreateLinkedResource(ctx, s.DefaultListID(), taskId, resource); err != nil {
+		t.Fatalf("Client.CreateLinkedResource() error = %v", err)
+	}
+
+	resources, err := client.GetLinkedResources(ctx, s.DefaultListID(), taskId)
+	if err != nil || len(resources) != 1 || resources[0].Id == "" || resources[0].WebUrl != resource.WebUrl || resources[0].ApplicationName != "Outlook" {
+		t.Errorf("Client.GetLinkedResources() = %v, %v", resources, err)
+	}
+}
+
+func TestClient_Attachments(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	taskId, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pay rent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachment := &Attachment{Name: "lease.txt", ContentType: "text/plain", ContentBytes: []byte("lease")}
+	if err := client.CreateAttachment(ctx, s.DefaultListID(), taskId, attachment); err != nil {
+		t.Fatalf("Client.CreateAttachment() error = %v", err)
+	}
+
+	attachments, err := client.GetAttachments(ctx, s.DefaultListID(), taskId)
+	if err != nil || len(attachments) != 1 || attachments[0].Name != "lease.txt" || attachments[0].Size != 5 || attachments[0].ContentBytes != nil {
+		t.Fatalf("Client.GetAttachments() = %v, %v", attachments, err)
+	}
+
+	got, err := client.GetAttachment(ctx, s.DefaultListID(), taskId, attachments[0].Id)
+	if err != nil || string(got.ContentBytes) != "lease" || got.ContentType != "text/plain" {
+		t.Errorf("Client.GetAttachment() = %v, %v", got, err)
+	}
+
+	tasks, err := client.GetTasks(ctx, s.DefaultListID())
+	if err != nil || len(*tasks) != 1 || !(*tasks)[0].HasAttachments {
+		t.Errorf("Client.GetTasks() = %v, %v, want a task with attachments", tasks, err)
+	}
+
+	big := &Attachment{Name: "big.bin", ContentBytes: make([]byte, MaxAttachmentSize+1)}
+	if err := client.CreateAttachment(ctx, s.DefaultListID(), taskId, big); err == nil {
+		t.Error("Client.CreateAttachment() of a big attachment should fail")
+	}
+}
+
+func TestClient_errors(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	_, err := client.GetTasks(ctx, "missing")
+	graphErr := &GraphError{}
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusNotFound || graphErr.Code != graphfake.CodeItemNotFound {
+		t.Errorf("Client.GetTasks() error = %v, want a 404 GraphError", err)
+	}
+
+	s.Throttle(1, 0)
+	_, err = client.GetLists(ctx)
+	if !errors.As(err, &graphErr) || graphErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Client.GetLists() error = %v, want a 429 GraphError", err)
+	}
+}
+
+func TestClient_timeZone(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+	s.MailboxTimeZone = "W. Europe Standard Time"
+
+	client := newTestClient(s)
+	ctx := context.Background()
+
+	name, err := client.GetMailboxTimeZone(ctx)
+	if err != nil || name != "W. Europe Standard Time" {
+		t.Fatalf("Client.GetMailboxTimeZone() = %v, %v", name, err)
+	}
+
+	if _, err := s.AddTask(s.DefaultListID(), graphfake.Object{
+		"title":       "Pay rent",
+		"dueDateTime": graphfake.Object{"dateTime": "2021-07-06T22:00:00.0000000", "timeZone": "UTC"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	client.SetTimeZone(loc)
+
+	tasks, err := client.GetTasks(ctx, s.DefaultListID())
+	if err != nil {
+		t.Fatalf("Client.GetTasks() error = %v", err)
+	}
+
+	due := time.Time(*(*tasks)[0].DueDateTime)
+	if due.Location().String() != "Europe/Berlin" || due.Day() != 7 || due.Hour() != 0 {
+		t.Errorf("Client.GetTasks() due date = %v, want midnight on the 7th in Europe/Berlin", due)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// GraphError is the error returned by Microsoft Graph, as per
+// https://docs.microsoft.com/en-us/graph/errors
+type GraphError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("http code %v: %v: %v", e.StatusCode, e.Code, e.Message)
+}
+
+type graphErrorResponse struct {
+	Error GraphError `json:"error"`
+}
+
+// checkResponse returns a *GraphError if resp doesn't have the expected status
+// code
+func checkResponse(resp *resty.Response, expected int) error {
+	code := resp.StatusCode()
+	if code == expected {
+		return nil
+	}
+
+	body := graphErrorResponse{}
+	if err := json.Unmarshal(resp.Body(), &body); err != nil || body.Error.Code == "" {
+		return &GraphError{StatusCode: code, Code: "unknown", Message: string(resp.Body())}
+	}
+
+	body.Error.StatusCode = code
+	return &body.Error
+}
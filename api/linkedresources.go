@@ -0,0 +1,92 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// LinkedResource is an item in another app which a task was created from,
+// based on https://docs.microsoft.com/en-us/graph/api/resources/linkedresource?view=graph-rest-1.0
+type LinkedResource struct {
+	Id              string `json:"id"`
+	WebUrl          string `json:"webUrl"`
+	ApplicationName string `json:"applicationName"`
+	DisplayName     string `json:"displayName"`
+	ExternalId      string `json:"externalId"`
+}
+
+type linkedResourceMarshal struct {
+	WebUrl          string `json:"webUrl"`
+	ApplicationName string `json:"applicationName"`
+	DisplayName     string `json:"displayName"`
+	ExternalId      string `json:"externalId,omitempty"`
+}
+
+// MarshalJSON only writes the fields which can be changed
+func (r *LinkedResource) MarshalJSON() ([]byte, error) {
+	return json.Marshal(linkedResourceMarshal{WebUrl: r.WebUrl, ApplicationName: r.ApplicationName, DisplayName: r.DisplayName, ExternalId: r.ExternalId})
+}
+
+type linkedResourcesResponse struct {
+	Value    []LinkedResource `json:"value"`
+	NextLink string           `json:"@odata.nextLink"`
+}
+
+// GetLinkedResources gets the linked resources of the task with the id taskId
+func (c *Client) GetLinkedResources(ctx context.Context, listId string, taskId string) ([]LinkedResource, error) {
+	resources := []LinkedResource{}
+
+	// Get each page
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/linkedResources", listId, taskId)
+	for url != "" {
+		resp, err := c.request(ctx).SetResult(&linkedResourcesResponse{}).Get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkResponse(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		page := resp.Result().(*linkedResourcesResponse)
+		resources = append(resources, page.Value...)
+		url = page.NextLink
+	}
+
+	return resources, nil
+}
+
+// CreateLinkedResource adds resource to the task with the id taskId
+func (c *Client) CreateLinkedResource(ctx context.Context, listId string, taskId string, resource *LinkedResource) error {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v/linkedResources", listId, taskId)
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).Post(url)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(resp, http.StatusCreated)
+}
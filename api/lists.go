@@ -18,7 +18,9 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package api
 
 import (
+	"context"
 	"errors"
+	"net/http"
 	"strings"
 )
 
@@ -46,7 +48,8 @@ type TodoTaskListItem struct {
 type TodoTaskListList []TodoTaskListItem
 
 type todoTaskListListResponse struct {
-	Value TodoTaskListList `json:"value"`
+	Value    TodoTaskListList `json:"value"`
+	NextLink string           `json:"@odata.nextLink"`
 }
 
 func (l *TodoTaskListList) GetListId(name string) (string, error) {
@@ -60,19 +63,41 @@ func (l *TodoTaskListList) GetListId(name string) (string, error) {
 	return "", errors.New("could not find name '" + name + "'")
 }
 
-func GetLists() (*TodoTaskListList, error) {
-	// Create request
-	req, err := CreateRequest()
+func (c *Client) GetLists(ctx context.Context) (*TodoTaskListList, error) {
+	lists := TodoTaskListList{}
+
+	// Get each page
+	url := "/me/todo/lists"
+	for url != "" {
+		resp, err := c.request(ctx).SetResult(&todoTaskListListResponse{}).Get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkResponse(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		page := resp.Result().(*todoTaskListListResponse)
+		lists = append(lists, page.Value...)
+		url = page.NextLink
+	}
+
+	return &lists, nil
+}
+
+// CreateList creates a task list called displayName, and returns it
+func (c *Client) CreateList(ctx context.Context, displayName string) (*TodoTaskListItem, error) {
+	body := map[string]string{"displayName": displayName}
+
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).SetResult(&TodoTaskListItem{}).Post("/me/todo/lists")
 	if err != nil {
 		return nil, err
 	}
 
-	// Get request
-	resp, err := req.SetResult(&todoTaskListListResponse{}).Get("/me/todo/lists")
-	if err != nil {
+	if err := checkResponse(resp, http.StatusCreated); err != nil {
 		return nil, err
 	}
 
-	lists := resp.Result().(*todoTaskListListResponse).Value
-	return &lists, nil
+	return resp.Result().(*TodoTaskListItem), nil
 }
@@ -0,0 +1,43 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+type mailboxTimeZoneResponse struct {
+	Value string `json:"value"`
+}
+
+// GetMailboxTimeZone returns the time zone of the user's mailbox, which is
+// usually a Windows name like "Pacific Standard Time". This requires the
+// MailboxSettings.Read scope.
+func (c *Client) GetMailboxTimeZone(ctx context.Context) (string, error) {
+	resp, err := c.request(ctx).SetResult(&mailboxTimeZoneResponse{}).Get("/me/mailboxSettings/timeZone")
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+
+	return resp.Result().(*mailboxTimeZoneResponse).Value, nil
+}
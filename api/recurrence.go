@@ -0,0 +1,72 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package api
+
+// PatternedRecurrence based on https://docs.microsoft.com/en-us/graph/api/resources/patternedrecurrence?view=graph-rest-1.0
+type PatternedRecurrence struct {
+	Pattern RecurrencePattern `json:"pattern"`
+	Range   RecurrenceRange   `json:"range"`
+}
+
+// Recurrence pattern types
+const (
+	DailyRecurrence           = "daily"
+	WeeklyRecurrence          = "weekly"
+	AbsoluteMonthlyRecurrence = "absoluteMonthly"
+	AbsoluteYearlyRecurrence  = "absoluteYearly"
+)
+
+// RecurrencePattern is how often the task repeats
+type RecurrencePattern struct {
+	// Type is one of the recurrence pattern types, e.g. "weekly"
+	Type string `json:"type"`
+
+	// Interval is the number of units between occurrences
+	Interval int `json:"interval"`
+
+	// DaysOfWeek are the lower-case weekdays of a weekly pattern, e.g. "monday"
+	DaysOfWeek []string `json:"daysOfWeek,omitempty"`
+
+	// DayOfMonth is the day of the month of monthly and yearly patterns
+	DayOfMonth int `json:"dayOfMonth,omitempty"`
+
+	// Month is the month of yearly patterns, from 1 to 12
+	Month int `json:"month,omitempty"`
+
+	// FirstDayOfWeek is the first day of the week of weekly patterns
+	FirstDayOfWeek string `json:"firstDayOfWeek,omitempty"`
+
+	// Index is the week of the month of relative monthly and yearly patterns,
+	// e.g. "first" or "last"
+	Index string `json:"index,omitempty"`
+}
+
+// RecurrenceRange is when the task starts and stops repeating
+type RecurrenceRange struct {
+	// Type is "noEnd", "endDate" or "numbered"
+	Type string `json:"type"`
+
+	// StartDate is the first occurrence, e.g. "2021-07-07"
+	StartDate string `json:"startDate"`
+
+	// EndDate is the last day of "endDate" ranges, e.g. "2021-12-31"
+	EndDate string `json:"endDate,omitempty"`
+
+	// NumberOfOccurrences is the number of times "numbered" ranges repeat
+	NumberOfOccurrences int `json:"numberOfOccurrences,omitempty"`
+}
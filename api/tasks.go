@@ -18,24 +18,50 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/dalyisaac/mstodo/datetime"
 )
 
 type TodoTask struct {
-	Id                   string              `json:"id"`
-	Title                string              `json:"title"`
-	Importance           string              `json:"importance"`
-	IsReminderOn         bool                `json:"isReminderOn"`
-	Status               GraphStatus         `json:"status"`
-	ReminderDateTime     *datetime.GraphTime `json:"reminderDateTime"`
-	DueDateTime          *datetime.GraphTime `json:"dueDateTime"`
-	Completed            *datetime.GraphTime `json:"completedDateTime"`
-	CreatedDateTime      time.Time           `json:"createdDateTime"`
-	LastModifiedDateTime time.Time           `json:"lastModifiedDateTime"`
+	Id                   string               `json:"id"`
+	Title                string               `json:"title"`
+	Importance           string               `json:"importance"`
+	IsReminderOn         bool                 `json:"isReminderOn"`
+	Status               GraphStatus          `json:"status"`
+	ReminderDateTime     *datetime.GraphTime  `json:"reminderDateTime"`
+	DueDateTime          *datetime.GraphTime  `json:"dueDateTime"`
+	StartDateTime        *datetime.GraphTime  `json:"startDateTime"`
+	Completed            *datetime.GraphTime  `json:"completedDateTime"`
+	CreatedDateTime      time.Time            `json:"createdDateTime"`
+	LastModifiedDateTime time.Time            `json:"lastModifiedDateTime"`
+	Categories           []string             `json:"categories"`
+	Recurrence           *PatternedRecurrence `json:"recurrence"`
+	Body                 *ItemBody            `json:"body"`
+	HasAttachments       bool                 `json:"hasAttachments"`
+}
+
+// ItemBody is the notes of a task
+type ItemBody struct {
+	Content string `json:"content"`
+
+	// ContentType is "text" or "html"
+	ContentType string `json:"contentType"`
+}
+
+// In returns a copy of t with its times in loc, for showing them to the user
+func (t TodoTask) In(loc *time.Location) TodoTask {
+	t.ReminderDateTime = t.ReminderDateTime.In(loc)
+	t.DueDateTime = t.DueDateTime.In(loc)
+	t.StartDateTime = t.StartDateTime.In(loc)
+	t.Completed = t.Completed.In(loc)
+	t.CreatedDateTime = t.CreatedDateTime.In(loc)
+	t.LastModifiedDateTime = t.LastModifiedDateTime.In(loc)
+	return t
 }
 
 type todoTaskMarshal struct {
@@ -45,11 +71,23 @@ type todoTaskMarshal struct {
 	Status           string                     `json:"status"`
 	ReminderDateTime *datetime.GraphTimeMarshal `json:"reminderDateTime"`
 	DueDateTime      *datetime.GraphTimeMarshal `json:"dueDateTime"`
+	StartDateTime    *datetime.GraphTimeMarshal `json:"startDateTime"`
+	Completed        *datetime.GraphTimeMarshal `json:"completedDateTime,omitempty"`
+	Categories       *[]string                  `json:"categories,omitempty"`
+	Recurrence       *PatternedRecurrence       `json:"recurrence"`
+	Body             *ItemBody                  `json:"body,omitempty"`
 }
 
 func (t *TodoTask) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.marshal())
+}
+
+// marshal returns the fields which are sent to Graph. The categories are left
+// out when there are none.
+func (t *TodoTask) marshal() todoTaskMarshal {
 	var reminderDateTime *datetime.GraphTimeMarshal = nil
 	var dueDateTime *datetime.GraphTimeMarshal = nil
+	var startDateTime *datetime.GraphTimeMarshal = nil
 
 	if t.ReminderDateTime != nil {
 		reminderDateTime = t.ReminderDateTime.Marshal()
@@ -59,6 +97,16 @@ func (t *TodoTask) MarshalJSON() ([]byte, error) {
 		dueDateTime = t.DueDateTime.Marshal()
 	}
 
+	if t.StartDateTime != nil {
+		startDateTime = t.StartDateTime.Marshal()
+	}
+
+	// The completed date is only kept by Graph for completed tasks
+	var completed *datetime.GraphTimeMarshal = nil
+	if t.Completed != nil && t.Status == "completed" {
+		completed = t.Completed.Marshal()
+	}
+
 	marshal := todoTaskMarshal{
 		Title:            t.Title,
 		Importance:       t.Importance,
@@ -66,59 +114,97 @@ func (t *TodoTask) MarshalJSON() ([]byte, error) {
 		Status:           t.Status.Marshal(),
 		ReminderDateTime: reminderDateTime,
 		DueDateTime:      dueDateTime,
+		StartDateTime:    startDateTime,
+		Completed:        completed,
+		Recurrence:       t.Recurrence,
+		Body:             t.Body,
 	}
 
-	return json.Marshal(marshal)
+	if len(t.Categories) != 0 {
+		marshal.Categories = &t.Categories
+	}
+
+	return marshal
 }
 
 type TodoTaskList []TodoTask
 
 type todoTaskListResponse struct {
-	Value TodoTaskList `json:"value"`
+	Value    TodoTaskList `json:"value"`
+	NextLink string       `json:"@odata.nextLink"`
 }
 
-func GetTasks(listId string) (*TodoTaskList, error) {
-	// Create request
-	req, err := CreateRequest()
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) GetTasks(ctx context.Context, listId string) (*TodoTaskList, error) {
+	tasks := TodoTaskList{}
 
-	// Get request
+	// Get each page
 	url := fmt.Sprintf("/me/todo/lists/%v/tasks", listId)
-	resp, err := req.SetResult(&todoTaskListResponse{}).Get(url)
-	if err != nil {
-		return nil, err
+	for url != "" {
+		resp, err := c.request(ctx).SetResult(&todoTaskListResponse{}).Get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkResponse(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		page := resp.Result().(*todoTaskListResponse)
+		tasks = append(tasks, page.Value...)
+		url = page.NextLink
 	}
 
-	tasks := resp.Result().(*todoTaskListResponse).Value
 	return &tasks, nil
 }
 
-func CreateTask(listId string, task *TodoTask) error {
-	// Create request
-	req, err := CreateRequest()
+// CreateTask creates the task, and sets task to the created task, with its ID
+func (c *Client) CreateTask(ctx context.Context, listId string, task *TodoTask) error {
+	// Post request
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks", listId)
+	body, err := json.Marshal(&task)
 	if err != nil {
 		return err
 	}
 
-	// Post request
-	url := fmt.Sprintf("/me/todo/lists/%v/tasks", listId)
-	body, err := json.Marshal(&task)
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).SetResult(task).Post(url)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(string(body))
+	return checkResponse(resp, http.StatusCreated)
+}
 
-	resp, err := req.SetHeader("Content-Type", "application/json").SetBody(body).Post(url)
+// UpdateTask replaces the fields of the task with the id taskId with task's
+func (c *Client) UpdateTask(ctx context.Context, listId string, taskId string, task *TodoTask) error {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v", listId, taskId)
+
+	// The categories are always sent as [], so that they're cleared
+	marshal := task.marshal()
+	if marshal.Categories == nil {
+		marshal.Categories = &[]string{}
+	}
+
+	body, err := json.Marshal(marshal)
 	if err != nil {
 		return err
 	}
 
-	if code := resp.StatusCode(); code != 201 {
-		return fmt.Errorf("http code %v\n%v", code, string(resp.Body()))
+	resp, err := c.request(ctx).SetHeader("Content-Type", "application/json").SetBody(body).Patch(url)
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(resp, http.StatusOK)
+}
+
+// DeleteTask deletes the task with the id taskId
+func (c *Client) DeleteTask(ctx context.Context, listId string, taskId string) error {
+	url := fmt.Sprintf("/me/todo/lists/%v/tasks/%v", listId, taskId)
+
+	resp, err := c.request(ctx).Delete(url)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return checkResponse(resp, http.StatusNoContent)
 }
@@ -0,0 +1,149 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+// Cloud is a Microsoft identity platform and Microsoft Graph deployment, as per
+// https://docs.microsoft.com/en-us/graph/deployments
+type Cloud struct {
+	// Name is the value used for the cloud config option
+	Name string
+
+	// AuthorityURL is the identity platform authority, including the tenant
+	AuthorityURL string
+
+	// GraphURL is the Microsoft Graph base URL, including the version
+	GraphURL string
+}
+
+const GlobalCloud = "global"
+
+// globalGraphHost is the host of the global Microsoft Graph, whose scopes
+// don't need to be qualified
+const globalGraphHost = "graph.microsoft.com"
+
+var clouds = []Cloud{
+	{Name: GlobalCloud, AuthorityURL: "https://login.microsoftonline.com/common", GraphURL: "https://graph.microsoft.com/v1.0"},
+	{Name: "usgov-l4", AuthorityURL: "https://login.microsoftonline.us/common", GraphURL: "https://graph.microsoft.us/v1.0"},
+	{Name: "usgov-l5", AuthorityURL: "https://login.microsoftonline.us/common", GraphURL: "https://dod-graph.microsoft.us/v1.0"},
+	{Name: "china", AuthorityURL: "https://login.chinacloudapi.cn/common", GraphURL: "https://microsoftgraph.chinacloudapi.cn/v1.0"},
+	{Name: "germany", AuthorityURL: "https://login.microsoftonline.de/common", GraphURL: "https://graph.microsoft.de/v1.0"},
+}
+
+// CloudNames returns the valid values for the cloud config option
+func CloudNames() []string {
+	names := []string{}
+	for _, c := range clouds {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// GetCloud returns the cloud with the given name
+func GetCloud(name string) (*Cloud, error) {
+	name = strings.ToLower(name)
+	for _, c := range clouds {
+		if c.Name == name {
+			cloud := c
+			return &cloud, nil
+		}
+	}
+
+	return nil, fmt.Errorf("'%s' is not a valid cloud - choices: [%s]", name, strings.Join(CloudNames(), ", "))
+}
+
+// CurrentCloud returns the configured cloud, with the authority-url and
+// graph-url overrides applied.
+func CurrentCloud() (*Cloud, error) {
+	name := viper.GetString("cloud")
+	if name == "" {
+		name = GlobalCloud
+	}
+
+	cloud, err := GetCloud(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if authorityURL := viper.GetString("authority-url"); authorityURL != "" {
+		if err := ValidateEndpointURL(authorityURL); err != nil {
+			return nil, fmt.Errorf("authority-url: %w", err)
+		}
+		cloud.AuthorityURL = authorityURL
+	}
+
+	if graphURL := viper.GetString("graph-url"); graphURL != "" {
+		if err := ValidateEndpointURL(graphURL); err != nil {
+			return nil, fmt.Errorf("graph-url: %w", err)
+		}
+		cloud.GraphURL = graphURL
+	}
+
+	cloud.AuthorityURL = strings.TrimRight(cloud.AuthorityURL, "/")
+	cloud.GraphURL = strings.TrimRight(cloud.GraphURL, "/")
+	return cloud, nil
+}
+
+// ValidateEndpointURL checks that s is an absolute http or https URL
+func ValidateEndpointURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("'%s' must be an absolute http or https URL", s)
+	}
+
+	return nil
+}
+
+// Endpoint returns the OAuth2 endpoint for the cloud's authority
+func (c *Cloud) Endpoint() oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  c.AuthorityURL + "/oauth2/v2.0/authorize",
+		TokenURL: c.AuthorityURL + "/oauth2/v2.0/token",
+	}
+}
+
+// Scopes returns the scopes to request. Unless the Graph URL in use, which
+// includes the graph-url override, is the global Microsoft Graph, the Graph
+// scopes are qualified with its resource.
+func (c *Cloud) Scopes() []string {
+	scopes := getScopes()
+
+	graph, err := url.Parse(c.GraphURL)
+	if err != nil || graph.Host == globalGraphHost {
+		return scopes
+	}
+
+	for i, s := range scopes {
+		if s != "offline_access" {
+			scopes[i] = fmt.Sprintf("%s://%s/%s", graph.Scheme, graph.Host, s)
+		}
+	}
+	return scopes
+}
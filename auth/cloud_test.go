@@ -0,0 +1,89 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestCurrentCloud(t *testing.T) {
+	tests := []struct {
+		name         string
+		cloud        string
+		authorityURL string
+		graphURL     string
+		want         *Cloud
+		wantErr      bool
+	}{
+		{name: "default", want: &Cloud{Name: "global", AuthorityURL: "https://login.microsoftonline.com/common", GraphURL: "https://graph.microsoft.com/v1.0"}},
+		{name: "us gov l5", cloud: "USGov-L5", want: &Cloud{Name: "usgov-l5", AuthorityURL: "https://login.microsoftonline.us/common", GraphURL: "https://dod-graph.microsoft.us/v1.0"}},
+		{name: "china", cloud: "china", want: &Cloud{Name: "china", AuthorityURL: "https://login.chinacloudapi.cn/common", GraphURL: "https://microsoftgraph.chinacloudapi.cn/v1.0"}},
+		{name: "overrides", cloud: "global", authorityURL: "http://127.0.0.1:8080/tenant/", graphURL: "http://127.0.0.1:8081/v1.0/", want: &Cloud{Name: "global", AuthorityURL: "http://127.0.0.1:8080/tenant", GraphURL: "http://127.0.0.1:8081/v1.0"}},
+		{name: "invalid cloud", cloud: "mars", wantErr: true},
+		{name: "invalid graph url", graphURL: "graph.microsoft.com", wantErr: true},
+		{name: "invalid authority url", authorityURL: "ftp://login.microsoftonline.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("cloud", tt.cloud)
+			viper.Set("authority-url", tt.authorityURL)
+			viper.Set("graph-url", tt.graphURL)
+			defer viper.Reset()
+
+			got, err := CurrentCloud()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CurrentCloud() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CurrentCloud() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloud_Scopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		cloud    string
+		graphURL string
+		want     []string
+	}{
+		{name: "global", cloud: "global", want: []string{"offline_access", "Tasks.ReadWrite", "MailboxSettings.Read"}},
+		{name: "us gov l4", cloud: "usgov-l4", want: []string{"offline_access", "https://graph.microsoft.us/Tasks.ReadWrite", "https://graph.microsoft.us/MailboxSettings.Read"}},
+		{name: "global with graph-url", cloud: "global", graphURL: "http://127.0.0.1:8080/v1.0", want: []string{"offline_access", "http://127.0.0.1:8080/Tasks.ReadWrite", "http://127.0.0.1:8080/MailboxSettings.Read"}},
+		{name: "china with graph-url", cloud: "china", graphURL: "https://graph.microsoft.com/beta", want: []string{"offline_access", "Tasks.ReadWrite", "MailboxSettings.Read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := GetCloud(tt.cloud)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.graphURL != "" {
+				c.GraphURL = tt.graphURL
+			}
+			if got := c.Scopes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cloud.Scopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
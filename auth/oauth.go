@@ -19,10 +19,11 @@ package auth
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
+	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -33,115 +34,224 @@ import (
 	"golang.org/x/oauth2"
 )
 
-type AuthorizedClient struct {
-	*http.Client
-	Token *oauth2.Token
-}
+const (
+	loopbackHost = "127.0.0.1"
+	callbackPath = "/oauth/callback"
+)
 
-type oauthStateStringContextKeyType int
+var (
+	ErrStateMismatch = errors.New("oauth state mismatch")
+	ErrMissingCode   = errors.New("authorization code missing from callback")
+	ErrAuthTimeout   = errors.New("authentication timed out and was cancelled")
+)
 
-const (
-	oauthStateStringContextKey oauthStateStringContextKeyType = 987
+// OAuthError is an error returned by the authorization server to the
+// redirect URI, as per https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.2.1
+type OAuthError struct {
+	// Code is the error code, e.g. "access_denied"
+	Code string
+
+	// Description is the human-readable error_description, if any
+	Description string
+
+	// URI is the error_uri, if any
+	URI string
+}
+
+// Well-known OAuth error codes, for use with errors.Is
+var (
+	ErrAccessDenied           = &OAuthError{Code: "access_denied"}
+	ErrInvalidRequest         = &OAuthError{Code: "invalid_request"}
+	ErrUnauthorizedClient     = &OAuthError{Code: "unauthorized_client"}
+	ErrInvalidScope           = &OAuthError{Code: "invalid_scope"}
+	ErrServerError            = &OAuthError{Code: "server_error"}
+	ErrTemporarilyUnavailable = &OAuthError{Code: "temporarily_unavailable"}
 )
 
-// authenticateUser starts the login process
-func authenticateUser(config *oauth2.Config, port int, authTimeout int) (*AuthorizedClient, error) {
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return "oauth error: " + e.Code
+	}
+	return fmt.Sprintf("oauth error: %s: %s", e.Code, e.Description)
+}
+
+// Is reports whether target is an *OAuthError with the same code
+func (e *OAuthError) Is(target error) bool {
+	t, ok := target.(*OAuthError)
+	return ok && t.Code == e.Code
+}
+
+// callbackResult is sent from the callback handler to authenticateUser
+type callbackResult struct {
+	token *oauth2.Token
+	err   error
+}
+
+// authenticateUser starts the login process. If port is 0, an ephemeral port
+// is chosen.
+func authenticateUser(ctx context.Context, config *oauth2.Config, port int, authTimeout int) (*oauth2.Token, error) {
 	// validate config
 	if config == nil {
 		return nil, errors.New("OAuth2 config was unexpectedly nil")
 	}
 
-	// http transport for self-signed certificate, to be added to the context
-	httpTransport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	sslClient := &http.Client{Transport: httpTransport}
-	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, sslClient)
-
-	// redirect the user to the consent page to ask permission for the scopes
-	config.RedirectURL = fmt.Sprintf("http://localhost:%s/oauth/callback", strconv.Itoa(port))
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(authTimeout)*time.Second)
+	defer cancel()
 
 	// some random string, used for getting the AuthCodeURL
 	oauthStateString := rndm.String(8)
-	ctx = context.WithValue(ctx, oauthStateStringContextKey, oauthStateString)
-	url := config.AuthCodeURL(oauthStateString, oauth2.AccessTypeOffline)
-
-	clientChan, stopHTTPServerChan, cancelAuthentication := startHTTPServer(ctx, config, port)
-	log.Println(color.CyanString("You will now be taken to your browser for authentication or open the url below in a browser:"))
-	log.Println(color.CyanString(url))
 
-	err := open.Run(url)
+	listener, err := net.Listen("tcp", net.JoinHostPort(loopbackHost, strconv.Itoa(port)))
 	if err != nil {
-		log.Println("Failed to open URL")
-		return nil, err
+		return nil, fmt.Errorf("could not start the auth server: %w", err)
 	}
 
-	// shutdown the server after timeout
+	// The registered redirect URI is http://127.0.0.1/oauth/callback, and Azure
+	// AD ignores the port for loopback redirect URIs. The redirect must use the
+	// address the listener is bound to, since localhost may resolve to ::1.
+	port = listener.Addr().(*net.TCPAddr).Port
+	config.RedirectURL = fmt.Sprintf("http://%s%s", net.JoinHostPort(loopbackHost, strconv.Itoa(port)), callbackPath)
+
+	results := make(chan callbackResult, 1)
+	srv := &http.Server{Handler: newCallbackMux(ctx, config, oauthStateString, results)}
 	go func() {
-		log.Printf("Authentication will be cancelled in %s seconds", strconv.Itoa(authTimeout))
-		time.Sleep(time.Duration(authTimeout) * time.Second)
-		stopHTTPServerChan <- struct{}{}
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			sendResult(results, callbackResult{err: fmt.Errorf("auth server failed: %w", err)})
+		}
 	}()
+	defer shutdownServer(srv)
+
+	// redirect the user to the consent page to ask permission for the scopes
+	url := config.AuthCodeURL(oauthStateString, oauth2.AccessTypeOffline)
+	log.Println(color.CyanString("You will now be taken to your browser for authentication or open the url below in a browser:"))
+	log.Println(color.CyanString(url))
+
+	if err := open.Run(url); err != nil {
+		log.Println(color.YellowString("Failed to open the browser: %v", err))
+	}
+
+	log.Printf("Authentication will be cancelled in %s seconds", strconv.Itoa(authTimeout))
 
 	select {
-	// wait for client on clientChan
-	case client := <-clientChan:
-		// after the callbackHandler returns a client, shutdown the server gracefully
-		stopHTTPServerChan <- struct{}{}
-		return client, nil
-
-	case <-cancelAuthentication:
-		// if authentication process is cancelled first, return an error
-		return nil, errors.New("authentication timed out and was cancelled")
+	case res := <-results:
+		return res.token, res.err
+
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrAuthTimeout
+		}
+		return nil, ctx.Err()
 	}
 }
 
-func startHTTPServer(ctx context.Context, conf *oauth2.Config, port int) (clientChan chan *AuthorizedClient, stopHTTPServerChan chan struct{}, cancelAuthentication chan struct{}) {
-	// init returns
-	clientChan = make(chan *AuthorizedClient)
-	stopHTTPServerChan = make(chan struct{})
-	cancelAuthentication = make(chan struct{})
+// shutdownServer gives the server 5 seconds to shutdown gracefully
+func shutdownServer(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf(color.RedString("Auth server could not shutdown gracefully: %v"), err)
+	}
+}
 
-	http.HandleFunc("/oauth/callback", callbackHandler(ctx, conf, clientChan))
-	srv := &http.Server{Addr: ":" + strconv.Itoa(port)}
+// sendResult sends res without blocking, so that repeated callbacks are ignored
+func sendResult(results chan<- callbackResult, res callbackResult) {
+	select {
+	case results <- res:
+	default:
+	}
+}
 
-	// handle server shutdown signal
-	go func() {
-		// wait for signal on stopHTTPServerChan
-		<-stopHTTPServerChan
-		log.Println("Shutting down server...")
+func newCallbackMux(ctx context.Context, config *oauth2.Config, state string, results chan<- callbackResult) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, callbackHandler(ctx, config, state, results))
+	return mux
+}
 
-		// give it 5 sec to shutdown gracefully, else quit program
-		d := time.Now().Add(5 * time.Second)
-		ctx, cancel := context.WithDeadline(context.Background(), d)
-		defer cancel()
+// callbackHandler finishes the login with the first request which carries the
+// state. Requests without it, like a browser prefetch or a stale tab, are
+// answered with 400 and the login keeps waiting.
+func callbackHandler(ctx context.Context, config *oauth2.Config, state string, results chan<- callbackResult) func(w http.ResponseWriter, r *http.Request) {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if responseState := r.URL.Query().Get("state"); responseState != state {
+			renderPage(rw, http.StatusBadRequest, failurePage(fmt.Errorf("got '%s': %w", responseState, ErrStateMismatch)))
+			return
+		}
 
-		if err := srv.Shutdown(ctx); err != nil {
-			log.Printf(color.RedString("Auth server could not shutdown gracefully: %v"), err)
+		token, err := handleCallback(ctx, config, r)
+		if err != nil {
+			renderPage(rw, http.StatusBadRequest, failurePage(err))
+		} else {
+			renderPage(rw, http.StatusOK, successPage)
 		}
 
-		// after server is shutdown, quit program
-		cancelAuthentication <- struct{}{}
-	}()
+		sendResult(results, callbackResult{token: token, err: err})
+	}
+}
 
-	// handle callback request
-	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+func handleCallback(ctx context.Context, config *oauth2.Config, r *http.Request) (*oauth2.Token, error) {
+	query := r.URL.Query()
+
+	if code := query.Get("error"); code != "" {
+		return nil, &OAuthError{
+			Code:        code,
+			Description: query.Get("error_description"),
+			URI:         query.Get("error_uri"),
 		}
-		fmt.Println("Server gracefully stopped")
-	}()
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return nil, ErrMissingCode
+	}
+
+	token, err := config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("could not exchange the authorization code: %w", err)
+	}
+
+	return token, nil
+}
+
+type page struct {
+	Title      string
+	Heading    string
+	Message    string
+	Background string
+}
 
-	return clientChan, stopHTTPServerChan, cancelAuthentication
+var successPage = page{
+	Title:      "Todo CLI Authentication Success",
+	Heading:    "Success",
+	Message:    "You can now close this window and return to the application.",
+	Background: "#2ecc71",
 }
 
-const success = `
+func failurePage(err error) page {
+	return page{
+		Title:      "Todo CLI Authentication Failed",
+		Heading:    "Authentication failed",
+		Message:    err.Error(),
+		Background: "#e74c3c",
+	}
+}
+
+func renderPage(rw http.ResponseWriter, status int, p page) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(status)
+
+	if err := pageTemplate.Execute(rw, p); err != nil {
+		log.Printf(color.RedString("Could not render the auth page: %v"), err)
+	}
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`
 <!DOCTYPE html>
 <html lang="en">
 
     <head>
         <meta charset="utf-8">
-        <title>Todo CLI Authentication Success</title>
+        <title>{{.Title}}</title>
         <style>
             .wrapper {
                 height: 100%;
@@ -150,7 +260,7 @@ const success = `
                 flex-direction: column;
                 align-items: center;
                 font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
-                background-color: #2ecc71;
+                background-color: {{.Background}};
                 padding-bottom: 10px;
             }
         </style>
@@ -158,41 +268,10 @@ const success = `
 
     <body>
         <div class="wrapper">
-            <h1>Success</h1>
-            <p>You can now close this window and return to the application.</p>
+            <h1>{{.Heading}}</h1>
+            <p>{{.Message}}</p>
         </div>
     </body>
 
 </html>
-`
-
-func callbackHandler(ctx context.Context, config *oauth2.Config, clientChan chan *AuthorizedClient) func(w http.ResponseWriter, r *http.Request) {
-	return func(rw http.ResponseWriter, r *http.Request) {
-		requestStateString := ctx.Value(oauthStateStringContextKey).(string)
-		responseStateString := r.FormValue("state")
-
-		if responseStateString != requestStateString {
-			fmt.Printf("invalid oauth state, expected '%s', got '%s'\n", requestStateString, responseStateString)
-			http.Redirect(rw, r, "/", http.StatusTemporaryRedirect)
-			return
-		}
-
-		fmt.Println(r.Form.Get("error_description"))
-		code := r.FormValue("code")
-		token, err := config.Exchange(ctx, code)
-		if err != nil {
-			fmt.Printf("oauthConfig.Exchange() failed with error '%s'\n", err)
-			http.Redirect(rw, r, "/", http.StatusTemporaryRedirect)
-			return
-		}
-
-		// The HTTP Client returned by oauthConfig.Client will refresh the token as necessary
-		client := &AuthorizedClient{
-			config.Client(ctx, token),
-			token,
-		}
-
-		rw.Write([]byte(success))
-		clientChan <- client
-	}
-}
+`))
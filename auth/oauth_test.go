@@ -0,0 +1,112 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func Test_callbackHandler(t *testing.T) {
+	const state = "abcdefgh"
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+		wantErr    error
+		// ignored is whether the login keeps waiting for another callback
+		ignored bool
+	}{
+		{name: "access denied", query: "?state=abcdefgh&error=access_denied&error_description=The+user+declined", wantStatus: http.StatusBadRequest, wantBody: "The user declined", wantErr: ErrAccessDenied},
+		{name: "invalid scope", query: "?state=abcdefgh&error=invalid_scope", wantStatus: http.StatusBadRequest, wantBody: "invalid_scope", wantErr: ErrInvalidScope},
+		{name: "state mismatch", query: "?state=wrong&code=123", wantStatus: http.StatusBadRequest, wantBody: "Authentication failed", ignored: true},
+		{name: "missing state", query: "?code=123", wantStatus: http.StatusBadRequest, wantBody: "Authentication failed", ignored: true},
+		{name: "error with the wrong state", query: "?state=wrong&error=access_denied", wantStatus: http.StatusBadRequest, wantBody: "oauth state mismatch", ignored: true},
+		{name: "missing code", query: "?state=abcdefgh", wantStatus: http.StatusBadRequest, wantBody: "Authentication failed", wantErr: ErrMissingCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan callbackResult, 1)
+			mux := newCallbackMux(context.Background(), &oauth2.Config{}, state, results)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, callbackPath+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("callbackHandler() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("callbackHandler() body = %v, want it to contain %v", body, tt.wantBody)
+			}
+
+			if tt.ignored {
+				select {
+				case res := <-results:
+					t.Errorf("callbackHandler() sent %v, want the login to keep waiting", res.err)
+				default:
+				}
+				return
+			}
+
+			res := <-results
+			if !errors.Is(res.err, tt.wantErr) {
+				t.Errorf("callbackHandler() error = %v, want %v", res.err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_callbackHandler_exchange(t *testing.T) {
+	const state = "abcdefgh"
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write([]byte(`{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL}}
+	results := make(chan callbackResult, 1)
+	mux := newCallbackMux(context.Background(), config, state, results)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, callbackPath+"?state=abcdefgh&code=123", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("callbackHandler() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "#2ecc71") {
+		t.Errorf("callbackHandler() body = %v, want the success page", body)
+	}
+
+	res := <-results
+	if res.err != nil {
+		t.Fatalf("callbackHandler() error = %v", res.err)
+	}
+	if res.token.AccessToken != "access" {
+		t.Errorf("callbackHandler() access token = %v, want %v", res.token.AccessToken, "access")
+	}
+}
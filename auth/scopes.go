@@ -21,5 +21,6 @@ func getScopes() []string {
 	return []string{
 		"offline_access",
 		"Tasks.ReadWrite",
+		"MailboxSettings.Read",
 	}
 }
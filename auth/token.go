@@ -24,91 +24,131 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path"
+	"sync"
 
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/microsoft"
 )
 
+// ErrNotSignedIn is returned by GetSavedTokenManager when there's no saved
+// token
+var ErrNotSignedIn = errors.New("not signed in")
+
 var (
 	errTokenNotFound = errors.New("token not found")
-	errTokenWeb      = errors.New("error getting token from web")
 	errTokenOpen     = errors.New("error opening token file")
 	errTokenSave     = errors.New("error saving token file")
 )
 
 type TokenManager struct {
-	conf                *oauth2.Config
-	token               *oauth2.Token
-	originalAccessToken string
-	filepath            string
+	conf     *oauth2.Config
+	token    *oauth2.Token
+	filepath string
 }
 
-func GetToken() (*oauth2.Token, error) {
-	tm, err := GetTokenManager()
+// GetTokenManager loads the token from the config directory, or starts the web
+// login flow if there isn't one.
+func GetTokenManager(ctx context.Context) (*TokenManager, error) {
+	tm, err := newTokenManager()
 	if err != nil {
 		return nil, err
 	}
 
-	return tm.token, nil
-}
-
-func GetTokenManager() (*TokenManager, error) {
-	tm := &TokenManager{
-		conf: &oauth2.Config{
-			ClientID:     viper.GetString("client-id"),
-			ClientSecret: viper.GetString("client-secret"),
-			Scopes:       getScopes(),
-			Endpoint:     microsoft.AzureADEndpoint(""),
-		},
-		filepath: path.Join(viper.GetString("config-dir"), "token.json"),
-	}
-
-	isNewToken := false
 	token, err := tm.getFromFile()
 	if errors.Is(err, errTokenOpen) || errors.Is(err, errTokenNotFound) {
-		token, err = tm.getFromWeb()
+		token, err = tm.getFromWeb(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("error getting token from web: %w", err)
 		}
-		isNewToken = true
+
+		tm.token = token
+		if err := tm.save(); err != nil {
+			return nil, err
+		}
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting token: %w", err)
 	}
 
 	tm.token = token
-	tm.originalAccessToken = token.AccessToken
+	return tm, nil
+}
 
-	// This will refresh the token when needed
-	ts := tm.TokenSource(context.Background())
-	newTok, err := ts.Token()
+// GetSavedTokenManager loads the token from the config directory, without
+// starting the web login flow, for when the user can't sign in, like during
+// shell completion.
+func GetSavedTokenManager() (*TokenManager, error) {
+	tm, err := newTokenManager()
 	if err != nil {
 		return nil, err
 	}
 
-	tokenRefreshed := token.AccessToken != newTok.AccessToken
-
-	if isNewToken || tokenRefreshed {
-		tm.token = newTok
-		err = tm.save()
-		if err != nil {
-			return nil, err
-		}
+	token, err := tm.getFromFile()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSignedIn, err)
 	}
 
+	tm.token = token
 	return tm, nil
 }
 
+func newTokenManager() (*TokenManager, error) {
+	cloud, err := CurrentCloud()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenManager{
+		conf: &oauth2.Config{
+			ClientID:     viper.GetString("client-id"),
+			ClientSecret: viper.GetString("client-secret"),
+			Scopes:       cloud.Scopes(),
+			Endpoint:     cloud.Endpoint(),
+		},
+		filepath: path.Join(viper.GetString("config-dir"), "token.json"),
+	}, nil
+}
+
+// TokenSource returns a token source which refreshes the token when needed,
+// and saves rotated tokens to the token file.
 func (t *TokenManager) TokenSource(ctx context.Context) oauth2.TokenSource {
-	return t.conf.TokenSource(ctx, t.token)
+	return &persistingTokenSource{
+		source: t.conf.TokenSource(ctx, t.token),
+		tm:     t,
+	}
+}
+
+type persistingTokenSource struct {
+	mu     sync.Mutex
+	source oauth2.TokenSource
+	tm     *TokenManager
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token.AccessToken != s.tm.token.AccessToken {
+		s.tm.token = token
+		if err := s.tm.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
 }
 
 // save stores the token in json file.
 func (t *TokenManager) save() error {
-	fmt.Printf("Saving token to file: %s\n", t.filepath)
+	log.Printf("Saving token to file: %s\n", t.filepath)
 	f, err := os.OpenFile(t.filepath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return fmt.Errorf("%q: %w", err, errTokenSave)
@@ -138,16 +178,10 @@ func (t *TokenManager) getFromFile() (*oauth2.Token, error) {
 		return nil, fmt.Errorf("%q: %w", err, errTokenOpen)
 	}
 
-	t.originalAccessToken = tok.AccessToken
-
 	return tok, err
 }
 
 // getFromWeb Starts a local server and the oauth flow
-func (t *TokenManager) getFromWeb() (*oauth2.Token, error) {
-	client, err := authenticateUser(t.conf, viper.GetInt("port"), viper.GetInt("auth-timeout"))
-	if err != nil {
-		return nil, fmt.Errorf("%q: %w", err, errTokenWeb)
-	}
-	return client.Token, nil
+func (t *TokenManager) getFromWeb(ctx context.Context) (*oauth2.Token, error) {
+	return authenticateUser(ctx, t.conf, viper.GetInt("port"), viper.GetInt("auth-timeout"))
 }
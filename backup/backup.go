@@ -0,0 +1,273 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package backup is the schema of mstodo backups, which have every list with
+// all of its tasks, and their checklist items, linked resources and
+// attachments.
+//
+// The schema has its own types, rather than the api ones, so that it only
+// changes on purpose. When it changes in a way which older versions of mstodo
+// can't read, Version is increased.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/iancoleman/strcase"
+)
+
+// Version is the version of the schema which this mstodo writes
+const Version = 1
+
+// Backup is a backup of the lists of an account
+type Backup struct {
+	// Version is the version of the schema
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+
+	// Attachments is whether the content of the attachments was backed up.
+	// Without it, only their names and sizes are.
+	Attachments bool `json:"attachments"`
+
+	Lists []List `json:"lists"`
+}
+
+// List is a list, with its tasks
+type List struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+
+	// WellknownListName is "defaultList" for the default list, "flaggedEmails"
+	// for the flagged email list, and "none" for the others
+	WellknownListName string `json:"wellknownListName"`
+
+	Tasks []Task `json:"tasks"`
+}
+
+// Task is a task, with its checklist items, linked resources and attachments.
+// The times are in RFC 3339, with the offset of the time zone they were in.
+type Task struct {
+	Id         string `json:"id"`
+	Title      string `json:"title"`
+	Importance string `json:"importance"`
+
+	// Status is like Graph's, e.g. "notStarted"
+	Status string `json:"status"`
+
+	IsReminderOn         bool                     `json:"isReminderOn"`
+	ReminderDateTime     *time.Time               `json:"reminderDateTime,omitempty"`
+	DueDateTime          *time.Time               `json:"dueDateTime,omitempty"`
+	StartDateTime        *time.Time               `json:"startDateTime,omitempty"`
+	CompletedDateTime    *time.Time               `json:"completedDateTime,omitempty"`
+	CreatedDateTime      time.Time                `json:"createdDateTime"`
+	LastModifiedDateTime time.Time                `json:"lastModifiedDateTime"`
+	Categories           []string                 `json:"categories"`
+	Recurrence           *api.PatternedRecurrence `json:"recurrence,omitempty"`
+	Body                 *api.ItemBody            `json:"body,omitempty"`
+
+	ChecklistItems  []ChecklistItem  `json:"checklistItems"`
+	LinkedResources []LinkedResource `json:"linkedResources"`
+	Attachments     []Attachment     `json:"attachments"`
+}
+
+// ChecklistItem is a step of a task
+type ChecklistItem struct {
+	Id              string     `json:"id"`
+	DisplayName     string     `json:"displayName"`
+	IsChecked       bool       `json:"isChecked"`
+	CheckedDateTime *time.Time `json:"checkedDateTime,omitempty"`
+	CreatedDateTime time.Time  `json:"createdDateTime"`
+}
+
+// LinkedResource is an item in another app which a task was created from
+type LinkedResource struct {
+	Id              string `json:"id"`
+	WebUrl          string `json:"webUrl"`
+	ApplicationName string `json:"applicationName"`
+	DisplayName     string `json:"displayName"`
+	ExternalId      string `json:"externalId"`
+}
+
+// Attachment is a file attached to a task
+type Attachment struct {
+	Id                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	ContentType          string    `json:"contentType"`
+	Size                 int       `json:"size"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+
+	// ContentBytes is the content, which is only backed up with the
+	// attachments. It's written in base64.
+	ContentBytes []byte `json:"contentBytes,omitempty"`
+}
+
+// NewList returns the backup of the list, without its tasks
+func NewList(list api.TodoTaskListItem) List {
+	return List{Id: list.Id, DisplayName: list.DisplayName, WellknownListName: list.WellknownListName, Tasks: []Task{}}
+}
+
+// NewTask returns the backup of the task, without its checklist items, linked
+// resources and attachments
+func NewTask(task api.TodoTask) Task {
+	return Task{
+		Id:                   task.Id,
+		Title:                task.Title,
+		Importance:           task.Importance,
+		Status:               task.Status.Marshal(),
+		IsReminderOn:         task.IsReminderOn,
+		ReminderDateTime:     timeOf(task.ReminderDateTime),
+		DueDateTime:          timeOf(task.DueDateTime),
+		StartDateTime:        timeOf(task.StartDateTime),
+		CompletedDateTime:    timeOf(task.Completed),
+		CreatedDateTime:      task.CreatedDateTime,
+		LastModifiedDateTime: task.LastModifiedDateTime,
+		Categories:           task.Categories,
+		Recurrence:           task.Recurrence,
+		Body:                 task.Body,
+		ChecklistItems:       []ChecklistItem{},
+		LinkedResources:      []LinkedResource{},
+		Attachments:          []Attachment{},
+	}
+}
+
+// NewChecklistItem returns the backup of the checklist item
+func NewChecklistItem(item api.ChecklistItem) ChecklistItem {
+	return ChecklistItem{Id: item.Id, DisplayName: item.DisplayName, IsChecked: item.IsChecked, CheckedDateTime: item.CheckedDateTime, CreatedDateTime: item.CreatedDateTime}
+}
+
+// NewLinkedResource returns the backup of the linked resource
+func NewLinkedResource(resource api.LinkedResource) LinkedResource {
+	return LinkedResource(resource)
+}
+
+// NewAttachment returns the backup of the attachment, with its content if it
+// was got
+func NewAttachment(attachment api.Attachment) Attachment {
+	return Attachment(attachment)
+}
+
+// TodoTask returns the task to create. The ID, created and last modified times
+// are kept, but they're set by Graph when the task is created.
+func (t Task) TodoTask() api.TodoTask {
+	return api.TodoTask{
+		Id:                   t.Id,
+		Title:                t.Title,
+		Importance:           t.Importance,
+		Status:               api.GraphStatus(strcase.ToDelimited(t.Status, ' ')),
+		IsReminderOn:         t.IsReminderOn,
+		ReminderDateTime:     graphTimeOf(t.ReminderDateTime),
+		DueDateTime:          graphTimeOf(t.DueDateTime),
+		StartDateTime:        graphTimeOf(t.StartDateTime),
+		Completed:            graphTimeOf(t.CompletedDateTime),
+		CreatedDateTime:      t.CreatedDateTime,
+		LastModifiedDateTime: t.LastModifiedDateTime,
+		Categories:           t.Categories,
+		Recurrence:           t.Recurrence,
+		Body:                 t.Body,
+		HasAttachments:       len(t.Attachments) > 0,
+	}
+}
+
+// ChecklistItem returns the checklist item to create
+func (item ChecklistItem) ChecklistItem() api.ChecklistItem {
+	return api.ChecklistItem{Id: item.Id, DisplayName: item.DisplayName, IsChecked: item.IsChecked, CheckedDateTime: item.CheckedDateTime, CreatedDateTime: item.CreatedDateTime}
+}
+
+// LinkedResource returns the linked resource to create
+func (r LinkedResource) LinkedResource() api.LinkedResource {
+	return api.LinkedResource(r)
+}
+
+// Attachment returns the attachment to create
+func (a Attachment) Attachment() api.Attachment {
+	return api.Attachment(a)
+}
+
+func timeOf(g *datetime.GraphTime) *time.Time {
+	if g == nil {
+		return nil
+	}
+	t := time.Time(*g)
+	return &t
+}
+
+func graphTimeOf(t *time.Time) *datetime.GraphTime {
+	if t == nil {
+		return nil
+	}
+	g := datetime.GraphTime(*t)
+	return &g
+}
+
+// Summary describes what the backup has, like "2 lists, 5 tasks, 3 checklist
+// items, 0 linked resources, 1 attachments"
+func (b *Backup) Summary() string {
+	tasks, items, resources, attachments := 0, 0, 0, 0
+	for _, list := range b.Lists {
+		tasks += len(list.Tasks)
+		for _, task := range list.Tasks {
+			items += len(task.ChecklistItems)
+			resources += len(task.LinkedResources)
+			attachments += len(task.Attachments)
+		}
+	}
+	return fmt.Sprintf("%d lists, %d tasks, %d checklist items, %d linked resources, %d attachments", len(b.Lists), tasks, items, resources, attachments)
+}
+
+// Encode writes the backup as JSON
+func Encode(w io.Writer, b *Backup) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+// Decode reads a backup written by Encode, which can be gzipped
+func Decode(r io.Reader) (*Backup, error) {
+	in := bufio.NewReader(r)
+
+	// Gzipped files start with 1f 8b
+	if magic, err := in.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = in
+	}
+
+	b := &Backup{}
+	if err := json.NewDecoder(r).Decode(b); err != nil {
+		return nil, fmt.Errorf("not an mstodo backup: %w", err)
+	}
+
+	if b.Version < 1 {
+		return nil, fmt.Errorf("not an mstodo backup: it has no version")
+	}
+	if b.Version > Version {
+		return nil, fmt.Errorf("the backup has version %d, but this mstodo reads up to version %d - upgrade mstodo to restore it", b.Version, Version)
+	}
+	return b, nil
+}
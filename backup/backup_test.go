@@ -0,0 +1,129 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+)
+
+func TestNewTask(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := datetime.GraphTime(time.Date(2021, 10, 1, 0, 0, 0, 0, paris))
+	created := time.Date(2021, 9, 30, 8, 0, 0, 0, time.UTC)
+
+	task := api.TodoTask{
+		Id:              "AAMk",
+		Title:           "Write report",
+		Importance:      "high",
+		Status:          "waiting on others",
+		DueDateTime:     &due,
+		CreatedDateTime: created,
+		Categories:      []string{"Office"},
+		Recurrence:      &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "relativeMonthly", Interval: 1, DaysOfWeek: []string{"monday"}, Index: "first"}, Range: api.RecurrenceRange{Type: "noEnd", StartDate: "2021-10-01"}},
+		Body:            &api.ItemBody{Content: "Notes", ContentType: "text"},
+	}
+
+	got := NewTask(task)
+	if got.Status != "waitingOnOthers" || got.DueDateTime == nil || !got.DueDateTime.Equal(time.Time(due)) {
+		t.Errorf("NewTask() = %+v", got)
+	}
+	if back := got.TodoTask(); !reflect.DeepEqual(back, task) {
+		t.Errorf("NewTask().TodoTask() = %+v, want %+v", back, task)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	b := &Backup{
+		Version: Version,
+		Created: time.Date(2021, 10, 1, 8, 0, 0, 0, time.UTC),
+		Lists: []List{{Id: "L1", DisplayName: "Work", WellknownListName: "none", Tasks: []Task{{
+			Id:              "T1",
+			Title:           "Pay rent",
+			Status:          "notStarted",
+			ChecklistItems:  []ChecklistItem{{Id: "C1", DisplayName: "Transfer"}},
+			LinkedResources: []LinkedResource{{Id: "R1", WebUrl: "https://example.com"}},
+			Attachments:     []Attachment{{Id: "A1", Name: "lease.txt", Size: 5, ContentBytes: []byte("lease")}},
+		}}}},
+	}
+
+	var plain bytes.Buffer
+	if err := Encode(&plain, b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plain.String(), `"contentBytes": "bGVhc2U="`) {
+		t.Errorf("Encode() = %s, want the content in base64", plain.String())
+	}
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write(plain.Bytes())
+	gz.Close()
+
+	tests := []struct {
+		name    string
+		in      []byte
+		want    *Backup
+		wantErr string
+	}{
+		{name: "plain", in: plain.Bytes(), want: b},
+		{name: "gzipped", in: gzipped.Bytes(), want: b},
+		{name: "newer version", in: []byte(`{"version": 2, "lists": []}`), wantErr: "the backup has version 2, but this mstodo reads up to version 1 - upgrade mstodo to restore it"},
+		{name: "no version", in: []byte(`{"lists": []}`), wantErr: "not an mstodo backup: it has no version"},
+		{name: "not JSON", in: []byte("- [ ] Pay rent"), wantErr: "not an mstodo backup: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(bytes.NewReader(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackup_Summary(t *testing.T) {
+	b := &Backup{Lists: []List{
+		{Tasks: []Task{{ChecklistItems: make([]ChecklistItem, 2), Attachments: make([]Attachment, 1)}, {}}},
+		{},
+	}}
+
+	want := "2 lists, 2 tasks, 2 checklist items, 0 linked resources, 1 attachments"
+	if got := b.Summary(); got != want {
+		t.Errorf("Backup.Summary() = %q, want %q", got, want)
+	}
+}
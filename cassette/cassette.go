@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package cassette records sanitized Microsoft Graph interactions to a
+// cassette file, and replays them in place of the network.
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// FileName is the name of the cassette file inside the cassette directory
+const FileName = "cassette.json"
+
+// Version is the current cassette schema version
+const Version = 1
+
+// Cassette is a sequence of recorded interactions
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response it received
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. The URL is relative to the Graph base URL.
+type Request struct {
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	BodyText string            `json:"bodyText,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	BodyText string            `json:"bodyText,omitempty"`
+}
+
+// Load reads the cassette in dir
+func Load(dir string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path.Join(dir, FileName))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the cassette: %w", err)
+	}
+
+	c := Cassette{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("could not parse the cassette: %w", err)
+	}
+
+	if c.Version != Version {
+		return nil, fmt.Errorf("unsupported cassette version %d, expected %d", c.Version, Version)
+	}
+
+	return &c, nil
+}
+
+// Save writes the cassette to dir, creating dir if needed
+func (c *Cassette) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("could not create the cassette directory: %w", err)
+	}
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path.Join(dir, FileName), b, 0600); err != nil {
+		return fmt.Errorf("could not write the cassette: %w", err)
+	}
+	return nil
+}
+
+// setBody stores b as JSON if it's valid JSON, otherwise as text
+func setBody(b []byte, body *json.RawMessage, text *string) {
+	if len(b) == 0 {
+		return
+	}
+
+	if json.Valid(b) {
+		*body = json.RawMessage(b)
+	} else {
+		*text = string(b)
+	}
+}
+
+// getBody is the inverse of setBody
+func getBody(body json.RawMessage, text string) []byte {
+	if len(body) != 0 {
+		return body
+	}
+	return []byte(text)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cassette
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"golang.org/x/oauth2"
+)
+
+func newClient(ctx context.Context, baseURL string, rt http.RoundTripper) *api.Client {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: rt})
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "secret-access-token"})
+	return api.NewClientWithTokenSource(ctx, baseURL, ts)
+}
+
+func TestRecorder_Player(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+	s.PageSize = 1
+
+	listId := s.AddList("Work")
+	taskId, err := s.AddTask(listId, graphfake.Object{"title": "Email Alice.Smith@contoso.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Record
+	recorded := newClient(ctx, s.URL(), NewRecorder(dir, s.URL(), nil))
+	wantLists, err := recorded.GetLists(ctx)
+	if err != nil {
+		t.Fatalf("GetLists() error = %v", err)
+	}
+	id, _ := wantLists.GetListId("work")
+	wantTasks, err := recorded.GetTasks(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(b)
+	for _, secret := range []string{listId, taskId, s.DefaultListID(), "Alice.Smith@contoso.com", "secret-access-token", s.URL()} {
+		if strings.Contains(content, secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	if !strings.Contains(content, "Email user1@example.com") {
+		t.Errorf("cassette doesn't contain the redacted email:\n%s", content)
+	}
+
+	// Replay, with a different base URL and the server closed
+	s.Close()
+	const replayURL = "https://graph.microsoft.com/v1.0"
+	player, err := NewPlayer(dir, replayURL)
+	if err != nil {
+		t.Fatalf("NewPlayer() error = %v", err)
+	}
+	replayed := newClient(ctx, replayURL, player)
+
+	gotLists, err := replayed.GetLists(ctx)
+	if err != nil {
+		t.Fatalf("replayed GetLists() error = %v", err)
+	}
+	if len(*gotLists) != len(*wantLists) {
+		t.Errorf("replayed GetLists() = %v, want %v", *gotLists, *wantLists)
+	}
+
+	replayedId, _ := gotLists.GetListId("work")
+	gotTasks, err := replayed.GetTasks(ctx, replayedId)
+	if err != nil {
+		t.Fatalf("replayed GetTasks() error = %v", err)
+	}
+	if len(*gotTasks) != 1 || (*gotTasks)[0].Title != "Email user1@example.com" || (*gotTasks)[0].Importance != (*wantTasks)[0].Importance {
+		t.Errorf("replayed GetTasks() = %v", *gotTasks)
+	}
+
+	// Every interaction has been used
+	if _, err := replayed.GetLists(ctx); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("GetLists() after the cassette was used error = %v", err)
+	}
+}
+
+func Test_sanitizer_body(t *testing.T) {
+	s := newSanitizer("https://graph.microsoft.com/v1.0")
+
+	got := string(s.body([]byte(`{"access_token":"abc","value":[{"id":"AAMkADU3","title":"see AAMkADU3"}],"@odata.nextLink":"https://graph.microsoft.com/v1.0/me/todo/lists/AAMkADU3/tasks?$skiptoken=2"}`)))
+	want := `{"@odata.nextLink":"/me/todo/lists/id-1/tasks?$skiptoken=2","access_token":"REDACTED","value":[{"id":"id-1","title":"see id-1"}]}`
+	if got != want {
+		t.Errorf("sanitizer.body() = %v, want %v", got, want)
+	}
+
+	gotURL := s.url("https://graph.microsoft.com/v1.0/me/todo/lists/AAMkADU3/tasks/AQMkAD%3D/checklistItems/delta?$top=2")
+	if wantURL := "/me/todo/lists/id-1/tasks/id-2/checklistItems/delta?$top=2"; gotURL != wantURL {
+		t.Errorf("sanitizer.url() = %v, want %v", gotURL, wantURL)
+	}
+
+	headers := s.headers(map[string][]string{"Authorization": {"Bearer abc"}, "Etag": {`W/"1"`}})
+	if !reflect.DeepEqual(headers, map[string]string{"ETag": `W/"1"`}) {
+		t.Errorf("sanitizer.headers() = %v", headers)
+	}
+}
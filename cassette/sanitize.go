@@ -0,0 +1,257 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces secrets like tokens
+const Redacted = "REDACTED"
+
+var emailRegexp = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// allowedHeaders are the only headers which are recorded
+var allowedHeaders = []string{"Content-Type", "ETag", "If-Match", "Location", "Prefer", "Retry-After"}
+
+// linkKeys are the JSON properties which contain Graph URLs
+var linkKeys = []string{"@odata.nextLink", "@odata.deltaLink", "@odata.context"}
+
+// idCollections are the URL path segments which are followed by an ID
+var idCollections = []string{"lists", "tasks", "checklistItems", "linkedResources", "attachments", "extensions"}
+
+// idFunctions are the segments after a collection which are functions, not IDs
+var idFunctions = []string{"delta", "createUploadSession"}
+
+// sanitizer redacts tokens, and replaces IDs and email addresses with stable
+// placeholders, so that the same ID is always replaced by the same placeholder
+// within a cassette.
+type sanitizer struct {
+	baseURL string
+	ids     map[string]string
+	idCount int
+	emails  map[string]string
+}
+
+func newSanitizer(baseURL string) *sanitizer {
+	return &sanitizer{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		ids:     map[string]string{},
+		emails:  map[string]string{},
+	}
+}
+
+// body sanitizes a JSON body. Non-JSON bodies only have their text sanitized.
+func (s *sanitizer) body(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return []byte(s.text(string(b)))
+	}
+
+	// Collect the IDs first, so that they're replaced wherever they appear
+	s.collectIDs(v)
+	v = s.value("", v)
+
+	res, err := json.Marshal(v)
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return res
+}
+
+func (s *sanitizer) collectIDs(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			if id, ok := v[k].(string); ok && k == "id" {
+				s.id(id)
+			} else {
+				s.collectIDs(v[k])
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			s.collectIDs(item)
+		}
+	}
+}
+
+func (s *sanitizer) value(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = s.value(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.value(key, item)
+		}
+		return v
+	case string:
+		switch {
+		case isSecretKey(key):
+			return Redacted
+		case key == "id":
+			return s.id(v)
+		case containsString(linkKeys, key):
+			return s.url(v)
+		default:
+			return s.text(v)
+		}
+	default:
+		return v
+	}
+}
+
+// id returns the placeholder for a Graph ID
+func (s *sanitizer) id(id string) string {
+	if p, ok := s.ids[id]; ok {
+		return p
+	}
+
+	s.idCount++
+	p := fmt.Sprintf("id-%d", s.idCount)
+	s.ids[id] = p
+	return p
+}
+
+// text replaces the known IDs and any email addresses in t
+func (s *sanitizer) text(t string) string {
+	// Replace the longest IDs first, in case an ID contains another
+	ids := make([]string, 0, len(s.ids))
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+
+	for _, id := range ids {
+		t = strings.ReplaceAll(t, id, s.ids[id])
+		if escaped := url.PathEscape(id); escaped != id {
+			t = strings.ReplaceAll(t, escaped, s.ids[id])
+		}
+	}
+
+	return emailRegexp.ReplaceAllStringFunc(t, func(email string) string {
+		email = strings.ToLower(email)
+		if p, ok := s.emails[email]; ok {
+			return p
+		}
+
+		p := fmt.Sprintf("user%d@example.com", len(s.emails)+1)
+		s.emails[email] = p
+		return p
+	})
+}
+
+// url makes u relative to the Graph base URL, and sanitizes its text. The IDs
+// in its path are learnt first, since an ID typed by the user is sent before
+// any response contains it.
+func (s *sanitizer) url(u string) string {
+	if strings.HasPrefix(u, s.baseURL) {
+		u = strings.TrimPrefix(u, s.baseURL)
+	} else if parsed, err := url.Parse(u); err == nil && parsed.IsAbs() {
+		parsed.Scheme = ""
+		parsed.Host = ""
+		u = parsed.String()
+	}
+
+	s.collectPathIDs(u)
+	return s.text(u)
+}
+
+// collectPathIDs learns the IDs which follow a collection in the path of u
+func (s *sanitizer) collectPathIDs(u string) {
+	path := u
+	if i := strings.IndexAny(path, "?#"); i != -1 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if !containsString(idCollections, segments[i-1]) {
+			continue
+		}
+
+		id, err := url.PathUnescape(segments[i])
+		if err != nil || id == "" || strings.HasPrefix(id, "$") || containsString(idFunctions, id) {
+			continue
+		}
+		// The segment may be escaped differently to url.PathEscape
+		p := s.id(id)
+		s.ids[segments[i]] = p
+	}
+}
+
+// headers keeps the allowed headers, sanitizing their values
+func (s *sanitizer) headers(h map[string][]string) map[string]string {
+	res := map[string]string{}
+	for _, name := range allowedHeaders {
+		for k, v := range h {
+			if strings.EqualFold(k, name) && len(v) != 0 {
+				if name == "Location" {
+					res[name] = s.url(v[0])
+				} else {
+					res[name] = s.text(v[0])
+				}
+			}
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range []string{"token", "secret", "password", "authorization", "assertion"} {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(items []string, target string) bool {
+	for _, v := range items {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
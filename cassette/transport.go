@@ -0,0 +1,186 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper which records the sanitized Graph
+// interactions into a cassette. Requests to other hosts, like the token
+// endpoint, are passed through without being recorded.
+type Recorder struct {
+	dir      string
+	baseURL  string
+	base     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+	sanitize *sanitizer
+}
+
+// NewRecorder creates a recorder which saves the cassette to dir after each
+// interaction. If base is nil, http.DefaultTransport is used.
+func NewRecorder(dir, baseURL string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Recorder{
+		dir:      dir,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		base:     base,
+		cassette: Cassette{Version: Version, Interactions: []Interaction{}},
+		sanitize: newSanitizer(baseURL),
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.String(), r.baseURL) {
+		return r.base.RoundTrip(req)
+	}
+
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The request is sanitized first, as its IDs came from earlier responses
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     r.sanitize.url(req.URL.String()),
+			Headers: r.sanitize.headers(req.Header),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+		},
+	}
+	setBody(r.sanitize.body(reqBody), &interaction.Request.Body, &interaction.Request.BodyText)
+	setBody(r.sanitize.body(respBody), &interaction.Response.Body, &interaction.Response.BodyText)
+	interaction.Response.Headers = r.sanitize.headers(resp.Header)
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.dir); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// readBody reads and replaces body, so that it can be read again
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// Player is an http.RoundTripper which serves the interactions of a cassette
+// instead of the network. Each interaction is served once, in the recorded
+// order for requests with the same method and URL.
+type Player struct {
+	baseURL  string
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewPlayer loads the cassette in dir. Requests are matched using their URL
+// relative to baseURL.
+func NewPlayer(dir, baseURL string) (*Player, error) {
+	c, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Player{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}, nil
+}
+
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	u := req.URL.String()
+	if !strings.HasPrefix(u, p.baseURL) {
+		return nil, fmt.Errorf("replay: %s is not a Graph URL", u)
+	}
+	u = strings.TrimPrefix(u, p.baseURL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, interaction := range p.cassette.Interactions {
+		if p.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != u {
+			continue
+		}
+		p.used[i] = true
+
+		res := interaction.Response
+		header := http.Header{}
+		for k, v := range res.Headers {
+			header.Set(k, v)
+		}
+
+		body := getBody(res.Body, res.BodyText)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", res.Status, http.StatusText(res.Status)),
+			StatusCode:    res.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("replay: no recorded interaction for %s %s", req.Method, u)
+}
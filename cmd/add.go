@@ -20,11 +20,15 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/dalyisaac/mstodo/api"
 	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/quickadd"
 	"github.com/dalyisaac/mstodo/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 )
 
@@ -38,6 +42,10 @@ type addParamsFlags struct {
 	reminder   string
 	dueDate    string
 	status     string
+	tz         string
+	explain    bool
+	verbose    bool
+	raw        bool
 }
 
 const emptyString = ""
@@ -51,31 +59,105 @@ func createAddCmd() *cobra.Command {
 	addCmd := &cobra.Command{
 		Use:   "add <task title>",
 		Short: "Add a task",
-		Long:  `Add a task`,
+		Long: `Add a task
+
+The title can contain the task's metadata, which is removed from the title:
+
+  !high, !normal, !low     the importance
+  #category                a category, which can be repeated
+  @list or @"my list"      the list
+  every [n] day|week|...   the recurrence, or "every weekday" or "every mon and thu"
+  a date time or date      the reminder, like "tomorrow 9am", or due date, like "next friday"
+
+A backslash escapes the next character, like \#1, and quoted words are kept in the
+title as they are. Flags like --list take precedence over the title. With --raw,
+the title is kept as it is.`,
+		Example: `  mstodo add "Pay rent tomorrow 9am !high #finance @home every month"
+  mstodo add 'Fix bug \#1 next friday' --explain
+  mstodo add "Fix #42 for @alice" --raw`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return errors.New("missing task name")
 			}
 
+			// The title's syntax is checked before signing in, so that a typo
+			// doesn't start a login. Its dates are parsed again below, once the
+			// time zone is known.
+			if _, err := parseAddTitle(args[0], flags.raw); err != nil {
+				return err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Dates are parsed in the user's time zone
+			if _, err := setTimeZone(ctx, client, flags.tz); err != nil {
+				return err
+			}
+
+			if flags.verbose {
+				printAddDateExplanations(cmd.ErrOrStderr(), flags)
+			}
+
+			// Extract the metadata from the title
+			quick, err := parseAddTitle(args[0], flags.raw)
+			if err != nil {
+				return err
+			}
+
+			if flags.verbose && quick.Date != "" {
+				if quick.Reminder != nil {
+					printDateExplanation(cmd.ErrOrStderr(), datetime.ExplainDateTime(quick.Date))
+				} else {
+					printDateExplanation(cmd.ErrOrStderr(), datetime.ExplainDate(quick.Date))
+				}
+			}
+
+			f := flags
+			if session != nil && session.list != "" && !cmd.Flags().Changed("list") {
+				f.list = session.list
+			}
+			if quick.List != "" && !cmd.Flags().Changed("list") {
+				f.list = quick.List
+			}
+			if quick.Importance != "" && !cmd.Flags().Changed("importance") {
+				f.importance = quick.Importance
+			}
+
 			// Construct task
-			task, err := constructTaskPayload(flags, args[0])
+			task, err := constructTaskPayload(f, quick.Title)
 			if err != nil {
 				return err
 			}
 
+			if err := quick.Apply(task, cmd.Flags().Changed("reminder"), cmd.Flags().Changed("due-date")); err != nil {
+				return err
+			}
+
+			// --explain only shows the task
+			if flags.explain {
+				printTaskExplanation(cmd.OutOrStdout(), task, f.list, quick.Recurrence)
+				return nil
+			}
+
 			// Get lists
-			lists, err := api.GetLists()
+			lists, err := getLists(ctx, client)
 			if err != nil {
 				return err
 			}
 
 			// Get task list id
-			listId, err := lists.GetListId(flags.list)
+			listId, err := lists.GetListId(f.list)
 			if err != nil {
 				return err
 			}
 
-			if err := api.CreateTask(listId, task); err != nil {
+			if err := client.CreateTask(ctx, listId, task); err != nil {
 				return err
 			}
 
@@ -88,6 +170,16 @@ func createAddCmd() *cobra.Command {
 	addCmd.Flags().StringVarP(&flags.dueDate, "due-date", "d", emptyString, "Task due date (date). For example, --due-date=\"next friday\"")
 	addCmd.Flags().StringVarP(&flags.importance, "importance", "i", "normal", fmt.Sprintf("Task importance - choices: [%v]", strings.Join(importanceChoices, ", ")))
 	addCmd.Flags().StringVarP(&flags.status, "status", "s", "not started", fmt.Sprintf("Task status - choices: [%v]", strings.Join(api.GraphStatusOptions, ", ")))
+	addCmd.Flags().BoolVar(&flags.explain, "explain", false, "Print the task's fields, including the metadata extracted from the title, without creating it")
+	addCmd.Flags().BoolVar(&flags.raw, "raw", false, "Keep the title as it is, without extracting the metadata")
+	addCmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Print how the reminder and due date were parsed")
+	addCmd.Flags().StringVar(&flags.tz, "tz", emptyString, "Time zone for the reminder and due date, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+
+	// completions
+	addCmd.ValidArgsFunction = noCompletions
+	addCmd.RegisterFlagCompletionFunc("list", completeListNames)
+	addCmd.RegisterFlagCompletionFunc("importance", completeChoices(importanceChoices...))
+	addCmd.RegisterFlagCompletionFunc("status", completeChoices(api.GraphStatusOptions...))
 
 	return addCmd
 }
@@ -106,21 +198,21 @@ func constructTaskPayload(flags addParamsFlags, title string) (*api.TodoTask, er
 	task.IsReminderOn = false
 	reminder := strings.Trim(flags.reminder, addCutset)
 	if reminder != emptyString {
-		if reminder, err := datetime.DateTimeParser(reminder); err != nil {
-			return nil, err
+		if parsed, err := datetime.DateTimeParser(reminder); err != nil {
+			return nil, fmt.Errorf("'%v' is not a valid value for reminder: %w", reminder, err)
 		} else {
 			task.IsReminderOn = true
-			task.ReminderDateTime = (*datetime.GraphTime)(reminder)
+			task.ReminderDateTime = (*datetime.GraphTime)(parsed)
 		}
 	}
 
 	// due date
 	dueDate := strings.Trim(flags.dueDate, addCutset)
 	if dueDate != emptyString {
-		if dueDate, err := datetime.DateParser(dueDate); err != nil {
-			return nil, err
+		if parsed, err := datetime.DateParser(dueDate); err != nil {
+			return nil, fmt.Errorf("'%v' is not a valid value for due date: %w", dueDate, err)
 		} else {
-			task.DueDateTime = (*datetime.GraphTime)(dueDate)
+			task.DueDateTime = (*datetime.GraphTime)(parsed)
 		}
 	}
 
@@ -140,3 +232,50 @@ func constructTaskPayload(flags addParamsFlags, title string) (*api.TodoTask, er
 
 	return &task, nil
 }
+
+// parseAddTitle extracts the metadata from title, unless raw is set
+func parseAddTitle(title string, raw bool) (*quickadd.Result, error) {
+	if raw {
+		return &quickadd.Result{Title: title}, nil
+	}
+	return quickadd.Parse(title)
+}
+
+// printAddDateExplanations prints how the --reminder and --due-date flags are
+// parsed
+func printAddDateExplanations(out io.Writer, flags addParamsFlags) {
+	printDateReference(out, datetime.CurrentReference())
+
+	if reminder := strings.Trim(flags.reminder, addCutset); reminder != emptyString {
+		printDateExplanation(out, datetime.ExplainDateTime(reminder))
+	}
+
+	if dueDate := strings.Trim(flags.dueDate, addCutset); dueDate != emptyString {
+		printDateExplanation(out, datetime.ExplainDate(dueDate))
+	}
+}
+
+// printTaskExplanation prints the fields of the task which will be created
+func printTaskExplanation(out io.Writer, task *api.TodoTask, list string, recurrence *quickadd.Recurrence) {
+	formatTime := func(t *datetime.GraphTime) string {
+		if t == nil {
+			return ""
+		}
+		return time.Time(*t).Format(time.RFC1123)
+	}
+
+	t := utils.CreateBasicTable(out, &table.Row{"Field", "Value"})
+	t.AppendRow(table.Row{"Title", task.Title})
+	t.AppendRow(table.Row{"List", list})
+	t.AppendRow(table.Row{"Importance", task.Importance})
+	t.AppendRow(table.Row{"Status", string(task.Status)})
+	t.AppendRow(table.Row{"Reminder", formatTime(task.ReminderDateTime)})
+	t.AppendRow(table.Row{"Due date", formatTime(task.DueDateTime)})
+	t.AppendRow(table.Row{"Categories", strings.Join(task.Categories, ", ")})
+	if recurrence != nil {
+		t.AppendRow(table.Row{"Recurrence", recurrence.String()})
+	} else {
+		t.AppendRow(table.Row{"Recurrence", ""})
+	}
+	t.Render()
+}
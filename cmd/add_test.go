@@ -0,0 +1,192 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_addCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+
+	if _, err := executeCmd(t, s, "add", "Write report", "--list", "work", "--importance", "high", "--due-date", "02/01/2021", "--date-locale", "en-gb"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	tasks := s.Tasks(listId)
+	if len(tasks) != 1 {
+		t.Fatalf("tasks = %v, want 1 task", tasks)
+	}
+
+	task := tasks[0]
+	if task["title"] != "Write report" || task["importance"] != "high" || task["status"] != "notStarted" {
+		t.Errorf("task = %v", task)
+	}
+
+	due, ok := task["dueDateTime"].(map[string]interface{})
+	if !ok || due["dateTime"] != "2021-01-02T00:00:00.0000000" {
+		t.Errorf("task dueDateTime = %v", task["dueDateTime"])
+	}
+
+	if _, err := executeCmd(t, s, "add", "Write report", "--due-date", "02/01/2021", "--date-order", "any"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("add with an ambiguous due date error = %v, want an ambiguous date error", err)
+	}
+
+	if _, err := executeCmd(t, s, "add", "Plan trip", "--list", "work", "--due-date", "02/01/2021", "--date-order", "mdy"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	due, ok = s.Tasks(listId)[1]["dueDateTime"].(map[string]interface{})
+	if !ok || due["dateTime"] != "2021-02-01T00:00:00.0000000" {
+		t.Errorf("task dueDateTime = %v", due)
+	}
+
+	if _, err := executeCmd(t, s, "add", "Call", "--list", "work", "--reminder", "2021-07-07 09:00", "--tz", "Pacific Standard Time"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	reminder, _ := s.Tasks(listId)[2]["reminderDateTime"].(map[string]interface{})
+	if reminder["dateTime"] != "2021-07-07T09:00:00.0000000" || reminder["timeZone"] != "Pacific Standard Time" {
+		t.Errorf("task reminderDateTime = %v", reminder)
+	}
+
+	// Without date-locale or date-order, numeric dates are day-month-year
+	if _, err := executeCmd(t, s, "add", "Send card", "--list", "work", "--due-date", "02/01/2021"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	due, _ = s.Tasks(listId)[3]["dueDateTime"].(map[string]interface{})
+	if due["dateTime"] != "2021-01-02T00:00:00.0000000" {
+		t.Errorf("task dueDateTime = %v", due)
+	}
+
+	// Without --tz or time-zone, the mailbox time zone is used
+	nzServer := graphfake.NewServer()
+	defer nzServer.Close()
+	nzServer.MailboxTimeZone = "New Zealand Standard Time"
+
+	if _, err := executeCmd(t, nzServer, "add", "Call again", "--reminder", "2021-07-07 09:00"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	reminder, _ = nzServer.Tasks(nzServer.DefaultListID())[0]["reminderDateTime"].(map[string]interface{})
+	if reminder["dateTime"] != "2021-07-07T09:00:00.0000000" || reminder["timeZone"] != "New Zealand Standard Time" {
+		t.Errorf("task reminderDateTime = %v", reminder)
+	}
+
+	if _, err := executeCmd(t, s, "add", "Write report", "--tz", "Nowhere/Nothing"); err == nil {
+		t.Errorf("add with an invalid time zone should fail")
+	}
+
+	if _, err := executeCmd(t, s, "add", "Write report", "--reminder", "soonish"); err == nil || !strings.HasPrefix(err.Error(), "'soonish' is not a valid value for reminder: ") {
+		t.Errorf("add --reminder soonish error = %v", err)
+	}
+
+	if _, err := executeCmd(t, s, "add", "Write report", "--importance", "urgent"); err == nil {
+		t.Errorf("add with an invalid importance should fail")
+	}
+}
+
+func Test_addCmd_quickAdd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Home")
+
+	out, err := executeCmd(t, s, "add", "Pay rent 2021-08-01 09:00 !high #finance @home every month", "--explain")
+	if err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+	assertContains(t, out, "Pay rent", "home", "high", "finance", "every month")
+
+	// --explain doesn't create the task
+	if tasks := s.Tasks(listId); len(tasks) != 0 {
+		t.Fatalf("tasks after --explain = %v, want none", tasks)
+	}
+
+	if _, err := executeCmd(t, s, "add", "Pay rent 2021-08-01 09:00 !high #finance @home every month"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	tasks := s.Tasks(listId)
+	if len(tasks) != 1 {
+		t.Fatalf("tasks = %v, want 1 task", tasks)
+	}
+
+	task := tasks[0]
+	if task["title"] != "Pay rent" || task["importance"] != "high" || task["isReminderOn"] != true {
+		t.Errorf("task = %v", task)
+	}
+
+	reminder, _ := task["reminderDateTime"].(map[string]interface{})
+	if reminder["dateTime"] != "2021-08-01T09:00:00.0000000" {
+		t.Errorf("task reminderDateTime = %v", reminder)
+	}
+
+	categories, _ := task["categories"].([]interface{})
+	if len(categories) != 1 || categories[0] != "finance" {
+		t.Errorf("task categories = %v", task["categories"])
+	}
+
+	recurrence, _ := task["recurrence"].(map[string]interface{})
+	pattern, _ := recurrence["pattern"].(map[string]interface{})
+	if pattern["type"] != "absoluteMonthly" || pattern["dayOfMonth"] != 1.0 {
+		t.Errorf("task recurrence = %v", task["recurrence"])
+	}
+
+	// Flags take precedence over the title, and escaped words are kept
+	if _, err := executeCmd(t, s, "add", `Fix bug \#1 !low @home`, "--importance", "normal", "--list", "tasks"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	task = s.Tasks(s.DefaultListID())[0]
+	if task["title"] != "Fix bug #1" || task["importance"] != "normal" {
+		t.Errorf("task = %v", task)
+	}
+
+	out, err = executeCmd(t, s, "add", "Party 2021-08-07", "--due-date", "Oct 17th", "--verbose")
+	if err == nil {
+		t.Errorf("add with an invalid due date should fail")
+	}
+	assertContains(t, out, `Date:       "Oct 17th"`, "Closest layouts", `Date:       "2021-08-07"`, "ISO 8601")
+
+	// A typo in the title fails before signing in
+	requests := s.RequestCount()
+	if _, err := executeCmd(t, s, "add", "Party !urgent"); err == nil || !strings.Contains(err.Error(), `\!`) {
+		t.Errorf("add with an invalid importance error = %v, want the escape hint", err)
+	}
+	if got := s.RequestCount(); got != requests {
+		t.Errorf("add with an invalid importance made %d requests, want none", got-requests)
+	}
+
+	// --raw keeps the title as it is
+	if _, err := executeCmd(t, s, "add", "Fix #42 for @alice tomorrow", "--raw"); err != nil {
+		t.Fatalf("add --raw error = %v", err)
+	}
+
+	task = s.Tasks(s.DefaultListID())[1]
+	if categories, _ := task["categories"].([]interface{}); task["title"] != "Fix #42 for @alice tomorrow" || len(categories) != 0 || task["dueDateTime"] != nil {
+		t.Errorf("task added with --raw = %v", task)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createAgendaCmd())
+}
+
+type agendaParamsFlags struct {
+	days                 int
+	tz                   string
+	absoluteTime, showId bool
+}
+
+const overdueGroup = "Overdue"
+
+// agendaColumns are the columns which agenda shows
+const agendaColumns = "title,importance,status,reminder,due date,categories"
+
+func createAgendaCmd() *cobra.Command {
+	flags := agendaParamsFlags{}
+
+	agendaCmd := &cobra.Command{
+		Use:   "agenda [list name]...",
+		Short: "View the overdue tasks and the tasks due in the next days",
+		Long: `View the open tasks which are overdue or due in the next --days, with a table
+for the overdue tasks and for each day. Without a list name, every list is shown.
+
+The days start at midnight in the time zone from --tz, the time-zone config or the
+mailbox time zone.`,
+		Example: `  mstodo agenda
+  mstodo agenda work --days 14 --tz Europe/Paris`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.days < 1 {
+				return errors.New("--days must be at least 1")
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			// The days are in the user's time zone
+			loc, err := setTimeZone(ctx, client, flags.tz)
+			if err != nil {
+				return err
+			}
+
+			params, err := getViewCmdParams(viewParamsFlags{
+				title: matchAll, status: matchAll, reminder: matchAll, dueDate: matchAll,
+				completed: matchAll, created: matchAll, lastModified: matchAll,
+				sort: "due", columns: agendaColumns,
+				absoluteTime: flags.absoluteTime, showId: flags.showId,
+			}, loc)
+			if err != nil {
+				return err
+			}
+			params.grouper = agendaGrouper()
+
+			lists, err := getLists(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			if len(args) < 1 {
+				for _, list := range *lists {
+					args = append(args, list.DisplayName)
+				}
+			}
+
+			now := time.Now().In(loc)
+			tasks := api.TodoTaskList{}
+			params.taskListIds = map[string]string{}
+			params.numbered = session != nil
+
+			for _, arg := range args {
+				name, err := utils.CleanName(arg)
+				if err != nil {
+					return err
+				}
+
+				listId, err := lists.GetListId(name)
+				if err != nil {
+					return err
+				}
+
+				listTasks, err := client.GetTasks(ctx, listId)
+				if err != nil {
+					return err
+				}
+
+				for _, task := range *listTasks {
+					if inAgenda(task, now, flags.days) {
+						params.taskListIds[task.Id] = listId
+						tasks = append(tasks, task)
+					}
+				}
+			}
+
+			shown := params.printTaskList(cmd.OutOrStdout(), tasks)
+
+			// The shell's commands refer to the tasks by their numbers
+			if session != nil {
+				session.setListing(shown, params.taskListIds)
+			}
+
+			return nil
+		},
+	}
+
+	agendaCmd.Flags().IntVar(&flags.days, "days", 7, "The number of days to show, from today")
+	agendaCmd.Flags().StringVar(&flags.tz, "tz", "", "Time zone for the days and shown dates, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+	agendaCmd.Flags().BoolVarP(&flags.absoluteTime, "absolute", "a", false, "Show absolute datetime")
+	agendaCmd.Flags().BoolVarP(&flags.showId, "id", "i", false, "Show the task IDs")
+
+	// completions
+	agendaCmd.ValidArgsFunction = completeListNames
+
+	return agendaCmd
+}
+
+// inAgenda reports whether task is open, and overdue or due in the next days
+func inAgenda(task api.TodoTask, now time.Time, days int) bool {
+	if task.Status == "completed" {
+		return false
+	}
+
+	until, ok := utils.DaysUntilDue(task.In(now.Location()), now)
+	return ok && until < days
+}
+
+// agendaGrouper groups the tasks by their due day, after the overdue tasks
+func agendaGrouper() *taskGrouper {
+	return &taskGrouper{
+		keys: func(params *viewParams, task api.TodoTask) []string {
+			if until, _ := utils.DaysUntilDue(task, time.Now().In(params.location)); until < 0 {
+				return []string{overdueGroup}
+			}
+			return taskGroupers["due-day"].keys(params, task)
+		},
+		less: func(params *viewParams, a, b string) bool {
+			return a == overdueGroup && b != overdueGroup || a != overdueGroup && b != overdueGroup && a < b
+		},
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_agendaCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	loc, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Skip(err)
+	}
+	today := time.Now().In(loc)
+	due := func(days int) graphfake.Object {
+		day := today.AddDate(0, 0, days)
+		return graphfake.Object{"dateTime": day.Format("2006-01-02") + "T00:00:00.0000000", "timeZone": "New Zealand Standard Time"}
+	}
+
+	workId := s.AddList("Work")
+	homeId := s.AddList("Home")
+	for _, task := range []struct {
+		listId string
+		fields graphfake.Object
+	}{
+		{listId: workId, fields: graphfake.Object{"title": "Write report", "dueDateTime": due(-2)}},
+		{listId: workId, fields: graphfake.Object{"title": "Book flights", "dueDateTime": due(0)}},
+		{listId: workId, fields: graphfake.Object{"title": "Send invoice", "dueDateTime": due(0), "status": "completed"}},
+		{listId: homeId, fields: graphfake.Object{"title": "Water plants", "dueDateTime": due(3)}},
+		{listId: homeId, fields: graphfake.Object{"title": "Renew passport", "dueDateTime": due(30)}},
+		{listId: homeId, fields: graphfake.Object{"title": "Tidy garage"}},
+	} {
+		if _, err := s.AddTask(task.listId, task.fields); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := executeCmd(t, s, "agenda", "--tz", "Pacific/Auckland")
+	if err != nil {
+		t.Fatalf("agenda error = %v", err)
+	}
+	assertContains(t, out, "Overdue (1)", today.Format("2006-01-02 Monday")+" (1)", "Write report", "Book flights", "Water plants")
+	assertNotContains(t, out, "Send invoice", "Renew passport", "Tidy garage")
+	if overdue, flights := strings.Index(out, "Write report"), strings.Index(out, "Book flights"); overdue > flights {
+		t.Errorf("agenda should show the overdue tasks first:\n%s", out)
+	}
+
+	out, err = executeCmd(t, s, "agenda", "home", "--days", "31", "--tz", "Pacific/Auckland")
+	if err != nil {
+		t.Fatalf("agenda error = %v", err)
+	}
+	assertContains(t, out, "Water plants", "Renew passport")
+	assertNotContains(t, out, "Write report", "Tidy garage")
+
+	if _, err := executeCmd(t, s, "agenda", "--days", "0"); err == nil {
+		t.Errorf("agenda --days 0 should fail")
+	}
+}
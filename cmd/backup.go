@@ -0,0 +1,205 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/backup"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createBackupCmd())
+}
+
+type backupParamsFlags struct {
+	output      string
+	attachments bool
+}
+
+func createBackupCmd() *cobra.Command {
+	flags := backupParamsFlags{}
+
+	// backupCmd represents the backup command
+	var backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Back up every list and task to a file",
+		Long: `Back up every list, with all of its tasks and their checklist items, linked resources,
+notes, categories and recurrence, to a JSON file which restore reads.
+
+The content of attachments is only backed up with --attachments, as it can make the
+backup big. Without it, only their names and sizes are.
+
+Files ending in .gz are gzipped. The file is only replaced once the backup is complete,
+so a failed backup doesn't overwrite the last one.`,
+		Example: `  mstodo backup -o todo.json.gz
+  mstodo backup -o todo.json.gz --attachments
+  mstodo backup > todo.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			lists, err := getLists(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			b, err := getBackup(ctx, client, *lists, flags.attachments, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+
+			if flags.output == "" || flags.output == "-" {
+				return backup.Encode(cmd.OutOrStdout(), b)
+			}
+
+			if err := writeBackup(flags.output, b); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Backed up %s to %s\n", b.Summary(), flags.output)
+			return nil
+		},
+	}
+
+	backupCmd.Flags().StringVarP(&flags.output, "output", "o", "", "The file to write, instead of the standard output, which is gzipped if it ends in .gz")
+	backupCmd.Flags().BoolVar(&flags.attachments, "attachments", false, "Back up the content of attachments")
+
+	return backupCmd
+}
+
+// getBackup gets the lists, with all of their tasks. The content of
+// attachments is only got if attachments is set. The number of tasks of each
+// list is printed to progress.
+func getBackup(ctx context.Context, client *api.Client, lists api.TodoTaskListList, attachments bool, progress io.Writer) (*backup.Backup, error) {
+	b := &backup.Backup{Version: backup.Version, Created: time.Now().UTC(), Attachments: attachments, Lists: []backup.List{}}
+
+	for _, list := range lists {
+		backupList := backup.NewList(list)
+
+		tasks, err := client.GetTasks(ctx, list.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range *tasks {
+			backupTask, err := getBackupTask(ctx, client, list.Id, task, attachments)
+			if err != nil {
+				return nil, err
+			}
+			backupList.Tasks = append(backupList.Tasks, *backupTask)
+		}
+
+		b.Lists = append(b.Lists, backupList)
+		fmt.Fprintf(progress, "Backed up %s: %d tasks\n", list.DisplayName, len(backupList.Tasks))
+	}
+
+	return b, nil
+}
+
+// getBackupTask gets the checklist items, linked resources and attachments of
+// the task
+func getBackupTask(ctx context.Context, client *api.Client, listId string, task api.TodoTask, attachments bool) (*backup.Task, error) {
+	backupTask := backup.NewTask(task)
+
+	items, err := client.GetChecklistItems(ctx, listId, task.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		backupTask.ChecklistItems = append(backupTask.ChecklistItems, backup.NewChecklistItem(item))
+	}
+
+	resources, err := client.GetLinkedResources(ctx, listId, task.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range resources {
+		backupTask.LinkedResources = append(backupTask.LinkedResources, backup.NewLinkedResource(resource))
+	}
+
+	// Tasks say whether they have attachments, which saves a request for most
+	if !task.HasAttachments {
+		return &backupTask, nil
+	}
+
+	got, err := client.GetAttachments(ctx, listId, task.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range got {
+		if attachments {
+			withContent, err := client.GetAttachment(ctx, listId, task.Id, attachment.Id)
+			if err != nil {
+				return nil, err
+			}
+			attachment = *withContent
+		}
+		backupTask.Attachments = append(backupTask.Attachments, backup.NewAttachment(attachment))
+	}
+
+	return &backupTask, nil
+}
+
+// writeBackup writes the backup to a temporary file, which replaces the file
+// called name once it's complete. It's gzipped if name ends in .gz.
+func writeBackup(name string, b *backup.Backup) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), ".mstodo-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	var w io.Writer = f
+	gz := (*gzip.Writer)(nil)
+	if strings.HasSuffix(strings.ToLower(name), ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	if err := backup.Encode(w, b); err != nil {
+		f.Close()
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
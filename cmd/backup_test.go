@@ -0,0 +1,129 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/backup"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+// addBackupTasks adds a task with a checklist item, a linked resource and an
+// attachment to a new Work list, and a completed task to the default list. It
+// returns the ID of the Work task.
+func addBackupTasks(t *testing.T, s *graphfake.Server) string {
+	t.Helper()
+
+	workId := s.AddList("Work")
+	taskId, err := s.AddTask(workId, graphfake.Object{
+		"title":       "Write report",
+		"importance":  "high",
+		"categories":  []interface{}{"Office"},
+		"dueDateTime": graphfake.Object{"dateTime": "2021-10-01T00:00:00.0000000", "timeZone": "UTC"},
+		"recurrence": graphfake.Object{
+			"pattern": graphfake.Object{"type": "weekly", "interval": 1, "daysOfWeek": []interface{}{"friday"}},
+			"range":   graphfake.Object{"type": "noEnd", "startDate": "2021-10-01"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddChecklistItem(workId, taskId, graphfake.Object{"displayName": "Outline", "isChecked": true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddLinkedResource(workId, taskId, graphfake.Object{"webUrl": "https://example.com/report", "applicationName": "Example"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAttachment(workId, taskId, "notes.txt", "text/plain", []byte("notes")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pay rent", "status": "completed"}); err != nil {
+		t.Fatal(err)
+	}
+	return taskId
+}
+
+// readBackup reads the backup in the file called name
+func readBackup(t *testing.T, name string) *backup.Backup {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	b, err := backup.Decode(f)
+	if err != nil {
+		t.Fatalf("backup.Decode() error = %v", err)
+	}
+	return b
+}
+
+func Test_backupCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	taskId := addBackupTasks(t, s)
+	dir := t.TempDir()
+
+	file := path.Join(dir, "todo.json.gz")
+	out, err := executeCmd(t, s, "backup", "-o", file)
+	if err != nil {
+		t.Fatalf("backup error = %v", err)
+	}
+	assertContains(t, out,
+		"Backed up Tasks: 1 tasks\n",
+		"Backed up Work: 1 tasks\n",
+		"Backed up 2 lists, 2 tasks, 1 checklist items, 1 linked resources, 1 attachments to "+file,
+	)
+
+	b := readBackup(t, file)
+	if b.Version != backup.Version || b.Attachments || len(b.Lists) != 2 || b.Lists[0].WellknownListName != "defaultList" {
+		t.Fatalf("backup = %+v", b)
+	}
+
+	task := b.Lists[1].Tasks[0]
+	if task.Id != taskId || task.Importance != "high" || task.Recurrence == nil || task.DueDateTime == nil || len(task.Categories) != 1 {
+		t.Errorf("backed up task = %+v", task)
+	}
+	if len(task.ChecklistItems) != 1 || !task.ChecklistItems[0].IsChecked || len(task.LinkedResources) != 1 || task.LinkedResources[0].WebUrl != "https://example.com/report" {
+		t.Errorf("backed up checklist items = %+v, linked resources = %+v", task.ChecklistItems, task.LinkedResources)
+	}
+	if len(task.Attachments) != 1 || task.Attachments[0].Name != "notes.txt" || task.Attachments[0].ContentBytes != nil {
+		t.Errorf("backed up attachments = %+v, want one without content", task.Attachments)
+	}
+
+	// With --attachments, the content is backed up too
+	file = path.Join(dir, "todo.json")
+	if _, err := executeCmd(t, s, "backup", "-o", file, "--attachments"); err != nil {
+		t.Fatalf("backup --attachments error = %v", err)
+	}
+	b = readBackup(t, file)
+	if attachments := b.Lists[1].Tasks[0].Attachments; !b.Attachments || len(attachments) != 1 || string(attachments[0].ContentBytes) != "notes" {
+		t.Errorf("backed up attachments = %+v, want one with content", attachments)
+	}
+
+	if _, err := executeCmd(t, s, "backup", "-o", path.Join(dir, "missing", "todo.json")); err == nil {
+		t.Error("backup into a missing directory should fail")
+	}
+}
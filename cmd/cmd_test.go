@@ -0,0 +1,121 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/oauth2"
+)
+
+// testConfigDir contains the config and a valid token, so that commands can
+// run against a graphfake server without signing in
+var testConfigDir string
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "mstodo-cmd-test")
+	if err != nil {
+		panic(err)
+	}
+	testConfigDir = dir
+
+	config := "client-id: id\nclient-secret: secret\nauth-timeout: 1\n"
+	if err := ioutil.WriteFile(path.Join(dir, "config.yaml"), []byte(config), 0600); err != nil {
+		panic(err)
+	}
+
+	token, err := json.Marshal(&oauth2.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "token.json"), token, 0600); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// executeCmd runs mstodo with args against s, returning the output
+func executeCmd(t *testing.T, s *graphfake.Server, args ...string) (string, error) {
+	t.Helper()
+
+	resetFlags(rootCmd)
+
+	out := &bytes.Buffer{}
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+	rootCmd.SetArgs(append([]string{"--config-dir", testConfigDir, "--graph-url", s.URL()}, args...))
+
+	err := rootCmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+// resetFlags restores the default flag values, as the commands are reused
+// between tests
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// assertContains fails the test if out doesn't contain each of want
+func assertContains(t *testing.T, out string, want ...string) {
+	t.Helper()
+
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("output doesn't contain %q:\n%s", w, out)
+		}
+	}
+}
+
+// assertNotContains fails the test if out contains any of unwanted
+func assertNotContains(t *testing.T, out string, unwanted ...string) {
+	t.Helper()
+
+	for _, u := range unwanted {
+		if strings.Contains(out, u) {
+			t.Errorf("output contains %q:\n%s", u, out)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dalyisaac/mstodo/utils"
+)
+
+// defaultColumns are the columns which each command shows when neither
+// --columns nor the columns config are set
+var defaultColumns = map[string]string{
+	"lists": "name,owner,shared",
+	"view":  "title,importance,status,reminder,due date,completed,created,last modified",
+}
+
+// columnRegistries are the columns which each command can show
+func columnRegistries() map[string]utils.Columns {
+	return map[string]utils.Columns{
+		"lists": listsColumns,
+		"view":  viewColumns(utils.Transformer, nil),
+	}
+}
+
+// selectColumns returns the columns which command shows. These are
+// columnsFlag, or else the columns config for the command, or else the
+// defaults, without the excluded columns. With showId, the Id column is shown
+// first.
+func selectColumns(command string, registry utils.Columns, columnsFlag, excludeFlag string, showId bool) (utils.Columns, error) {
+	include := columnsFlag
+	if include == "" {
+		include = cliConfig.Columns[command]
+	}
+	if include == "" {
+		include = defaultColumns[command]
+	}
+
+	cols, err := registry.Select(include, excludeFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	if showId {
+		return cols.Prepend(registry, "id")
+	}
+	return cols, nil
+}
+
+// validateColumnsConfig checks the columns config, like:
+//
+//	columns:
+//	  view: title,due,status
+func validateColumnsConfig(columns map[string]string) error {
+	registries := columnRegistries()
+
+	for command, include := range columns {
+		registry, ok := registries[command]
+		if !ok {
+			commands := []string{}
+			for c := range registries {
+				commands = append(commands, c)
+			}
+			sort.Strings(commands)
+
+			return fmt.Errorf("columns: '%s' is not a command with columns - choices: [%s]", command, strings.Join(commands, ", "))
+		}
+
+		if _, err := registry.Select(include, ""); err != nil {
+			return fmt.Errorf("columns.%s: %w", command, err)
+		}
+	}
+
+	return nil
+}
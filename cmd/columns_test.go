@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_selectColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		config      map[string]string
+		columnsFlag string
+		excludeFlag string
+		showId      bool
+		want        []string
+		wantErr     bool
+	}{
+		{name: "view defaults", command: "view", want: []string{"Title", "Importance", "Status", "Reminder", "Due Date", "Completed", "Created", "Last Modified"}},
+		{name: "view columns", command: "view", columnsFlag: "title,DUE,status", want: []string{"Title", "Due Date", "Status"}},
+		{name: "view aliases", command: "view", columnsFlag: "[last-modified, due_date, Modified]", want: []string{"Last Modified", "Due Date"}},
+		{name: "view id", command: "view", columnsFlag: "title", showId: true, want: []string{"Id", "Title"}},
+		{name: "view id selected", command: "view", columnsFlag: "title,id", showId: true, want: []string{"Title", "Id"}},
+		{name: "view exclude", command: "view", excludeFlag: "importance,due", want: []string{"Title", "Status", "Reminder", "Completed", "Created", "Last Modified"}},
+		{name: "view config", command: "view", config: map[string]string{"view": "status,title"}, want: []string{"Status", "Title"}},
+		{name: "flag over config", command: "view", config: map[string]string{"view": "status,title"}, columnsFlag: "title", want: []string{"Title"}},
+		{name: "lists defaults", command: "lists", want: []string{"Name", "Owner", "Shared"}},
+		{name: "lists id", command: "lists", showId: true, want: []string{"Id", "Name", "Owner", "Shared"}},
+		{name: "unknown column", command: "view", columnsFlag: "title,colour", wantErr: true},
+		{name: "unknown excluded column", command: "lists", excludeFlag: "title", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cliConfig.Columns = tt.config
+			defer func() { cliConfig.Columns = nil }()
+
+			got, err := selectColumns(tt.command, columnRegistries()[tt.command], tt.columnsFlag, tt.excludeFlag, tt.showId)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("selectColumns() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !reflect.DeepEqual(got.Names(), tt.want) {
+				t.Errorf("selectColumns() = %v, want %v", got.Names(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateColumnsConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns map[string]string
+		wantErr bool
+	}{
+		{name: "empty", columns: nil},
+		{name: "valid", columns: map[string]string{"view": "title,due", "lists": "name"}},
+		{name: "unknown command", columns: map[string]string{"add": "title"}, wantErr: true},
+		{name: "unknown column", columns: map[string]string{"view": "colour"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateColumnsConfig(tt.columns); (err != nil) != tt.wantErr {
+				t.Errorf("validateColumnsConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
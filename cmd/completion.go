@@ -0,0 +1,347 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/auth"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// listsCacheFile is the file in the config directory which the lists are
+	// saved to, so that they can be completed without waiting for Graph
+	listsCacheFile = "lists_cache.json"
+
+	// listsCacheMaxAge is how long the saved lists are used before they're got
+	// again
+	listsCacheMaxAge = time.Hour
+
+	// completionTimeout is how long completions wait for Graph, as the shell
+	// waits for them
+	completionTimeout = 2 * time.Second
+)
+
+func init() {
+	rootCmd.AddCommand(createCompletionCmd())
+}
+
+func createCompletionCmd() *cobra.Command {
+	// completionCmd represents the completion command
+	var completionCmd = &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate the shell completion script",
+		Long: `Generate the completion script for bash, zsh, fish or PowerShell.
+Besides the commands and flags, list names, task titles and flag values like
+--importance are completed. The list names are saved in the config directory,
+and are got again after an hour.
+
+Bash:
+  $ source <(mstodo completion bash)
+  # To load the completions for each session, on Linux:
+  $ mstodo completion bash > /etc/bash_completion.d/mstodo
+  # On macOS:
+  $ mstodo completion bash > /usr/local/etc/bash_completion.d/mstodo
+
+Zsh:
+  # If completion isn't enabled, enable it once with:
+  $ echo "autoload -U compinit; compinit" >> ~/.zshrc
+  # To load the completions for each session:
+  $ mstodo completion zsh > "${fpath[1]}/_mstodo"
+
+Fish:
+  $ mstodo completion fish | source
+  # To load the completions for each session:
+  $ mstodo completion fish > ~/.config/fish/completions/mstodo.fish
+
+PowerShell:
+  PS> mstodo completion powershell | Out-String | Invoke-Expression
+  # To load the completions for each session, add the output to your profile:
+  PS> mstodo completion powershell >> $PROFILE`,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.ExactValidArgs(1),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			root := cmd.Root()
+
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletion(out)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			default:
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+		},
+	}
+
+	return completionCmd
+}
+
+// registerRootCompletions registers the completions of the persistent flags.
+// It's called after they're created.
+func registerRootCompletions() {
+	rootCmd.RegisterFlagCompletionFunc("table-style", completeChoices(utils.TableStyleNames()...))
+	rootCmd.RegisterFlagCompletionFunc("cloud", completeChoices(auth.CloudNames()...))
+	rootCmd.RegisterFlagCompletionFunc("week-start", completeChoices(weekdayNames()...))
+	rootCmd.RegisterFlagCompletionFunc("date-locale", completeChoices(datetime.LocaleNames()...))
+	rootCmd.RegisterFlagCompletionFunc("date-order", completeChoices("dmy", "mdy", "ymd"))
+
+	for _, name := range []string{"config-dir", "record", "replay"} {
+		rootCmd.RegisterFlagCompletionFunc(name, completeDirs)
+	}
+}
+
+// completeChoices returns a completion function for a fixed set of choices
+func completeChoices(choices ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return filterCompletions(choices, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeCommaChoices returns a completion function for comma-separated
+// choices, like --columns="title,due". Only the last choice is completed.
+func completeCommaChoices(choices ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		done, last := "", toComplete
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			done, last = toComplete[:i+1], toComplete[i+1:]
+		}
+
+		completions := []string{}
+		for _, c := range filterCompletions(choices, last) {
+			completions = append(completions, done+c)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// noCompletions completes nothing, for arguments like titles
+func noCompletions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDirs completes directories
+func completeDirs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completeListNames completes the list names, without the lists which are
+// already in args
+func completeListNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	lists := completionLists(cmd)
+	if lists == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := []string{}
+	for _, list := range *lists {
+		if !containsFold(args, list.DisplayName) {
+			names = append(names, list.DisplayName)
+		}
+	}
+	sort.Strings(names)
+	return filterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeViewTitle completes the titles of the tasks in the lists in args, as
+// regexes which match the title
+func completeViewTitle(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	completions := []string{}
+	for _, c := range completeTasks(cmd, args) {
+		completions = append(completions, regexp.QuoteMeta(c))
+	}
+	return filterCompletions(completions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTasks returns the titles of the tasks in the named lists, described
+// by their list
+func completeTasks(cmd *cobra.Command, listNames []string) []string {
+	lists := completionLists(cmd)
+	if lists == nil || len(listNames) == 0 {
+		return nil
+	}
+
+	ctx, cancel := completionContext(cmd)
+	defer cancel()
+
+	client, err := newCompletionClient(ctx)
+	if err != nil {
+		return nil
+	}
+
+	completions := []string{}
+	for _, name := range listNames {
+		listId, err := lists.GetListId(name)
+		if err != nil {
+			continue
+		}
+
+		tasks, err := client.GetTasks(ctx, listId)
+		if err != nil {
+			continue
+		}
+
+		for _, task := range *tasks {
+			completions = append(completions, task.Title+"\t"+name)
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// completionLists returns the saved lists, or gets them if they're too old.
+// If they can't be got, the old lists are used. Errors aren't shown, as they'd
+// be mixed up with the completions.
+func completionLists(cmd *cobra.Command) *api.TodoTaskListList {
+	lists, modified, err := readListsCache()
+	if err == nil && time.Since(modified) < listsCacheMaxAge {
+		return lists
+	}
+
+	ctx, cancel := completionContext(cmd)
+	defer cancel()
+
+	client, err := newCompletionClient(ctx)
+	if err != nil {
+		return lists
+	}
+
+	if fresh, err := getLists(ctx, client); err == nil {
+		return fresh
+	}
+	return lists
+}
+
+// completionContext returns the context for getting completions, which is
+// cancelled after completionTimeout
+func completionContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithTimeout(ctx, completionTimeout)
+}
+
+// newCompletionClient creates the Graph client for completions, which never
+// starts the login flow
+func newCompletionClient(ctx context.Context) (*api.Client, error) {
+	if session != nil || replayDir != "" {
+		return newClient(ctx)
+	}
+	return api.NewClientWithSavedToken(ctx)
+}
+
+// readListsCache reads the saved lists, returning when they were saved
+func readListsCache() (*api.TodoTaskListList, time.Time, error) {
+	filepath := path.Join(viper.GetString("config-dir"), listsCacheFile)
+
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	lists := api.TodoTaskListList{}
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, time.Time{}, fmt.Errorf("could not read %s: %w", listsCacheFile, err)
+	}
+	return &lists, info.ModTime(), nil
+}
+
+// writeListsCache saves the lists for completions
+func writeListsCache(lists *api.TodoTaskListList) error {
+	data, err := json.Marshal(lists)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(viper.GetString("config-dir"), listsCacheFile), data, 0600)
+}
+
+// filterCompletions returns the completions which start with toComplete,
+// ignoring case. The descriptions after a tab aren't compared.
+func filterCompletions(completions []string, toComplete string) []string {
+	prefix := strings.ToLower(toComplete)
+
+	filtered := []string{}
+	for _, c := range completions {
+		value := strings.SplitN(c, "\t", 2)[0]
+		if strings.HasPrefix(strings.ToLower(value), prefix) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// containsFold returns true if names contains name, ignoring case
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnChoices returns the column names to complete, like "due-date"
+func columnChoices(columns utils.Columns) []string {
+	choices := []string{}
+	for _, name := range columns.Names() {
+		choices = append(choices, strings.ReplaceAll(strings.ToLower(name), " ", "-"))
+	}
+	return choices
+}
+
+// sortChoices returns the --sort choices for the columns, like "due-date:dsc"
+func sortChoices(columns utils.Columns) []string {
+	choices := []string{}
+	for _, name := range columnChoices(columns) {
+		choices = append(choices, name, name+":asc", name+":dsc")
+	}
+	return choices
+}
+
+// weekdayNames returns the names of the days of the week, from Monday
+func weekdayNames() []string {
+	names := []string{}
+	for i := 1; i <= 7; i++ {
+		names = append(names, strings.ToLower(time.Weekday(i%7).String()))
+	}
+	return names
+}
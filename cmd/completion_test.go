@@ -0,0 +1,144 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"github.com/spf13/cobra"
+)
+
+// completions runs the hidden __complete command, returning the completions
+// without the directive
+func completions(t *testing.T, s *graphfake.Server, args ...string) []string {
+	t.Helper()
+
+	out, err := executeCmd(t, s, append([]string{cobra.ShellCompRequestCmd}, args...)...)
+	if err != nil {
+		t.Fatalf("%v error = %v", args, err)
+	}
+
+	// The directive is followed by a message on stderr, which is also in out
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ":") {
+			return lines[:i]
+		}
+	}
+
+	t.Fatalf("%v has no directive:\n%s", args, out)
+	return nil
+}
+
+func Test_completeListNames(t *testing.T) {
+	os.Remove(path.Join(testConfigDir, listsCacheFile))
+
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	s.AddList("Work")
+	s.AddList("Groceries")
+
+	if got, want := completions(t, s, "view", ""), []string{"Groceries", "Tasks", "Work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("view completions = %v, want %v", got, want)
+	}
+
+	// The saved lists are used, so the new list isn't completed
+	requests := s.RequestCount()
+	s.AddList("Garden")
+
+	if got, want := completions(t, s, "view", "work", "g"), []string{"Groceries"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("view work g completions = %v, want %v", got, want)
+	}
+	if got, want := completions(t, s, "add", "--list", "W"), []string{"Work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("add --list W completions = %v, want %v", got, want)
+	}
+	if s.RequestCount() != requests {
+		t.Errorf("completions made %d requests, want 0", s.RequestCount()-requests)
+	}
+}
+
+func Test_completeViewTitle(t *testing.T) {
+	os.Remove(path.Join(testConfigDir, listsCacheFile))
+
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	workId := s.AddList("Work")
+	if _, err := s.AddTask(workId, graphfake.Object{"title": "Write report (draft)"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddTask(workId, graphfake.Object{"title": "Call Sam"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := completions(t, s, "view", "work", "--title", "Wr")
+	want := []string{`Write report \(draft\)` + "\twork"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("view --title completions = %v, want %v", got, want)
+	}
+}
+
+func Test_completeFlagChoices(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "importance", args: []string{"add", "--importance", ""}, want: []string{"low", "normal", "high"}},
+		{name: "status", args: []string{"view", "--status", "not"}, want: []string{"not started"}},
+		{name: "table style", args: []string{"lists", "--table-style", "colored"}, want: []string{"ColoredBlackOnBlueWhite", "ColoredBlackOnCyanWhite", "ColoredBlackOnGreenWhite", "ColoredBlackOnMagentaWhite", "ColoredBlackOnRedWhite", "ColoredBlackOnYellowWhite", "ColoredBlueWhiteOnBlack", "ColoredBright", "ColoredCyanWhiteOnBlack", "ColoredDark", "ColoredGreenWhiteOnBlack", "ColoredMagentaWhiteOnBlack", "ColoredRedWhiteOnBlack", "ColoredYellowWhiteOnBlack"}},
+		{name: "sort", args: []string{"view", "--sort", "title:dsc,due"}, want: []string{"title:dsc,due-date", "title:dsc,due-date:asc", "title:dsc,due-date:dsc"}},
+		{name: "lists sort", args: []string{"lists", "--sort", ""}, want: []string{"asc", "dsc", "none"}},
+		{name: "columns", args: []string{"lists", "--columns", "name,s"}, want: []string{"name,shared"}},
+		{name: "group by", args: []string{"view", "--group-by", "d"}, want: []string{"due-day"}},
+		{name: "completion", args: []string{"completion", "f"}, want: []string{"fish"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completions(t, s, tt.args...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_completionCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		out, err := executeCmd(t, s, "completion", shell)
+		if err != nil {
+			t.Fatalf("completion %s error = %v", shell, err)
+		}
+		assertContains(t, out, "mstodo")
+	}
+
+	if _, err := executeCmd(t, s, "completion", "tcsh"); err == nil {
+		t.Error("completion tcsh error = nil, want an error")
+	}
+}
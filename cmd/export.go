@@ -0,0 +1,198 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createExportCmd())
+}
+
+type exportParamsFlags struct {
+	format, output, tz string
+}
+
+func createExportCmd() *cobra.Command {
+	flags := exportParamsFlags{}
+
+	// exportCmd represents the export command
+	var exportCmd = &cobra.Command{
+		Use:   "export [list name]...",
+		Short: "Export tasks to another format",
+		Long: `Export the tasks of the lists, or of all the lists, to another format.
+
+todotxt writes a todo.txt line for each task, like:
+  (A) 2021-09-30 Write report +Work @Office due:2021-10-01 mstodo:AAMk...
+The priority is the importance, +project is the list, @context are the categories and
+due: is the due date. The mstodo: tag is the task's ID, so that importing the file
+again updates the tasks instead of adding them.
+
+ics writes an iCalendar file with a VTODO for each task, which calendar apps can
+import. The UID is the task's ID, the reminder is a VALARM, the notes are the
+DESCRIPTION and the recurrence is an RRULE. Recurrences on the nth weekday of a month
+are left out.
+
+taskwarrior writes the JSON which task import reads. The project is the list, the tags
+are the categories, the annotations are the notes, scheduled is the start date and
+wait is the reminder. What Taskwarrior can't represent, like the waiting on others
+status, is listed on the standard error.
+
+markdown writes a checklist with a heading for each list, like:
+  # Work
+
+  - [ ] Write report due: 2021-10-01 <!-- mstodo:AAMk... -->
+    - [x] Outline
+The nested bullets are the checklist items, and the comment is the task's ID, so that
+the file can be kept in a git repo or a notes app and imported again.
+
+csv writes a row for each task, with the title, list, importance, status, due date,
+reminder, categories and notes, which spreadsheets can open.`,
+		Example: `  mstodo export --format todotxt -o todo.txt
+  mstodo export work home --format todotxt
+  mstodo export work --format ics -o work.ics
+  mstodo export --format taskwarrior | task import
+  mstodo export work --format markdown -o work.md
+  mstodo export --format csv -o tasks.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := getTransferFormat(flags.format)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Dates are written in the user's time zone
+			loc, err := setTimeZone(ctx, client, flags.tz)
+			if err != nil {
+				return err
+			}
+
+			lists, err := getLists(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			selected, err := selectLists(lists, args)
+			if err != nil {
+				return err
+			}
+
+			tasks, err := getTransferTasks(ctx, client, selected, loc, format.fields.Has(transfer.Checklist))
+			if err != nil {
+				return err
+			}
+
+			// The report goes to the standard error, as the tasks can be
+			// written to the standard output
+			report := transfer.Report{}
+			if flags.output == "" || flags.output == "-" {
+				if err := format.encode(cmd.OutOrStdout(), tasks, report); err != nil {
+					return err
+				}
+				printReport(cmd.ErrOrStderr(), strings.ToLower(flags.format), report)
+				return nil
+			}
+
+			f, err := os.Create(flags.output)
+			if err != nil {
+				return err
+			}
+			if err := format.encode(f, tasks, report); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d tasks from %d lists to %s\n", len(tasks), len(selected), flags.output)
+			printReport(cmd.ErrOrStderr(), strings.ToLower(flags.format), report)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&flags.format, "format", "f", "", fmt.Sprintf("The format - choices: [%s]", strings.Join(transferFormatNames(), ", ")))
+	exportCmd.Flags().StringVarP(&flags.output, "output", "o", "", "The file to write, instead of the standard output")
+	exportCmd.Flags().StringVar(&flags.tz, "tz", "", "Time zone for the dates, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+
+	// completions
+	exportCmd.ValidArgsFunction = completeListNames
+	exportCmd.RegisterFlagCompletionFunc("format", completeChoices(transferFormatNames()...))
+
+	return exportCmd
+}
+
+// selectLists returns the lists with the names, or all the lists if there are
+// no names
+func selectLists(lists *api.TodoTaskListList, names []string) ([]api.TodoTaskListItem, error) {
+	if len(names) == 0 {
+		return *lists, nil
+	}
+
+	selected := []api.TodoTaskListItem{}
+	for _, name := range names {
+		id, err := lists.GetListId(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		for _, list := range *lists {
+			if list.Id == id {
+				selected = append(selected, list)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// getTransferTasks gets the tasks of the lists, with their times in loc, and
+// with their checklist items if checklists is set
+func getTransferTasks(ctx context.Context, client *api.Client, lists []api.TodoTaskListItem, loc *time.Location, checklists bool) ([]transfer.Task, error) {
+	tasks := []transfer.Task{}
+	for _, list := range lists {
+		listTasks, err := client.GetTasks(ctx, list.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range *listTasks {
+			transferTask := transfer.Task{TodoTask: task.In(loc), List: list.DisplayName}
+			if checklists {
+				if transferTask.Checklist, err = client.GetChecklistItems(ctx, list.Id, task.Id); err != nil {
+					return nil, err
+				}
+			}
+			tasks = append(tasks, transferTask)
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_exportCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work Stuff")
+	id, err := s.AddTask(listId, graphfake.Object{
+		"title":       "Write report",
+		"importance":  "high",
+		"categories":  []interface{}{"Red category"},
+		"dueDateTime": graphfake.Object{"dateTime": "2021-10-01T00:00:00.0000000", "timeZone": "UTC"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pay rent", "status": "completed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, s, "export", "--format", "todotxt", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	assertContains(t, out,
+		"(A) ",
+		" Write report +Work_Stuff @Red_category due:2021-10-01 mstodo:"+id+"\n",
+		"x ",
+		" Pay rent +Tasks mstodo:",
+	)
+
+	out, err = executeCmd(t, s, "export", "tasks", "--format", "todotxt")
+	if err != nil {
+		t.Fatalf("export tasks error = %v", err)
+	}
+	assertContains(t, out, "Pay rent")
+	assertNotContains(t, out, "Write report")
+
+	file := path.Join(t.TempDir(), "todo.txt")
+	out, err = executeCmd(t, s, "export", "--format", "todotxt", "-o", file)
+	if err != nil {
+		t.Fatalf("export -o error = %v", err)
+	}
+	assertContains(t, out, "Exported 2 tasks from 2 lists to "+file)
+
+	if b, err := ioutil.ReadFile(file); err != nil || !strings.Contains(string(b), "Write report") {
+		t.Errorf("export -o wrote %q, %v", b, err)
+	}
+
+	if _, err := executeCmd(t, s, "export"); err == nil || !strings.Contains(err.Error(), "missing --format") {
+		t.Errorf("export without --format error = %v", err)
+	}
+	if _, err := executeCmd(t, s, "export", "--format", "xml"); err == nil {
+		t.Error("export --format xml should fail")
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	noDueDate  = "no due date"
+	noCategory = "no category"
+)
+
+// taskGroup is a section of view's output
+type taskGroup struct {
+	name  string
+	tasks api.TodoTaskList
+}
+
+// taskGrouper splits the tasks into groups for --group-by
+type taskGrouper struct {
+	// keys returns the names of the groups which the task is in
+	keys func(params *viewParams, task api.TodoTask) []string
+
+	// less reports whether the group a is shown before the group b
+	less func(params *viewParams, a, b string) bool
+}
+
+var taskGroupers = map[string]taskGrouper{
+	"status": {
+		keys: func(params *viewParams, task api.TodoTask) []string { return []string{string(task.Status)} },
+		less: func(params *viewParams, a, b string) bool {
+			return indexOf(api.GraphStatusOptions, a) < indexOf(api.GraphStatusOptions, b)
+		},
+	},
+	"importance": {
+		keys: func(params *viewParams, task api.TodoTask) []string { return []string{task.Importance} },
+		less: func(params *viewParams, a, b string) bool {
+			order := []string{"high", "normal", "low"}
+			return indexOf(order, a) < indexOf(order, b)
+		},
+	},
+	"due-day": {
+		keys: func(params *viewParams, task api.TodoTask) []string {
+			if task.DueDateTime == nil {
+				return []string{noDueDate}
+			}
+			return []string{time.Time(*task.DueDateTime).Format("2006-01-02 Monday")}
+		},
+		less: lessWithLast(noDueDate),
+	},
+	"category": {
+		keys: func(params *viewParams, task api.TodoTask) []string {
+			if len(task.Categories) == 0 {
+				return []string{noCategory}
+			}
+			return task.Categories
+		},
+		less: lessWithLast(noCategory),
+	},
+	"list": {
+		keys: func(params *viewParams, task api.TodoTask) []string { return []string{params.taskLists[task.Id]} },
+		less: func(params *viewParams, a, b string) bool {
+			return indexOf(params.listNames, a) < indexOf(params.listNames, b)
+		},
+	},
+}
+
+// taskGrouperNames are the choices for --group-by
+func taskGrouperNames() []string {
+	names := []string{}
+	for name := range taskGroupers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getTaskGrouper returns the grouper for --group-by, or nil if flag is empty
+func getTaskGrouper(flag string) (*taskGrouper, error) {
+	if flag == "" {
+		return nil, nil
+	}
+
+	grouper, ok := taskGroupers[strings.ToLower(flag)]
+	if !ok {
+		return nil, fmt.Errorf("'%s' can't be grouped by - choices: [%s]", flag, strings.Join(taskGrouperNames(), ", "))
+	}
+	return &grouper, nil
+}
+
+// group splits the tasks into the groups, in order. A task can be in more than
+// one group, like when it has several categories.
+func (grouper *taskGrouper) group(params *viewParams, tasks api.TodoTaskList) []taskGroup {
+	groups := []taskGroup{}
+	indexes := map[string]int{}
+
+	for _, task := range tasks {
+		for _, key := range grouper.keys(params, task) {
+			i, ok := indexes[key]
+			if !ok {
+				i = len(groups)
+				indexes[key] = i
+				groups = append(groups, taskGroup{name: key})
+			}
+			groups[i].tasks = append(groups[i].tasks, task)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return grouper.less(params, groups[i].name, groups[j].name)
+	})
+
+	return groups
+}
+
+// lessWithLast compares the group names alphabetically, with last at the end
+func lessWithLast(last string) func(params *viewParams, a, b string) bool {
+	return func(params *viewParams, a, b string) bool {
+		if a == last || b == last {
+			return b == last && a != last
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	}
+}
+
+// indexOf returns the index of target in items, or len(items) if it's missing
+func indexOf(items []string, target string) int {
+	for i, item := range items {
+		if item == target {
+			return i
+		}
+	}
+	return len(items)
+}
+
+// taskSummary is printed by view --summary
+type taskSummary struct {
+	open, completed, overdue int
+	next                     *api.TodoTask
+}
+
+// summarizeTasks counts the open, completed and overdue tasks, and finds the
+// open task which is due next, from today
+func summarizeTasks(tasks api.TodoTaskList, now time.Time) taskSummary {
+	summary := taskSummary{}
+	nextDays := 0
+
+	for i, task := range tasks {
+		if task.Status == "completed" {
+			summary.completed++
+			continue
+		}
+		summary.open++
+
+		days, ok := utils.DaysUntilDue(task, now)
+		if !ok {
+			continue
+		}
+
+		if days < 0 {
+			summary.overdue++
+		} else if summary.next == nil || days < nextDays {
+			summary.next = &tasks[i]
+			nextDays = days
+		}
+	}
+
+	return summary
+}
+
+// printTaskSummary prints the totals of the tasks
+func printTaskSummary(out io.Writer, tasks api.TodoTaskList, now time.Time) {
+	summary := summarizeTasks(tasks, now)
+
+	next := ""
+	if summary.next != nil {
+		next = fmt.Sprintf("%s (%s)", summary.next.Title, time.Time(*summary.next.DueDateTime).Format("Mon 2 Jan 2006"))
+	}
+
+	t := utils.CreateBasicTable(out, &table.Row{"Summary", "Total"})
+	t.AppendRow(table.Row{"Open", summary.open})
+	t.AppendRow(table.Row{"Completed", summary.completed})
+	t.AppendRow(table.Row{"Overdue", summary.overdue})
+	t.AppendRow(table.Row{"Next due", next})
+	t.Render()
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_taskGrouper_group(t *testing.T) {
+	due := func(day int) *datetime.GraphTime {
+		g := datetime.GraphTime(time.Date(2021, 7, day, 0, 0, 0, 0, time.UTC))
+		return &g
+	}
+
+	tasks := api.TodoTaskList{
+		{Id: "1", Title: "a", Status: "completed", Importance: "low", Categories: []string{"work", "urgent"}},
+		{Id: "2", Title: "b", Status: "not started", Importance: "high", DueDateTime: due(10)},
+		{Id: "3", Title: "c", Status: "in progress", Importance: "normal", DueDateTime: due(9), Categories: []string{"work"}},
+	}
+	params := &viewParams{
+		listNames: []string{"work", "home"},
+		taskLists: map[string]string{"1": "home", "2": "work", "3": "home"},
+	}
+
+	tests := []struct {
+		groupBy string
+		want    map[string][]string
+		order   []string
+	}{
+		{groupBy: "status", order: []string{"not started", "in progress", "completed"}},
+		{groupBy: "importance", order: []string{"high", "normal", "low"}},
+		{groupBy: "due-day", order: []string{"2021-07-09 Friday", "2021-07-10 Saturday", noDueDate}},
+		{groupBy: "category", order: []string{"urgent", "work", noCategory}},
+		{groupBy: "list", order: []string{"work", "home"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			grouper, err := getTaskGrouper(tt.groupBy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := []string{}
+			for _, g := range grouper.group(params, tasks) {
+				names = append(names, g.name)
+			}
+			if !reflect.DeepEqual(names, tt.order) {
+				t.Errorf("taskGrouper.group() = %v, want %v", names, tt.order)
+			}
+		})
+	}
+
+	if _, err := getTaskGrouper("colour"); err == nil {
+		t.Errorf("getTaskGrouper() with an unknown group should fail")
+	}
+}
+
+func Test_summarizeTasks(t *testing.T) {
+	due := func(day int) *datetime.GraphTime {
+		g := datetime.GraphTime(time.Date(2021, 7, day, 0, 0, 0, 0, time.UTC))
+		return &g
+	}
+	now := time.Date(2021, 7, 9, 12, 0, 0, 0, time.UTC)
+
+	tasks := api.TodoTaskList{
+		{Title: "done", Status: "completed", DueDateTime: due(1)},
+		{Title: "late", Status: "not started", DueDateTime: due(8)},
+		{Title: "later", Status: "not started", DueDateTime: due(12)},
+		{Title: "today", Status: "in progress", DueDateTime: due(9)},
+		{Title: "whenever", Status: "not started"},
+	}
+
+	got := summarizeTasks(tasks, now)
+	if got.open != 4 || got.completed != 1 || got.overdue != 1 {
+		t.Errorf("summarizeTasks() = %d open, %d completed, %d overdue, want 4, 1, 1", got.open, got.completed, got.overdue)
+	}
+	if got.next == nil || got.next.Title != "today" {
+		t.Errorf("summarizeTasks() next = %v, want today", got.next)
+	}
+
+	if got := summarizeTasks(api.TodoTaskList{}, now); got.next != nil {
+		t.Errorf("summarizeTasks() of no tasks next = %v, want nil", got.next)
+	}
+}
+
+func Test_viewCmd_groupBy(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	for list, tasks := range map[string][]graphfake.Object{
+		"Work": {
+			{"title": "Write report", "importance": "high"},
+			{"title": "Book flights", "status": "completed"},
+		},
+		"Home": {
+			{"title": "Water plants"},
+		},
+	} {
+		listId := s.AddList(list)
+		for _, task := range tasks {
+			if _, err := s.AddTask(listId, task); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	out, err := executeCmd(t, s, "view", "work", "home", "--group-by", "list", "--summary")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "work (2)", "home (1)", "Water plants", "SUMMARY", "Open      │ 2")
+	if work, home := strings.Index(out, "work (2)"), strings.Index(out, "home (1)"); work > home {
+		t.Errorf("view --group-by list should show the lists in order:\n%s", out)
+	}
+
+	out, err = executeCmd(t, s, "view", "work", "--group-by", "importance")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "high (1)", "normal (1)")
+	assertNotContains(t, out, "Water plants")
+
+	if _, err := executeCmd(t, s, "view", "work", "--group-by", "colour"); err == nil {
+		t.Errorf("view --group-by with an unknown group should fail")
+	}
+}
@@ -0,0 +1,405 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/dalyisaac/mstodo/transfer/spreadsheet"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createImportCmd())
+}
+
+type importParamsFlags struct {
+	format, list, tz string
+	mapping, rejects string
+	dryRun, yes      bool
+}
+
+func createImportCmd() *cobra.Command {
+	flags := importParamsFlags{}
+
+	// importCmd represents the import command
+	var importCmd = &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import tasks from another format",
+		Long: `Import tasks from a file in another format, or from the standard input.
+
+Importing is idempotent: a task with the ID of a task in its list, like the mstodo: tag
+of todotxt or the UID of ics, is updated instead of added again. Tasks without an ID, or
+with the ID of another app, like the UID of a calendar, update the task with the same
+title, and tasks with a Microsoft To Do ID which isn't found are added.
+Only the fields which the format keeps are changed. Lists which don't exist are
+created, and ics tasks are in the list of their X-MSTODO-LIST property.
+
+What Microsoft To Do can't represent, like the depends of taskwarrior, is listed after
+the summary. Deleted Taskwarrior tasks are skipped.
+
+markdown tasks are in the list of the heading above them. Their checklist items are
+made the nested bullets: the missing ones are added, the checked state is updated and
+the others are deleted.
+
+csv reads a row for each task. The columns are found by their names in the header, like
+Title, Due date, Priority or Tags, or mapped with --map, like "Deadline=due", or by their
+numbers, like "1=title", for files without a header. Only the mapped columns are changed.
+Each row is checked like the flags of add, and the dates are parsed like add's. A table
+of the tasks is shown, and the import is confirmed unless --yes is given. Once it's
+confirmed, the rejected rows are written to --rejects, or <file>.rejects.csv, with the
+reason. The rejected rows of the standard input are written to the standard error
+unless --rejects is given.
+
+--dry-run prints what would be created and updated, with the changes of each updated
+task, without changing anything.`,
+		Example: `  mstodo import todo.txt --format todotxt --dry-run
+  mstodo export --format todotxt | mstodo import --format todotxt --list backup
+  mstodo import work.ics --format ics --list work
+  task export | mstodo import --format taskwarrior
+  mstodo import work.md --format markdown --dry-run
+  mstodo import plan.csv --format csv --map "Deadline=due,Prio=importance" --list work`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := getTransferFormat(flags.format)
+			if err != nil {
+				return err
+			}
+			if format.decode != nil && (flags.mapping != "" || flags.rejects != "") {
+				return errors.New("--map and --rejects are only used with --format csv")
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Dates are read in the user's time zone
+			loc, err := setTimeZone(ctx, client, flags.tz)
+			if err != nil {
+				return err
+			}
+
+			in, file := cmd.InOrStdin(), ""
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in, file = f, args[0]
+			}
+
+			report := transfer.Report{}
+			fields := format.fields
+			var tasks []transfer.Task
+			if format.decode == nil {
+				csvRows, ok, err := readCSV(cmd, in, file, flags, loc)
+				if err != nil || !ok {
+					return err
+				}
+				tasks, fields = csvRows.tasks, csvRows.fields()
+			} else {
+				tasks, err = format.decode(in, loc, report)
+				if err != nil {
+					return err
+				}
+			}
+
+			lists, err := getLists(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			im := &importer{
+				client:       client,
+				lists:        lists,
+				fields:       fields,
+				loc:          loc,
+				dryRun:       flags.dryRun,
+				out:          cmd.OutOrStdout(),
+				defaultList:  flags.list,
+				tasks:        map[string]api.TodoTaskList{},
+				plannedLists: map[string]string{},
+				matched:      map[string]bool{},
+			}
+			for _, task := range tasks {
+				if err := im.importTask(ctx, task); err != nil {
+					return err
+				}
+			}
+
+			// The report goes to the standard error, like export's
+			im.printSummary()
+			printReport(cmd.ErrOrStderr(), "Microsoft To Do", report)
+			return nil
+		},
+	}
+
+	importCmd.Flags().StringVarP(&flags.format, "format", "f", "", fmt.Sprintf("The format - choices: [%s]", strings.Join(transferFormatNames(), ", ")))
+	importCmd.Flags().StringVarP(&flags.list, "list", "l", "tasks", "The list of the tasks which don't name one")
+	importCmd.Flags().StringVar(&flags.mapping, "map", "", "The fields of the CSV columns, like \"Task=title,Deadline=due\" or \"1=title\" - fields: ["+strings.Join(spreadsheet.FieldNames, ", ")+"]")
+	importCmd.Flags().StringVar(&flags.rejects, "rejects", "", "The file which the rejected CSV rows are written to (defaults to <file>.rejects.csv, or the standard error for the standard input)")
+	importCmd.Flags().BoolVarP(&flags.yes, "yes", "y", false, "Import the CSV rows without asking")
+	importCmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print what would be created and updated, and the changes, without changing anything")
+	importCmd.Flags().StringVar(&flags.tz, "tz", "", "Time zone for the dates, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+
+	// completions
+	importCmd.RegisterFlagCompletionFunc("format", completeChoices(transferFormatNames()...))
+	importCmd.RegisterFlagCompletionFunc("list", completeListNames)
+
+	return importCmd
+}
+
+// importer creates or updates the imported tasks
+type importer struct {
+	client *api.Client
+	lists  *api.TodoTaskListList
+	fields transfer.Fields
+	loc    *time.Location
+	dryRun bool
+	out    io.Writer
+
+	// defaultList is the list of the tasks which don't name one
+	defaultList string
+
+	// tasks are the tasks of each list, by list ID, which are got when a task
+	// is imported into the list
+	tasks map[string]api.TodoTaskList
+
+	// plannedLists are the names of the lists which would be created in a dry
+	// run, by their lower-case names
+	plannedLists map[string]string
+
+	// matched are the IDs of the tasks which were updated, so that two imported
+	// tasks don't update the same task
+	matched map[string]bool
+
+	created, updated, unchanged, createdLists int
+}
+
+// importTask creates the task, or updates the task which it matches
+func (im *importer) importTask(ctx context.Context, task transfer.Task) error {
+	name := task.List
+	if name == "" {
+		name = im.defaultList
+	}
+
+	listId, name, err := im.list(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := im.find(ctx, listId, task.TodoTask)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		im.created++
+		im.print("Created", "Would create", fmt.Sprintf("%q in %s", task.Title, name))
+		if im.dryRun {
+			return nil
+		}
+
+		created := task.TodoTask
+		created.IsReminderOn = created.ReminderDateTime != nil
+		if err := im.client.CreateTask(ctx, listId, &created); err != nil {
+			return err
+		}
+
+		if !im.fields.Has(transfer.Checklist) {
+			return nil
+		}
+		return updateChecklist(ctx, im.client, listId, created.Id, transfer.ChecklistChanges{Create: task.Checklist})
+	}
+
+	im.matched[existing.Id] = true
+	merged, changed := transfer.Merge(*existing, task.TodoTask, im.fields, im.loc)
+
+	checklist := transfer.ChecklistChanges{}
+	if im.fields.Has(transfer.Checklist) {
+		items, err := im.client.GetChecklistItems(ctx, listId, existing.Id)
+		if err != nil {
+			return err
+		}
+		checklist = transfer.MergeChecklist(items, task.Checklist)
+	}
+
+	if !changed && checklist.Empty() {
+		im.unchanged++
+		return nil
+	}
+
+	changes := transfer.Changes(*existing, merged)
+	if !checklist.Empty() {
+		changes = append(changes, "checklist")
+	}
+
+	im.updated++
+	im.print("Updated", "Would update", fmt.Sprintf("%q in %s: %s", merged.Title, name, strings.Join(changes, ", ")))
+	if im.dryRun {
+		// The diff shows what the update would change
+		for _, line := range transfer.Diff(*existing, merged, im.loc) {
+			fmt.Fprintf(im.out, "    %s\n", line)
+		}
+		for _, line := range checklist.Lines() {
+			fmt.Fprintf(im.out, "    checklist: %s\n", line)
+		}
+		return nil
+	}
+
+	if changed {
+		if err := im.client.UpdateTask(ctx, listId, existing.Id, &merged); err != nil {
+			return err
+		}
+	}
+	return updateChecklist(ctx, im.client, listId, existing.Id, checklist)
+}
+
+// updateChecklist makes the changes to the checklist items of the task
+func updateChecklist(ctx context.Context, client *api.Client, listId string, taskId string, changes transfer.ChecklistChanges) error {
+	for i := range changes.Create {
+		if err := client.CreateChecklistItem(ctx, listId, taskId, &changes.Create[i]); err != nil {
+			return err
+		}
+	}
+	for i, item := range changes.Update {
+		if err := client.UpdateChecklistItem(ctx, listId, taskId, item.Id, &changes.Update[i]); err != nil {
+			return err
+		}
+	}
+	for _, item := range changes.Delete {
+		if err := client.DeleteChecklistItem(ctx, listId, taskId, item.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns the ID and name of the list called name, creating it if it
+// doesn't exist. In a dry run, the ID of a new list is empty, and it has no
+// tasks.
+func (im *importer) list(ctx context.Context, name string) (string, string, error) {
+	if id, err := im.lists.GetListId(strings.TrimSpace(name)); err == nil {
+		for _, list := range *im.lists {
+			if list.Id == id {
+				return id, list.DisplayName, nil
+			}
+		}
+	}
+
+	// In a dry run, the lists which would be created are only remembered
+	key := strings.ToLower(strings.TrimSpace(name))
+	if planned, ok := im.plannedLists[key]; ok {
+		return "", planned, nil
+	}
+
+	im.createdLists++
+	im.print("Created list", "Would create list", name)
+
+	if im.dryRun {
+		im.plannedLists[key] = name
+		im.tasks[""] = api.TodoTaskList{}
+		return "", name, nil
+	}
+
+	list, err := im.client.CreateList(ctx, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	*im.lists = append(*im.lists, *list)
+	writeListsCache(im.lists)
+	im.tasks[list.Id] = api.TodoTaskList{}
+	return list.Id, list.DisplayName, nil
+}
+
+// graphIdRegexp matches the IDs of Microsoft To Do, which are base64 and
+// start like "AAMk" or "AQMk"
+var graphIdRegexp = regexp.MustCompile(`^A[AQ]Mk[A-Za-z0-9_=+/-]*$`)
+
+// find returns the task in the list with the ID of task, or nil if there isn't
+// one. A task with an unknown Microsoft To Do ID is from another list or
+// account, and shouldn't replace a task which only has the same title. Tasks
+// without an ID, or with the ID of another app, like the UID of a calendar,
+// are found by their title instead, so that importing them again doesn't add
+// them twice.
+func (im *importer) find(ctx context.Context, listId string, task api.TodoTask) (*api.TodoTask, error) {
+	tasks, ok := im.tasks[listId]
+	if !ok {
+		got, err := im.client.GetTasks(ctx, listId)
+		if err != nil {
+			return nil, err
+		}
+		tasks = *got
+		im.tasks[listId] = tasks
+	}
+
+	if graphIdRegexp.MatchString(task.Id) {
+		for i, t := range tasks {
+			if t.Id == task.Id && !im.matched[t.Id] {
+				return &tasks[i], nil
+			}
+		}
+		return nil, nil
+	}
+
+	for i, t := range tasks {
+		if t.Title == task.Title && !im.matched[t.Id] {
+			return &tasks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// print prints what was done, or what would be done in a dry run
+func (im *importer) print(done string, dryRun string, what string) {
+	if im.dryRun {
+		fmt.Fprintf(im.out, "%s %s\n", dryRun, what)
+	} else {
+		fmt.Fprintf(im.out, "%s %s\n", done, what)
+	}
+}
+
+// printSummary prints the number of tasks which were created, updated and
+// unchanged
+func (im *importer) printSummary() {
+	summary := fmt.Sprintf("%d created, %d updated, %d unchanged", im.created, im.updated, im.unchanged)
+	if im.createdLists > 0 {
+		summary += fmt.Sprintf(", %d new lists", im.createdLists)
+	}
+
+	if im.dryRun {
+		fmt.Fprintf(im.out, "Dry run: %s\n", summary)
+	} else {
+		fmt.Fprintf(im.out, "Imported %d tasks: %s\n", im.created+im.updated+im.unchanged, summary)
+	}
+}
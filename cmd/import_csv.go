@@ -0,0 +1,276 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/dalyisaac/mstodo/transfer/spreadsheet"
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// csvFields are the fields which the columns of a CSV file are mapped to
+var csvFields = map[spreadsheet.Field]transfer.Fields{
+	spreadsheet.TitleField:      transfer.Title,
+	spreadsheet.DueField:        transfer.DueDate,
+	spreadsheet.ReminderField:   transfer.Reminder,
+	spreadsheet.ImportanceField: transfer.Importance,
+	spreadsheet.StatusField:     transfer.Status,
+	spreadsheet.CategoriesField: transfer.Categories,
+	spreadsheet.NotesField:      transfer.Body,
+}
+
+// readCSV reads the rows of the CSV file called file, or of in if it's empty,
+// and prints a preview. Unless it's a dry run or --yes is given, the import is
+// confirmed, and false is returned if it isn't. The rejected rows are written
+// once the import is confirmed, and a dry run doesn't write them.
+func readCSV(cmd *cobra.Command, in io.Reader, file string, flags importParamsFlags, loc *time.Location) (*csvImport, bool, error) {
+	out := cmd.OutOrStdout()
+
+	im, err := decodeCSV(in, flags.mapping)
+	if err != nil {
+		return nil, false, err
+	}
+	im.printPreview(out, flags.list, loc)
+
+	save := func() error {
+		return im.saveRejects(out, cmd.ErrOrStderr(), file, flags.rejects)
+	}
+
+	if len(im.tasks) == 0 {
+		if !flags.dryRun {
+			if err := save(); err != nil {
+				return nil, false, err
+			}
+		}
+		return nil, false, fmt.Errorf("none of the %d rows can be imported", len(im.sheet.Rows))
+	}
+	if flags.dryRun {
+		return im, true, nil
+	}
+
+	if !flags.yes {
+		if file == "" {
+			return nil, false, errors.New("the CSV is read from the standard input, so confirm the import with --yes, or preview it with --dry-run")
+		}
+
+		if !confirm(cmd.InOrStdin(), out, fmt.Sprintf("Import %d tasks?", len(im.tasks))) {
+			fmt.Fprintln(out, "Nothing was imported")
+
+			// The rejects are still wanted when their file was given
+			if flags.rejects != "" {
+				return nil, false, save()
+			}
+			return nil, false, nil
+		}
+	}
+
+	if err := save(); err != nil {
+		return nil, false, err
+	}
+	return im, true, nil
+}
+
+// csvImport is the rows of a CSV file, which are the tasks to import or the
+// rejected rows
+type csvImport struct {
+	sheet *spreadsheet.Sheet
+	tasks []transfer.Task
+
+	// rows are the numbers of the rows of the tasks
+	rows []int
+
+	rejects []csvReject
+}
+
+// csvReject is a row which can't be imported, with the reason
+type csvReject struct {
+	row    spreadsheet.Row
+	reason string
+}
+
+// decodeCSV reads the rows of a CSV file, with the columns mapped by mapping,
+// like "Deadline=due". Each row is checked like the flags of add, and the rows
+// which aren't valid are rejected.
+func decodeCSV(in io.Reader, mapping string) (*csvImport, error) {
+	m, err := spreadsheet.ParseMapping(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("--map: %w", err)
+	}
+
+	sheet, err := spreadsheet.Decode(in, m)
+	if errors.Is(err, spreadsheet.ErrNoTitle) {
+		return nil, fmt.Errorf("%w - map a column with --map, like --map \"Task=title\" or --map \"1=title\"", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	im := &csvImport{sheet: sheet, tasks: []transfer.Task{}, rows: []int{}, rejects: []csvReject{}}
+	for _, row := range sheet.Rows {
+		task, err := csvTask(sheet, row)
+		if err != nil {
+			im.rejects = append(im.rejects, csvReject{row: row, reason: err.Error()})
+			continue
+		}
+		im.tasks = append(im.tasks, *task)
+		im.rows = append(im.rows, row.Number)
+	}
+	return im, nil
+}
+
+// csvTask returns the task of the row. Its title, dates, importance and status
+// are checked by constructTaskPayload, like the flags of add, and blank cells
+// are add's defaults.
+func csvTask(sheet *spreadsheet.Sheet, row spreadsheet.Row) (*transfer.Task, error) {
+	flags := addParamsFlags{
+		importance: "normal",
+		status:     "not started",
+		reminder:   sheet.Value(row, spreadsheet.ReminderField),
+		dueDate:    sheet.Value(row, spreadsheet.DueField),
+	}
+	if importance := sheet.Value(row, spreadsheet.ImportanceField); importance != "" {
+		flags.importance = strings.ToLower(importance)
+	}
+	if status := sheet.Value(row, spreadsheet.StatusField); status != "" {
+		flags.status = strings.ToLower(status)
+	}
+
+	task, err := constructTaskPayload(flags, sheet.Value(row, spreadsheet.TitleField))
+	if err != nil {
+		return nil, err
+	}
+
+	task.Categories = spreadsheet.Categories(sheet.Value(row, spreadsheet.CategoriesField))
+	if notes := sheet.Value(row, spreadsheet.NotesField); notes != "" {
+		task.Body = &api.ItemBody{Content: notes, ContentType: "text"}
+	}
+	return &transfer.Task{TodoTask: *task, List: sheet.Value(row, spreadsheet.ListField)}, nil
+}
+
+// fields returns the fields of the mapped columns, which are the only ones
+// changed by importing
+func (im *csvImport) fields() transfer.Fields {
+	fields := transfer.Fields(0)
+	for field := range im.sheet.Columns {
+		fields |= csvFields[field]
+	}
+	return fields
+}
+
+// printPreview prints a table of the tasks which would be imported, and the
+// rows which are rejected. The tasks without a list are in defaultList.
+func (im *csvImport) printPreview(out io.Writer, defaultList string, loc *time.Location) {
+	formatTime := func(g *datetime.GraphTime, layout string) string {
+		if g == nil {
+			return ""
+		}
+		return time.Time(*g).In(loc).Format(layout)
+	}
+
+	if len(im.tasks) > 0 {
+		t := utils.CreateBasicTable(out, &table.Row{"Row", "Title", "List", "Importance", "Status", "Due date", "Reminder", "Categories"})
+		for i, task := range im.tasks {
+			list := task.List
+			if list == "" {
+				list = defaultList
+			}
+			t.AppendRow(table.Row{im.rows[i], task.Title, list, task.Importance, string(task.Status), formatTime(task.DueDateTime, "2006-01-02"), formatTime(task.ReminderDateTime, "2006-01-02 15:04"), strings.Join(task.Categories, ", ")})
+		}
+		t.Render()
+	}
+
+	for _, reject := range im.rejects {
+		fmt.Fprintf(out, "Rejected row %d: %s\n", reject.row.Number, reject.reason)
+	}
+}
+
+// saveRejects writes the rejected rows to the file called rejects, or else
+// next to file, like tasks.rejects.csv for tasks.csv. When the CSV was read
+// from the standard input, they're written to errOut instead.
+func (im *csvImport) saveRejects(out io.Writer, errOut io.Writer, file string, rejects string) error {
+	if len(im.rejects) == 0 {
+		return nil
+	}
+
+	if rejects == "" && file == "" {
+		return im.writeRejects(errOut)
+	}
+	if rejects == "" {
+		rejects = rejectsFileName(file)
+	}
+
+	f, err := os.OpenFile(rejects, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := im.writeRejects(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Wrote %d rejected rows to %s\n", len(im.rejects), rejects)
+	return nil
+}
+
+// writeRejects writes the rejected rows as CSV, with the header and a Reason
+// column
+func (im *csvImport) writeRejects(out io.Writer) error {
+	w := csv.NewWriter(out)
+	w.Comma = im.sheet.Comma
+	if im.sheet.Header != nil {
+		w.Write(append(append([]string{}, im.sheet.Header...), "Reason"))
+	}
+	for _, reject := range im.rejects {
+		w.Write(append(append([]string{}, reject.row.Record...), reject.reason))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// rejectsFileName returns the name of the rejects file of the CSV file called
+// name, like tasks.rejects.csv for tasks.csv
+func rejectsFileName(name string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + ".rejects.csv"
+}
+
+// confirm asks the question, and reports whether the answer is yes
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,466 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_importCmd_todotxt(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	todo := `(A) Write report +Work @Office due:2021-10-01
+x 2021-10-02 Pay rent
+Plant tulips +Garden
+`
+
+	workId := s.AddList("Work")
+
+	// A dry run doesn't change anything
+	rootCmd.SetIn(strings.NewReader(todo))
+	defer rootCmd.SetIn(nil)
+
+	out, err := executeCmd(t, s, "import", "--format", "todotxt", "--list", "work", "--dry-run", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import --dry-run error = %v", err)
+	}
+	assertContains(t, out,
+		`Would create "Write report" in Work`,
+		`Would create "Pay rent" in Work`,
+		"Would create list Garden",
+		`Would create "Plant tulips" in Garden`,
+		"Dry run: 3 created, 0 updated, 0 unchanged, 1 new lists",
+	)
+	if len(s.Lists()) != 2 || len(s.Tasks(workId)) != 0 {
+		t.Errorf("import --dry-run changed the lists %v", s.Lists())
+	}
+
+	rootCmd.SetIn(strings.NewReader(todo))
+	out, err = executeCmd(t, s, "import", "--format", "todotxt", "--list", "work", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out, "Created list Garden", "Imported 3 tasks: 3 created, 0 updated, 0 unchanged, 1 new lists")
+
+	tasks := s.Tasks(workId)
+	if len(tasks) != 2 {
+		t.Fatalf("import should add 2 tasks to Work, got %v", tasks)
+	}
+	for _, task := range tasks {
+		if task["title"] == "Write report" && task["importance"] != "high" {
+			t.Errorf("(A) should be high importance, got %v", task["importance"])
+		}
+		if task["title"] == "Pay rent" && task["status"] != "completed" {
+			t.Errorf("x should complete the task, got %v", task["status"])
+		}
+	}
+
+	// Importing the export again changes nothing
+	exported, err := executeCmd(t, s, "export", "--format", "todotxt", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+
+	rootCmd.SetIn(strings.NewReader(exported))
+	out, err = executeCmd(t, s, "import", "--format", "todotxt", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import of the export error = %v", err)
+	}
+	assertContains(t, out, "0 created, 0 updated, 3 unchanged")
+
+	// The ID finds the task even when its title changed
+	edited := strings.Replace(exported, "Write report", "Write the report", 1)
+	edited = strings.Replace(edited, "due:2021-10-01", "due:2021-10-08", 1)
+
+	rootCmd.SetIn(strings.NewReader(edited))
+	out, err = executeCmd(t, s, "import", "--format", "todotxt", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import of the edited export error = %v", err)
+	}
+	assertContains(t, out, `Updated "Write the report" in Work: title, due date`, "0 created, 1 updated, 2 unchanged")
+	if len(s.Tasks(workId)) != 2 {
+		t.Errorf("import should update the task instead of adding one, got %v", s.Tasks(workId))
+	}
+
+	// An ID which isn't found creates a task, even if one has the same title
+	rootCmd.SetIn(strings.NewReader("Write the report +Work mstodo:AAMkFromAnotherAccount\n"))
+	out, err = executeCmd(t, s, "import", "--format", "todotxt", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import with an unknown ID error = %v", err)
+	}
+	assertContains(t, out, `Created "Write the report" in Work`, "1 created, 0 updated, 0 unchanged")
+	if len(s.Tasks(workId)) != 3 {
+		t.Errorf("import with an unknown ID should add a task, got %v", s.Tasks(workId))
+	}
+
+	rootCmd.SetIn(strings.NewReader("Pay rent due:soon\n"))
+	if _, err := executeCmd(t, s, "import", "--format", "todotxt"); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("import of an invalid file error = %v", err)
+	}
+}
+
+func Test_importCmd_ics(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Garden")
+	if _, err := s.AddTask(listId, graphfake.Object{
+		"title":            "Water the plants",
+		"importance":       "low",
+		"categories":       []interface{}{"Outside"},
+		"dueDateTime":      graphfake.Object{"dateTime": "2021-10-01T00:00:00.0000000", "timeZone": "UTC"},
+		"isReminderOn":     true,
+		"reminderDateTime": graphfake.Object{"dateTime": "2021-10-01T08:00:00.0000000", "timeZone": "UTC"},
+		"body":             graphfake.Object{"content": "<p>Not the cactus</p>", "contentType": "html"},
+		"recurrence": graphfake.Object{
+			"pattern": graphfake.Object{"type": "weekly", "interval": 1, "daysOfWeek": []interface{}{"friday"}, "firstDayOfWeek": "sunday", "dayOfMonth": 0, "month": 0, "index": "first"},
+			"range":   graphfake.Object{"type": "noEnd", "startDate": "2021-10-01", "endDate": "0001-01-01", "numberOfOccurrences": 0},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := executeCmd(t, s, "export", "--format", "ics", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	assertContains(t, exported,
+		"SUMMARY:Water the plants\r\n",
+		"DUE;VALUE=DATE:20211001\r\n",
+		"PRIORITY:9\r\n",
+		"DESCRIPTION:Not the cactus\r\n",
+		"RRULE:FREQ=WEEKLY;BYDAY=FR;WKST=SU\r\n",
+		"TRIGGER;VALUE=DATE-TIME:20211001T080000Z\r\n",
+	)
+
+	// Importing the export again changes nothing
+	rootCmd.SetIn(strings.NewReader(exported))
+	defer rootCmd.SetIn(nil)
+
+	out, err := executeCmd(t, s, "import", "--format", "ics", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out, "Imported 1 tasks: 0 created, 0 updated, 1 unchanged")
+
+	edited := strings.Replace(exported, "RRULE:FREQ=WEEKLY;BYDAY=FR", "RRULE:FREQ=WEEKLY;BYDAY=MO,FR", 1)
+	rootCmd.SetIn(strings.NewReader(edited))
+	out, err = executeCmd(t, s, "import", "--format", "ics", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import edited error = %v", err)
+	}
+	assertContains(t, out, `Updated "Water the plants" in Garden: recurrence`)
+}
+
+func Test_importCmd_icsTwice(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Garden")
+
+	// The UIDs of another calendar aren't Microsoft To Do IDs, so the tasks
+	// are found by their title the second time
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//Calendar//EN",
+		"BEGIN:VTODO",
+		"UID:7f3c2a10-1d2e-4b5f-9a8c-0e1f2a3b4c5d@example.com",
+		"SUMMARY:Water the plants",
+		"X-MSTODO-LIST:Garden",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:8a4d3b21-2e3f-4c6a-8b9d-1f2a3b4c5d6e@example.com",
+		"SUMMARY:Mow the lawn",
+		"X-MSTODO-LIST:Garden",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	file := path.Join(t.TempDir(), "garden.ics")
+	if err := ioutil.WriteFile(file, []byte(calendar), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, s, "import", file, "--format", "ics", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out, "Imported 2 tasks: 2 created, 0 updated, 0 unchanged")
+
+	out, err = executeCmd(t, s, "import", file, "--format", "ics", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import again error = %v", err)
+	}
+	assertContains(t, out, "Imported 2 tasks: 0 created, 0 updated, 2 unchanged")
+
+	if tasks := s.Tasks(listId); len(tasks) != 2 {
+		t.Errorf("tasks = %v, want 2 tasks", tasks)
+	}
+}
+
+func Test_importCmd_taskwarrior(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	workId := s.AddList("Work")
+
+	export := `[
+{"id":1,"description":"Write report","status":"pending","uuid":"u1","project":"Work","tags":["Office"],"priority":"H","due":"20211001T000000Z","depends":"u2"},
+{"id":0,"description":"Old task","status":"deleted","uuid":"u2"}
+]`
+	rootCmd.SetIn(strings.NewReader(export))
+	defer rootCmd.SetIn(nil)
+
+	out, err := executeCmd(t, s, "import", "--format", "taskwarrior", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out,
+		`Created "Write report" in Work`,
+		"Imported 1 tasks: 1 created, 0 updated, 0 unchanged",
+		"Not represented in Microsoft To Do:\n  deleted tasks, which were skipped: 1 task\n  depends: 1 task\n",
+	)
+
+	tasks := s.Tasks(workId)
+	if len(tasks) != 1 || tasks[0]["importance"] != "high" {
+		t.Fatalf("import should add a high importance task to Work, got %v", tasks)
+	}
+
+	// The report goes to the standard error
+	resetFlags(rootCmd)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetIn(strings.NewReader(export))
+	rootCmd.SetArgs([]string{"--config-dir", testConfigDir, "--graph-url", s.URL(), "import", "--format", "taskwarrior", "--tz", "UTC"})
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("import again error = %v", err)
+	}
+	assertContains(t, stdout.String(), "Imported 1 tasks: 0 created, 0 updated, 1 unchanged")
+	assertNotContains(t, stdout.String(), "Not represented")
+	assertContains(t, stderr.String(), "Not represented in Microsoft To Do:\n  deleted tasks, which were skipped: 1 task\n  depends: 1 task\n")
+
+	// The export has the task's ID, so importing it again changes nothing
+	exported, err := executeCmd(t, s, "export", "--format", "taskwarrior", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	assertContains(t, exported, `"description":"Write report"`, `"project":"Work"`, `"tags":["Office"]`, `"mstodoid":"`)
+
+	rootCmd.SetIn(strings.NewReader(exported))
+	out, err = executeCmd(t, s, "import", "--format", "taskwarrior", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import of the export error = %v", err)
+	}
+	assertContains(t, out, "Imported 1 tasks: 0 created, 0 updated, 1 unchanged")
+	assertNotContains(t, out, "Not represented")
+}
+
+func Test_importCmd_markdown(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	workId := s.AddList("Work")
+	taskId, err := s.AddTask(workId, graphfake.Object{"title": "Write report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Outline", "Old"} {
+		if _, err := s.AddChecklistItem(workId, taskId, graphfake.Object{"displayName": name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file := `# Work
+
+- [ ] Write report due: 2021-10-01
+  - [x] Outline
+  - [ ] Draft
+- [ ] Book flights
+  - [ ] Passports
+`
+	rootCmd.SetIn(strings.NewReader(file))
+	defer rootCmd.SetIn(nil)
+
+	out, err := executeCmd(t, s, "import", "--format", "markdown", "--tz", "UTC", "--dry-run")
+	if err != nil {
+		t.Fatalf("import --dry-run error = %v", err)
+	}
+	assertContains(t, out,
+		`Would update "Write report" in Work: due date, checklist`,
+		"    due date: none -> 2021-10-01\n",
+		`    checklist: + "Draft"`,
+		`    checklist: checked "Outline"`,
+		`    checklist: - "Old"`,
+		`Would create "Book flights" in Work`,
+		"Dry run: 1 created, 1 updated, 0 unchanged",
+	)
+
+	rootCmd.SetIn(strings.NewReader(file))
+	out, err = executeCmd(t, s, "import", "--format", "markdown", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out, "Imported 2 tasks: 1 created, 1 updated, 0 unchanged")
+
+	exported, err := executeCmd(t, s, "export", "work", "--format", "markdown", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	assertContains(t, exported,
+		"# Work\n\n",
+		"- [ ] Write report due: 2021-10-01 <!-- mstodo:"+taskId+" -->\n  - [x] Outline\n  - [ ] Draft\n",
+		"- [ ] Book flights <!-- mstodo:",
+		" -->\n  - [ ] Passports\n",
+	)
+	assertNotContains(t, exported, "Old")
+
+	// Checking an item of the export is the only change
+	rootCmd.SetIn(strings.NewReader(strings.Replace(exported, "- [ ] Passports", "- [x] Passports", 1)))
+	out, err = executeCmd(t, s, "import", "--format", "markdown", "--tz", "UTC", "--dry-run")
+	if err != nil {
+		t.Fatalf("import of the export error = %v", err)
+	}
+	assertContains(t, out,
+		`Would update "Book flights" in Work: checklist`+"\n"+`    checklist: checked "Passports"`,
+		"Dry run: 0 created, 1 updated, 1 unchanged",
+	)
+}
+
+func Test_importCmd_csv(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	workId := s.AddList("Work")
+	if _, err := s.AddTask(workId, graphfake.Object{"title": "Pay rent"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	file := path.Join(dir, "plan.csv")
+	plan := "Task;Deadline;Prio;Notes;Owner\n" +
+		"Pay rent;2021-10-01;High;;Sam\n" +
+		"Write report;2021-10-08;low;Draft first;Kim\n" +
+		"Call Sam;soon;high;;Kim\n" +
+		";2021-10-01;;;\n" +
+		"Book flights;;urgent;;Kim\n"
+	if err := ioutil.WriteFile(file, []byte(plan), 0600); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"import", file, "--format", "csv", "--map", "Deadline=due,Prio=importance", "--list", "work", "--tz", "UTC"}
+
+	out, err := executeCmd(t, s, append(args, "--dry-run")...)
+	if err != nil {
+		t.Fatalf("import --dry-run error = %v", err)
+	}
+	assertContains(t, out,
+		"Pay rent", "2021-10-01", "Write report", "2021-10-08",
+		"Rejected row 4: 'soon' is not a valid value for due date: invalid date\n",
+		"Rejected row 5: title is empty\n",
+		"Rejected row 6: 'urgent' is not a valid value for importance\n",
+		`Would update "Pay rent" in Work: importance, due date`,
+		`Would create "Write report" in Work`,
+		"Dry run: 1 created, 1 updated, 0 unchanged",
+	)
+	assertNotContains(t, out, "Import 2 tasks?", "Wrote 3 rejected rows")
+
+	// The rejects are only written once the import is confirmed
+	rejectsFile := path.Join(dir, "plan.rejects.csv")
+	if _, err := os.Stat(rejectsFile); !os.IsNotExist(err) {
+		t.Errorf("import --dry-run shouldn't write the rejects, got %v", err)
+	}
+
+	// The import is confirmed
+	rootCmd.SetIn(strings.NewReader("n\n"))
+	defer rootCmd.SetIn(nil)
+	out, err = executeCmd(t, s, args...)
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out, "Import 2 tasks? [y/N] ", "Nothing was imported")
+	if tasks := s.Tasks(workId); len(tasks) != 1 {
+		t.Fatalf("an import which isn't confirmed shouldn't change the tasks, got %v", tasks)
+	}
+	if _, err := os.Stat(rejectsFile); !os.IsNotExist(err) {
+		t.Errorf("an import which isn't confirmed shouldn't write the rejects, got %v", err)
+	}
+
+	rootCmd.SetIn(strings.NewReader("y\n"))
+	out, err = executeCmd(t, s, args...)
+	if err != nil {
+		t.Fatalf("import error = %v", err)
+	}
+	assertContains(t, out, "Wrote 3 rejected rows to "+rejectsFile, "Imported 2 tasks: 1 created, 1 updated, 0 unchanged")
+
+	rejects, err := ioutil.ReadFile(rejectsFile)
+	if err != nil || !strings.HasPrefix(string(rejects), "Task;Deadline;Prio;Notes;Owner;Reason\nCall Sam;soon;high;;Kim;'soon' is not a valid value for due date: invalid date\n") {
+		t.Errorf("rejects = %q, %v", rejects, err)
+	}
+
+	tasks := s.Tasks(workId)
+	if len(tasks) != 2 || tasks[0]["importance"] != "high" || tasks[1]["body"].(map[string]interface{})["content"] != "Draft first" {
+		t.Errorf("imported tasks = %v", tasks)
+	}
+
+	// The export has the columns which import finds
+	exported, err := executeCmd(t, s, "export", "work", "--format", "csv", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("export error = %v", err)
+	}
+	assertContains(t, exported, "Title,List,Importance,Status,Due,Reminder,Categories,Notes\n", "Pay rent,Work,high,not started,2021-10-01,,,\n")
+
+	rootCmd.SetIn(strings.NewReader(exported))
+	out, err = executeCmd(t, s, "import", "--format", "csv", "--tz", "UTC", "--yes")
+	if err != nil {
+		t.Fatalf("import of the export error = %v", err)
+	}
+	assertContains(t, out, "Imported 2 tasks: 0 created, 0 updated, 2 unchanged")
+
+	rootCmd.SetIn(strings.NewReader(exported))
+	if _, err := executeCmd(t, s, "import", "--format", "csv"); err == nil || !strings.Contains(err.Error(), "confirm the import with --yes") {
+		t.Errorf("import from the standard input without --yes error = %v", err)
+	}
+
+	// The rejects of the standard input are written to the standard error
+	rootCmd.SetIn(strings.NewReader("Title,Due\nPay rent,soon\n"))
+	out, err = executeCmd(t, s, "import", "--format", "csv", "--yes")
+	if err == nil || err.Error() != "none of the 1 rows can be imported" {
+		t.Errorf("import of rejected rows error = %v", err)
+	}
+	assertContains(t, out, "Title,Due,Reason\nPay rent,soon,'soon' is not a valid value for due date: invalid date\n")
+
+	rootCmd.SetIn(strings.NewReader("Due\n2021-10-01\n"))
+	if _, err := executeCmd(t, s, "import", "--format", "csv", "--yes"); err == nil || !strings.Contains(err.Error(), `--map "Task=title"`) {
+		t.Errorf("import without a title column error = %v", err)
+	}
+
+	if _, err := executeCmd(t, s, "import", file, "--format", "todotxt", "--map", "Task=title"); err == nil || !strings.Contains(err.Error(), "only used with --format csv") {
+		t.Errorf("import --format todotxt --map error = %v", err)
+	}
+}
@@ -18,7 +18,10 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"fmt"
+	"io"
 	"regexp"
+	"strings"
 
 	"github.com/dalyisaac/mstodo/api"
 	"github.com/dalyisaac/mstodo/utils"
@@ -31,15 +34,15 @@ func init() {
 }
 
 type listsParams struct {
-	columns  []table.ColumnConfig
+	columns  utils.Columns
 	filter   *regexp.Regexp
 	sortMode table.SortMode
 }
 
 func createListsCmd() *cobra.Command {
 	var (
-		filterFlag, sortFlag, excludeFlag string
-		showIdFlag                        bool
+		filterFlag, sortFlag, excludeFlag, columnsFlag string
+		showIdFlag                                     bool
 	)
 
 	// listsCmd represents the list command
@@ -48,39 +51,54 @@ func createListsCmd() *cobra.Command {
 		Short: "Get a list of the task lists",
 		Long:  `Get a list of the Microsoft To Do task lists`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			params, err := getListsCmdParams(filterFlag, sortFlag, excludeFlag, showIdFlag)
+			params, err := getListsCmdParams(filterFlag, sortFlag, columnsFlag, excludeFlag, showIdFlag)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
 			if err != nil {
 				return err
 			}
 
 			// Get lists
-			lists, err := api.GetLists()
+			lists, err := getLists(ctx, client)
 			if err != nil {
 				return err
 			}
 
 			// Display results
-			printTaskListList(*lists, params)
+			printTaskListList(cmd.OutOrStdout(), *lists, params)
 			return nil
 		},
 	}
 
 	listsCmd.Flags().StringVarP(&filterFlag, "filter", "f", ".", "Filter the lists which contain this regex")
 	listsCmd.Flags().StringVarP(&sortFlag, "sort", "s", "none", "Sort by the name - choices: "+utils.GetSortOptions())
+	listsCmd.Flags().StringVar(&columnsFlag, "columns", "", fmt.Sprintf("The columns to show, in order, for example: --columns=\"name,shared\" - choices: [%s]", strings.Join(listsColumns.Names(), ", ")))
 	listsCmd.Flags().StringVarP(&excludeFlag, "exclude", "x", "", "Exclude columns")
 	listsCmd.Flags().BoolVarP(&showIdFlag, "id", "i", false, "Show the list IDs")
 
+	// completions
+	listsCmd.RegisterFlagCompletionFunc("sort", completeChoices(utils.SortOptions()...))
+	listsCmd.RegisterFlagCompletionFunc("columns", completeCommaChoices(columnChoices(listsColumns)...))
+	listsCmd.RegisterFlagCompletionFunc("exclude", completeCommaChoices(columnChoices(listsColumns)...))
+
 	return listsCmd
 }
 
-var listsCmdCols = []table.ColumnConfig{
-	utils.LeftColumn("ID"),
-	utils.LeftColumn("Name"),
-	utils.CenterColumn("Owner"),
-	utils.CenterColumn("Shared"),
+// listsColumns are the columns which lists can show
+var listsColumns = utils.Columns{
+	{ColumnConfig: utils.LeftColumn("Id"), Value: func(item interface{}) interface{} { return item.(api.TodoTaskListItem).Id }},
+	{ColumnConfig: utils.LeftColumn("Name"), Value: func(item interface{}) interface{} { return item.(api.TodoTaskListItem).DisplayName }},
+	{ColumnConfig: utils.CenterColumn("Owner"), Value: func(item interface{}) interface{} { return item.(api.TodoTaskListItem).IsOwner }},
+	{ColumnConfig: utils.CenterColumn("Shared"), Value: func(item interface{}) interface{} { return item.(api.TodoTaskListItem).IsShared }},
 }
 
-func getListsCmdParams(filterFlag, sortFlag, excludeFlag string, showIdFlag bool) (*listsParams, error) {
+func getListsCmdParams(filterFlag, sortFlag, columnsFlag, excludeFlag string, showIdFlag bool) (*listsParams, error) {
 	// Validate filter
 	r, err := regexp.Compile(filterFlag)
 	if err != nil {
@@ -93,13 +111,8 @@ func getListsCmdParams(filterFlag, sortFlag, excludeFlag string, showIdFlag bool
 		return nil, err
 	}
 
-	// Show ID flag
-	if !showIdFlag {
-		excludeFlag = "ID," + excludeFlag
-	}
-
-	// Construct excluded columns
-	cols, err := utils.GetAllowedColumns(excludeFlag, listsCmdCols)
+	// Construct the shown columns
+	cols, err := selectColumns("lists", listsColumns, columnsFlag, excludeFlag, showIdFlag)
 	if err != nil {
 		return nil, err
 	}
@@ -111,23 +124,15 @@ func getListsCmdParams(filterFlag, sortFlag, excludeFlag string, showIdFlag bool
 	}, nil
 }
 
-func printTaskListList(taskListList api.TodoTaskListList, params *listsParams) {
-	headerRow := table.Row{}
-	columns := params.columns
+func printTaskListList(out io.Writer, taskListList api.TodoTaskListList, params *listsParams) {
+	headerRow := params.columns.Header()
+	configs := params.columns.Configs()
 
-	// Add the column names to the header row
-	for _, c := range columns {
-		headerRow = append(headerRow, c.Name)
-	}
-
-	t := utils.CreateFormattedTable(&headerRow, &columns)
+	t := utils.CreateFormattedTable(out, &headerRow, &configs)
 
 	for _, taskList := range taskListList {
 		if params.filter.MatchString(taskList.DisplayName) {
-			row := table.Row{}
-
-			row = append(row, getAllowedTaskListItemFields(taskList, columns)...)
-			t.AppendRow(row)
+			t.AppendRow(params.columns.Row(taskList))
 		}
 	}
 
@@ -139,22 +144,3 @@ func printTaskListList(taskListList api.TodoTaskListList, params *listsParams) {
 
 	t.Render()
 }
-
-func getAllowedTaskListItemFields(taskItem api.TodoTaskListItem, columns []table.ColumnConfig) table.Row {
-	fields := table.Row{}
-
-	for _, col := range columns {
-		switch col.Name {
-		case "ID":
-			fields = append(fields, taskItem.Id)
-		case "Name":
-			fields = append(fields, taskItem.DisplayName)
-		case "Owner":
-			fields = append(fields, taskItem.IsOwner)
-		case "Shared":
-			fields = append(fields, taskItem.IsShared)
-		}
-	}
-
-	return fields
-}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_listsCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+	s.PageSize = 1
+
+	s.AddList("Work")
+	s.AddList("Groceries")
+
+	out, err := executeCmd(t, s, "lists", "--sort", "asc")
+	if err != nil {
+		t.Fatalf("lists error = %v", err)
+	}
+	assertContains(t, out, "Tasks", "Work", "Groceries")
+	assertNotContains(t, out, s.DefaultListID())
+
+	out, err = executeCmd(t, s, "lists", "--filter", "^W", "--id")
+	if err != nil {
+		t.Fatalf("lists error = %v", err)
+	}
+	assertContains(t, out, "Work")
+	assertNotContains(t, out, "Groceries", s.DefaultListID())
+}
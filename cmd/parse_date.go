@@ -0,0 +1,171 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createParseDateCmd())
+}
+
+type parseDateParamsFlags struct {
+	dateTime bool
+	filter   bool
+	tz       string
+}
+
+func createParseDateCmd() *cobra.Command {
+	flags := parseDateParamsFlags{}
+
+	parseDateCmd := &cobra.Command{
+		Use:   "parse-date <expr>",
+		Short: "Show how a date is parsed",
+		Long: `Show how a date is parsed, for diagnosing rejected dates.
+Prints the parsed date in your time zone and in UTC, the format which matched, and the
+clock and settings which the date is relative to. If the date is rejected, the layouts
+which almost matched are printed.`,
+		Example: `  mstodo parse-date "next friday"
+  mstodo parse-date --datetime "tomorrow 9am"
+  mstodo parse-date --filter "this week; after wednesday"`,
+		// The diagnostics explain a rejected date better than the usage
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("missing date")
+			}
+
+			if flags.dateTime && flags.filter {
+				return errors.New("--datetime and --filter can't be used together")
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			// The mailbox time zone isn't used, so that no sign in is needed
+			if _, err := setTimeZone(ctx, nil, flags.tz); err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			input := strings.Join(args, " ")
+
+			printDateReference(out, datetime.CurrentReference())
+
+			if flags.filter {
+				return printFilterExplanation(out, input)
+			}
+
+			explanation := datetime.ExplainDate(input)
+			if flags.dateTime {
+				explanation = datetime.ExplainDateTime(input)
+			}
+			printDateExplanation(out, explanation)
+			return explanation.Err
+		},
+	}
+
+	parseDateCmd.Flags().BoolVar(&flags.dateTime, "datetime", false, "Parse a date time, like --reminder of add")
+	parseDateCmd.Flags().BoolVar(&flags.filter, "filter", false, "Parse a date filter, like --due of view")
+	parseDateCmd.Flags().StringVar(&flags.tz, "tz", emptyString, "Time zone to parse the date in, like \"Europe/Paris\" (defaults to the time-zone config, then the system time zone)")
+
+	return parseDateCmd
+}
+
+// printDateReference prints the clock and settings which dates are parsed
+// relative to
+func printDateReference(out io.Writer, ref datetime.Reference) {
+	fmt.Fprintf(out, "Now:        %s (%s)\n", ref.Now.Format(time.RFC1123), ref.Location)
+	fmt.Fprintf(out, "Settings:   week starts on %s, locale %s, date order %s\n", ref.WeekStart, ref.Locale, ref.Order)
+}
+
+// printDateExplanation prints how a date was parsed, or why it was rejected
+func printDateExplanation(out io.Writer, e *datetime.Explanation) {
+	fmt.Fprintln(out)
+	if e.DateTime {
+		fmt.Fprintf(out, "Date time:  %q\n", e.Input)
+	} else {
+		fmt.Fprintf(out, "Date:       %q\n", e.Input)
+	}
+
+	if !strings.EqualFold(e.Translated, e.Input) {
+		fmt.Fprintf(out, "Translated: %q\n", e.Translated)
+	}
+
+	if e.Rule != "" {
+		fmt.Fprintf(out, "Rule:       %s\n", e.Rule)
+	}
+
+	if e.Err != nil {
+		fmt.Fprintf(out, "Error:      %v\n", e.Err)
+
+		if len(e.NearMisses) > 0 {
+			fmt.Fprintln(out, "Closest layouts:")
+			for _, miss := range e.NearMisses {
+				fmt.Fprintf(out, "  %q matched %q, then %s\n", miss.Layout, miss.Matched, miss.Reason)
+			}
+		}
+		return
+	}
+
+	fmt.Fprintf(out, "Local:      %s\n", e.Time.Format(time.RFC1123))
+	fmt.Fprintf(out, "UTC:        %s\n", e.Time.UTC().Format(time.RFC1123))
+}
+
+// printFilterExplanation prints the range of a date filter, and how each date
+// in it was parsed
+func printFilterExplanation(out io.Writer, input string) error {
+	filters, explanations, err := datetime.ExplainFilter(input)
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Filter:     %q\n", input)
+
+	if err == nil {
+		switch {
+		case filters.None:
+			fmt.Fprintln(out, "Matches:    tasks without a date")
+		case filters.Start == nil && filters.End == nil:
+			fmt.Fprintln(out, "Matches:    tasks with a date")
+		}
+
+		for _, bound := range []struct {
+			name string
+			t    *time.Time
+		}{{name: "Start", t: filters.Start}, {name: "End", t: filters.End}} {
+			if bound.t != nil {
+				fmt.Fprintf(out, "%-11s %s (%s UTC)\n", bound.name+":", bound.t.Format(time.RFC1123), bound.t.UTC().Format("2006-01-02 15:04:05"))
+			}
+		}
+	} else {
+		fmt.Fprintf(out, "Error:      %v\n", err)
+	}
+
+	for _, e := range explanations {
+		printDateExplanation(out, e)
+	}
+
+	return err
+}
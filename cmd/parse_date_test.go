@@ -0,0 +1,57 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_parseDateCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	out, err := executeCmd(t, s, "parse-date", "--datetime", "2021-10-17 15:00", "--tz", "Tokyo Standard Time")
+	if err != nil {
+		t.Fatalf("parse-date error = %v", err)
+	}
+	assertContains(t, out, "Asia/Tokyo", "ISO 8601", "Sun, 17 Oct 2021 15:00:00 JST", "Sun, 17 Oct 2021 06:00:00 UTC")
+
+	out, err = executeCmd(t, s, "parse-date", "17. Oktober 2021", "--date-locale", "de", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("parse-date error = %v", err)
+	}
+	assertContains(t, out, `Translated: "17 October 2021"`, `layout "2 January 2006"`, "Sun, 17 Oct 2021 00:00:00 UTC")
+
+	out, err = executeCmd(t, s, "parse-date", "Oct 17th")
+	if err == nil {
+		t.Errorf("parse-date of an invalid date should fail")
+	}
+	assertContains(t, out, "invalid date", "Closest layouts", `"Jan 2" matched "Oct 17", then extra text: "th"`)
+
+	out, err = executeCmd(t, s, "parse-date", "--filter", "2021-10-01..2021-10-31", "--tz", "UTC")
+	if err != nil {
+		t.Fatalf("parse-date error = %v", err)
+	}
+	assertContains(t, out, "Start:      Fri, 01 Oct 2021 00:00:00 UTC", "End:        Sun, 31 Oct 2021 23:59:59 UTC", `Date:       "2021-10-31"`)
+
+	if _, err := executeCmd(t, s, "parse-date", "--filter", "--datetime", "today"); err == nil {
+		t.Errorf("parse-date with --filter and --datetime should fail")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/picker"
+	"github.com/dalyisaac/mstodo/internal/term"
+	"github.com/dalyisaac/mstodo/utils"
+)
+
+// builtinPicker is the picker config for the picker built into mstodo
+const builtinPicker = "builtin"
+
+// errNotInteractive is returned by pick when the user can't be asked
+var errNotInteractive = errors.New("can't choose interactively, as the input or output isn't a terminal")
+
+// pick asks the user to choose from items, and returns the indexes of the
+// chosen ones. With multi, several can be chosen. It uses the built-in picker,
+// or the external command in the picker config, like fzf.
+func pick(in io.Reader, prompt string, items []string, multi bool) ([]int, error) {
+	if !canPick(in) {
+		return nil, errNotInteractive
+	}
+
+	if cliConfig.Picker == "" || cliConfig.Picker == builtinPicker {
+		return picker.Pick(prompt, items, multi)
+	}
+	return pickExternal(cliConfig.Picker, prompt, items, multi)
+}
+
+// canPick reports whether the user can be asked to choose, which needs the
+// input and output to be a terminal. In the shell, it's the shell's input.
+func canPick(in io.Reader) bool {
+	if session != nil {
+		return session.terminal
+	}
+
+	f, ok := in.(*os.File)
+	return ok && term.IsTerminal(f) && isTerminal()
+}
+
+// fzfPickers are the pickers which take fzf's options, so the items can be
+// numbered and the numbers hidden
+var fzfPickers = []string{"fzf", "sk"}
+
+// pickExternal runs command, like "fzf --height 40%" or "dmenu -p {prompt}",
+// with the items on its input, one per line. For fzf and sk, the items are
+// numbered so that the chosen ones can be found, and --with-nth hides the
+// numbers. Other pickers get the items as they are, and the chosen lines are
+// matched to them. {prompt} in command is replaced with the prompt.
+func pickExternal(command string, prompt string, items []string, multi bool) ([]int, error) {
+	words, err := splitShellWords(command)
+	if err != nil || len(words) == 0 {
+		return nil, fmt.Errorf("picker: %q isn't a command", command)
+	}
+
+	args := []string{}
+	for _, word := range words[1:] {
+		args = append(args, strings.ReplaceAll(word, "{prompt}", prompt))
+	}
+
+	numbered := utils.ContainsString(fzfPickers, strings.TrimSuffix(filepath.Base(words[0]), ".exe"))
+	if numbered {
+		args = append(args, "--prompt", prompt+"> ", "--delimiter", "\t", "--with-nth", "2..")
+		if multi {
+			args = append(args, "--multi")
+		}
+	}
+
+	input := &bytes.Buffer{}
+	lines := make([]string, len(items))
+	clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for i, item := range items {
+		lines[i] = clean.Replace(item)
+		if numbered {
+			fmt.Fprintf(input, "%d\t%s\n", i, lines[i])
+		} else {
+			fmt.Fprintf(input, "%s\n", lines[i])
+		}
+	}
+
+	c := exec.Command(words[0], args...)
+	c.Stdin = input
+	c.Stderr = os.Stderr
+
+	out, err := c.Output()
+	if exitErr := (&exec.ExitError{}); errors.As(err, &exitErr) {
+		// fzf exits with 1 when nothing matches, and 130 when it's cancelled.
+		// dmenu and rofi exit with 1 when they're cancelled.
+		if code := exitErr.ExitCode(); code == 1 || code == 130 {
+			return nil, picker.ErrCancelled
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("picker: %w", err)
+	}
+
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, picker.ErrCancelled
+	}
+
+	chosen := []int{}
+	used := make([]bool, len(items))
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		i := -1
+		if numbered {
+			if n, err := strconv.Atoi(strings.SplitN(line, "\t", 2)[0]); err == nil {
+				i = n
+			}
+		} else {
+			// Items with the same text are chosen in order
+			for j, l := range lines {
+				if l == line && !used[j] {
+					i = j
+					break
+				}
+			}
+		}
+
+		if i < 0 || i >= len(items) {
+			return nil, fmt.Errorf("picker: unexpected output %q", line)
+		}
+		used[i] = true
+		chosen = append(chosen, i)
+	}
+	return chosen, nil
+}
+
+// pickLists asks the user to choose lists, and returns their names
+func pickLists(in io.Reader, lists *api.TodoTaskListList) ([]string, error) {
+	items := []string{}
+	for _, list := range *lists {
+		items = append(items, list.DisplayName)
+	}
+
+	chosen, err := pick(in, "List", items, true)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, i := range chosen {
+		names = append(names, items[i])
+	}
+	return names, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"github.com/dalyisaac/mstodo/internal/picker"
+)
+
+func Test_pick(t *testing.T) {
+	if _, err := pick(strings.NewReader(""), "List", []string{"Work"}, false); !errors.Is(err, errNotInteractive) {
+		t.Errorf("pick() error = %v, want %v", err, errNotInteractive)
+	}
+}
+
+func Test_pickExternal(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh isn't installed")
+	}
+
+	// The stubs write their arguments to a file, and print the chosen lines
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	for _, name := range []string{"fzf", "dmenu"} {
+		script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %q\nsed -n \"$PICK\"\n", argsFile)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		pick     string
+		multi    bool
+		want     []int
+		wantArgs []string
+		wantErr  error
+	}{
+		{name: "fzf", command: filepath.Join(dir, "fzf") + " --height 40%", pick: "2p", want: []int{1}, wantArgs: []string{"--height", "40%", "--prompt", "List> ", "--delimiter", "\t", "--with-nth", "2.."}},
+		{name: "fzf several", command: filepath.Join(dir, "fzf"), pick: "1,2p", multi: true, want: []int{0, 1}, wantArgs: []string{"--prompt", "List> ", "--delimiter", "\t", "--with-nth", "2..", "--multi"}},
+		{name: "other", command: filepath.Join(dir, "dmenu") + " -p {prompt}", pick: "2p", want: []int{1}, wantArgs: []string{"-p", "List"}},
+		{name: "other several", command: filepath.Join(dir, "dmenu"), pick: "1p;3p", multi: true, want: []int{0, 2}, wantArgs: []string{}},
+		{name: "same text", command: filepath.Join(dir, "dmenu"), pick: "2p;4p", multi: true, want: []int{1, 3}, wantArgs: []string{}},
+		{name: "cancelled", command: "sh -c 'exit 130'", wantErr: picker.ErrCancelled},
+		{name: "no match", command: "sh -c 'exit 1'", wantErr: picker.ErrCancelled},
+		{name: "nothing chosen", command: "sh -c 'true'", wantErr: picker.ErrCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(argsFile)
+			os.Setenv("PICK", tt.pick)
+			defer os.Unsetenv("PICK")
+
+			got, err := pickExternal(tt.command, "List", []string{"Work", "Home", "Groceries", "Home"}, tt.multi)
+			if err != tt.wantErr {
+				t.Fatalf("pickExternal() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pickExternal() = %v, want %v", got, tt.want)
+			}
+
+			if tt.wantArgs == nil {
+				return
+			}
+			out, err := ioutil.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := []string{}
+			if s := strings.TrimSuffix(string(out), "\n"); s != "" {
+				args = strings.Split(s, "\n")
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("pickExternal() ran the picker with %q, want %q", args, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, err := pickExternal("sh -c 'echo Nowhere'", "List", []string{"Work"}, false); err == nil {
+		t.Error("pickExternal() should fail on output which isn't an item")
+	}
+	if _, err := pickExternal(filepath.Join(dir, "fzf"), "List", []string{"Work"}, false); err == nil {
+		t.Error("pickExternal() with fzf should fail on output without the numbers")
+	}
+}
+
+func Test_viewCmd_pickNotInteractive(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	_, err := executeCmd(t, s, "view")
+	if err == nil || !strings.Contains(err.Error(), "missing list name") {
+		t.Errorf("view error = %v, want missing list name", err)
+	}
+	if s.RequestCount() != 0 {
+		t.Errorf("view made %d requests, want 0", s.RequestCount())
+	}
+}
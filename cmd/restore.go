@@ -0,0 +1,417 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/backup"
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createRestoreCmd())
+}
+
+type restoreParamsFlags struct {
+	idMap             string
+	dryRun, overwrite bool
+}
+
+func createRestoreCmd() *cobra.Command {
+	flags := restoreParamsFlags{}
+
+	// restoreCmd represents the restore command
+	var restoreCmd = &cobra.Command{
+		Use:   "restore [file]",
+		Short: "Restore the lists and tasks of a backup",
+		Long: `Restore the lists and tasks of a backup made by backup, from the file or from the
+standard input, into the signed in account.
+
+A list of the backup is restored into the list with its ID, or the default list for the
+default list, or else the list with its name, which is created if there isn't one. A task
+is matched to the task of the list with its ID, or else with its title. Tasks which
+don't match, like deleted ones, are created with their checklist items, linked resources
+and attachments, and matched tasks are kept as they are, unless --overwrite is given.
+
+Graph gives the restored lists, tasks and checklist items new IDs and created times.
+Restoring into another account matches by title, so --id-map keeps a file of the IDs of
+the backup and the IDs which they were restored to, which is read before restoring and
+written after, even if the restore fails partway, to match them exactly the next time.
+
+Attachments are only restored if the backup was made with --attachments, and attachments
+bigger than 3 MB are skipped.`,
+		Example: `  mstodo restore todo.json.gz --dry-run
+  mstodo restore todo.json.gz
+  mstodo restore todo.json.gz --overwrite --id-map todo-ids.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (returnErr error) {
+			in := cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			b, err := backup.Decode(in)
+			if err != nil {
+				return err
+			}
+
+			idMap, err := readIdMap(flags.idMap)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			lists, err := getLists(ctx, client)
+			if err != nil {
+				return err
+			}
+
+			r := &restorer{
+				client:    client,
+				lists:     lists,
+				dryRun:    flags.dryRun,
+				overwrite: flags.overwrite,
+				out:       cmd.OutOrStdout(),
+				idMap:     idMap,
+				matched:   map[string]bool{},
+			}
+			// The IDs are written even when the restore fails partway, so that
+			// the next run matches what was already restored
+			if flags.idMap != "" && !flags.dryRun {
+				defer func() {
+					if err := writeIdMap(flags.idMap, idMap); err != nil && returnErr == nil {
+						returnErr = err
+					}
+				}()
+			}
+
+			for _, list := range b.Lists {
+				if err := r.restoreList(ctx, list); err != nil {
+					return err
+				}
+			}
+
+			r.printSummary()
+			return nil
+		},
+	}
+
+	restoreCmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print what would be created and updated, without changing anything")
+	restoreCmd.Flags().BoolVar(&flags.overwrite, "overwrite", false, "Replace the tasks which match with the backup's, instead of keeping them")
+	restoreCmd.Flags().StringVar(&flags.idMap, "id-map", "", "The file of the IDs of the backup and the IDs which they were restored to")
+
+	return restoreCmd
+}
+
+// restorer creates the lists and tasks of a backup, or updates the ones which
+// they match
+type restorer struct {
+	client    *api.Client
+	lists     *api.TodoTaskListList
+	dryRun    bool
+	overwrite bool
+	out       io.Writer
+
+	// idMap maps the IDs of the backup's lists and tasks to the IDs which
+	// they were restored to
+	idMap map[string]string
+
+	// matched are the IDs of the tasks which were matched, so that two tasks
+	// of the backup don't match the same task
+	matched map[string]bool
+
+	created, updated, kept, createdLists int
+}
+
+// restoreList restores the list and its tasks
+func (r *restorer) restoreList(ctx context.Context, list backup.List) error {
+	target := r.findList(list)
+	tasks := api.TodoTaskList{}
+
+	if target == nil {
+		r.createdLists++
+		r.print("Created list", "Would create list", list.DisplayName)
+		if r.dryRun {
+			target = &api.TodoTaskListItem{DisplayName: list.DisplayName}
+		} else {
+			created, err := r.client.CreateList(ctx, list.DisplayName)
+			if err != nil {
+				return err
+			}
+			*r.lists = append(*r.lists, *created)
+			writeListsCache(r.lists)
+			target = created
+		}
+	} else {
+		got, err := r.client.GetTasks(ctx, target.Id)
+		if err != nil {
+			return err
+		}
+		tasks = *got
+	}
+
+	if target.Id != "" {
+		r.idMap[list.Id] = target.Id
+	}
+
+	for _, task := range list.Tasks {
+		if err := r.restoreTask(ctx, target, tasks, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findList returns the list which the list of the backup is restored into, or
+// nil if it has to be created
+func (r *restorer) findList(list backup.List) *api.TodoTaskListItem {
+	for _, id := range []string{r.idMap[list.Id], list.Id} {
+		for i, l := range *r.lists {
+			if id != "" && l.Id == id {
+				return &(*r.lists)[i]
+			}
+		}
+	}
+
+	// The default list can't be created, and can have another name
+	if list.WellknownListName != "" && list.WellknownListName != "none" {
+		for i, l := range *r.lists {
+			if l.WellknownListName == list.WellknownListName {
+				return &(*r.lists)[i]
+			}
+		}
+	}
+
+	for i, l := range *r.lists {
+		if strings.EqualFold(l.DisplayName, list.DisplayName) {
+			return &(*r.lists)[i]
+		}
+	}
+	return nil
+}
+
+// restoreTask creates the task in the list, or else keeps or overwrites the
+// task of tasks which it matches
+func (r *restorer) restoreTask(ctx context.Context, list *api.TodoTaskListItem, tasks api.TodoTaskList, task backup.Task) error {
+	existing := r.findTask(tasks, task)
+
+	if existing == nil {
+		r.created++
+		r.print("Created", "Would create", fmt.Sprintf("%q in %s", task.Title, list.DisplayName))
+		if r.dryRun {
+			return nil
+		}
+
+		created := task.TodoTask()
+		if err := r.client.CreateTask(ctx, list.Id, &created); err != nil {
+			return err
+		}
+		r.idMap[task.Id] = created.Id
+
+		return r.restoreChildren(ctx, list.Id, created.Id, task, nil, nil, nil)
+	}
+
+	r.matched[existing.Id] = true
+	r.idMap[task.Id] = existing.Id
+	if !r.overwrite {
+		r.kept++
+		return nil
+	}
+
+	r.updated++
+	r.print("Overwrote", "Would overwrite", fmt.Sprintf("%q in %s", task.Title, list.DisplayName))
+	if r.dryRun {
+		return nil
+	}
+
+	updated := task.TodoTask()
+	if err := r.client.UpdateTask(ctx, list.Id, existing.Id, &updated); err != nil {
+		return err
+	}
+
+	items, err := r.client.GetChecklistItems(ctx, list.Id, existing.Id)
+	if err != nil {
+		return err
+	}
+	resources, err := r.client.GetLinkedResources(ctx, list.Id, existing.Id)
+	if err != nil {
+		return err
+	}
+	attachments := []api.Attachment{}
+	if existing.HasAttachments {
+		if attachments, err = r.client.GetAttachments(ctx, list.Id, existing.Id); err != nil {
+			return err
+		}
+	}
+	return r.restoreChildren(ctx, list.Id, existing.Id, task, items, resources, attachments)
+}
+
+// findTask returns the task of tasks which the task of the backup matches, or
+// nil if there isn't one
+func (r *restorer) findTask(tasks api.TodoTaskList, task backup.Task) *api.TodoTask {
+	for _, id := range []string{r.idMap[task.Id], task.Id} {
+		for i, t := range tasks {
+			if id != "" && t.Id == id && !r.matched[t.Id] {
+				return &tasks[i]
+			}
+		}
+	}
+
+	for i, t := range tasks {
+		if t.Title == task.Title && !r.matched[t.Id] {
+			return &tasks[i]
+		}
+	}
+	return nil
+}
+
+// restoreChildren makes the checklist items of the task the backup's, and adds
+// the linked resources and attachments which it doesn't have, by their URL and
+// name
+func (r *restorer) restoreChildren(ctx context.Context, listId string, taskId string, task backup.Task, items []api.ChecklistItem, resources []api.LinkedResource, attachments []api.Attachment) error {
+	restored := []api.ChecklistItem{}
+	for _, item := range task.ChecklistItems {
+		restored = append(restored, item.ChecklistItem())
+	}
+	if err := updateChecklist(ctx, r.client, listId, taskId, transfer.MergeChecklist(items, restored)); err != nil {
+		return err
+	}
+
+	for _, resource := range task.LinkedResources {
+		if hasLinkedResource(resources, resource.WebUrl) {
+			continue
+		}
+		created := resource.LinkedResource()
+		if err := r.client.CreateLinkedResource(ctx, listId, taskId, &created); err != nil {
+			return err
+		}
+	}
+
+	for _, attachment := range task.Attachments {
+		if hasAttachment(attachments, attachment.Name) {
+			continue
+		}
+
+		switch {
+		case attachment.ContentBytes == nil:
+			fmt.Fprintf(r.out, "Skipped the attachment %q of %q, which was backed up without --attachments\n", attachment.Name, task.Title)
+		case len(attachment.ContentBytes) > api.MaxAttachmentSize:
+			fmt.Fprintf(r.out, "Skipped the attachment %q of %q, which is bigger than 3 MB\n", attachment.Name, task.Title)
+		default:
+			created := attachment.Attachment()
+			if err := r.client.CreateAttachment(ctx, listId, taskId, &created); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasLinkedResource(resources []api.LinkedResource, webUrl string) bool {
+	for _, resource := range resources {
+		if resource.WebUrl == webUrl {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttachment(attachments []api.Attachment, name string) bool {
+	for _, attachment := range attachments {
+		if attachment.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// print prints what was done, or what would be done in a dry run
+func (r *restorer) print(done string, dryRun string, what string) {
+	if r.dryRun {
+		fmt.Fprintf(r.out, "%s %s\n", dryRun, what)
+	} else {
+		fmt.Fprintf(r.out, "%s %s\n", done, what)
+	}
+}
+
+// printSummary prints the number of tasks which were created, overwritten and
+// kept
+func (r *restorer) printSummary() {
+	summary := fmt.Sprintf("%d created, %d overwritten, %d kept", r.created, r.updated, r.kept)
+	if r.createdLists > 0 {
+		summary += fmt.Sprintf(", %d new lists", r.createdLists)
+	}
+
+	if r.dryRun {
+		fmt.Fprintf(r.out, "Dry run: %s\n", summary)
+	} else {
+		fmt.Fprintf(r.out, "Restored %d tasks: %s\n", r.created+r.updated+r.kept, summary)
+	}
+}
+
+// readIdMap reads the ID map in the file called name. It's empty if name is
+// empty or the file doesn't exist yet.
+func readIdMap(name string) (map[string]string, error) {
+	idMap := map[string]string{}
+	if name == "" {
+		return idMap, nil
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return idMap, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &idMap); err != nil {
+		return nil, fmt.Errorf("%s isn't an ID map: %w", name, err)
+	}
+	return idMap, nil
+}
+
+// writeIdMap writes the ID map to the file called name
+func writeIdMap(name string, idMap map[string]string) error {
+	b, err := json.MarshalIndent(idMap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, append(b, '\n'), 0600)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"golang.org/x/oauth2"
+)
+
+func Test_restoreCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	taskId := addBackupTasks(t, s)
+	dir := t.TempDir()
+
+	file := path.Join(dir, "todo.json.gz")
+	if _, err := executeCmd(t, s, "backup", "-o", file, "--attachments"); err != nil {
+		t.Fatalf("backup error = %v", err)
+	}
+
+	ctx := context.Background()
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "access"})
+	client := api.NewClientWithTokenSource(ctx, s.URL(), ts)
+
+	// Restoring a deleted task
+	rent := s.Tasks(s.DefaultListID())[0]["id"].(string)
+	if err := client.DeleteTask(ctx, s.DefaultListID(), rent); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, s, "restore", file, "--dry-run")
+	if err != nil {
+		t.Fatalf("restore --dry-run error = %v", err)
+	}
+	assertContains(t, out, `Would create "Pay rent" in Tasks`, "Dry run: 1 created, 0 overwritten, 1 kept")
+	if len(s.Tasks(s.DefaultListID())) != 0 {
+		t.Fatal("restore --dry-run shouldn't create tasks")
+	}
+
+	out, err = executeCmd(t, s, "restore", file)
+	if err != nil {
+		t.Fatalf("restore error = %v", err)
+	}
+	assertContains(t, out, `Created "Pay rent" in Tasks`, "Restored 2 tasks: 1 created, 0 overwritten, 1 kept")
+	if tasks := s.Tasks(s.DefaultListID()); len(tasks) != 1 || tasks[0]["status"] != "completed" {
+		t.Errorf("restored tasks = %v, want the completed task", tasks)
+	}
+
+	// Restoring into another account
+	other := graphfake.NewServer()
+	defer other.Close()
+
+	idMap := path.Join(dir, "ids.json")
+	out, err = executeCmd(t, other, "restore", file, "--id-map", idMap)
+	if err != nil {
+		t.Fatalf("restore into another account error = %v", err)
+	}
+	assertContains(t, out, "Created list Work", `Created "Write report" in Work`, "Restored 2 tasks: 2 created, 0 overwritten, 0 kept, 1 new lists")
+
+	ids, err := ioutil.ReadFile(idMap)
+	if err != nil || !strings.Contains(string(ids), `"`+taskId+`": "`) {
+		t.Fatalf("ID map = %s, %v, want the ID of the Work task", ids, err)
+	}
+
+	// The tasks are matched by the ID map, even when they're renamed
+	var workId string
+	for _, list := range other.Lists() {
+		if list["displayName"] == "Work" {
+			workId = list["id"].(string)
+		}
+	}
+	restored := other.Tasks(workId)[0]
+	renamed := api.TodoTask{Title: "Renamed", Importance: "normal", Status: "not started"}
+	otherClient := api.NewClientWithTokenSource(ctx, other.URL(), ts)
+	if err := otherClient.UpdateTask(ctx, workId, restored["id"].(string), &renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = executeCmd(t, other, "restore", file, "--id-map", idMap, "--overwrite")
+	if err != nil {
+		t.Fatalf("restore --overwrite error = %v", err)
+	}
+	assertContains(t, out, `Overwrote "Write report" in Work`, "Restored 2 tasks: 0 created, 2 overwritten, 0 kept")
+	if tasks := other.Tasks(workId); len(tasks) != 1 || tasks[0]["title"] != "Write report" || tasks[0]["recurrence"] == nil {
+		t.Errorf("overwritten tasks = %v", tasks)
+	}
+
+	// The other account has everything which was backed up
+	otherFile := path.Join(dir, "other.json")
+	out, err = executeCmd(t, other, "backup", "-o", otherFile)
+	if err != nil {
+		t.Fatalf("backup of the other account error = %v", err)
+	}
+	assertContains(t, out, "Backed up 2 lists, 2 tasks, 1 checklist items, 1 linked resources, 1 attachments")
+
+	if _, err := executeCmd(t, s, "restore", otherFile+".missing"); err == nil {
+		t.Error("restore of a missing file should fail")
+	}
+}
+
+func Test_restoreCmd_idMapAfterFailure(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	// The Graph fake rejects the second list's task, after the first is restored
+	b := `{"version": 1, "created": "2021-10-01T00:00:00Z", "lists": [
+		{"id": "backup-work", "displayName": "Work", "tasks": [{"id": "backup-report", "title": "Write report", "importance": "normal", "status": "notStarted"}]},
+		{"id": "backup-home", "displayName": "Home", "tasks": [{"id": "backup-rent", "title": "Pay rent", "importance": "urgent", "status": "notStarted"}]}
+	]}`
+	dir := t.TempDir()
+	file := path.Join(dir, "todo.json")
+	if err := ioutil.WriteFile(file, []byte(b), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	idMap := path.Join(dir, "ids.json")
+	if _, err := executeCmd(t, s, "restore", file, "--id-map", idMap); err == nil {
+		t.Fatal("restore of an invalid task should fail")
+	}
+
+	ids, err := ioutil.ReadFile(idMap)
+	if err != nil || !strings.Contains(string(ids), `"backup-report": "`) || !strings.Contains(string(ids), `"backup-work": "`) {
+		t.Errorf("ID map = %s, %v, want the IDs of what was restored", ids, err)
+	}
+}
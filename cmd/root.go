@@ -18,25 +18,47 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
+	"strings"
+	"time"
 
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/auth"
+	"github.com/dalyisaac/mstodo/cassette"
+	"github.com/dalyisaac/mstodo/datetime"
 	"github.com/dalyisaac/mstodo/utils"
 	"github.com/spf13/cobra"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
 )
 
 type Config struct {
-	ConfigDir    string `mapstructure:"config-dir"`
-	ClientID     string `mapstructure:"client-id"`
-	ClientSecret string `mapstructure:"client-secret"`
-	AuthTimeout  int    `mapstructure:"auth-timeout"`
-	Port         int    `mapstructure:"port"`
-	TableStyle   string `mapstructure:"table-style"`
+	ConfigDir    string            `mapstructure:"config-dir"`
+	ClientID     string            `mapstructure:"client-id"`
+	ClientSecret string            `mapstructure:"client-secret"`
+	AuthTimeout  int               `mapstructure:"auth-timeout"`
+	Port         int               `mapstructure:"port"`
+	TableStyle   string            `mapstructure:"table-style"`
+	Timeout      time.Duration     `mapstructure:"timeout"`
+	Cloud        string            `mapstructure:"cloud"`
+	GraphURL     string            `mapstructure:"graph-url"`
+	AuthorityURL string            `mapstructure:"authority-url"`
+	WeekStart    string            `mapstructure:"week-start"`
+	DateLocale   string            `mapstructure:"date-locale"`
+	DateOrder    string            `mapstructure:"date-order"`
+	TimeZone     string            `mapstructure:"time-zone"`
+	Columns      map[string]string `mapstructure:"columns"`
+	Styles       StylesConfig      `mapstructure:"styles"`
+	NoColor      bool              `mapstructure:"no-color"`
+	Picker       string            `mapstructure:"picker"`
 }
 
 var (
@@ -45,6 +67,16 @@ var (
 	portStr        string
 	authTimeoutStr string
 	tableStyle     string
+	timeoutStr     string
+	cloud          string
+	graphURL       string
+	authorityURL   string
+	weekStart      string
+	dateLocale     string
+	dateOrder      string
+	recordDir      string
+	replayDir      string
+	noColor        bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -60,7 +92,11 @@ To see available commands, type mstodo help`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	rootCmd.Execute()
+	// Ctrl-C cancels any in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
@@ -71,7 +107,7 @@ func init() {
 	viper.BindPFlag("config-dir", rootCmd.PersistentFlags().Lookup("config-dir"))
 
 	// port
-	rootCmd.PersistentFlags().StringVar(&portStr, "port", "", "port for mstodo")
+	rootCmd.PersistentFlags().StringVar(&portStr, "port", "", "port for the authentication callback server (0 picks a free port)")
 	viper.BindPFlag("port", rootCmd.PersistentFlags().Lookup("port"))
 
 	// auth timeout
@@ -81,6 +117,47 @@ func init() {
 	// table style
 	rootCmd.PersistentFlags().StringVarP(&tableStyle, "table-style", "t", "Rounded", "the style for the table")
 	viper.BindPFlag("table-style", rootCmd.PersistentFlags().Lookup("table-style"))
+
+	// timeout
+	rootCmd.PersistentFlags().StringVar(&timeoutStr, "timeout", "0", "maximum duration of a command, for example 30s (0 for no timeout)")
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+
+	// cloud
+	rootCmd.PersistentFlags().StringVar(&cloud, "cloud", auth.GlobalCloud, fmt.Sprintf("the Microsoft cloud to use - choices: [%s]", strings.Join(auth.CloudNames(), ", ")))
+	viper.BindPFlag("cloud", rootCmd.PersistentFlags().Lookup("cloud"))
+
+	// graph url
+	rootCmd.PersistentFlags().StringVar(&graphURL, "graph-url", "", "override the Microsoft Graph base URL, for example https://graph.microsoft.com/v1.0")
+	viper.BindPFlag("graph-url", rootCmd.PersistentFlags().Lookup("graph-url"))
+
+	// authority url
+	rootCmd.PersistentFlags().StringVar(&authorityURL, "authority-url", "", "override the sign-in authority URL, for example https://login.microsoftonline.com/common")
+	viper.BindPFlag("authority-url", rootCmd.PersistentFlags().Lookup("authority-url"))
+
+	// week start
+	rootCmd.PersistentFlags().StringVar(&weekStart, "week-start", "monday", "the first day of the week, used by dates like \"start of week\"")
+	viper.BindPFlag("week-start", rootCmd.PersistentFlags().Lookup("week-start"))
+
+	// date locale
+	rootCmd.PersistentFlags().StringVar(&dateLocale, "date-locale", datetime.DefaultLocale, fmt.Sprintf("the language of month and weekday names, and the default date order - choices: [%s]", strings.Join(datetime.LocaleNames(), ", ")))
+	viper.BindPFlag("date-locale", rootCmd.PersistentFlags().Lookup("date-locale"))
+
+	// date order
+	rootCmd.PersistentFlags().StringVar(&dateOrder, "date-order", "", "the order of numeric dates, overriding date-locale - choices: [dmy, mdy, ymd, any]")
+	viper.BindPFlag("date-order", rootCmd.PersistentFlags().Lookup("date-order"))
+
+	// colours
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colours, which are also disabled by NO_COLOR or when the output isn't a terminal")
+	viper.BindPFlag("no-color", rootCmd.PersistentFlags().Lookup("no-color"))
+	for name, style := range defaultStyles {
+		viper.SetDefault("styles."+name, style)
+	}
+
+	// record and replay
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "record the sanitized Graph requests and responses into a cassette in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "replay the Graph responses from the cassette in this directory, instead of using the network")
+
+	registerRootCompletions()
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -124,9 +201,9 @@ func validateConfig() error {
 		return errors.New("client-secret must not be empty")
 	}
 
-	// validate port
-	if cliConfig.Port <= 1023 {
-		return errors.New("port must be greater than 1023")
+	// validate port - 0 picks an ephemeral port
+	if cliConfig.Port != 0 && (cliConfig.Port <= 1023 || cliConfig.Port > 65535) {
+		return errors.New("port must be 0, or between 1024 and 65535")
 	}
 
 	// auth timeout
@@ -138,9 +215,201 @@ func validateConfig() error {
 		return fmt.Errorf("%s is an invalid table style", cliConfig.TableStyle)
 	}
 
+	// timeout
+	if cliConfig.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+
+	// cloud, graph url and authority url
+	if _, err := auth.CurrentCloud(); err != nil {
+		return err
+	}
+
+	// week start
+	day, err := datetime.ParseWeekday(cliConfig.WeekStart)
+	if err != nil {
+		return fmt.Errorf("week-start: %w", err)
+	}
+	datetime.SetWeekStart(day)
+
+	// date locale and date order
+	locale, err := datetime.GetLocale(cliConfig.DateLocale)
+	if err != nil {
+		return fmt.Errorf("date-locale: %w", err)
+	}
+
+	order := locale.Order
+	if cliConfig.DateOrder != "" {
+		if order, err = datetime.ParseDateOrder(cliConfig.DateOrder); err != nil {
+			return fmt.Errorf("date-order: %w", err)
+		}
+	}
+
+	datetime.SetLocale(locale)
+	datetime.SetDateOrder(order)
+
+	// time zone
+	if cliConfig.TimeZone != "" {
+		if _, err := datetime.LoadLocation(cliConfig.TimeZone); err != nil {
+			return fmt.Errorf("time-zone: %w", err)
+		}
+	}
+
+	// columns
+	if err := validateColumnsConfig(cliConfig.Columns); err != nil {
+		return err
+	}
+
+	// picker
+	if cliConfig.Picker != "" && cliConfig.Picker != builtinPicker {
+		if words, err := splitShellWords(cliConfig.Picker); err != nil || len(words) == 0 {
+			return fmt.Errorf("picker must be %q or a command like \"fzf\"", builtinPicker)
+		}
+	}
+
+	// styles
+	styles, err := parseStylesConfig(cliConfig.Styles)
+	if err != nil {
+		return err
+	}
+
+	colored := colorEnabled(cliConfig.NoColor)
+	setColor(colored)
+	if colored {
+		taskStyles = styles
+	} else {
+		taskStyles = nil
+	}
+
 	return nil
 }
 
+// commandContext returns the context for cmd, which is cancelled by Ctrl-C or
+// after the configured timeout.
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if cliConfig.Timeout > 0 {
+		return context.WithTimeout(ctx, cliConfig.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// newClient creates the Graph client for a command. With --replay, the
+// responses come from a cassette and no sign in is needed. In the shell, the
+// shell's client is reused.
+func newClient(ctx context.Context) (*api.Client, error) {
+	if session != nil {
+		return session.client, nil
+	}
+
+	if recordDir != "" && replayDir != "" {
+		return nil, errors.New("--record and --replay can't be used together")
+	}
+
+	cloud, err := auth.CurrentCloud()
+	if err != nil {
+		return nil, err
+	}
+
+	if replayDir != "" {
+		player, err := cassette.NewPlayer(replayDir, cloud.GraphURL)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: player})
+		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "replay"})
+		return api.NewClientWithTokenSource(ctx, cloud.GraphURL, ts), nil
+	}
+
+	if recordDir != "" {
+		recorder := cassette.NewRecorder(recordDir, cloud.GraphURL, nil)
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: recorder})
+	}
+
+	return api.NewClient(ctx)
+}
+
+// getLists gets the task lists, and saves them for completions. In the shell,
+// they're only got once.
+func getLists(ctx context.Context, client *api.Client) (*api.TodoTaskListList, error) {
+	if session != nil && session.lists != nil {
+		return session.lists, nil
+	}
+
+	lists, err := client.GetLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if session != nil {
+		session.lists = lists
+	}
+
+	// The lists are only saved to speed up completions, so errors are ignored
+	writeListsCache(lists)
+	return lists, nil
+}
+
+// getMailboxTimeZone gets the name of the mailbox time zone. In the shell,
+// it's only got once.
+func getMailboxTimeZone(ctx context.Context, client *api.Client) (string, error) {
+	if session != nil && session.mailboxTimeZone != "" {
+		return session.mailboxTimeZone, nil
+	}
+
+	name, err := client.GetMailboxTimeZone(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if session != nil {
+		session.mailboxTimeZone = name
+	}
+	return name, nil
+}
+
+// setTimeZone sets the time zone which dates are parsed and shown in, and
+// which Graph returns times in. This is the --tz flag, the time-zone config,
+// the mailbox time zone or the system time zone, in that order. If client is
+// nil, the mailbox time zone isn't used.
+func setTimeZone(ctx context.Context, client *api.Client, tz string) (*time.Location, error) {
+	if tz == "" {
+		tz = cliConfig.TimeZone
+	}
+
+	var loc *time.Location
+	if tz != "" {
+		l, err := datetime.LoadLocation(tz)
+		if err != nil {
+			return nil, err
+		}
+		loc = l
+	} else if client != nil {
+		// Older tokens don't have the MailboxSettings.Read scope, and custom
+		// mailbox time zones can't be loaded, so errors are ignored
+		if name, err := getMailboxTimeZone(ctx, client); err == nil {
+			if l, err := datetime.LoadLocation(name); err == nil {
+				loc = l
+			}
+		}
+	}
+
+	if loc == nil {
+		loc = datetime.LocalLocation()
+	}
+
+	datetime.SetLocation(loc)
+	if client != nil {
+		client.SetTimeZone(loc)
+	}
+	return loc, nil
+}
+
 func defaultConfigDir() string {
 	// Find home directory.
 	dir, err := homedir.Dir()
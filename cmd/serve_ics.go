@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/transfer/ics"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createServeICSCmd())
+}
+
+// defaultICSPort is the port of the feed without --port
+const defaultICSPort = 8080
+
+type serveICSParamsFlags struct {
+	tz   string
+	port int
+}
+
+func createServeICSCmd() *cobra.Command {
+	flags := serveICSParamsFlags{}
+
+	// serveICSCmd represents the serve-ics command
+	var serveICSCmd = &cobra.Command{
+		Use:   "serve-ics [list name]...",
+		Short: "Serve the tasks as a calendar feed",
+		Long: `Serve the tasks of the lists, or of all the lists, as a read-only iCalendar feed
+on 127.0.0.1, which calendar apps can subscribe to. The tasks are got again for each
+request, so the feed is always up to date.
+
+The feed of the lists is at http://127.0.0.1:<port>/, and the feed of each list is
+at http://127.0.0.1:<port>/<list name>.ics. When lists are given, only their feeds
+are served. The tasks are written like export --format ics. --port sets the port,
+which is 8080 by default, and 0 picks a free one. Press Ctrl+C to stop.`,
+		Example: `  mstodo serve-ics
+  mstodo serve-ics work home --port 8081`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.port < 0 || flags.port > 65535 {
+				return fmt.Errorf("'%d' is not a valid value for port: it must be from 0 to 65535", flags.port)
+			}
+
+			// The server runs until Ctrl+C, so the command's timeout is for
+			// each request instead
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			loc, err := setTimeZone(ctx, client, flags.tz)
+			if err != nil {
+				return err
+			}
+
+			// Check the list names before serving
+			lists, err := getLists(ctx, client)
+			if err != nil {
+				return err
+			}
+			if _, err := selectLists(lists, args); err != nil {
+				return err
+			}
+
+			listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(flags.port)))
+			if err != nil {
+				return err
+			}
+
+			port := listener.Addr().(*net.TCPAddr).Port
+			server := &http.Server{Handler: newICSHandler(client, args, loc, port)}
+			done := make(chan error, 1)
+			go func() {
+				done <- server.Serve(listener)
+			}()
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving the calendar feed at http://%s/ - press Ctrl+C to stop\n", listener.Addr())
+
+			select {
+			case err := <-done:
+				return err
+			case <-ctx.Done():
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				return err
+			}
+			if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	serveICSCmd.Flags().IntVar(&flags.port, "port", defaultICSPort, "The port of the feed (0 picks a free port)")
+	serveICSCmd.Flags().StringVar(&flags.tz, "tz", "", "Time zone for the dates, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+
+	// completions
+	serveICSCmd.ValidArgsFunction = completeListNames
+
+	return serveICSCmd
+}
+
+// newICSHandler returns the handler of the calendar feed of the lists called
+// names, or of all the lists if there are no names. Only requests for
+// localhost or 127.0.0.1 on port are served, so that a web page can't read the
+// feed by pointing its own host name at 127.0.0.1.
+func newICSHandler(client *api.Client, names []string, loc *time.Location, port int) http.Handler {
+	hosts := []string{
+		net.JoinHostPort("localhost", strconv.Itoa(port)),
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+	}
+	if port == 80 {
+		hosts = append(hosts, "localhost", "127.0.0.1")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !containsFold(hosts, r.Host) {
+			http.Error(w, "the feed is only served to localhost", http.StatusForbidden)
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "the feed is read-only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx := r.Context()
+		if cliConfig.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cliConfig.Timeout)
+			defer cancel()
+		}
+
+		lists, err := getLists(ctx, client)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		selected, err := selectLists(lists, names)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		// The feed of one list is at /<list name>.ics, and it must be one of
+		// the served lists
+		if r.URL.Path != "/" {
+			name, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/"))
+			if err != nil || path.Ext(name) != ".ics" || strings.Contains(name, "/") {
+				http.NotFound(w, r)
+				return
+			}
+
+			list, err := selectLists(lists, []string{strings.TrimSuffix(name, ".ics")})
+			if err != nil || !containsList(selected, list[0].Id) {
+				http.NotFound(w, r)
+				return
+			}
+			selected = list
+		}
+
+		tasks, err := getTransferTasks(ctx, client, selected, loc, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		var b bytes.Buffer
+		if err := ics.Encode(&b, tasks); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+		w.Write(b.Bytes())
+	})
+}
+
+// containsList reports whether the list with the id is one of lists
+func containsList(lists []api.TodoTaskListItem, id string) bool {
+	for _, list := range lists {
+		if list.Id == id {
+			return true
+		}
+	}
+	return false
+}
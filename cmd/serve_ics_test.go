@@ -0,0 +1,153 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"golang.org/x/oauth2"
+)
+
+func Test_newICSHandler(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work Stuff")
+	if _, err := s.AddTask(listId, graphfake.Object{"title": "Write report"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pay rent"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "access"})
+	client := api.NewClientWithTokenSource(context.Background(), s.URL(), ts)
+
+	tests := []struct {
+		name     string
+		names    []string
+		method   string
+		path     string
+		host     string
+		wantCode int
+		want     []string
+		unwanted []string
+	}{
+		{name: "all lists", method: "GET", path: "/", wantCode: http.StatusOK, want: []string{"SUMMARY:Write report", "SUMMARY:Pay rent", "X-WR-CALNAME:Microsoft To Do"}},
+		{name: "selected lists", names: []string{"tasks"}, method: "GET", path: "/", wantCode: http.StatusOK, want: []string{"SUMMARY:Pay rent"}, unwanted: []string{"Write report"}},
+		{name: "one list", method: "GET", path: "/Work%20Stuff.ics", wantCode: http.StatusOK, want: []string{"X-WR-CALNAME:Work Stuff", "SUMMARY:Write report"}, unwanted: []string{"Pay rent"}},
+		{name: "unknown list", method: "GET", path: "/Home.ics", wantCode: http.StatusNotFound},
+		{name: "list which isn't served", names: []string{"work stuff"}, method: "GET", path: "/Tasks.ics", wantCode: http.StatusNotFound},
+		{name: "served list", names: []string{"work stuff"}, method: "GET", path: "/work%20stuff.ics", wantCode: http.StatusOK, want: []string{"SUMMARY:Write report"}},
+		{name: "localhost", method: "GET", path: "/", host: "localhost:8080", wantCode: http.StatusOK},
+		{name: "another host", method: "GET", path: "/", host: "rebound.example.com:8080", wantCode: http.StatusForbidden},
+		{name: "another port", method: "GET", path: "/", host: "127.0.0.1:8081", wantCode: http.StatusForbidden},
+		{name: "not a feed", method: "GET", path: "/favicon.ico", wantCode: http.StatusNotFound},
+		{name: "read-only", method: "PUT", path: "/", wantCode: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Host = "127.0.0.1:8080"
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+
+			rec := httptest.NewRecorder()
+			newICSHandler(client, tt.names, time.UTC, 8080).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %v, want %v: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if rec.Code == http.StatusOK && !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/calendar") {
+				t.Errorf("Content-Type = %v", rec.Header().Get("Content-Type"))
+			}
+			assertContains(t, rec.Body.String(), tt.want...)
+			assertNotContains(t, rec.Body.String(), tt.unwanted...)
+		})
+	}
+}
+
+func Test_serveICSCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	if _, err := s.AddTask(s.DefaultListID(), graphfake.Object{"title": "Pay rent"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Serve on a free port until the context is cancelled. This runs serve-ics
+	// first, as cobra only gives the context to a command the first time.
+	resetFlags(rootCmd)
+	r, w := io.Pipe()
+	rootCmd.SetOut(w)
+	rootCmd.SetErr(w)
+	rootCmd.SetArgs([]string{"--config-dir", testConfigDir, "--graph-url", s.URL(), "serve-ics", "--port", "0", "--tz", "UTC"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+		w.Close()
+	}()
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatalf("serve-ics printed %q, %v", line, err)
+	}
+	url := regexp.MustCompile(`http://127\.0\.0\.1:\d+/`).FindString(line)
+	if url == "" {
+		t.Fatalf("serve-ics printed %q, want the feed's URL", line)
+	}
+
+	resp, err := http.Get(url + "Tasks.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %sTasks.ics = %v, %v", url, resp.Status, err)
+	}
+	assertContains(t, string(body), "SUMMARY:Pay rent")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("serve-ics error = %v", err)
+	}
+
+	if _, err := executeCmd(t, s, "serve-ics", "nope", "--tz", "UTC"); err == nil {
+		t.Error("serve-ics with an unknown list should fail")
+	}
+
+	if _, err := executeCmd(t, s, "serve-ics", "--port", "70000", "--tz", "UTC"); err == nil || !strings.Contains(err.Error(), "port") {
+		t.Errorf("serve-ics --port 70000 error = %v, want an invalid port error", err)
+	}
+}
@@ -0,0 +1,609 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/lineedit"
+	"github.com/dalyisaac/mstodo/internal/term"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	rootCmd.AddCommand(createShellCmd())
+}
+
+const (
+	shellHistoryFile = "shell_history"
+	shellHistorySize = 1000
+	shellHelp        = `Commands:
+  use <list>              choose the list which ls and add use
+  ls [list...] [flags]    show the numbered tasks, with the flags of view, like: ls --due today
+  add <title> [flags]     add a task, with the flags of add, like: add "Pay rent tomorrow !high"
+  done [n|title|id]...    complete tasks from the last ls, like: done 1 3, or choose them
+  undone [n|title|id]...  mark tasks from the last ls as not started
+  rm [n|title|id]...      delete tasks from the last ls
+  lists [flags]           show the lists
+  view, parse-date        the same as mstodo view and mstodo parse-date
+  refresh                 get the lists again
+  help                    show this help
+  exit, quit              leave the shell, like ctrl+d
+
+Titles can be regexes, like: done ^pay. If one matches several tasks, or no task is given,
+you choose them.
+
+Tab completes the commands, list names, task titles and IDs, and flags, and up and down go through
+the history. Lines starting with # are ignored.`
+)
+
+// shellCommands are the mstodo commands which can be run in the shell. Each
+// line gets a new command, so that flags don't carry over.
+var shellCommands = map[string]func() *cobra.Command{
+	"add":        createAddCmd,
+	"lists":      createListsCmd,
+	"ls":         createViewCmd,
+	"parse-date": createParseDateCmd,
+	"view":       createViewCmd,
+}
+
+// shellBuiltins are the commands which only the shell has
+var shellBuiltins = []string{"done", "exit", "help", "quit", "refresh", "rm", "undone", "use"}
+
+// shellTask is a task in the last listing
+type shellTask struct {
+	listId string
+	task   api.TodoTask
+}
+
+// shellSession is the state of mstodo shell, which the commands run in the
+// shell share, so that they sign in and get the lists once
+type shellSession struct {
+	client          *api.Client
+	lists           *api.TodoTaskListList
+	mailboxTimeZone string
+
+	// list is the list chosen by use
+	list string
+
+	// listing are the tasks shown by the last ls, in the order of their numbers
+	listing []shellTask
+
+	// terminal is true when the commands are read from the terminal, so that
+	// the user can be asked to choose tasks
+	terminal bool
+
+	out, errOut io.Writer
+}
+
+// session is the running shell, or nil outside of the shell
+var session *shellSession
+
+func createShellCmd() *cobra.Command {
+	shellCmd := &cobra.Command{
+		Use:   "shell",
+		Short: "Run commands in an interactive shell",
+		Long: `Run commands in an interactive shell, which signs in and gets the lists once,
+so that a chain of commands is quick.
+
+` + shellHelp + `
+
+When stdin isn't a terminal, the commands are read from it, one per line, and the
+shell stops at the first command which fails.`,
+		Example: `  mstodo shell
+  printf 'use Work\nls --due today\ndone 1\n' | mstodo shell`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The client outlives each command's context
+			client, err := newClient(context.Background())
+			if err != nil {
+				return err
+			}
+
+			session = &shellSession{client: client, out: cmd.OutOrStdout(), errOut: cmd.ErrOrStderr()}
+			defer func() { session = nil }()
+
+			in := cmd.InOrStdin()
+			if f, ok := in.(*os.File); ok && term.IsTerminal(f) {
+				session.terminal = isTerminal()
+				return session.interactive()
+			}
+			return session.runScript(in)
+		},
+	}
+
+	return shellCmd
+}
+
+// interactive reads commands from the terminal until exit or ctrl+d
+func (s *shellSession) interactive() error {
+	editor := &lineedit.Editor{History: loadShellHistory(), MaxHistory: shellHistorySize, Complete: s.complete}
+	fmt.Fprintln(s.out, `Type "help" for the commands, and "exit" or ctrl+d to leave`)
+
+	for {
+		line, err := editor.ReadLine(s.prompt())
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		quit, err := s.execute(line)
+		if err != nil {
+			fmt.Fprintln(s.errOut, "Error:", err)
+		}
+		if quit {
+			break
+		}
+	}
+
+	return saveShellHistory(editor.History)
+}
+
+// runScript runs the commands read from in, until one fails
+func (s *shellSession) runScript(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+
+	for n := 1; scanner.Scan(); n++ {
+		quit, err := s.execute(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if quit {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *shellSession) prompt() string {
+	if s.list == "" {
+		return "mstodo> "
+	}
+	return fmt.Sprintf("mstodo (%s)> ", s.list)
+}
+
+// context returns the context of a command, which is cancelled by ctrl+c or
+// after the configured timeout, without leaving the shell
+func (s *shellSession) context() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if cliConfig.Timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cliConfig.Timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// execute runs the command on the line, and reports whether to leave the shell
+func (s *shellSession) execute(line string) (bool, error) {
+	words, err := splitShellWords(line)
+	if err != nil {
+		return false, err
+	}
+	if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+		return false, nil
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	name, args := words[0], words[1:]
+	switch name {
+	case "exit", "quit":
+		return true, nil
+	case "help":
+		fmt.Fprintln(s.out, shellHelp)
+		return false, nil
+	case "use":
+		return false, s.use(ctx, args)
+	case "refresh":
+		s.lists = nil
+		_, err := getLists(ctx, s.client)
+		return false, err
+	case "done", "undone", "rm":
+		return false, s.changeTasks(ctx, name, args)
+	}
+
+	factory, ok := shellCommands[name]
+	if !ok {
+		return false, fmt.Errorf("unknown command %q - type help for the commands", name)
+	}
+
+	c := factory()
+	c.SetArgs(args)
+	c.SetOut(s.out)
+	c.SetErr(s.errOut)
+	c.SilenceErrors = true
+	c.SilenceUsage = true
+	return false, c.ExecuteContext(ctx)
+}
+
+// use chooses the list which ls and add use
+func (s *shellSession) use(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("use needs one list name, like: use Work")
+	}
+
+	lists, err := getLists(ctx, s.client)
+	if err != nil {
+		return err
+	}
+
+	id, err := lists.GetListId(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, list := range *lists {
+		if list.Id == id {
+			s.list = list.DisplayName
+		}
+	}
+	return nil
+}
+
+// setListing keeps the tasks shown by ls, with the IDs of their lists, so that
+// they can be referred to by their numbers
+func (s *shellSession) setListing(tasks api.TodoTaskList, listIds map[string]string) {
+	s.listing = []shellTask{}
+	for _, task := range tasks {
+		s.listing = append(s.listing, shellTask{listId: listIds[task.Id], task: task})
+	}
+}
+
+// findTasks returns the tasks in the last listing with the number, ID or title.
+// Otherwise, arg is a regex of the titles, and if it matches several tasks,
+// the user chooses them.
+func (s *shellSession) findTasks(arg string) ([]*shellTask, error) {
+	if len(s.listing) == 0 {
+		return nil, errors.New("there are no numbered tasks - show them with ls first")
+	}
+
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(s.listing) {
+			return nil, fmt.Errorf("there's no task %d in the last ls", n)
+		}
+		return []*shellTask{&s.listing[n-1]}, nil
+	}
+
+	for i, t := range s.listing {
+		if t.task.Id == arg || strings.EqualFold(t.task.Title, arg) {
+			return []*shellTask{&s.listing[i]}, nil
+		}
+	}
+
+	matches := []*shellTask{}
+	if re, err := regexp.Compile("(?i)" + arg); err == nil {
+		for i, t := range s.listing {
+			if re.MatchString(t.task.Title) {
+				matches = append(matches, &s.listing[i])
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("there's no task %q in the last ls", arg)
+	case len(matches) > 1 && !s.terminal:
+		return nil, fmt.Errorf("%q matches %d tasks in the last ls - give their numbers instead", arg, len(matches))
+	case len(matches) > 1:
+		return s.pickTasks(matches)
+	}
+	return matches, nil
+}
+
+// pickTasks asks the user to choose from the tasks
+func (s *shellSession) pickTasks(tasks []*shellTask) ([]*shellTask, error) {
+	items := []string{}
+	for _, t := range tasks {
+		items = append(items, fmt.Sprintf("%d  %s", s.number(t), t.task.Title))
+	}
+
+	chosen, err := pick(nil, "Task", items, true)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := []*shellTask{}
+	for _, i := range chosen {
+		picked = append(picked, tasks[i])
+	}
+	return picked, nil
+}
+
+// number returns the number of the task in the last listing
+func (s *shellSession) number(t *shellTask) int {
+	for i := range s.listing {
+		if &s.listing[i] == t {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// changeTasks completes, uncompletes or deletes the tasks
+func (s *shellSession) changeTasks(ctx context.Context, action string, args []string) error {
+	// All the tasks are found first, so that a typo doesn't change some of them
+	targets := []*shellTask{}
+	for _, arg := range args {
+		tasks, err := s.findTasks(arg)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, tasks...)
+	}
+
+	// Without arguments, the user chooses the tasks
+	if len(args) == 0 {
+		if !s.terminal || len(s.listing) == 0 {
+			return fmt.Errorf("%s needs the numbers or titles of tasks from the last ls, like: %s 1 3", action, action)
+		}
+
+		tasks := []*shellTask{}
+		for i := range s.listing {
+			tasks = append(tasks, &s.listing[i])
+		}
+
+		picked, err := s.pickTasks(tasks)
+		if err != nil {
+			return err
+		}
+		targets = picked
+	}
+
+	for _, t := range targets {
+		task := t.task
+
+		switch action {
+		case "done", "undone":
+			task.Status = "completed"
+			if action == "undone" {
+				task.Status = "not started"
+			}
+			if err := s.client.UpdateTask(ctx, t.listId, task.Id, &task); err != nil {
+				return err
+			}
+			t.task.Status = task.Status
+			fmt.Fprintf(s.out, "Marked %q as %s\n", task.Title, task.Status)
+		case "rm":
+			if err := s.client.DeleteTask(ctx, t.listId, task.Id); err != nil {
+				return err
+			}
+			fmt.Fprintf(s.out, "Deleted %q\n", task.Title)
+		}
+	}
+
+	return nil
+}
+
+// complete returns the completions of the word at pos, which are commands,
+// list names, task titles and IDs, or flags, depending on the command
+func (s *shellSession) complete(line []rune, pos int) ([]string, int) {
+	words, start := shellWordsBefore(string(line[:pos]))
+	word := string(line[start:pos])
+	quote := quoteShellWord
+
+	candidates := []string{}
+	switch {
+	case len(words) == 0:
+		candidates = append(candidates, shellBuiltins...)
+		for name := range shellCommands {
+			candidates = append(candidates, name)
+		}
+	case strings.HasPrefix(word, "-"):
+		if factory, ok := shellCommands[words[0]]; ok {
+			factory().Flags().VisitAll(func(f *pflag.Flag) {
+				candidates = append(candidates, "--"+f.Name)
+			})
+		}
+	case words[0] == "use" || words[0] == "ls" || words[0] == "view":
+		candidates = s.listNames("")
+	case words[0] == "add":
+		// Lists are completed after the @ in the quoted title, like
+		// add "Pay rent @ho
+		at := strings.LastIndex(word, "@")
+		if at < 0 || strings.ContainsAny(word[at:], " \t") {
+			return nil, start
+		}
+		start += len([]rune(word[:at]))
+		word = word[at:]
+		candidates = s.listNames("@")
+		quote = func(c string) string {
+			if strings.Contains(c, " ") {
+				return `@\"` + c[1:] + `\"`
+			}
+			return c
+		}
+	case words[0] == "done" || words[0] == "undone" || words[0] == "rm":
+		for _, t := range s.listing {
+			candidates = append(candidates, t.task.Title)
+			if t.task.Id != "" {
+				candidates = append(candidates, t.task.Id)
+			}
+		}
+	}
+
+	unquote := strings.NewReplacer(`"`, "", `'`, "", `\`, "")
+	prefix := strings.ToLower(unquote.Replace(word))
+
+	sort.Strings(candidates)
+	completions := []string{}
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), prefix) {
+			completions = append(completions, quote(c))
+		}
+	}
+	return completions, start
+}
+
+// listNames returns the names of the lists after prefix, getting the lists if
+// they haven't been got yet
+func (s *shellSession) listNames(prefix string) []string {
+	if s.lists == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := getLists(ctx, s.client); err != nil {
+			return nil
+		}
+	}
+
+	names := []string{}
+	for _, list := range *s.lists {
+		names = append(names, prefix+list.DisplayName)
+	}
+	return names
+}
+
+// splitShellWords splits the line into words like a shell. Single quotes keep
+// everything, double quotes keep everything but \" and \\, and a backslash
+// outside of quotes keeps the next character.
+func splitShellWords(line string) ([]string, error) {
+	words := []string{}
+	word := strings.Builder{}
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("missing closing %c", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// shellWordsBefore returns the whole words before the last word of s, and the
+// index of the rune where the last word starts
+func shellWordsBefore(s string) ([]string, int) {
+	runes := []rune(s)
+	start := 0
+	var quote rune
+
+	for i, r := range runes {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ' ' || r == '\t':
+			start = i + 1
+		}
+	}
+
+	words, err := splitShellWords(string(runes[:start]))
+	if err != nil {
+		return nil, start
+	}
+	return words, start
+}
+
+// quoteShellWord quotes s if it has spaces or quotes, so that it's one word
+func quoteShellWord(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func loadShellHistory() []string {
+	b, err := ioutil.ReadFile(path.Join(configDir, shellHistoryFile))
+	if err != nil {
+		return nil
+	}
+
+	history := []string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+	return history
+}
+
+func saveShellHistory(history []string) error {
+	if len(history) > shellHistorySize {
+		history = history[len(history)-shellHistorySize:]
+	}
+
+	return ioutil.WriteFile(path.Join(configDir, shellHistoryFile), []byte(strings.Join(history, "\n")+"\n"), 0600)
+}
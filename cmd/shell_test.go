@@ -0,0 +1,163 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_splitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "words", line: "  ls  --due today ", want: []string{"ls", "--due", "today"}},
+		{name: "double quotes", line: `add "Pay rent \"now\" tomorrow"`, want: []string{"add", `Pay rent "now" tomorrow`}},
+		{name: "double quotes keep other backslashes", line: `add "Fix \#1"`, want: []string{"add", `Fix \#1`}},
+		{name: "single quotes", line: `add 'a "b" \c'`, want: []string{"add", `a "b" \c`}},
+		{name: "backslash", line: `use my\ list`, want: []string{"use", "my list"}},
+		{name: "empty quotes", line: `ls --title ""`, want: []string{"ls", "--title", ""}},
+		{name: "empty", line: "", want: []string{}},
+		{name: "unclosed quote", line: `add "Pay`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellWords(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("splitShellWords() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitShellWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_shellSession_complete(t *testing.T) {
+	s := &shellSession{
+		lists: &api.TodoTaskListList{{DisplayName: "Work"}, {DisplayName: "Work stuff"}, {DisplayName: "Home"}},
+		listing: []shellTask{
+			{task: api.TodoTask{Id: "AAMkAD1", Title: "Write report"}},
+			{task: api.TodoTask{Id: "AAMkAD2", Title: "Water plants"}},
+		},
+	}
+
+	tests := []struct {
+		line      string
+		want      []string
+		wantStart int
+	}{
+		{line: "us", want: []string{"use"}, wantStart: 0},
+		{line: "use h", want: []string{"Home"}, wantStart: 4},
+		{line: "use wo", want: []string{"Work", `"Work stuff"`}, wantStart: 4},
+		{line: `done "wri`, want: []string{`"Write report"`}, wantStart: 5},
+		{line: "rm aamk", want: []string{"AAMkAD1", "AAMkAD2"}, wantStart: 3},
+		{line: "ls --du", want: []string{"--due"}, wantStart: 3},
+		{line: `add "Pay rent @ho`, want: []string{"@Home"}, wantStart: 14},
+		{line: `add "Pay rent @work s`, want: []string{}, wantStart: 4},
+		{line: `add "Pay rent @work`, want: []string{"@Work", `@\"Work stuff\"`}, wantStart: 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, start := s.complete([]rune(tt.line), len([]rune(tt.line)))
+			if len(got) == 0 {
+				got = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) || start != tt.wantStart {
+				t.Errorf("shellSession.complete() = %q, %v, want %q, %v", got, start, tt.want, tt.wantStart)
+			}
+		})
+	}
+}
+
+func Test_shellCmd_script(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	for _, task := range []graphfake.Object{
+		{"title": "Write report"},
+		{"title": "Book flights"},
+	} {
+		if _, err := s.AddTask(listId, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := `# triage
+use work
+ls
+done 2
+add "Pay rent !high"
+ls --status "not started" --columns title,importance
+rm "pay rent"
+ls
+`
+	rootCmd.SetIn(strings.NewReader(script))
+	defer rootCmd.SetIn(nil)
+
+	out, err := executeCmd(t, s, "shell")
+	if err != nil {
+		t.Fatalf("shell error = %v\n%s", err, out)
+	}
+	assertContains(t, out, "#", `Marked "Book flights" as completed`, `Deleted "Pay rent"`)
+
+	tasks := s.Tasks(listId)
+	if len(tasks) != 2 {
+		t.Errorf("shell should leave 2 tasks, got %v", tasks)
+	}
+	for _, task := range tasks {
+		if task["title"] == "Book flights" && task["status"] != "completed" {
+			t.Errorf("done 2 should complete Book flights, got %v", task["status"])
+		}
+	}
+
+	// Tasks can be given by their IDs
+	rootCmd.SetIn(strings.NewReader("use work\nls\nundone " + s.Tasks(listId)[1]["id"].(string) + "\n"))
+	out, err = executeCmd(t, s, "shell")
+	if err != nil {
+		t.Fatalf("shell error = %v\n%s", err, out)
+	}
+	assertContains(t, out, `Marked "Book flights" as not started`)
+
+	rootCmd.SetIn(strings.NewReader("use work\ndone 1\n"))
+	if _, err := executeCmd(t, s, "shell"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("shell should fail at a task number without a listing, got %v", err)
+	}
+
+	rootCmd.SetIn(strings.NewReader("ls\n"))
+	if _, err := executeCmd(t, s, "shell"); err == nil {
+		t.Errorf("ls without a list should fail")
+	}
+
+	// Titles are regexes, and several matches can't be chosen from in a script
+	rootCmd.SetIn(strings.NewReader("use work\nls\nundone ^book\nundone o\n"))
+	out, err = executeCmd(t, s, "shell")
+	if err == nil || !strings.Contains(err.Error(), `line 4: "o" matches 2 tasks`) {
+		t.Errorf("shell should fail at a title matching several tasks, got %v", err)
+	}
+	assertContains(t, out, `Marked "Book flights" as not started`)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/mattn/go-isatty"
+)
+
+// StylesConfig is the styles config, where each style is a list of colours
+// and attributes, like "red,bold":
+//
+//	styles:
+//	  overdue: red
+//	  due-today: yellow
+//	  high-importance: bold
+//	  completed: faint,crossed-out
+//	  categories:
+//	    work: blue
+type StylesConfig struct {
+	Overdue        string            `mapstructure:"overdue"`
+	DueToday       string            `mapstructure:"due-today"`
+	HighImportance string            `mapstructure:"high-importance"`
+	Completed      string            `mapstructure:"completed"`
+	Categories     map[string]string `mapstructure:"categories"`
+}
+
+// defaultStyles are the styles which aren't in the config
+var defaultStyles = map[string]string{
+	"overdue":         "red",
+	"due-today":       "yellow",
+	"high-importance": "bold",
+	"completed":       "faint,crossed-out",
+}
+
+// taskStyles are the colours of the task rows, or nil when colours are
+// disabled
+var taskStyles *utils.TaskStyles
+
+// isTerminal reports whether stdout is a terminal
+var isTerminal = func() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// colorEnabled reports whether the output is coloured, which it isn't with
+// --no-color or NO_COLOR, or when stdout isn't a terminal
+func colorEnabled(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal()
+}
+
+// setColor enables or disables the colours of all the output
+func setColor(enabled bool) {
+	color.NoColor = !enabled
+	if enabled {
+		text.EnableColors()
+	} else {
+		text.DisableColors()
+	}
+}
+
+// parseStylesConfig checks the styles config and returns the task styles
+func parseStylesConfig(config StylesConfig) (*utils.TaskStyles, error) {
+	styles := utils.TaskStyles{Categories: map[string]text.Colors{}}
+
+	fields := []struct {
+		name   string
+		value  string
+		colors *text.Colors
+	}{
+		{name: "overdue", value: config.Overdue, colors: &styles.Overdue},
+		{name: "due-today", value: config.DueToday, colors: &styles.DueToday},
+		{name: "high-importance", value: config.HighImportance, colors: &styles.HighImportance},
+		{name: "completed", value: config.Completed, colors: &styles.Completed},
+	}
+
+	for _, f := range fields {
+		colors, err := utils.ParseColors(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("styles.%s: %w", f.name, err)
+		}
+		*f.colors = colors
+	}
+
+	for category, value := range config.Categories {
+		colors, err := utils.ParseColors(value)
+		if err != nil {
+			return nil, fmt.Errorf("styles.categories.%s: %w", category, err)
+		}
+		styles.Categories[category] = colors
+	}
+
+	return &styles, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+func Test_parseStylesConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StylesConfig
+		want    *utils.TaskStyles
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: StylesConfig{Overdue: "red", DueToday: "yellow", HighImportance: "bold", Completed: "faint,crossed-out"},
+			want: &utils.TaskStyles{
+				Overdue:        text.Colors{text.FgRed},
+				DueToday:       text.Colors{text.FgYellow},
+				HighImportance: text.Colors{text.Bold},
+				Completed:      text.Colors{text.Faint, text.CrossedOut},
+				Categories:     map[string]text.Colors{},
+			},
+		},
+		{
+			name:   "categories and empty styles",
+			config: StylesConfig{Overdue: "hi-red bg-black", Categories: map[string]string{"work": "Blue"}},
+			want: &utils.TaskStyles{
+				Overdue:        text.Colors{text.FgHiRed, text.BgBlack},
+				DueToday:       text.Colors{},
+				HighImportance: text.Colors{},
+				Completed:      text.Colors{},
+				Categories:     map[string]text.Colors{"work": {text.FgBlue}},
+			},
+		},
+		{name: "unknown colour", config: StylesConfig{Overdue: "rouge"}, wantErr: true},
+		{name: "unknown category colour", config: StylesConfig{Categories: map[string]string{"work": "blu"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStylesConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStylesConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStylesConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskStyles_Colors(t *testing.T) {
+	styles, err := parseStylesConfig(StylesConfig{Overdue: "red", DueToday: "yellow", HighImportance: "bold", Completed: "faint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 7, 9, 15, 0, 0, 0, time.UTC)
+	due := func(year int, month time.Month, day int) *datetime.GraphTime {
+		g := datetime.GraphTime(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+		return &g
+	}
+
+	tests := []struct {
+		name string
+		task api.TodoTask
+		want text.Colors
+	}{
+		{name: "no due date", task: api.TodoTask{}, want: text.Colors{}},
+		{name: "overdue", task: api.TodoTask{DueDateTime: due(2021, 7, 8)}, want: text.Colors{text.FgRed}},
+		{name: "due today", task: api.TodoTask{DueDateTime: due(2021, 7, 9)}, want: text.Colors{text.FgYellow}},
+		{name: "due tomorrow", task: api.TodoTask{DueDateTime: due(2021, 7, 10)}, want: text.Colors{}},
+		{name: "overdue and high importance", task: api.TodoTask{DueDateTime: due(2021, 7, 1), Importance: "high"}, want: text.Colors{text.FgRed, text.Bold}},
+		{name: "completed isn't overdue", task: api.TodoTask{DueDateTime: due(2021, 7, 1), Status: "completed"}, want: text.Colors{text.Faint}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := styles.Colors(tt.task, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TaskStyles.Colors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_viewCmd_styles(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	for _, task := range []graphfake.Object{
+		{"title": "Write report", "dueDateTime": graphfake.Object{"dateTime": "2021-07-09T00:00:00.0000000", "timeZone": "UTC"}},
+		{"title": "Water plants"},
+	} {
+		if _, err := s.AddTask(listId, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(f func() bool) { isTerminal = f }(isTerminal)
+	isTerminal = func() bool { return true }
+
+	out, err := executeCmd(t, s, "view", "work")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, text.FgRed.EscapeSeq()+" Write report")
+	assertNotContains(t, out, text.FgRed.EscapeSeq()+" Water plants")
+
+	out, err = executeCmd(t, s, "view", "work", "--no-color")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "Write report")
+	assertNotContains(t, out, "\x1b[")
+
+	isTerminal = func() bool { return false }
+	out, err = executeCmd(t, s, "view", "work")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertNotContains(t, out, "\x1b[")
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/dalyisaac/mstodo/transfer/ics"
+	"github.com/dalyisaac/mstodo/transfer/markdown"
+	"github.com/dalyisaac/mstodo/transfer/spreadsheet"
+	"github.com/dalyisaac/mstodo/transfer/taskwarrior"
+	"github.com/dalyisaac/mstodo/transfer/todotxt"
+)
+
+// transferFormat is a format which tasks can be imported from and exported to
+type transferFormat struct {
+	// fields are the fields which the format keeps, which are the only ones
+	// changed by importing
+	fields transfer.Fields
+
+	// encode and decode count what the other side can't represent in report.
+	// decode is nil for csv, whose rows are read by decodeCSV.
+	encode func(w io.Writer, tasks []transfer.Task, report transfer.Report) error
+	decode func(r io.Reader, loc *time.Location, report transfer.Report) ([]transfer.Task, error)
+}
+
+// transferFormats are the formats of import and export, by their --format name
+var transferFormats = map[string]transferFormat{
+	"csv":         {fields: spreadsheet.Fields, encode: encodeWithoutReport(spreadsheet.Encode)},
+	"ics":         {fields: ics.Fields, encode: encodeWithoutReport(ics.Encode), decode: ics.Decode},
+	"markdown":    {fields: markdown.Fields, encode: encodeWithoutReport(markdown.Encode), decode: decodeWithoutReport(markdown.Decode)},
+	"taskwarrior": {fields: taskwarrior.Fields, encode: taskwarrior.Encode, decode: taskwarrior.Decode},
+	"todotxt":     {fields: todotxt.Fields, encode: encodeWithoutReport(todotxt.Encode), decode: decodeWithoutReport(todotxt.Decode)},
+}
+
+// encodeWithoutReport returns the encode func of a format which doesn't report
+// what it can't represent
+func encodeWithoutReport(encode func(io.Writer, []transfer.Task) error) func(io.Writer, []transfer.Task, transfer.Report) error {
+	return func(w io.Writer, tasks []transfer.Task, _ transfer.Report) error {
+		return encode(w, tasks)
+	}
+}
+
+// decodeWithoutReport returns the decode func of a format which doesn't report
+// what it can't represent
+func decodeWithoutReport(decode func(io.Reader, *time.Location) ([]transfer.Task, error)) func(io.Reader, *time.Location, transfer.Report) ([]transfer.Task, error) {
+	return func(r io.Reader, loc *time.Location, _ transfer.Report) ([]transfer.Task, error) {
+		return decode(r, loc)
+	}
+}
+
+// printReport prints what the tool called name couldn't represent, if anything
+func printReport(w io.Writer, name string, report transfer.Report) {
+	if len(report) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Not represented in %s:\n", name)
+	for _, line := range report.Lines() {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// transferFormatNames returns the names of the formats, sorted
+func transferFormatNames() []string {
+	names := []string{}
+	for name := range transferFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getTransferFormat returns the format called name
+func getTransferFormat(name string) (*transferFormat, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing --format - choices: [%s]", strings.Join(transferFormatNames(), ", "))
+	}
+
+	format, ok := transferFormats[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a format - choices: [%s]", name, strings.Join(transferFormatNames(), ", "))
+	}
+	return &format, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/dalyisaac/mstodo/tui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(createTuiCmd())
+}
+
+type tuiParamsFlags struct {
+	refresh time.Duration
+	tz      string
+}
+
+func createTuiCmd() *cobra.Command {
+	flags := tuiParamsFlags{}
+
+	tuiCmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Browse and edit tasks in a full-screen terminal UI",
+		Long: `Browse and edit tasks in a full-screen terminal UI, with the lists on the left
+and the tasks of the selected list on the right.
+
+  j/k, up/down    move
+  g/G             first/last
+  tab, h/l        switch between the lists and the tasks
+  space, x        toggle completed
+  e               edit the title
+  d               edit the due date, like "next friday" (empty removes it)
+  i               cycle the importance
+  a               add a task, with the metadata syntax of add, like "Pay rent tomorrow !high"
+  D, delete       delete the task
+  u               undo the last delete
+  /               search the titles, and esc to clear the search
+  r               refresh
+  ?               show all the keys
+  q, ctrl+c       quit
+
+The lists and tasks are also refreshed every --refresh. The --timeout config applies
+to the requests made by each key, rather than to the whole session.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The session runs until it's quit, so it has no overall timeout
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			client, err := newClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Dates are parsed and shown in the user's time zone
+			loc, err := setTimeZone(ctx, client, flags.tz)
+			if err != nil {
+				return err
+			}
+
+			return tui.Run(ctx, client, tui.Options{
+				Location: loc,
+				Styles:   taskStyles,
+				Refresh:  flags.refresh,
+				Timeout:  cliConfig.Timeout,
+			})
+		},
+	}
+
+	tuiCmd.Flags().DurationVar(&flags.refresh, "refresh", 30*time.Second, "How often the lists and tasks are reloaded (0 to only reload them with r)")
+	tuiCmd.Flags().StringVar(&flags.tz, "tz", "", "Time zone for the shown and entered dates, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+
+	return tuiCmd
+}
@@ -19,13 +19,17 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/dalyisaac/mstodo/api"
 	"github.com/dalyisaac/mstodo/datetime"
 	"github.com/dalyisaac/mstodo/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 )
 
@@ -36,12 +40,13 @@ func init() {
 type viewParamsFlags struct {
 	// filter flags
 	title, status, reminder, dueDate, completed, created, lastModified string
-	sort, exclude                                                      string
-	absoluteTime, showId                                               bool
+	sort, exclude, columns, groupBy                                    string
+	tz                                                                 string
+	absoluteTime, showId, verbose, summary                             bool
 }
 
 type viewParams struct {
-	columns            []table.ColumnConfig
+	columns            utils.Columns
 	sort               []table.SortBy
 	titleFilter        *regexp.Regexp
 	statusFilter       *regexp.Regexp
@@ -50,6 +55,20 @@ type viewParams struct {
 	completedFilter    *datetime.DateFilters
 	createdFilter      *datetime.DateFilters
 	lastModifiedFilter *datetime.DateFilters
+	location           *time.Location
+	styles             *utils.TaskStyles
+	grouper            *taskGrouper
+	summary            bool
+
+	// listNames are the viewed lists, and taskLists and taskListIds map the
+	// task IDs to their names and IDs
+	listNames   []string
+	taskLists   map[string]string
+	taskListIds map[string]string
+
+	// numbered shows the number of each task, which the shell's commands use
+	numbered bool
+	numbers  map[string]int
 }
 
 const matchAll = "."
@@ -59,47 +78,114 @@ func createViewCmd() *cobra.Command {
 
 	// viewCmd represents the view command
 	var viewCmd = &cobra.Command{
-		Use:   "view <list name>",
-		Short: "View a specific list",
-		Long: `View a specific task list.
+		Use:   "view [list name]...",
+		Short: "View specific lists",
+		Long: `View one or more task lists.
 Dates can be filtered using by specifying the start and/or end date you're interested in. For example:
---reminder="start Monday; end fri"`,
+--reminder="start Monday; end fri"
+--due="start today; end end of month"
+--created="start 2 weeks ago"
+
+Dates can also be filtered with:
+  before X, after X, on X   the days before, after or on X, like --due="before friday"
+  X..Y                      the days from X to Y, like --due="2021-10-01..2021-10-31", "today.." or "..friday"
+  named ranges              today, tomorrow, yesterday, this/next/last week, month, quarter or year,
+                            last/next N days, weeks, months or years, and overdue
+  none, any                 the tasks without or with the date, like --due=none
+Clauses separated by ";" are combined, like --due="this month; after 2021-10-15"
+
+Without a list name, you choose the lists.
+
+--group-by shows a table for each status, importance, due day, category or list,
+and --summary prints the number of open, completed and overdue tasks, and the
+task which is due next.`,
+		Example: `  mstodo view work --due "this week" --group-by due-day
+  mstodo view work home --group-by list --summary`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
-				return errors.New("missing list name")
+			// In the shell, the list is the one chosen by use
+			if len(args) < 1 && session != nil && session.list != "" {
+				args = []string{session.list}
 			}
 
-			params, err := getViewCmdParams(flags)
-			if err != nil {
-				return err
+			// Otherwise, the user chooses the lists
+			if len(args) < 1 && !canPick(cmd.InOrStdin()) {
+				return errors.New("missing list name - the lists can only be chosen when the input and output are a terminal")
 			}
 
-			// Get name
-			name, err := utils.CleanName(args[0])
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			client, err := newClient(ctx)
 			if err != nil {
-				return nil
+				return err
 			}
 
-			// Get lists
-			lists, err := api.GetLists()
+			// Dates are parsed and shown in the user's time zone
+			loc, err := setTimeZone(ctx, client, flags.tz)
 			if err != nil {
 				return err
 			}
 
-			// Get task list id
-			listId, err := lists.GetListId(name)
+			if flags.verbose {
+				printViewDateExplanations(cmd.ErrOrStderr(), flags)
+			}
+
+			params, err := getViewCmdParams(flags, loc)
 			if err != nil {
 				return err
 			}
 
-			// Get task list
-			tasks, err := api.GetTasks(listId)
+			// Get lists
+			lists, err := getLists(ctx, client)
 			if err != nil {
 				return err
 			}
 
+			if len(args) < 1 {
+				if args, err = pickLists(cmd.InOrStdin(), lists); err != nil {
+					return err
+				}
+			}
+
+			tasks := api.TodoTaskList{}
+			params.taskLists = map[string]string{}
+			params.taskListIds = map[string]string{}
+			params.numbered = session != nil
+
+			for _, arg := range args {
+				// Get name
+				name, err := utils.CleanName(arg)
+				if err != nil {
+					return err
+				}
+
+				// Get task list id
+				listId, err := lists.GetListId(name)
+				if err != nil {
+					return err
+				}
+
+				// Get task list
+				listTasks, err := client.GetTasks(ctx, listId)
+				if err != nil {
+					return err
+				}
+
+				params.listNames = append(params.listNames, name)
+				for _, task := range *listTasks {
+					params.taskLists[task.Id] = name
+					params.taskListIds[task.Id] = listId
+				}
+				tasks = append(tasks, *listTasks...)
+			}
+
 			// Display results
-			params.printTaskList(*tasks)
+			shown := params.printTaskList(cmd.OutOrStdout(), tasks)
+
+			// The shell's commands refer to the tasks by their numbers
+			if session != nil {
+				session.setListing(shown, params.taskListIds)
+			}
 
 			return nil
 		},
@@ -114,15 +200,30 @@ Dates can be filtered using by specifying the start and/or end date you're inter
 	viewCmd.Flags().StringVarP(&flags.lastModified, "last-modified", "m", matchAll, "Filter by last-modified using the date syntax")
 
 	viewCmd.Flags().StringVarP(&flags.sort, "sort", "s", "none", "Sort by the fields, for example: --sort=\"[title:dsc,created:asc,status]\"")
+	viewCmd.Flags().StringVar(&flags.columns, "columns", "", fmt.Sprintf("The columns to show, in order, for example: --columns=\"title,due,status\" - choices: [%s]", strings.Join(viewColumns(utils.Transformer, nil).Names(), ", ")))
 	viewCmd.Flags().StringVarP(&flags.exclude, "exclude", "x", "", "Exclude columns")
 	viewCmd.Flags().BoolVarP(&flags.absoluteTime, "absolute", "a", false, "Show absolute datetime")
 	viewCmd.Flags().BoolVarP(&flags.showId, "id", "i", false, "Show the task IDs")
+	viewCmd.Flags().StringVarP(&flags.groupBy, "group-by", "g", "", fmt.Sprintf("Show a table for each group of tasks - choices: [%s]", strings.Join(taskGrouperNames(), ", ")))
+	viewCmd.Flags().BoolVar(&flags.summary, "summary", false, "Print the number of open, completed and overdue tasks, and the task due next")
+	viewCmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Print how the date filters were parsed")
+	viewCmd.Flags().StringVar(&flags.tz, "tz", "", "Time zone for the filters and shown dates, like \"Europe/Paris\" (defaults to the time-zone config, then the mailbox time zone)")
+
+	// completions
+	columns := viewColumns(utils.Transformer, nil)
+	viewCmd.ValidArgsFunction = completeListNames
+	viewCmd.RegisterFlagCompletionFunc("title", completeViewTitle)
+	viewCmd.RegisterFlagCompletionFunc("status", completeChoices(api.GraphStatusOptions...))
+	viewCmd.RegisterFlagCompletionFunc("sort", completeCommaChoices(sortChoices(columns)...))
+	viewCmd.RegisterFlagCompletionFunc("columns", completeCommaChoices(columnChoices(columns)...))
+	viewCmd.RegisterFlagCompletionFunc("exclude", completeCommaChoices(columnChoices(columns)...))
+	viewCmd.RegisterFlagCompletionFunc("group-by", completeChoices(taskGrouperNames()...))
 
 	return viewCmd
 }
 
-func getViewCmdParams(flags viewParamsFlags) (*viewParams, error) {
-	params := viewParams{}
+func getViewCmdParams(flags viewParamsFlags, loc *time.Location) (*viewParams, error) {
+	params := viewParams{location: loc, styles: taskStyles, summary: flags.summary}
 
 	timeTransformer := utils.Transformer
 	if flags.absoluteTime {
@@ -130,38 +231,32 @@ func getViewCmdParams(flags viewParamsFlags) (*viewParams, error) {
 	}
 
 	// Validate filter
-	getFilters(&params, flags)
-
-	var viewCmdCols = []table.ColumnConfig{
-		utils.LeftColumn("Id"),
-		utils.LeftColumn("Title"),
-		utils.CenterColumn("Importance"),
-		utils.CenterColumnTransformer("Status", utils.StatusTransformer),
-		utils.CenterColumnTransformer("Reminder", timeTransformer),
-		utils.CenterColumnTransformer("Due Date", timeTransformer),
-		utils.CenterColumnTransformer("Completed", timeTransformer),
-		utils.CenterColumnTransformer("Created", timeTransformer),
-		utils.CenterColumnTransformer("Last Modified", timeTransformer),
+	if err := getFilters(&params, flags); err != nil {
+		return nil, err
 	}
 
+	columns := viewColumns(timeTransformer, params.styles)
+
 	// Get sort
-	sortBy, err := utils.GetSortByColumns(flags.sort, viewCmdCols)
+	sortBy, err := columns.SortBy(flags.sort)
 	if err != nil {
 		return nil, err
 	}
 	params.sort = sortBy
 
-	// Show ID flag
-	if !flags.showId {
-		flags.exclude = "ID," + flags.exclude
+	// Construct the shown columns
+	cols, err := selectColumns("view", columns, flags.columns, flags.exclude, flags.showId)
+	if err != nil {
+		return nil, err
 	}
+	params.columns = cols
 
-	// Construct excluded columns
-	cols, err := utils.GetAllowedColumns(flags.exclude, viewCmdCols)
+	// Get group by
+	grouper, err := getTaskGrouper(flags.groupBy)
 	if err != nil {
 		return nil, err
 	}
-	params.columns = cols
+	params.grouper = grouper
 
 	return &params, nil
 }
@@ -205,6 +300,17 @@ func getFilters(params *viewParams, flags viewParamsFlags) error {
 	return nil
 }
 
+// printViewDateExplanations prints how the date filters are parsed
+func printViewDateExplanations(out io.Writer, flags viewParamsFlags) {
+	printDateReference(out, datetime.CurrentReference())
+
+	for _, flag := range []string{flags.reminder, flags.dueDate, flags.completed, flags.created, flags.lastModified} {
+		if flag != matchAll {
+			printFilterExplanation(out, flag)
+		}
+	}
+}
+
 func setStringFilter(filter **regexp.Regexp, flag string) error {
 	r, err := regexp.Compile(flag)
 	if err != nil {
@@ -225,23 +331,68 @@ func setDateFilter(filter **datetime.DateFilters, flag string) error {
 	return nil
 }
 
-func (params *viewParams) printTaskList(taskList api.TodoTaskList) {
-	headerRow := table.Row{}
-	columns := params.columns
+// printTaskList prints the tasks which match the filters, and returns them
+func (params *viewParams) printTaskList(out io.Writer, taskList api.TodoTaskList) api.TodoTaskList {
+	tasks := api.TodoTaskList{}
+	params.numbers = map[string]int{}
+	for _, todoTask := range taskList {
+		if params.canAdd(todoTask) {
+			tasks = append(tasks, todoTask.In(params.location))
+			params.numbers[todoTask.Id] = len(tasks)
+		}
+	}
+
+	now := time.Now().In(params.location)
 
-	// Add the column names to the header row
-	for _, c := range columns {
-		headerRow = append(headerRow, c.Name)
+	if params.grouper == nil {
+		params.printTaskTable(out, "", tasks, now)
+	} else {
+		for _, group := range params.grouper.group(params, tasks) {
+			params.printTaskTable(out, fmt.Sprintf("%s (%d)", group.name, len(group.tasks)), group.tasks, now)
+		}
 	}
 
-	t := utils.CreateFormattedTable(&headerRow, &columns)
+	if params.summary {
+		printTaskSummary(out, tasks, now)
+	}
 
-	for _, todoTask := range taskList {
-		if params.canAdd(todoTask) {
-			row := table.Row{}
-			row = append(row, getAllowedTodoTaskFields(todoTask, columns)...)
-			t.AppendRow(row)
+	return tasks
+}
+
+// printTaskTable prints the tasks in a table, with the title if it isn't empty
+func (params *viewParams) printTaskTable(out io.Writer, title string, tasks api.TodoTaskList, now time.Time) {
+	headerRow := params.columns.Header()
+	configs := params.columns.Configs()
+
+	if params.numbered {
+		headerRow = append(table.Row{"#"}, headerRow...)
+	}
+
+	// The colours of each row are in a hidden last column, so that they're
+	// sorted with the row
+	if params.styles != nil {
+		headerRow = append(headerRow, "")
+		configs = append(configs, table.ColumnConfig{Number: len(headerRow), Hidden: true})
+	}
+
+	t := utils.CreateFormattedTable(out, &headerRow, &configs)
+	if title != "" {
+		t.SetTitle(title)
+	}
+
+	for _, task := range tasks {
+		row := params.columns.Row(task)
+		if params.numbered {
+			row = append(table.Row{params.numbers[task.Id]}, row...)
 		}
+		if params.styles != nil {
+			row = append(row, params.styles.Colors(task, now))
+		}
+		t.AppendRow(row)
+	}
+
+	if params.styles != nil {
+		t.SetRowPainter(utils.StyleRowPainter)
 	}
 
 	if len(params.sort) != 0 {
@@ -290,33 +441,31 @@ func (params *viewParams) canAdd(task api.TodoTask) bool {
 	return true
 }
 
-func getAllowedTodoTaskFields(todoTask api.TodoTask, columns []table.ColumnConfig) table.Row {
-	fields := table.Row{}
-
-	for _, col := range columns {
-		switch col.Name {
-		case "Id":
-			fields = append(fields, todoTask.Id)
-		case "Title":
-			fields = append(fields, todoTask.Title)
-		case "Importance":
-			fields = append(fields, todoTask.Importance)
-		case "Status":
-			fields = append(fields, todoTask.Status)
-		case "Reminder":
-			fields = append(fields, todoTask.ReminderDateTime)
-		case "Due Date":
-			fields = append(fields, todoTask.DueDateTime)
-		case "Completed":
-			fields = append(fields, todoTask.Completed)
-		case "Created":
-			fields = append(fields, todoTask.CreatedDateTime)
-		case "Last Modified":
-			fields = append(fields, todoTask.LastModifiedDateTime)
-		}
+// viewColumns are the columns which view can show, with the dates shown by
+// timeTransformer and the categories coloured by styles, if it isn't nil
+func viewColumns(timeTransformer text.Transformer, styles *utils.TaskStyles) utils.Columns {
+	task := func(item interface{}) api.TodoTask {
+		return item.(api.TodoTask)
 	}
 
-	return fields
+	return utils.Columns{
+		{ColumnConfig: utils.LeftColumn("Id"), Value: func(item interface{}) interface{} { return task(item).Id }},
+		{ColumnConfig: utils.LeftColumn("Title"), Value: func(item interface{}) interface{} { return task(item).Title }},
+		{ColumnConfig: utils.CenterColumn("Importance"), Value: func(item interface{}) interface{} { return task(item).Importance }},
+		{ColumnConfig: utils.CenterColumnTransformer("Status", utils.StatusTransformer), Value: func(item interface{}) interface{} { return task(item).Status }},
+		{ColumnConfig: utils.CenterColumnTransformer("Reminder", timeTransformer), Value: func(item interface{}) interface{} { return task(item).ReminderDateTime }},
+		{ColumnConfig: utils.CenterColumnTransformer("Due Date", timeTransformer), Aliases: []string{"due"}, Value: func(item interface{}) interface{} { return task(item).DueDateTime }},
+		{ColumnConfig: utils.CenterColumnTransformer("Completed", timeTransformer), Value: func(item interface{}) interface{} { return task(item).Completed }},
+		{ColumnConfig: utils.CenterColumnTransformer("Created", timeTransformer), Value: func(item interface{}) interface{} { return task(item).CreatedDateTime }},
+		{ColumnConfig: utils.CenterColumnTransformer("Last Modified", timeTransformer), Aliases: []string{"modified"}, Value: func(item interface{}) interface{} { return task(item).LastModifiedDateTime }},
+		{ColumnConfig: utils.LeftColumn("Categories"), Value: func(item interface{}) interface{} {
+			categories := []string{}
+			for _, c := range task(item).Categories {
+				categories = append(categories, styles.Category(c))
+			}
+			return strings.Join(categories, ", ")
+		}},
+	}
 }
 
 // graphtime converts `time.Time` to a `datetime.GraphTime` pointer
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+)
+
+func Test_viewCmd(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	for _, task := range []graphfake.Object{
+		{"title": "Write report", "importance": "high", "dueDateTime": graphfake.Object{"dateTime": "2021-07-09T00:00:00.0000000", "timeZone": "UTC"}},
+		{"title": "Book flights", "status": "completed"},
+		{"title": "Water plants"},
+	} {
+		if _, err := s.AddTask(listId, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := executeCmd(t, s, "view", "work")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "Write report", "Book flights", "Water plants", "✅")
+
+	out, err = executeCmd(t, s, "view", "work", "--title", "^W", "--exclude", "importance")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "Write report", "Water plants")
+	assertNotContains(t, out, "Book flights", "IMPORTANCE")
+
+	out, err = executeCmd(t, s, "view", "work", "--columns", "due,title", "--sort", "title:dsc")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "DUE DATE")
+	assertNotContains(t, out, "IMPORTANCE", "STATUS")
+	if due, title := strings.Index(out, "DUE DATE"), strings.Index(out, "TITLE"); due > title {
+		t.Errorf("view --columns should show Due Date before Title:\n%s", out)
+	}
+	if water, book := strings.Index(out, "Water plants"), strings.Index(out, "Book flights"); water > book {
+		t.Errorf("view --sort title:dsc should sort the titles descending:\n%s", out)
+	}
+
+	if _, err := executeCmd(t, s, "view", "work", "--columns", "colour"); err == nil {
+		t.Errorf("view with an unknown column should fail")
+	}
+
+	out, err = executeCmd(t, s, "view", "work", "--due", "none")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "Book flights", "Water plants")
+	assertNotContains(t, out, "Write report")
+
+	out, err = executeCmd(t, s, "view", "work", "--due", "2021-07-01..2021-07-31")
+	if err != nil {
+		t.Fatalf("view error = %v", err)
+	}
+	assertContains(t, out, "Write report")
+	assertNotContains(t, out, "Book flights", "Water plants")
+
+	out, err = executeCmd(t, s, "view", "work", "--due", "someday", "--verbose")
+	if err == nil {
+		t.Errorf("view with an invalid due filter should fail")
+	}
+	assertContains(t, out, `Filter:     "someday"`, "missing qualifier")
+
+	if _, err := executeCmd(t, s, "view", "missing"); err == nil {
+		t.Errorf("view of a missing list should fail")
+	}
+
+	if _, err := executeCmd(t, s, "view", "work", " "); err == nil {
+		t.Errorf("view with an empty list name should fail")
+	}
+}
+
+func Test_viewCmd_recordReplay(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	if _, err := s.AddTask(listId, graphfake.Object{"title": "Write report"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	recorded, err := executeCmd(t, s, "view", "work", "--id", "--record", dir)
+	if err != nil {
+		t.Fatalf("view --record error = %v", err)
+	}
+	assertContains(t, recorded, "Write report")
+
+	s.Close()
+	replayed, err := executeCmd(t, s, "view", "work", "--id", "--replay", dir)
+	if err != nil {
+		t.Fatalf("view --replay error = %v", err)
+	}
+	assertContains(t, replayed, "Write report", "id-")
+	assertNotContains(t, replayed, listId)
+}
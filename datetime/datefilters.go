@@ -22,6 +22,9 @@ import "time"
 type DateFilters struct {
 	Start *time.Time
 	End   *time.Time
+
+	// None matches only the tasks without a date
+	None bool
 }
 
 func (filters *DateFilters) Contains(g *GraphTime) bool {
@@ -29,8 +32,8 @@ func (filters *DateFilters) Contains(g *GraphTime) bool {
 		return true
 	}
 
-	if g == nil {
-		return false
+	if g == nil || filters.None {
+		return g == nil && filters.None
 	}
 
 	t := time.Time(*g)
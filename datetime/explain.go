@@ -0,0 +1,225 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxNearMisses is the number of near misses in an explanation
+const maxNearMisses = 3
+
+// Explanation describes how a date was parsed, for diagnosing dates which are
+// rejected or parsed unexpectedly
+type Explanation struct {
+	// Input is the date, as given
+	Input string
+
+	// Translated is the input after the localized month and weekday names
+	// were translated to English
+	Translated string
+
+	// DateTime is true if the input was parsed as a date time
+	DateTime bool
+
+	// Rule is the format which matched, like `layout "2 Jan 2006"`
+	Rule string
+
+	// Time is the parsed date, if the input was valid
+	Time *time.Time
+
+	// Err is why the input was rejected
+	Err error
+
+	// NearMisses are the layouts which matched the most of a rejected input
+	NearMisses []NearMiss
+}
+
+// NearMiss is a layout which almost matched a rejected date
+type NearMiss struct {
+	// Layout is the Go layout, like "2 Jan 2006"
+	Layout string
+
+	// Matched is the start of the input which the layout matched
+	Matched string
+
+	// Reason is why the rest of the input didn't match
+	Reason string
+}
+
+// Reference is what dates are parsed relative to
+type Reference struct {
+	Now       time.Time
+	Location  *time.Location
+	WeekStart time.Weekday
+	Locale    string
+	Order     DateOrder
+}
+
+// CurrentReference returns the clock and settings which dates are currently
+// parsed with
+func CurrentReference() Reference {
+	return wrapperInstance.reference()
+}
+
+// ExplainDate parses input as a date, and explains the result
+func ExplainDate(input string) *Explanation {
+	return wrapperInstance.explainParse(input, dateParseType)
+}
+
+// ExplainDateTime parses input as a date time, and explains the result
+func ExplainDateTime(input string) *Explanation {
+	return wrapperInstance.explainParse(input, dateTimeParseType)
+}
+
+// ExplainFilter parses input as a date filter, like the filters of view, and
+// explains each date in it
+func ExplainFilter(input string) (*DateFilters, []*Explanation, error) {
+	explanations := []*Explanation{}
+
+	parser := *wrapperInstance
+	parser.trace = func(e *Explanation) {
+		explanations = append(explanations, e)
+	}
+
+	filters, err := parser.filterParser(input, dateParseType)
+	return filters, explanations, err
+}
+
+func (parser *parserWrapper) reference() Reference {
+	ref := Reference{
+		Now:       parser.now(),
+		Location:  parser.location(),
+		WeekStart: parser.weekStart,
+		Locale:    "English",
+		Order:     parser.order,
+	}
+	if parser.locale != nil {
+		ref.Locale = parser.locale.Name
+	}
+	return ref
+}
+
+func (parser *parserWrapper) explainParse(input string, parseType parseType) *Explanation {
+	var explanation *Explanation
+
+	traced := *parser
+	traced.trace = func(e *Explanation) {
+		explanation = e
+	}
+
+	traced.parse(input, 0, parseType)
+	return explanation
+}
+
+// explain creates the explanation of parsing input
+func (parser *parserWrapper) explain(input string, parseType parseType, date *time.Time, rule string, err error) *Explanation {
+	translated := parser.locale.translate(strings.TrimSpace(input))
+
+	explanation := &Explanation{
+		Input:      strings.TrimSpace(input),
+		Translated: translated,
+		DateTime:   parseType == dateTimeParseType,
+		Rule:       rule,
+		Time:       date,
+		Err:        err,
+	}
+
+	// If a format matched, like ISO 8601, its error is already specific
+	if err != nil && rule == "" {
+		explanation.NearMisses = parser.nearMisses(translated, parseType)
+	}
+
+	return explanation
+}
+
+// nearMisses returns the layouts which matched the most of input before
+// failing
+func (parser *parserWrapper) nearMisses(input string, parseType parseType) []NearMiss {
+	layouts := append([]string{}, dateLayouts...)
+
+	orders := []DateOrder{parser.order}
+	if parser.order == AnyOrder {
+		orders = []DateOrder{DayMonthYear, MonthDayYear, YearMonthDay}
+	}
+	for _, order := range orders {
+		layouts = append(layouts, numericLayouts[order]...)
+	}
+
+	misses := []NearMiss{}
+	for _, layout := range layouts {
+		candidates := []string{layout}
+		if parseType == dateTimeParseType {
+			candidates = generateDateTimeLayouts(candidates)
+		}
+
+		// The date time layout which matched the most of input
+		var best *NearMiss
+		for _, candidate := range candidates {
+			if miss := nearMiss(candidate, input, parser.location()); miss != nil && (best == nil || len(miss.Matched) > len(best.Matched)) {
+				best = miss
+			}
+		}
+
+		if best != nil {
+			misses = append(misses, *best)
+		}
+	}
+
+	// The layouts which matched the most first, and otherwise in the order
+	// they're tried
+	sort.SliceStable(misses, func(i, j int) bool {
+		return len(misses[i].Matched) > len(misses[j].Matched)
+	})
+
+	if len(misses) > maxNearMisses {
+		misses = misses[:maxNearMisses]
+	}
+	return misses
+}
+
+// nearMiss returns how much of input layout matched, or nil if layout matched
+// none of it
+func nearMiss(layout string, input string, loc *time.Location) *NearMiss {
+	_, err := time.ParseInLocation(layout, input, loc)
+
+	var parseErr *time.ParseError
+	if !errors.As(err, &parseErr) {
+		return nil
+	}
+
+	matched := input[:len(input)-len(parseErr.ValueElem)]
+	if strings.TrimSpace(matched) == "" {
+		return nil
+	}
+
+	reason := strings.TrimPrefix(parseErr.Message, ": ")
+	switch {
+	case reason != "":
+	case parseErr.ValueElem == "":
+		reason = fmt.Sprintf("expected %q but the input ended", parseErr.LayoutElem)
+	default:
+		reason = fmt.Sprintf("expected %q but found %q", parseErr.LayoutElem, parseErr.ValueElem)
+	}
+
+	return &NearMiss{Layout: layout, Matched: matched, Reason: reason}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parserWrapper_explainParse(t *testing.T) {
+	tests := []struct {
+		input      string
+		parseType  parseType
+		order      DateOrder
+		wantRule   string
+		wantTime   *time.Time
+		wantErr    bool
+		wantLayout string
+	}{
+		{input: "2021-07-09", parseType: dateParseType, wantRule: "ISO 8601", wantTime: p(date(9, 7))},
+		{input: "tomorrow", parseType: dateParseType, wantRule: "keyword, offset or period boundary", wantTime: p(date(8, 7))},
+		{input: "9 Jul 2021", parseType: dateParseType, wantRule: `layout "2 Jan 2006"`, wantTime: p(date(9, 7))},
+		{input: "09/07/2021", parseType: dateParseType, order: DayMonthYear, wantRule: `numeric layout "2/1/2006" (day-month-year)`, wantTime: p(date(9, 7))},
+		{input: "friday", parseType: dateParseType, wantRule: "weekday name", wantTime: p(date(9, 7))},
+		{input: "2021-13-01", parseType: dateParseType, wantRule: "ISO 8601", wantErr: true},
+		{input: "Jul 9th", parseType: dateParseType, wantErr: true, wantLayout: "Jan 2"},
+		{input: "9 Jul 2021x", parseType: dateTimeParseType, wantErr: true, wantLayout: "2 Jan 2006 at 15:04"},
+		{input: "9 Jul at 25:00", parseType: dateTimeParseType, wantRule: "keyword, offset or period boundary", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := &parserWrapper{
+				now:   func() time.Time { return date(7, 7) },
+				order: tt.order,
+			}
+			got := parser.explainParse(tt.input, tt.parseType)
+			if (got.Err != nil) != tt.wantErr {
+				t.Errorf("parserWrapper.explainParse() error = %v, wantErr %v", got.Err, tt.wantErr)
+			}
+			if got.Rule != tt.wantRule {
+				t.Errorf("parserWrapper.explainParse() rule = %v, want %v", got.Rule, tt.wantRule)
+			}
+			if !reflect.DeepEqual(got.Time, tt.wantTime) {
+				t.Errorf("parserWrapper.explainParse() time = %v, want %v", got.Time, tt.wantTime)
+			}
+			if tt.wantLayout != "" && (len(got.NearMisses) == 0 || got.NearMisses[0].Layout != tt.wantLayout) {
+				t.Errorf("parserWrapper.explainParse() near misses = %+v, want %v first", got.NearMisses, tt.wantLayout)
+			}
+		})
+	}
+}
+
+func Test_nearMiss(t *testing.T) {
+	tests := []struct {
+		layout string
+		input  string
+		want   *NearMiss
+	}{
+		{layout: "2 Jan", input: "9 Jul", want: nil},
+		{layout: "2 Jan", input: "9 Jul 2021", want: &NearMiss{Layout: "2 Jan", Matched: "9 Jul", Reason: `extra text: " 2021"`}},
+		{layout: "2 Jan 2006", input: "9 Jul", want: &NearMiss{Layout: "2 Jan 2006", Matched: "9 Jul", Reason: `expected "2006" but the input ended`}},
+		{layout: "Jan 2", input: "9 Jul", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout+" "+tt.input, func(t *testing.T) {
+			if got := nearMiss(tt.layout, tt.input, time.UTC); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nearMiss() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
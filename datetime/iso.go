@@ -0,0 +1,204 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotISO is returned by parseISO when the input doesn't start with a
+// four-digit year, so that the other formats should be tried.
+var errNotISO = errors.New("not an ISO 8601 date")
+
+// isoPrefixRegexp matches the start of an ISO 8601 date. None of the other
+// formats start with a four-digit year.
+var isoPrefixRegexp = regexp.MustCompile(`^\d{4}(?:-|W|\d)`)
+
+// isoCalendarLayouts are the complete calendar dates, like 2021-10-17
+var isoCalendarLayouts = []string{
+	"2006-01-02",
+	"20060102",
+}
+
+// isoWeekRegexps match week dates, like 2021-W42-3 or 2021W423. Without a day,
+// the Monday of the week is used.
+var isoWeekRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`^(\d{4})-W(\d{2})(?:-([1-7]))?$`),
+	regexp.MustCompile(`^(\d{4})W(\d{2})([1-7])?$`),
+}
+
+// isoOrdinalRegexps match ordinal dates, like 2021-290 or 2021290
+var isoOrdinalRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`^(\d{4})-(\d{3})$`),
+	regexp.MustCompile(`^(\d{4})(\d{3})$`),
+}
+
+// isoOffsetRegexp matches a trailing UTC offset, like +13:00, -0500 or +01
+var isoOffsetRegexp = regexp.MustCompile(`[+-](\d{2})(?::?(\d{2}))?$`)
+
+// isoClockLayouts are the times of day, with fractional seconds accepted after
+// the seconds
+var isoClockLayouts = []string{
+	"15:04:05",
+	"15:04",
+	"150405",
+	"1504",
+	"15",
+}
+
+// parseISO parses ISO 8601 dates and RFC 3339 timestamps. The date can be a
+// calendar date (2021-10-17), a week date (2021-W42-7) or an ordinal date
+// (2021-290), in the extended or basic format. It can be followed by a time,
+// separated by a "T" or a space, and an optional "Z" or UTC offset.
+//
+// When parsing a date, the calendar date is used as written, and any time is
+// ignored. When parsing a date time, a time is required. Dates, and times
+// without an offset, are in loc.
+func parseISO(input string, parseType parseType, loc *time.Location) (*time.Time, error) {
+	// Inputs are lower-cased by the filter parser
+	input = strings.ToUpper(input)
+
+	if !isoPrefixRegexp.MatchString(input) {
+		return nil, errNotISO
+	}
+
+	datePart, timePart := input, ""
+	if idx := strings.IndexAny(input, "T "); idx != -1 {
+		datePart, timePart = input[:idx], strings.TrimSpace(input[idx+1:])
+	}
+
+	date, err := parseISODate(datePart)
+	if err != nil {
+		return nil, err
+	}
+
+	if parseType == dateParseType {
+		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+		return &date, nil
+	}
+
+	if timePart == "" {
+		return nil, errors.New("invalid time: the date time has no time")
+	}
+
+	clock, loc, err := parseISOTime(timePart, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), loc)
+	return &result, nil
+}
+
+// parseISODate parses the date part of an ISO 8601 date, returning midnight UTC
+func parseISODate(input string) (time.Time, error) {
+	for _, layout := range isoCalendarLayouts {
+		if date, err := time.Parse(layout, input); err == nil {
+			return date, nil
+		}
+	}
+
+	for _, re := range isoWeekRegexps {
+		if match := re.FindStringSubmatch(input); match != nil {
+			return isoWeekDate(match[1], match[2], match[3])
+		}
+	}
+
+	for _, re := range isoOrdinalRegexps {
+		if match := re.FindStringSubmatch(input); match != nil {
+			return isoOrdinalDate(match[1], match[2])
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid ISO 8601 date '%s'", input)
+}
+
+// isoWeekDate returns the date of the given ISO week and weekday, where 1 is
+// Monday. Week 1 is the week containing the 4th of January.
+func isoWeekDate(yearStr, weekStr, dayStr string) (time.Time, error) {
+	year, _ := strconv.Atoi(yearStr)
+	week, _ := strconv.Atoi(weekStr)
+	day := 1
+	if dayStr != "" {
+		day, _ = strconv.Atoi(dayStr)
+	}
+
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	firstMonday := jan4.AddDate(0, 0, -mod(int(jan4.Weekday())-1, 7))
+	date := firstMonday.AddDate(0, 0, (week-1)*7+day-1)
+
+	// Reject weeks which don't exist in the year, like 2021-W53
+	if y, w := date.ISOWeek(); week == 0 || y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid ISO 8601 week %d of %d", week, year)
+	}
+
+	return date, nil
+}
+
+// isoOrdinalDate returns the date of the given day of the year, where 1 is the
+// 1st of January
+func isoOrdinalDate(yearStr, dayStr string) (time.Time, error) {
+	year, _ := strconv.Atoi(yearStr)
+	day, _ := strconv.Atoi(dayStr)
+
+	date := time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC)
+	if day == 0 || date.Year() != year {
+		return time.Time{}, fmt.Errorf("invalid ISO 8601 day %d of %d", day, year)
+	}
+
+	return date, nil
+}
+
+// parseISOTime parses the time part of an ISO 8601 date time, with an optional
+// "Z" or UTC offset. Without an offset, the time is in loc.
+func parseISOTime(input string, loc *time.Location) (time.Time, *time.Location, error) {
+	if strings.HasSuffix(input, "Z") {
+		loc = time.UTC
+		input = strings.TrimSuffix(input, "Z")
+	} else if match := isoOffsetRegexp.FindStringSubmatch(input); match != nil {
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		if hours > 23 || minutes > 59 {
+			return time.Time{}, nil, fmt.Errorf("invalid UTC offset '%s'", match[0])
+		}
+
+		offset := hours*60*60 + minutes*60
+		if match[0][0] == '-' {
+			offset = -offset
+		}
+
+		loc = time.FixedZone("", offset)
+		input = strings.TrimSuffix(input, match[0])
+	}
+
+	// ISO 8601 allows a comma before the fractional seconds
+	input = strings.Replace(input, ",", ".", 1)
+
+	for _, layout := range isoClockLayouts {
+		if clock, err := time.Parse(layout, input); err == nil {
+			return clock, loc, nil
+		}
+	}
+
+	return time.Time{}, nil, fmt.Errorf("invalid ISO 8601 time '%s'", input)
+}
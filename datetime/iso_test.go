@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parserWrapper_parseISO(t *testing.T) {
+	type args struct {
+		input     string
+		parseType parseType
+	}
+
+	parser := &parserWrapper{
+		now:       func() time.Time { return date(7, 7) },
+		weekStart: time.Monday,
+	}
+
+	utc := func(year int, month time.Month, day, hour, min, sec, nsec int) *time.Time {
+		return p(time.Date(year, month, day, hour, min, sec, nsec, time.UTC))
+	}
+
+	tests := []struct {
+		args    args
+		want    *time.Time
+		wantErr bool
+	}{
+		// calendar dates
+		{args: args{input: "2021-10-17", parseType: dateParseType}, want: utc(2021, 10, 17, 0, 0, 0, 0)},
+		{args: args{input: "20211017", parseType: dateParseType}, want: utc(2021, 10, 17, 0, 0, 0, 0)},
+		{args: args{input: "2021-10-17T15:00", parseType: dateParseType}, want: utc(2021, 10, 17, 0, 0, 0, 0)},
+		{args: args{input: "2021-02-29", parseType: dateParseType}, want: nil, wantErr: true},
+		{args: args{input: "2021-13-01", parseType: dateParseType}, want: nil, wantErr: true},
+
+		// week dates
+		{args: args{input: "2021-W42-3", parseType: dateParseType}, want: utc(2021, 10, 20, 0, 0, 0, 0)},
+		{args: args{input: "2021w423", parseType: dateParseType}, want: utc(2021, 10, 20, 0, 0, 0, 0)},
+		{args: args{input: "2021-W42", parseType: dateParseType}, want: utc(2021, 10, 18, 0, 0, 0, 0)},
+		{args: args{input: "2021-W01-1", parseType: dateParseType}, want: utc(2021, 1, 4, 0, 0, 0, 0)},
+		{args: args{input: "2020-W53-7", parseType: dateParseType}, want: utc(2021, 1, 3, 0, 0, 0, 0)},
+		{args: args{input: "2026-W01-1", parseType: dateParseType}, want: utc(2025, 12, 29, 0, 0, 0, 0)},
+		{args: args{input: "2021-W53-1", parseType: dateParseType}, want: nil, wantErr: true},
+		{args: args{input: "2021-W00", parseType: dateParseType}, want: nil, wantErr: true},
+
+		// ordinal dates
+		{args: args{input: "2021-290", parseType: dateParseType}, want: utc(2021, 10, 17, 0, 0, 0, 0)},
+		{args: args{input: "2021290", parseType: dateParseType}, want: utc(2021, 10, 17, 0, 0, 0, 0)},
+		{args: args{input: "2020-366", parseType: dateParseType}, want: utc(2020, 12, 31, 0, 0, 0, 0)},
+		{args: args{input: "2021-366", parseType: dateParseType}, want: nil, wantErr: true},
+		{args: args{input: "2021-000", parseType: dateParseType}, want: nil, wantErr: true},
+
+		// date times
+		{args: args{input: "2021-10-17T15:00", parseType: dateTimeParseType}, want: utc(2021, 10, 17, 15, 0, 0, 0)},
+		{args: args{input: "2021-10-17 15:00:30", parseType: dateTimeParseType}, want: utc(2021, 10, 17, 15, 0, 30, 0)},
+		{args: args{input: "2021-10-17t15:00:30z", parseType: dateTimeParseType}, want: utc(2021, 10, 17, 15, 0, 30, 0)},
+		{args: args{input: "2021-10-17T15:00:30.25Z", parseType: dateTimeParseType}, want: utc(2021, 10, 17, 15, 0, 30, 250000000)},
+		{args: args{input: "2021-10-17T15:00:30,5Z", parseType: dateTimeParseType}, want: utc(2021, 10, 17, 15, 0, 30, 500000000)},
+		{args: args{input: "20211017T1500", parseType: dateTimeParseType}, want: utc(2021, 10, 17, 15, 0, 0, 0)},
+		{args: args{input: "2021-W42-7T09", parseType: dateTimeParseType}, want: utc(2021, 10, 24, 9, 0, 0, 0)},
+		{args: args{input: "2021-10-17T15:00:00+13:00", parseType: dateTimeParseType}, want: p(time.Date(2021, 10, 17, 15, 0, 0, 0, time.FixedZone("", 13*60*60)))},
+		{args: args{input: "2021-10-17T15:00-0530", parseType: dateTimeParseType}, want: p(time.Date(2021, 10, 17, 15, 0, 0, 0, time.FixedZone("", -(5*60*60+30*60))))},
+		{args: args{input: "2021-10-17T15:00+01", parseType: dateTimeParseType}, want: p(time.Date(2021, 10, 17, 15, 0, 0, 0, time.FixedZone("", 60*60)))},
+		{args: args{input: "2021-10-17", parseType: dateTimeParseType}, want: nil, wantErr: true},
+		{args: args{input: "2021-10-17T25:00", parseType: dateTimeParseType}, want: nil, wantErr: true},
+		{args: args{input: "2021-10-17T15:00+25:00", parseType: dateTimeParseType}, want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args.input, func(t *testing.T) {
+			got, err := parser.parse(tt.args.input, 0, tt.args.parseType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parserWrapper.parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parserWrapper.parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parserWrapper_isoFilter(t *testing.T) {
+	parser := &parserWrapper{
+		now:       func() time.Time { return date(7, 7) },
+		weekStart: time.Monday,
+	}
+
+	got, err := parser.filterParser("start 2021-W42; end 2021-10-24", dateParseType)
+	if err != nil {
+		t.Fatalf("parserWrapper.filterParser() error = %v", err)
+	}
+
+	want := &DateFilters{
+		Start: p(time.Date(2021, 10, 18, 0, 0, 0, 0, time.UTC)),
+		End:   p(time.Date(2021, 10, 24, 0, 0, 0, 0, time.UTC)),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parserWrapper.filterParser() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Locale is a set of date conventions, with the localized month and weekday
+// names. English names are accepted in every locale.
+type Locale struct {
+	// Name is the name used by date-locale, like "de"
+	Name string
+
+	// Order is the default date order for numeric dates
+	Order DateOrder
+
+	// months are the lower-case names and abbreviations of each month, without
+	// accents
+	months [12][]string
+
+	// weekdays are the lower-case names of each weekday, starting with Sunday,
+	// without accents
+	weekdays [7][]string
+
+	// fillers are words which are removed, like "de" in "17 de octubre"
+	fillers []string
+
+	// ordinal matches a suffix after the day which is removed, like the "." in
+	// "17. Oktober"
+	ordinal *regexp.Regexp
+}
+
+// DefaultLocale is the locale used when date-locale isn't configured. Numeric
+// dates are day-month-year, as they were before date-locale was added.
+const DefaultLocale = "en"
+
+var locales = map[string]*Locale{
+	"en":    {Name: "en", Order: DayMonthYear},
+	"en-gb": {Name: "en-gb", Order: DayMonthYear},
+	"en-us": {Name: "en-us", Order: MonthDayYear},
+	"de": {
+		Name:  "de",
+		Order: DayMonthYear,
+		months: [12][]string{
+			{"januar", "janner", "jan"},
+			{"februar", "feb"},
+			{"marz", "mar"},
+			{"april", "apr"},
+			{"mai"},
+			{"juni", "jun"},
+			{"juli", "jul"},
+			{"august", "aug"},
+			{"september", "sept", "sep"},
+			{"oktober", "okt"},
+			{"november", "nov"},
+			{"dezember", "dez"},
+		},
+		weekdays: [7][]string{
+			{"sonntag"},
+			{"montag"},
+			{"dienstag"},
+			{"mittwoch"},
+			{"donnerstag"},
+			{"freitag"},
+			{"samstag", "sonnabend"},
+		},
+		ordinal: regexp.MustCompile(`(\d)\.(\s|$)`),
+	},
+	"fr": {
+		Name:  "fr",
+		Order: DayMonthYear,
+		months: [12][]string{
+			{"janvier", "janv"},
+			{"fevrier", "fevr", "fev"},
+			{"mars"},
+			{"avril", "avr"},
+			{"mai"},
+			{"juin"},
+			{"juillet", "juil"},
+			{"aout"},
+			{"septembre", "sept"},
+			{"octobre", "oct"},
+			{"novembre", "nov"},
+			{"decembre", "dec"},
+		},
+		weekdays: [7][]string{
+			{"dimanche"},
+			{"lundi"},
+			{"mardi"},
+			{"mercredi"},
+			{"jeudi"},
+			{"vendredi"},
+			{"samedi"},
+		},
+		fillers: []string{"le"},
+		ordinal: regexp.MustCompile(`(\d)er\b`),
+	},
+	"es": {
+		Name:  "es",
+		Order: DayMonthYear,
+		months: [12][]string{
+			{"enero", "ene"},
+			{"febrero", "feb"},
+			{"marzo", "mar"},
+			{"abril", "abr"},
+			{"mayo"},
+			{"junio", "jun"},
+			{"julio", "jul"},
+			{"agosto"}, // not "ago", which is used by offsets like "3 days ago"
+			{"septiembre", "setiembre", "sept", "sep"},
+			{"octubre", "oct"},
+			{"noviembre", "nov"},
+			{"diciembre", "dic"},
+		},
+		weekdays: [7][]string{
+			{"domingo"},
+			{"lunes"},
+			{"martes"},
+			{"miercoles"},
+			{"jueves"},
+			{"viernes"},
+			{"sabado"},
+		},
+		fillers: []string{"de", "del", "el"},
+	},
+	"nl": {
+		Name:  "nl",
+		Order: DayMonthYear,
+		months: [12][]string{
+			{"januari", "jan"},
+			{"februari", "feb"},
+			{"maart", "mrt"},
+			{"april", "apr"},
+			{"mei"},
+			{"juni", "jun"},
+			{"juli", "jul"},
+			{"augustus", "aug"},
+			{"september", "sept", "sep"},
+			{"oktober", "okt"},
+			{"november", "nov"},
+			{"december", "dec"},
+		},
+		weekdays: [7][]string{
+			{"zondag"},
+			{"maandag"},
+			{"dinsdag"},
+			{"woensdag"},
+			{"donderdag"},
+			{"vrijdag"},
+			{"zaterdag"},
+		},
+	},
+}
+
+// LocaleNames returns the names of the supported locales, sorted
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetLocale returns the locale with the given name, like "de" or "en-us"
+func GetLocale(name string) (*Locale, error) {
+	if locale, ok := locales[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return locale, nil
+	}
+	return nil, fmt.Errorf("invalid date locale '%s' - choices: [%s]", name, strings.Join(LocaleNames(), ", "))
+}
+
+var wordRegexp = regexp.MustCompile(`\p{L}+`)
+
+var accentReplacer = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ä", "a",
+	"ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "ö", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+)
+
+// translate replaces the localized month and weekday names in input with their
+// English names, and removes the fillers and ordinal suffixes, so that input
+// can be parsed by the English layouts
+func (l *Locale) translate(input string) string {
+	if l == nil || l.months[0] == nil {
+		return input
+	}
+
+	if l.ordinal != nil {
+		input = l.ordinal.ReplaceAllString(input, "$1$2")
+	}
+
+	input = wordRegexp.ReplaceAllStringFunc(input, func(word string) string {
+		key := accentReplacer.Replace(strings.ToLower(word))
+
+		for _, filler := range l.fillers {
+			if key == filler {
+				return ""
+			}
+		}
+
+		for i, names := range l.months {
+			if containsString(names, key) {
+				return time.Month(i + 1).String()
+			}
+		}
+
+		for i, names := range l.weekdays {
+			if containsString(names, key) {
+				return time.Weekday(i).String()
+			}
+		}
+
+		return word
+	})
+
+	return strings.TrimSpace(whitespaceRegexp.ReplaceAllString(input, " "))
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
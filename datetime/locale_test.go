@@ -0,0 +1,201 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parserWrapper_parseOrder(t *testing.T) {
+	type fields struct {
+		order DateOrder
+	}
+	type args struct {
+		input     string
+		parseType parseType
+	}
+
+	tests := []struct {
+		fields        fields
+		args          args
+		want          *time.Time
+		wantErr       bool
+		wantAmbiguous bool
+	}{
+		{fields: fields{order: DayMonthYear}, args: args{input: "02/01/2021", parseType: dateParseType}, want: p(date(2, 1))},
+		{fields: fields{order: DayMonthYear}, args: args{input: "17.10.2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: DayMonthYear}, args: args{input: "17-10-2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: DayMonthYear}, args: args{input: "10/17", parseType: dateParseType}, want: nil, wantErr: true},
+		{fields: fields{order: MonthDayYear}, args: args{input: "02/01/2021", parseType: dateParseType}, want: p(date(1, 2))},
+		{fields: fields{order: MonthDayYear}, args: args{input: "10/17", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: MonthDayYear}, args: args{input: "10/17/2021 3:00pm", parseType: dateTimeParseType}, want: p(time.Date(2021, 10, 17, 15, 0, 0, 0, time.UTC))},
+		{fields: fields{order: YearMonthDay}, args: args{input: "2021/10/17", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: YearMonthDay}, args: args{input: "10/17", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: YearMonthDay}, args: args{input: "17/10/2021", parseType: dateParseType}, want: nil, wantErr: true},
+		{fields: fields{order: AnyOrder}, args: args{input: "17/10/2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: AnyOrder}, args: args{input: "10/17", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: AnyOrder}, args: args{input: "2021/10/17", parseType: dateParseType}, want: p(date(17, 10))},
+		{fields: fields{order: AnyOrder}, args: args{input: "05/05/2021", parseType: dateParseType}, want: p(date(5, 5))},
+		{fields: fields{order: AnyOrder}, args: args{input: "02/01/2021", parseType: dateParseType}, want: nil, wantErr: true, wantAmbiguous: true},
+		{fields: fields{order: AnyOrder}, args: args{input: "2/1 at 9:00am", parseType: dateTimeParseType}, want: nil, wantErr: true, wantAmbiguous: true},
+		{fields: fields{order: AnyOrder}, args: args{input: "32/13/2021", parseType: dateParseType}, want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fields.order.String()+" "+tt.args.input, func(t *testing.T) {
+			parser := &parserWrapper{
+				now:   func() time.Time { return date(7, 7) },
+				order: tt.fields.order,
+			}
+			got, err := parser.parse(tt.args.input, 0, tt.args.parseType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parserWrapper.parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var ambiguous *AmbiguousDateError
+			if errors.As(err, &ambiguous) != tt.wantAmbiguous {
+				t.Errorf("parserWrapper.parse() error = %v, wantAmbiguous %v", err, tt.wantAmbiguous)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parserWrapper.parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousDateError_Error(t *testing.T) {
+	err := &AmbiguousDateError{
+		Input:  "02/01/2021",
+		Dates:  []time.Time{date(2, 1), date(1, 2)},
+		Orders: []DateOrder{DayMonthYear, MonthDayYear},
+	}
+
+	want := "'02/01/2021' is ambiguous - it could be 2 January 2021 (day-month-year) or 1 February 2021 (month-day-year). Set date-order to dmy, mdy or ymd in the config, or use an ISO 8601 date like 2021-01-02"
+	if got := err.Error(); got != want {
+		t.Errorf("AmbiguousDateError.Error() = %v, want %v", got, want)
+	}
+}
+
+func Test_parserWrapper_parseLocale(t *testing.T) {
+	type args struct {
+		locale    string
+		input     string
+		parseType parseType
+	}
+
+	tests := []struct {
+		args    args
+		want    *time.Time
+		wantErr bool
+	}{
+		{args: args{locale: "en", input: "17 October 2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "en", input: "17 Oktober 2021", parseType: dateParseType}, want: nil, wantErr: true},
+		{args: args{locale: "de", input: "17. Oktober 2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "de", input: "3. März", parseType: dateParseType}, want: p(date(3, 3))},
+		{args: args{locale: "de", input: "17 October 2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "de", input: "next Freitag", parseType: dateParseType}, want: p(date(16, 7))},
+		{args: args{locale: "de", input: "Mittwoch", parseType: dateParseType}, want: p(date(7, 7))},
+		{args: args{locale: "de", input: "17.10.2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "de", input: "17. Okt 2021, 15:00", parseType: dateTimeParseType}, want: p(time.Date(2021, 10, 17, 15, 0, 0, 0, time.UTC))},
+		{args: args{locale: "fr", input: "1er août 2021", parseType: dateParseType}, want: p(date(1, 8))},
+		{args: args{locale: "fr", input: "le 17 octobre", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "fr", input: "Fevrier 2", parseType: dateParseType}, want: p(date(2, 2))},
+		{args: args{locale: "fr", input: "vendredi", parseType: dateParseType}, want: p(date(9, 7))},
+		{args: args{locale: "es", input: "17 de octubre de 2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "es", input: "sábado", parseType: dateParseType}, want: p(date(10, 7))},
+		{args: args{locale: "es", input: "3 days ago", parseType: dateParseType}, want: p(date(4, 7))},
+		{args: args{locale: "nl", input: "17 oktober 2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "nl", input: "3 mrt", parseType: dateParseType}, want: p(date(3, 3))},
+		{args: args{locale: "nl", input: "donderdag", parseType: dateParseType}, want: p(date(8, 7))},
+		{args: args{locale: "en-us", input: "10/17/2021", parseType: dateParseType}, want: p(date(17, 10))},
+		{args: args{locale: "en-gb", input: "10/17/2021", parseType: dateParseType}, want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args.locale+" "+tt.args.input, func(t *testing.T) {
+			locale, err := GetLocale(tt.args.locale)
+			if err != nil {
+				t.Fatalf("GetLocale() error = %v", err)
+			}
+
+			parser := &parserWrapper{
+				now:    func() time.Time { return date(7, 7) },
+				order:  locale.Order,
+				locale: locale,
+			}
+			got, err := parser.parse(tt.args.input, 0, tt.args.parseType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parserWrapper.parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parserWrapper.parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "de", want: "de"},
+		{name: " EN-US ", want: "en-us"},
+		{name: "jp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetLocale(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLocale() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.Name != tt.want {
+				t.Errorf("GetLocale() = %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    DateOrder
+		wantErr bool
+	}{
+		{name: "dmy", want: DayMonthYear},
+		{name: "MDY", want: MonthDayYear},
+		{name: "ymd", want: YearMonthDay},
+		{name: "ydm", want: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateOrder(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDateOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseDateOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
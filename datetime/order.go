@@ -0,0 +1,168 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errNotNumeric is returned by parseNumeric when the input isn't a numeric
+// date, so that the other formats should be tried.
+var errNotNumeric = errors.New("not a numeric date")
+
+// DateOrder is the order of the day, month and year in numeric dates, like
+// 17/10/2021
+type DateOrder int
+
+const (
+	// DayMonthYear reads 02/01/2021 as the 2nd of January. This is the zero
+	// value, as it was the only order before date-order was added.
+	DayMonthYear DateOrder = iota
+
+	// MonthDayYear reads 01/02/2021 as the 2nd of January
+	MonthDayYear
+
+	// YearMonthDay reads 2021/01/02 as the 2nd of January
+	YearMonthDay
+
+	// AnyOrder accepts numeric dates in any order, as long as only one order
+	// gives a valid date. It's only used when date-order is "any".
+	AnyOrder
+)
+
+var dateOrderNames = map[string]DateOrder{
+	"dmy": DayMonthYear,
+	"mdy": MonthDayYear,
+	"ymd": YearMonthDay,
+	"any": AnyOrder,
+}
+
+// ParseDateOrder parses a date order, which is one of dmy, mdy, ymd or any
+func ParseDateOrder(name string) (DateOrder, error) {
+	if order, ok := dateOrderNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return order, nil
+	}
+	return -1, fmt.Errorf("invalid date order '%s' - choices: [dmy, mdy, ymd, any]", name)
+}
+
+func (o DateOrder) String() string {
+	switch o {
+	case DayMonthYear:
+		return "day-month-year"
+	case MonthDayYear:
+		return "month-day-year"
+	case YearMonthDay:
+		return "year-month-day"
+	default:
+		return "any order"
+	}
+}
+
+// numericLayouts are the numeric date layouts for each order. Dates without a
+// year are always day-month or month-day, since a year-first date always has a
+// year.
+var numericLayouts = map[DateOrder][]string{
+	DayMonthYear: {"2/1/2006", "2.1.2006", "2-1-2006", "2/1", "2.1"},
+	MonthDayYear: {"1/2/2006", "1.2.2006", "1-2-2006", "1/2", "1.2"},
+	YearMonthDay: {"2006/1/2", "2006.1.2", "1/2", "1.2"},
+}
+
+// AmbiguousDateError is returned when a numeric date is valid in more than one
+// order, and date-order is "any"
+type AmbiguousDateError struct {
+	// Input is the ambiguous date
+	Input string
+
+	// Dates are the possible dates, in the order of Orders
+	Dates []time.Time
+
+	// Orders are the orders which gave each date
+	Orders []DateOrder
+}
+
+func (e *AmbiguousDateError) Error() string {
+	interpretations := make([]string, len(e.Dates))
+	for i, d := range e.Dates {
+		interpretations[i] = fmt.Sprintf("%s (%s)", d.Format("2 January 2006"), e.Orders[i])
+	}
+
+	return fmt.Sprintf("'%s' is ambiguous - it could be %s. Set date-order to dmy, mdy or ymd in the config, or use an ISO 8601 date like %s",
+		e.Input, strings.Join(interpretations, " or "), e.Dates[0].Format("2006-01-02"))
+}
+
+// parseNumeric parses numeric dates like 17/10/2021 or 17.10, in the order
+// configured for parser. It also returns the layout which matched.
+func (parser *parserWrapper) parseNumeric(input string, parseType parseType) (*time.Time, string, error) {
+	orders := []DateOrder{parser.order}
+	if parser.order == AnyOrder {
+		orders = []DateOrder{DayMonthYear, MonthDayYear, YearMonthDay}
+	}
+
+	ambiguous := AmbiguousDateError{Input: input}
+	rules := []string{}
+	for _, order := range orders {
+		layouts := numericLayouts[order]
+		if parseType == dateTimeParseType {
+			layouts = generateDateTimeLayouts(layouts)
+		}
+
+		date, layout, ok := parseLayouts(layouts, input, parser.location())
+		if !ok || containsTime(ambiguous.Dates, date) {
+			continue
+		}
+
+		ambiguous.Dates = append(ambiguous.Dates, date)
+		ambiguous.Orders = append(ambiguous.Orders, order)
+		rules = append(rules, fmt.Sprintf("numeric layout %q (%s)", layout, order))
+	}
+
+	switch len(ambiguous.Dates) {
+	case 0:
+		return nil, "", errNotNumeric
+	case 1:
+		return parser.fixYear(ambiguous.Dates[0]), rules[0], nil
+	default:
+		for i, d := range ambiguous.Dates {
+			ambiguous.Dates[i] = *parser.fixYear(d)
+		}
+		return nil, strings.Join(rules, " or "), &ambiguous
+	}
+}
+
+// parseLayouts returns the result of the first layout which parses input in
+// loc, and the layout
+func parseLayouts(layouts []string, input string, loc *time.Location) (time.Time, string, bool) {
+	for _, layout := range layouts {
+		if date, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return date, layout, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, v := range times {
+		if v.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -19,6 +19,7 @@ package datetime
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -34,7 +35,42 @@ const (
 	dateTimeParseType
 )
 
-var wrapperInstance = (&parserWrapper{now: time.Now})
+var wrapperInstance = (&parserWrapper{now: time.Now, weekStart: time.Monday, order: DayMonthYear, locale: locales[DefaultLocale]})
+
+// SetWeekStart sets the first day of the week, used by period boundaries like
+// "start of week"
+func SetWeekStart(day time.Weekday) {
+	wrapperInstance.weekStart = day
+}
+
+// SetLocale sets the locale, whose month and weekday names are accepted
+func SetLocale(locale *Locale) {
+	wrapperInstance.locale = locale
+}
+
+// SetDateOrder sets the order of the day, month and year in numeric dates
+func SetDateOrder(order DateOrder) {
+	wrapperInstance.order = order
+}
+
+// SetLocation sets the time zone of dates, and of date times without an offset
+func SetLocation(loc *time.Location) {
+	wrapperInstance.loc = loc
+	wrapperInstance.now = func() time.Time {
+		return time.Now().In(loc)
+	}
+}
+
+// ParseWeekday parses a full weekday name, like "monday"
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == name {
+			return day, nil
+		}
+	}
+	return -1, errors.New("invalid weekday '" + name + "'")
+}
 
 // Exported date parser
 var DateStartEndParser = func(input string) (*DateFilters, error) {
@@ -51,37 +87,28 @@ var DateParser = func(input string) (*time.Time, error) {
 }
 
 func (parser *parserWrapper) filterParser(input string, parseType parseType) (*DateFilters, error) {
-	parts := strings.Split(input, ";")
 	filters := DateFilters{}
 
-	if len(parts) == 0 {
+	if strings.Trim(input, parserCutset+";") == "" {
 		return nil, errors.New("empty filter")
 	}
 
-	if len(parts) > 2 {
-		return nil, errors.New("too many parts")
-	}
-
-	var err error
-	var start, end *time.Time
-	for _, p := range parts {
-		p = strings.Trim(strings.ToLower(p), parserCutset)
-
-		if idx := contains(p, "start"); idx != -1 {
-			start, err = parser.parse(p, idx, parseType)
-		} else if idx := contains(p, "end"); idx != -1 {
-			end, err = parser.parse(p, idx, parseType)
-		} else {
-			return nil, errors.New("missing qualifier")
+	// The clauses narrow the filter, like "after monday; before friday"
+	for _, clause := range strings.Split(input, ";") {
+		clause = strings.Trim(strings.ToLower(clause), parserCutset)
+		if clause == "" {
+			continue
 		}
 
-		if err != nil {
+		if err := parser.parseClause(clause, parseType, &filters); err != nil {
 			return nil, err
 		}
 	}
 
-	filters.Start = start
-	filters.End = end
+	if filters.None && (filters.Start != nil || filters.End != nil) {
+		return nil, errors.New("'none' can't be combined with dates, as it matches the tasks without a date")
+	}
+
 	return &filters, nil
 }
 
@@ -97,32 +124,33 @@ func contains(s string, substring string) int {
 	return -1
 }
 
+// dateLayouts are the dates with month names. The numeric dates depend on the
+// date order, and are in numericLayouts.
 var dateLayouts = []string{
 	"02/Jan/2006",
 	"02-Jan-2006",
 	"02-Jan-06",
 	"2/Jan/2006",
-	"02/01/2006",
-	"2/01/2006",
-	"2/01",
 	"02-Jan 2006",
 	"02-Jan",
+	"2 Jan 2006",
+	"2 Jan",
 	"Jan 02, 2006",
 	"Jan 02",
 	"Jan 2",
+	"Jan 2 2006",
 	"02/January/2006",
 	"02-January-2006",
 	"02-January-06",
 	"2/January/2006",
-	"02/01/2006",
-	"2/01/2006",
-	"2/01",
 	"02-January 2006",
 	"02-January",
+	"2 January 2006",
+	"2 January",
 	"January 02, 2006",
 	"January 02",
 	"January 2",
-	"January 02, 2006",
+	"January 2 2006",
 }
 
 var timeLayouts = []string{
@@ -142,7 +170,7 @@ func insert(slice []string, value string, idx int) ([]string, int) {
 	return slice, idx + 1
 }
 
-func generateDateTimeLayouts() []string {
+func generateDateTimeLayouts(dateLayouts []string) []string {
 	layouts := make([]string, len(dateLayouts)*len(timeLayouts)*8)
 
 	idx := 0
@@ -162,41 +190,84 @@ func generateDateTimeLayouts() []string {
 	return layouts
 }
 
+// parse parses input from startIdx. The formats are tried in this order, and
+// the first one which matches is used:
+//
+//  1. ISO 8601 and RFC 3339, like 2021-10-17, 2021-W42-7, 2021-290 or
+//     2021-10-17T15:00:00+13:00. These are the only formats which start with
+//     a four-digit year, so an invalid ISO 8601 date is an error rather than
+//     falling through to the other formats.
+//  2. Keywords, offsets and period boundaries, like "tomorrow", "in 3 days"
+//     or "end of month".
+//  3. Dates with month names, like 17 Oct or Oct 17, 2021. Localized month
+//     names are translated to English first.
+//  4. Numeric dates, like 17/10/2021, in the configured date order. If the
+//     order isn't configured and the date is valid in more than one order, an
+//     *AmbiguousDateError is returned.
+//  5. Weekday names, like "next friday".
 func (parser *parserWrapper) parse(input string, startIdx int, parseType parseType) (*time.Time, error) {
+	date, rule, err := parser.parseRule(input, startIdx, parseType)
+	if parser.trace != nil {
+		parser.trace(parser.explain(input[startIdx:], parseType, date, rule, err))
+	}
+	return date, err
+}
+
+// parseRule parses input like parse, and returns the name of the format which
+// matched
+func (parser *parserWrapper) parseRule(input string, startIdx int, parseType parseType) (*time.Time, string, error) {
 	layouts := dateLayouts
 
 	if parseType == dateTimeParseType {
-		layouts = generateDateTimeLayouts()
+		layouts = generateDateTimeLayouts(dateLayouts)
 	}
 
-	input = input[startIdx:]
+	input = parser.locale.translate(strings.TrimSpace(input[startIdx:]))
 
-	for _, layout := range layouts {
-		if date, err := time.Parse(layout, input); err == nil {
-			return parser.fixYear(date), nil
-		}
+	// ISO 8601 and RFC 3339
+	if date, err := parseISO(input, parseType, parser.location()); err == nil {
+		return date, "ISO 8601", nil
+	} else if err != errNotISO {
+		return nil, "ISO 8601", err
+	}
+
+	// Keywords, offsets and period boundaries
+	if date, err := parser.parseRelative(input, parseType); err == nil {
+		return date, "keyword, offset or period boundary", nil
+	} else if err != errNotRelative {
+		return nil, "keyword, offset or period boundary", err
+	}
+
+	if date, layout, ok := parseLayouts(layouts, input, parser.location()); ok {
+		return parser.fixYear(date), fmt.Sprintf("layout %q", layout), nil
+	}
+
+	if date, rule, err := parser.parseNumeric(input, parseType); err == nil {
+		return date, rule, nil
+	} else if err != errNotNumeric {
+		return nil, rule, err
 	}
 
 	if date, err := parser.parseDay(input); err == nil {
 		if parseType == dateParseType {
-			return date, nil
+			return date, "weekday name", nil
 		}
 
 		// parsing datetime
 		parsedTime, err := parser.getTime(input)
 		if err != nil {
-			return nil, err
+			return nil, "weekday name", err
 		}
 
 		// combine parsed time and date
 		combined := parser.combineDateTime(date, parsedTime)
-		return combined, nil
+		return combined, "weekday name and time", nil
 	}
 
 	if parseType == dateTimeParseType {
-		return nil, errors.New("invalid date time")
+		return nil, "", errors.New("invalid date time")
 	}
-	return nil, errors.New("invalid date")
+	return nil, "", errors.New("invalid date")
 }
 
 func (parser *parserWrapper) fixYear(date time.Time) *time.Time {
@@ -139,6 +139,9 @@ func Test_parserWrapper_parseDate(t *testing.T) {
 		{args: args{input: "Jan 02", parseType: dateParseType}, fields: testFields, want: wantDate, wantErr: false},
 		{args: args{input: "Jan 2", parseType: dateParseType}, fields: testFields, want: wantDate, wantErr: false},
 		{args: args{input: "January 02, 2021", parseType: dateParseType}, fields: testFields, want: wantDate, wantErr: false},
+		{args: args{input: "Jan 2 2021", parseType: dateParseType}, fields: testFields, want: wantDate, wantErr: false},
+		{args: args{input: "january 2 2021", parseType: dateParseType}, fields: testFields, want: wantDate, wantErr: false},
+		{args: args{input: "Jan 2 2021 8:13PM", parseType: dateTimeParseType}, fields: testFields, want: wantDatetime, wantErr: false},
 		{args: args{input: "last Mon", parseType: dateParseType}, fields: testFields, want: p(date(5, 7)), wantErr: false},
 		{args: args{input: "Monday", parseType: dateParseType}, fields: testFields, want: p(date(5, 7)), wantErr: false},
 		{args: args{input: "garbage", parseType: dateParseType}, fields: testFields, want: nil, wantErr: true},
@@ -19,7 +19,32 @@ package datetime
 
 import "time"
 
-
 type parserWrapper struct {
 	now func() time.Time
-}
\ No newline at end of file
+
+	// weekStart is the first day of the week, used by period boundaries like
+	// "start of week"
+	weekStart time.Weekday
+
+	// order is the order of the day, month and year in numeric dates
+	order DateOrder
+
+	// locale has the localized month and weekday names. If nil, only English
+	// names are accepted.
+	locale *Locale
+
+	// loc is the time zone of dates, and of date times without an offset. If
+	// nil, UTC is used.
+	loc *time.Location
+
+	// trace is called with the explanation of each parsed date, if not nil
+	trace func(*Explanation)
+}
+
+// location returns the time zone of parsed dates
+func (parser *parserWrapper) location() *time.Location {
+	if parser.loc == nil {
+		return time.UTC
+	}
+	return parser.loc
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rollingRangeRegexp matches rolling ranges, like "last 7 days" or "next 2
+// weeks"
+var rollingRangeRegexp = regexp.MustCompile(`^(last|next) (\d+) (days?|weeks?|months?|years?)$`)
+
+// periodRangeRegexp matches whole periods, like "this week" or "next month"
+var periodRangeRegexp = regexp.MustCompile(`^(this|next|last) (week|month|quarter|year)$`)
+
+// parseClause parses one clause of a filter into filters. The clauses are:
+//
+//	start X, end Y     the dates from X, or until Y
+//	after X, before Y  the dates after or before the day X
+//	on X               the dates on the day X
+//	X..Y               the days from X to Y, where X or Y can be left out
+//	none, any          the tasks without or with a date
+//	a named range      like today, this week, last 7 days or overdue
+func (parser *parserWrapper) parseClause(clause string, parseType parseType, filters *DateFilters) error {
+	if clause == "none" || clause == "any" {
+		filters.None = clause == "none"
+		return nil
+	}
+
+	if idx := strings.Index(clause, ".."); idx != -1 {
+		from, to := strings.TrimSpace(clause[:idx]), strings.TrimSpace(clause[idx+2:])
+		if from == "" && to == "" {
+			return errors.New("the range '..' needs a start or an end")
+		}
+
+		if from != "" {
+			start, err := parser.parse(from, 0, parseType)
+			if err != nil {
+				return err
+			}
+			from := startOfDay(*start)
+			filters.narrow(&from, nil)
+		}
+
+		if to != "" {
+			end, err := parser.parse(to, 0, parseType)
+			if err != nil {
+				return err
+			}
+			to := endOfDay(*end)
+			filters.narrow(nil, &to)
+		}
+		return nil
+	}
+
+	if idx := contains(clause, "start"); idx != -1 {
+		start, err := parser.parse(clause, idx, parseType)
+		if err != nil {
+			return err
+		}
+		filters.narrow(start, nil)
+		return nil
+	}
+
+	if idx := contains(clause, "end"); idx != -1 {
+		end, err := parser.parse(clause, idx, parseType)
+		if err != nil {
+			return err
+		}
+		filters.narrow(nil, end)
+		return nil
+	}
+
+	for _, qualifier := range []string{"after", "before", "on"} {
+		if !strings.HasPrefix(clause, qualifier+" ") {
+			continue
+		}
+
+		date, err := parser.parse(clause, len(qualifier), parseType)
+		if err != nil {
+			return err
+		}
+
+		start, end := startOfDay(*date), endOfDay(*date)
+		switch qualifier {
+		case "after":
+			after := end.Add(time.Nanosecond)
+			filters.narrow(&after, nil)
+		case "before":
+			before := start.Add(-time.Nanosecond)
+			filters.narrow(nil, &before)
+		default:
+			filters.narrow(&start, &end)
+		}
+		return nil
+	}
+
+	start, end, err := parser.namedRange(clause)
+	if err != nil {
+		return err
+	}
+	filters.narrow(start, end)
+	return nil
+}
+
+// namedRange returns the start and end of ranges like "today", "this week",
+// "last 7 days" and "overdue". Either can be nil for open ranges.
+func (parser *parserWrapper) namedRange(name string) (*time.Time, *time.Time, error) {
+	now := parser.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if days, ok := dayKeywords[name]; ok {
+		day := today.AddDate(0, 0, days)
+		end := endOfDay(day)
+		return &day, &end, nil
+	}
+
+	// Overdue is anything before today, since tasks due today aren't late yet
+	if name == "overdue" {
+		end := today.Add(-time.Nanosecond)
+		return nil, &end, nil
+	}
+
+	if match := periodRangeRegexp.FindStringSubmatch(name); match != nil {
+		n := 0
+		switch match[1] {
+		case "next":
+			n = 1
+		case "last":
+			n = -1
+		}
+
+		start, end := parser.periodBounds(periodNames[match[2]], n)
+		end = endOfDay(end)
+		return &start, &end, nil
+	}
+
+	// The last and next n days include today
+	if match := rollingRangeRegexp.FindStringSubmatch(name); match != nil {
+		n, err := strconv.Atoi(match[2])
+		if err != nil || n < 1 {
+			return nil, nil, fmt.Errorf("invalid range '%s'", name)
+		}
+
+		if match[1] == "last" {
+			n = -n
+		}
+
+		var other time.Time
+		switch unit := strings.TrimSuffix(match[3], "s"); unit {
+		case "day":
+			other = today.AddDate(0, 0, n)
+		case "week":
+			other = today.AddDate(0, 0, 7*n)
+		case "month":
+			other = addMonths(today, n)
+		default:
+			other = addMonths(today, 12*n)
+		}
+
+		if n < 0 {
+			start, end := other.AddDate(0, 0, 1), endOfDay(today)
+			return &start, &end, nil
+		}
+		end := endOfDay(other.AddDate(0, 0, -1))
+		return &today, &end, nil
+	}
+
+	return nil, nil, fmt.Errorf("missing qualifier in '%s' - use start, end, before, after, on, X..Y, none, any or a range like today, this week, last 7 days or overdue", name)
+}
+
+// narrow restricts filters to the dates from start to end, which can be nil
+func (filters *DateFilters) narrow(start, end *time.Time) {
+	if start != nil && (filters.Start == nil || start.After(*filters.Start)) {
+		filters.Start = start
+	}
+
+	if end != nil && (filters.End == nil || end.Before(*filters.End)) {
+		filters.End = end
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// endOfDay returns the last moment of t's day
+func endOfDay(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
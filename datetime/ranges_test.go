@@ -0,0 +1,110 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parserWrapper_filterClauses(t *testing.T) {
+	// The last moment of the day
+	eod := func(day int, month time.Month) *time.Time {
+		return p(date(day, month).AddDate(0, 0, 1).Add(-time.Nanosecond))
+	}
+
+	tests := []struct {
+		input   string
+		want    *DateFilters
+		wantErr bool
+	}{
+		{input: "today", want: &DateFilters{Start: p(date(7, 7)), End: eod(7, 7)}},
+		{input: "tomorrow", want: &DateFilters{Start: p(date(8, 7)), End: eod(8, 7)}},
+		{input: "this week", want: &DateFilters{Start: p(date(5, 7)), End: eod(11, 7)}},
+		{input: "next month", want: &DateFilters{Start: p(date(1, 8)), End: eod(31, 8)}},
+		{input: "last quarter", want: &DateFilters{Start: p(date(1, 4)), End: eod(30, 6)}},
+		{input: "last 7 days", want: &DateFilters{Start: p(date(1, 7)), End: eod(7, 7)}},
+		{input: "next 2 weeks", want: &DateFilters{Start: p(date(7, 7)), End: eod(20, 7)}},
+		{input: "next 1 month", want: &DateFilters{Start: p(date(7, 7)), End: eod(6, 8)}},
+		{input: "overdue", want: &DateFilters{End: p(date(7, 7).Add(-time.Nanosecond))}},
+		{input: "before friday", want: &DateFilters{End: p(date(9, 7).Add(-time.Nanosecond))}},
+		{input: "after friday", want: &DateFilters{Start: p(date(10, 7))}},
+		{input: "on 2021-07-09", want: &DateFilters{Start: p(date(9, 7)), End: eod(9, 7)}},
+		{input: "2021-07-01..2021-07-09", want: &DateFilters{Start: p(date(1, 7)), End: eod(9, 7)}},
+		{input: "today..", want: &DateFilters{Start: p(date(7, 7))}},
+		{input: "..tomorrow", want: &DateFilters{End: eod(8, 7)}},
+		{input: "this month; after 2021-07-20", want: &DateFilters{Start: p(date(21, 7)), End: eod(31, 7)}},
+		{input: "start 2021-07-05; end 2021-07-09; overdue", want: &DateFilters{Start: p(date(5, 7)), End: p(date(7, 7).Add(-time.Nanosecond))}},
+		{input: "none", want: &DateFilters{None: true}},
+		{input: "any", want: &DateFilters{}},
+		{input: "none; today", wantErr: true},
+		{input: "..", wantErr: true},
+		{input: "last 0 days", wantErr: true},
+		{input: "on garbage", wantErr: true},
+		{input: "someday", wantErr: true},
+		{input: " ; ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := &parserWrapper{
+				now:       func() time.Time { return date(7, 7).Add(10*time.Hour + 30*time.Minute) },
+				weekStart: time.Monday,
+				order:     AnyOrder,
+			}
+			got, err := parser.filterParser(tt.input, dateParseType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parserWrapper.filterParser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parserWrapper.filterParser() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateFilters_Contains(t *testing.T) {
+	g := func(t time.Time) *GraphTime {
+		gt := GraphTime(t)
+		return &gt
+	}
+
+	tests := []struct {
+		name    string
+		filters *DateFilters
+		g       *GraphTime
+		want    bool
+	}{
+		{name: "no filter, no date", filters: nil, g: nil, want: true},
+		{name: "any, no date", filters: &DateFilters{}, g: nil, want: false},
+		{name: "any, date", filters: &DateFilters{}, g: g(date(7, 7)), want: true},
+		{name: "none, no date", filters: &DateFilters{None: true}, g: nil, want: true},
+		{name: "none, date", filters: &DateFilters{None: true}, g: g(date(7, 7)), want: false},
+		{name: "range, no date", filters: &DateFilters{Start: p(date(5, 7))}, g: nil, want: false},
+		{name: "in range", filters: &DateFilters{Start: p(date(5, 7)), End: p(date(9, 7))}, g: g(date(7, 7)), want: true},
+		{name: "after range", filters: &DateFilters{Start: p(date(5, 7)), End: p(date(9, 7))}, g: g(date(10, 7)), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.Contains(tt.g); got != tt.want {
+				t.Errorf("DateFilters.Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
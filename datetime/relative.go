@@ -0,0 +1,304 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// errNotRelative is returned by parseRelative when the input isn't a keyword,
+// offset or period boundary, so that the other formats should be tried.
+var errNotRelative = errors.New("not a relative date")
+
+var whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+// relativeTimeRegexp matches a trailing or leading time, like "at 9am",
+// "15:00" or ", 3:30 pm"
+var relativeTimeRegexp = regexp.MustCompile(`^(?:(?:at|@)\s+)?(\d{1,2}(?::\d{2})?\s*(?:am|pm)|\d{1,2}:\d{2})\s*,?\s*(?:on\s+)?|\s*,?\s*(?:(?:at|@)\s+)?(\d{1,2}(?::\d{2})?\s*(?:am|pm)|\d{1,2}:\d{2})$`)
+
+// offsetRegexp matches a single signed duration like "+3d", "2 weeks" or "-1mo"
+var offsetRegexp = regexp.MustCompile(`^([+-]?)\s*(\d+)\s*(minutes|minute|mins|min|hours|hour|hrs|hr|h|days|day|d|weeks|week|wks|wk|w|months|month|mos|mo|years|year|yrs|yr|y)\s*`)
+
+// boundaryRegexp matches "start of next month", "end of week", "this quarter"
+// and so on
+var boundaryRegexp = regexp.MustCompile(`^(?:(start|beginning|end) of\s+)?(?:(this|next|last|the)\s+)?(day|week|month|quarter|year)$`)
+
+// period is a unit of time used by period boundaries
+type period int
+
+const (
+	dayPeriod period = iota
+	weekPeriod
+	monthPeriod
+	quarterPeriod
+	yearPeriod
+)
+
+var periodNames = map[string]period{
+	"day":     dayPeriod,
+	"week":    weekPeriod,
+	"month":   monthPeriod,
+	"quarter": quarterPeriod,
+	"year":    yearPeriod,
+}
+
+// endOfPeriodKeywords are the short forms of "end of <period>"
+var endOfPeriodKeywords = map[string]period{
+	"eod": dayPeriod,
+	"eow": weekPeriod,
+	"eom": monthPeriod,
+	"eoq": quarterPeriod,
+	"eoy": yearPeriod,
+}
+
+// dayKeywords are the day offsets of the keywords relative to today
+var dayKeywords = map[string]int{
+	"today":     0,
+	"tod":       0,
+	"tomorrow":  1,
+	"tmr":       1,
+	"tmrw":      1,
+	"yesterday": -1,
+}
+
+// relativeResult is a parsed relative date, before it's converted to a date or
+// date time
+type relativeResult struct {
+	t time.Time
+
+	// exact is true if t includes a meaningful time of day, like for "now" or
+	// "in 2 hours"
+	exact bool
+
+	// endOfDay is true if the result is the end of a period, so that a date time
+	// should be the last moment of the day
+	endOfDay bool
+}
+
+// parseRelative parses the keywords ("today", "eod"), signed offsets ("in 3
+// days", "+2w", "1 month ago") and period boundaries ("end of quarter", "next
+// month") relative to parser.now. Date times may include a time, like
+// "tomorrow 9am".
+func (parser *parserWrapper) parseRelative(input string, parseType parseType) (*time.Time, error) {
+	input = whitespaceRegexp.ReplaceAllString(strings.ToLower(strings.TrimSpace(input)), " ")
+
+	// Split out the time of day
+	var clock *time.Time
+	if parseType == dateTimeParseType {
+		if match := relativeTimeRegexp.FindStringSubmatch(input); match != nil {
+			timeStr := match[1] + match[2]
+			parsed, err := parseClock(timeStr)
+			if err != nil {
+				return nil, err
+			}
+			clock = parsed
+			input = strings.TrimSpace(strings.Replace(input, match[0], "", 1))
+		}
+	}
+
+	res, err := parser.parseRelativeDate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := parser.now()
+	t := res.t
+
+	if parseType == dateParseType {
+		// Dates are calendar dates, like the dates parsed from layouts
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, parser.location())
+		return &date, nil
+	}
+
+	switch {
+	case clock != nil:
+		t = time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	case res.exact:
+	case res.endOfDay:
+		t = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, now.Location())
+	default:
+		return nil, errors.New("invalid time")
+	}
+
+	return &t, nil
+}
+
+// parseClock parses times like "9am", "9:30 pm" and "15:00"
+func parseClock(s string) (*time.Time, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	for _, layout := range []string{"15:04", "3:04pm", "3pm"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid time '%s'", s)
+}
+
+func (parser *parserWrapper) parseRelativeDate(input string) (*relativeResult, error) {
+	now := parser.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if input == "now" {
+		return &relativeResult{t: now, exact: true}, nil
+	}
+
+	if days, ok := dayKeywords[input]; ok {
+		return &relativeResult{t: today.AddDate(0, 0, days)}, nil
+	}
+
+	if p, ok := endOfPeriodKeywords[input]; ok {
+		_, end := parser.periodBounds(p, 0)
+		return &relativeResult{t: end, endOfDay: true}, nil
+	}
+
+	if res, err := parser.parseOffset(input); err != errNotRelative {
+		return res, err
+	}
+
+	if match := boundaryRegexp.FindStringSubmatch(input); match != nil {
+		edge, relative, name := match[1], match[2], match[3]
+
+		// "day", "week" etc. on their own aren't dates
+		if edge == "" && (relative == "" || relative == "the") {
+			return nil, errNotRelative
+		}
+
+		n := 0
+		switch relative {
+		case "next":
+			n = 1
+		case "last":
+			n = -1
+		}
+
+		start, end := parser.periodBounds(periodNames[name], n)
+		if edge == "end" {
+			return &relativeResult{t: end, endOfDay: true}, nil
+		}
+		return &relativeResult{t: start}, nil
+	}
+
+	return nil, errNotRelative
+}
+
+// parseOffset parses one or more signed durations, like "in 3 days", "2 weeks
+// from now", "1 month ago", "+1w 2d" or "-3h"
+func (parser *parserWrapper) parseOffset(input string) (*relativeResult, error) {
+	negate := false
+	switch {
+	case strings.HasPrefix(input, "in "):
+		input = strings.TrimPrefix(input, "in ")
+	case strings.HasSuffix(input, " from now"):
+		input = strings.TrimSuffix(input, " from now")
+	case strings.HasSuffix(input, " from today"):
+		input = strings.TrimSuffix(input, " from today")
+	case strings.HasSuffix(input, " later"):
+		input = strings.TrimSuffix(input, " later")
+	case strings.HasSuffix(input, " ago"):
+		input = strings.TrimSuffix(input, " ago")
+		negate = true
+	}
+
+	t := parser.now()
+	for first := true; input != ""; first = false {
+		match := offsetRegexp.FindStringSubmatch(input)
+
+		// The unit must not be followed by a letter, e.g. "2 dec" isn't "2 d"
+		if match == nil || (len(input) > len(match[0]) && unicode.IsLetter(rune(input[len(match[0])])) && !strings.HasSuffix(match[0], " ")) {
+			if first {
+				return nil, errNotRelative
+			}
+			return nil, fmt.Errorf("invalid offset '%s'", input)
+		}
+		input = input[len(match[0]):]
+
+		n, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, err
+		}
+		if (match[1] == "-") != negate {
+			n = -n
+		}
+
+		switch unit := match[3]; {
+		case strings.HasPrefix(unit, "min"):
+			t = t.Add(time.Duration(n) * time.Minute)
+		case strings.HasPrefix(unit, "h"):
+			t = t.Add(time.Duration(n) * time.Hour)
+		case strings.HasPrefix(unit, "d"):
+			t = t.AddDate(0, 0, n)
+		case strings.HasPrefix(unit, "w"):
+			t = t.AddDate(0, 0, 7*n)
+		case strings.HasPrefix(unit, "mo"):
+			t = addMonths(t, n)
+		case strings.HasPrefix(unit, "y"):
+			t = addMonths(t, 12*n)
+		}
+	}
+
+	// Day based offsets keep the current time, like "in 3 days" at this time
+	return &relativeResult{t: t, exact: true}, nil
+}
+
+// addMonths adds n months to t, clamping the day to the end of the month (so
+// that 31 January plus a month is 28 February)
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	first = first.AddDate(0, n, 0)
+
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// periodBounds returns the first and last days of the period containing today,
+// shifted by n periods
+func (parser *parserWrapper) periodBounds(p period, n int) (time.Time, time.Time) {
+	now := parser.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var start time.Time
+	switch p {
+	case dayPeriod:
+		start = today.AddDate(0, 0, n)
+		return start, start
+	case weekPeriod:
+		offset := mod(int(today.Weekday()-parser.weekStart), 7)
+		start = today.AddDate(0, 0, 7*n-offset)
+		return start, start.AddDate(0, 0, 6)
+	case monthPeriod:
+		start = time.Date(today.Year(), today.Month()+time.Month(n), 1, 0, 0, 0, 0, today.Location())
+		return start, start.AddDate(0, 1, -1)
+	case quarterPeriod:
+		first := (int(today.Month())-1)/3*3 + 1
+		start = time.Date(today.Year(), time.Month(first+3*n), 1, 0, 0, 0, 0, today.Location())
+		return start, start.AddDate(0, 3, -1)
+	default:
+		start = time.Date(today.Year()+n, time.January, 1, 0, 0, 0, 0, today.Location())
+		return start, start.AddDate(1, 0, -1)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parserWrapper_parseRelative(t *testing.T) {
+	type fields struct {
+		now       func() time.Time
+		weekStart time.Weekday
+	}
+	type args struct {
+		input     string
+		parseType parseType
+	}
+
+	testFields := fields{now: func() time.Time {
+		// Today is Wednesday, 10:30
+		return time.Date(2021, 7, 7, 10, 30, 0, 0, time.UTC)
+	}, weekStart: time.Monday}
+
+	sundayFields := testFields
+	sundayFields.weekStart = time.Sunday
+
+	dt := func(day int, month time.Month, hour, min, sec int) *time.Time {
+		return p(time.Date(2021, month, day, hour, min, sec, 0, time.UTC))
+	}
+
+	tests := []struct {
+		fields  fields
+		args    args
+		want    *time.Time
+		wantErr bool
+	}{
+		// keywords
+		{fields: testFields, args: args{input: "today", parseType: dateParseType}, want: p(date(7, 7))},
+		{fields: testFields, args: args{input: "Tomorrow", parseType: dateParseType}, want: p(date(8, 7))},
+		{fields: testFields, args: args{input: "tmrw", parseType: dateParseType}, want: p(date(8, 7))},
+		{fields: testFields, args: args{input: "yesterday", parseType: dateParseType}, want: p(date(6, 7))},
+		{fields: testFields, args: args{input: "now", parseType: dateParseType}, want: p(date(7, 7))},
+		{fields: testFields, args: args{input: "eod", parseType: dateParseType}, want: p(date(7, 7))},
+
+		// offsets
+		{fields: testFields, args: args{input: "in 3 days", parseType: dateParseType}, want: p(date(10, 7))},
+		{fields: testFields, args: args{input: "2 weeks from now", parseType: dateParseType}, want: p(date(21, 7))},
+		{fields: testFields, args: args{input: "3 days ago", parseType: dateParseType}, want: p(date(4, 7))},
+		{fields: testFields, args: args{input: "+1mo", parseType: dateParseType}, want: p(date(7, 8))},
+		{fields: testFields, args: args{input: "-2w", parseType: dateParseType}, want: p(date(23, 6))},
+		{fields: testFields, args: args{input: "+1y", parseType: dateParseType}, want: p(time.Date(2022, 7, 7, 0, 0, 0, 0, time.UTC))},
+		{fields: testFields, args: args{input: "in 1w 2d", parseType: dateParseType}, want: p(date(16, 7))},
+		{fields: testFields, args: args{input: "+20h", parseType: dateParseType}, want: p(date(8, 7))},
+
+		// period boundaries
+		{fields: testFields, args: args{input: "start of week", parseType: dateParseType}, want: p(date(5, 7))},
+		{fields: sundayFields, args: args{input: "start of week", parseType: dateParseType}, want: p(date(4, 7))},
+		{fields: testFields, args: args{input: "end of week", parseType: dateParseType}, want: p(date(11, 7))},
+		{fields: sundayFields, args: args{input: "eow", parseType: dateParseType}, want: p(date(10, 7))},
+		{fields: testFields, args: args{input: "next week", parseType: dateParseType}, want: p(date(12, 7))},
+		{fields: testFields, args: args{input: "end of month", parseType: dateParseType}, want: p(date(31, 7))},
+		{fields: testFields, args: args{input: "eom", parseType: dateParseType}, want: p(date(31, 7))},
+		{fields: testFields, args: args{input: "last month", parseType: dateParseType}, want: p(date(1, 6))},
+		{fields: testFields, args: args{input: "end of last month", parseType: dateParseType}, want: p(date(30, 6))},
+		{fields: testFields, args: args{input: "next quarter", parseType: dateParseType}, want: p(date(1, 10))},
+		{fields: testFields, args: args{input: "end of the quarter", parseType: dateParseType}, want: p(date(30, 9))},
+		{fields: testFields, args: args{input: "beginning of year", parseType: dateParseType}, want: p(date(1, 1))},
+		{fields: testFields, args: args{input: "eoy", parseType: dateParseType}, want: p(date(31, 12))},
+
+		// date times
+		{fields: testFields, args: args{input: "now", parseType: dateTimeParseType}, want: dt(7, 7, 10, 30, 0)},
+		{fields: testFields, args: args{input: "in 2h", parseType: dateTimeParseType}, want: dt(7, 7, 12, 30, 0)},
+		{fields: testFields, args: args{input: "30 min", parseType: dateTimeParseType}, want: dt(7, 7, 11, 0, 0)},
+		{fields: testFields, args: args{input: "in 3 days", parseType: dateTimeParseType}, want: dt(10, 7, 10, 30, 0)},
+		{fields: testFields, args: args{input: "tomorrow 9am", parseType: dateTimeParseType}, want: dt(8, 7, 9, 0, 0)},
+		{fields: testFields, args: args{input: "today at 15:00", parseType: dateTimeParseType}, want: dt(7, 7, 15, 0, 0)},
+		{fields: testFields, args: args{input: "9:30 pm tomorrow", parseType: dateTimeParseType}, want: dt(8, 7, 21, 30, 0)},
+		{fields: testFields, args: args{input: "next quarter, 9:30am", parseType: dateTimeParseType}, want: dt(1, 10, 9, 30, 0)},
+		{fields: testFields, args: args{input: "eod", parseType: dateTimeParseType}, want: dt(7, 7, 23, 59, 59)},
+		{fields: testFields, args: args{input: "end of week", parseType: dateTimeParseType}, want: dt(11, 7, 23, 59, 59)},
+		{fields: testFields, args: args{input: "start of month", parseType: dateTimeParseType}, want: nil, wantErr: true},
+		{fields: testFields, args: args{input: "tomorrow", parseType: dateTimeParseType}, want: nil, wantErr: true},
+
+		// failures
+		{fields: testFields, args: args{input: "in 3 dogs", parseType: dateParseType}, want: nil, wantErr: true},
+		{fields: testFields, args: args{input: "+3m", parseType: dateParseType}, want: nil, wantErr: true},
+		{fields: testFields, args: args{input: "week", parseType: dateParseType}, want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args.input, func(t *testing.T) {
+			parser := &parserWrapper{
+				now:       tt.fields.now,
+				weekStart: tt.fields.weekStart,
+			}
+			got, err := parser.parse(tt.args.input, 0, tt.args.parseType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parserWrapper.parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parserWrapper.parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parserWrapper_relativeFilter(t *testing.T) {
+	parser := &parserWrapper{
+		now:       func() time.Time { return date(7, 7) },
+		weekStart: time.Monday,
+	}
+
+	got, err := parser.filterParser("start start of week; end end of week", dateParseType)
+	if err != nil {
+		t.Fatalf("parserWrapper.filterParser() error = %v", err)
+	}
+
+	want := &DateFilters{Start: p(date(5, 7)), End: p(date(11, 7))}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parserWrapper.filterParser() = %v, want %v", got, want)
+	}
+}
+
+func Test_addMonths(t *testing.T) {
+	type args struct {
+		t time.Time
+		n int
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Time
+	}{
+		{name: "31 Jan + 1", args: args{t: time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), n: 1}, want: time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{name: "31 Mar - 1", args: args{t: time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC), n: -1}, want: time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{name: "29 Feb + 12", args: args{t: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), n: 12}, want: time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{name: "15 Nov + 3", args: args{t: time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC), n: 3}, want: time.Date(2022, 2, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addMonths(tt.args.t, tt.args.n); !got.Equal(tt.want) {
+				t.Errorf("addMonths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
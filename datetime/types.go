@@ -43,11 +43,16 @@ func (ct *dateTimeTimeZoneTime) UnmarshalJSON(b []byte) (err error) {
 
 type dateTimeTimeZoneLocation time.Location
 
+// UnmarshalJSON loads the time zone, which Microsoft Graph names with the
+// Windows name, like "Pacific Standard Time"
 func (ct *dateTimeTimeZoneLocation) UnmarshalJSON(b []byte) (err error) {
 	s := strings.Trim(string(b), `"`)
-	loc, err := time.LoadLocation(s)
+	loc, err := LoadLocation(s)
+	if err != nil {
+		return err
+	}
 	*ct = dateTimeTimeZoneLocation(*loc)
-	return err
+	return nil
 }
 
 // DateTimeTimeZone based on https://docs.microsoft.com/en-us/graph/api/resources/datetimetimezone?view=graph-rest-1.0
@@ -80,29 +85,40 @@ type GraphTimeMarshal struct {
 	TimeZone string `json:"timeZone"`
 }
 
-// Marshal is called by TodoTask.MarshalJSON
+// Marshal is called by TodoTask.MarshalJSON. The time is sent in its own time
+// zone, so that Outlook shows reminders in the user's time zone. Times in a
+// zone without a Windows name are sent in UTC.
 func (t *GraphTime) Marshal() *GraphTimeMarshal {
-	utc := t.toUTC()
+	ct := time.Time(*t)
+
+	// Get timeZone
+	timeZone, ok := WindowsZone(ct.Location())
+	if !ok {
+		ct = ct.UTC()
+		timeZone = "UTC"
+	}
 
 	// Get dateTime
-	year, month, day := utc.Date()
-	hour, minute, second := utc.Clock()
-	nanosecond := utc.Nanosecond()
+	year, month, day := ct.Date()
+	hour, minute, second := ct.Clock()
+	// Graph uses 7 fractional digits, which are 100 nanosecond ticks
+	ticks := ct.Nanosecond() / 100
 
 	dateStr := fmt.Sprintf("%04d-%02d-%02d", year, month, day)
-	timeStr := fmt.Sprintf("%02d:%02d:%02d.%07d", hour, minute, second, nanosecond)
+	timeStr := fmt.Sprintf("%02d:%02d:%02d.%07d", hour, minute, second, ticks)
 	dateTime := fmt.Sprintf("%vT%v", dateStr, timeStr)
 
-	// Get timeZone
-	timeZone := utc.Location().String()
-
 	// Marshal
 	result := GraphTimeMarshal{DateTime: dateTime, TimeZone: timeZone}
 	return &result
 }
 
-func (t *GraphTime) toUTC() *time.Time {
-	ct := time.Time(*t)
-	utc := ct.UTC()
-	return &utc
+// In returns t in loc. If t is nil, nil is returned.
+func (t *GraphTime) In(loc *time.Location) *GraphTime {
+	if t == nil {
+		return nil
+	}
+
+	g := GraphTime(time.Time(*t).In(loc))
+	return &g
 }
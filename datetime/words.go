@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dateWords are the words, other than numbers and month and weekday names,
+// which can be part of a date
+var dateWords = map[string]bool{
+	"at": true, "on": true, "in": true, "of": true, "the": true, "from": true,
+	"now": true, "ago": true, "later": true, "am": true, "pm": true,
+	"this": true, "next": true, "last": true,
+	"start": true, "beginning": true, "end": true,
+	"today": true, "tod": true, "tomorrow": true, "tmr": true, "tmrw": true, "yesterday": true,
+	"eod": true, "eow": true, "eom": true, "eoq": true, "eoy": true,
+	"minute": true, "minutes": true, "min": true, "mins": true,
+	"hour": true, "hours": true, "hr": true, "hrs": true,
+	"day": true, "days": true, "week": true, "weeks": true, "wk": true, "wks": true,
+	"month": true, "months": true, "quarter": true, "year": true, "years": true, "yr": true, "yrs": true,
+}
+
+// numericWordRegexp matches words which start with a number, like "9am",
+// "15:00", "17/10", "2021-10-17" or "+3d"
+var numericWordRegexp = regexp.MustCompile(`^[+-]?\d`)
+
+// IsDateWord reports whether word can be part of a date, like "tomorrow",
+// "9am", "next" or a month or weekday name in the configured locale. It's used
+// to find the dates in free text, since the weekday names are matched loosely
+// by the parser.
+func IsDateWord(word string) bool {
+	word = strings.ToLower(strings.Trim(word, ",."))
+	if word == "" {
+		return false
+	}
+
+	if dateWords[word] || numericWordRegexp.MatchString(word) {
+		return true
+	}
+
+	// Month and weekday names, and abbreviations like "sept" or "thurs"
+	word = strings.ToLower(wrapperInstance.locale.translate(word))
+	if len(word) < 3 {
+		return false
+	}
+
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), word) {
+			return true
+		}
+	}
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.HasPrefix(strings.ToLower(d.String()), word) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package datetime
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_windowsZones(t *testing.T) {
+	for windows, iana := range windowsZones {
+		if _, err := time.LoadLocation(iana); err != nil {
+			t.Errorf("%s: time.LoadLocation(%s) error = %v", windows, iana, err)
+		}
+	}
+
+	for iana := range ianaAliases {
+		if _, err := time.LoadLocation(iana); err != nil {
+			t.Errorf("time.LoadLocation(%s) error = %v", iana, err)
+		}
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Pacific Standard Time", want: "America/Los_Angeles"},
+		{name: "New Zealand Standard Time", want: "Pacific/Auckland"},
+		{name: "UTC", want: "UTC"},
+		{name: "Europe/Amsterdam", want: "Europe/Amsterdam"},
+		{name: "Nowhere Standard Time", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadLocation(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadLocation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("LoadLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowsZone(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "America/Los_Angeles", want: "Pacific Standard Time", wantOk: true},
+		{name: "Europe/Amsterdam", want: "W. Europe Standard Time", wantOk: true},
+		{name: "Asia/Calcutta", want: "India Standard Time", wantOk: true},
+		{name: "UTC", want: "UTC", wantOk: true},
+		{name: "America/Marigot", want: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := WindowsZone(loc)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("WindowsZone() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGraphTime_Marshal(t *testing.T) {
+	auckland, _ := time.LoadLocation("Pacific/Auckland")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want GraphTimeMarshal
+	}{
+		{name: "utc", t: time.Date(2021, 7, 7, 9, 30, 0, 0, time.UTC), want: GraphTimeMarshal{DateTime: "2021-07-07T09:30:00.0000000", TimeZone: "UTC"}},
+		{name: "windows zone", t: time.Date(2021, 7, 7, 9, 30, 0, 250000000, auckland), want: GraphTimeMarshal{DateTime: "2021-07-07T09:30:00.2500000", TimeZone: "New Zealand Standard Time"}},
+		{name: "fixed offset", t: time.Date(2021, 7, 7, 9, 30, 0, 0, time.FixedZone("", 2*60*60)), want: GraphTimeMarshal{DateTime: "2021-07-07T07:30:00.0000000", TimeZone: "UTC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := GraphTime(tt.t)
+			if got := g.Marshal(); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("GraphTime.Marshal() = %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphTime_UnmarshalJSON(t *testing.T) {
+	var g GraphTime
+	b := []byte(`{"dateTime":"2021-07-07T09:30:00.0000000","timeZone":"Pacific Standard Time"}`)
+	if err := json.Unmarshal(b, &g); err != nil {
+		t.Fatalf("GraphTime.UnmarshalJSON() error = %v", err)
+	}
+
+	got := time.Time(g)
+	if got.Location().String() != "America/Los_Angeles" || got.Hour() != 9 || !got.Equal(time.Date(2021, 7, 7, 16, 30, 0, 0, time.UTC)) {
+		t.Errorf("GraphTime.UnmarshalJSON() = %v", got)
+	}
+
+	b = []byte(`{"dateTime":"2021-07-07T09:30:00.0000000","timeZone":"Nowhere Standard Time"}`)
+	if err := json.Unmarshal(b, &g); err == nil {
+		t.Errorf("GraphTime.UnmarshalJSON() with an unknown zone should fail")
+	}
+}
+
+func Test_parserWrapper_parseLocation(t *testing.T) {
+	auckland, _ := time.LoadLocation("Pacific/Auckland")
+
+	parser := &parserWrapper{
+		now:       func() time.Time { return time.Date(2021, 7, 7, 10, 30, 0, 0, auckland) },
+		weekStart: time.Monday,
+		loc:       auckland,
+	}
+
+	tests := []struct {
+		input     string
+		parseType parseType
+		want      time.Time
+	}{
+		{input: "2 Jan 2021", parseType: dateParseType, want: time.Date(2021, 1, 2, 0, 0, 0, 0, auckland)},
+		{input: "02/01/2021 at 15:00", parseType: dateTimeParseType, want: time.Date(2021, 1, 2, 15, 0, 0, 0, auckland)},
+		{input: "2021-10-17", parseType: dateParseType, want: time.Date(2021, 10, 17, 0, 0, 0, 0, auckland)},
+		{input: "2021-10-17T15:00", parseType: dateTimeParseType, want: time.Date(2021, 10, 17, 15, 0, 0, 0, auckland)},
+		{input: "2021-10-17T15:00Z", parseType: dateTimeParseType, want: time.Date(2021, 10, 17, 15, 0, 0, 0, time.UTC)},
+		{input: "tomorrow", parseType: dateParseType, want: time.Date(2021, 7, 8, 0, 0, 0, 0, auckland)},
+		{input: "tomorrow 9am", parseType: dateTimeParseType, want: time.Date(2021, 7, 8, 9, 0, 0, 0, auckland)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parser.parse(tt.input, 0, tt.parseType)
+			if err != nil {
+				t.Fatalf("parserWrapper.parse() error = %v", err)
+			}
+			if !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+				t.Errorf("parserWrapper.parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,10 +8,13 @@ require (
 	github.com/go-resty/resty/v2 v2.6.0
 	github.com/iancoleman/strcase v0.1.3
 	github.com/jedib0t/go-pretty/v6 v6.2.2
+	github.com/mattn/go-isatty v0.0.12
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/nmrshll/rndm-go v0.0.0-20170430161430-8da3024e53de
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 	github.com/spf13/cobra v1.1.3
+	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.8.0
 	golang.org/x/oauth2 v0.0.0-20210615190721-d04028783cf1
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007
 )
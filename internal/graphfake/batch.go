@@ -0,0 +1,143 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package graphfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// MaxBatchSize is the maximum number of requests in a JSON batch
+const MaxBatchSize = 20
+
+type batchRequest struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+type batchPayload struct {
+	Requests []batchRequest `json:"requests"`
+}
+
+type batchResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// batchResponseHeaders are the response headers copied into batch responses
+var batchResponseHeaders = []string{"Content-Type", "ETag", "Location", "Retry-After"}
+
+// serveBatch runs the requests of a JSON batch in order, as per
+// https://docs.microsoft.com/en-us/graph/json-batching
+func (s *Server) serveBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "The batch endpoint only supports POST.")
+		return
+	}
+
+	batch := batchPayload{}
+	if msg := decodeBatch(r, &batch); msg != "" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.writeError(w, r, http.StatusBadRequest, CodeBadRequest, msg)
+		return
+	}
+
+	statuses := map[string]int{}
+	responses := []batchResponse{}
+	for _, req := range batch.Requests {
+		res := s.serveBatchRequest(r, req, statuses)
+		statuses[req.ID] = res.Status
+		responses = append(responses, res)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"responses": responses})
+}
+
+func decodeBatch(r *http.Request, batch *batchPayload) string {
+	if err := json.NewDecoder(r.Body).Decode(batch); err != nil {
+		return "Invalid batch payload: " + err.Error()
+	}
+
+	if len(batch.Requests) == 0 {
+		return "The batch must contain at least one request."
+	}
+	if len(batch.Requests) > MaxBatchSize {
+		return fmt.Sprintf("The number of requests in the batch exceeds the limit of %d.", MaxBatchSize)
+	}
+
+	ids := map[string]bool{}
+	for _, req := range batch.Requests {
+		if req.ID == "" || req.Method == "" || req.URL == "" {
+			return "Each request must have an id, method and url."
+		}
+		if ids[req.ID] {
+			return fmt.Sprintf("The request id '%s' is duplicated.", req.ID)
+		}
+		ids[req.ID] = true
+	}
+
+	return ""
+}
+
+func (s *Server) serveBatchRequest(outer *http.Request, req batchRequest, statuses map[string]int) batchResponse {
+	for _, dep := range req.DependsOn {
+		if status, ok := statuses[dep]; !ok || status >= 400 {
+			return batchResponse{ID: req.ID, Status: http.StatusFailedDependency}
+		}
+	}
+
+	target := fmt.Sprintf("http://%s%s/%s", outer.Host, basePath, strings.TrimPrefix(req.URL, "/"))
+	inner, err := http.NewRequest(strings.ToUpper(req.Method), target, bytes.NewReader(req.Body))
+	if err != nil {
+		return batchResponse{ID: req.ID, Status: http.StatusBadRequest}
+	}
+
+	inner.Host = outer.Host
+	inner.Header.Set("Authorization", outer.Header.Get("Authorization"))
+	for k, v := range req.Headers {
+		inner.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	s.route(rec, inner)
+
+	res := batchResponse{ID: req.ID, Status: rec.Code, Headers: map[string]string{}}
+	for _, h := range batchResponseHeaders {
+		if v := rec.Header().Get(h); v != "" {
+			res.Headers[h] = v
+		}
+	}
+	if body := bytes.TrimSpace(rec.Body.Bytes()); len(body) != 0 {
+		res.Body = body
+	}
+
+	return res
+}
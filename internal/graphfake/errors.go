@@ -0,0 +1,83 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package graphfake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error codes used in the error envelopes, as per
+// https://docs.microsoft.com/en-us/graph/errors
+const (
+	CodeBadRequest         = "BadRequest"
+	CodeInvalidRequest     = "invalidRequest"
+	CodeInvalidAuthToken   = "InvalidAuthenticationToken"
+	CodeItemNotFound       = "ErrorItemNotFound"
+	CodeMethodNotAllowed   = "methodNotAllowed"
+	CodePreconditionFailed = "PreconditionFailed"
+	CodeTooManyRequests    = "TooManyRequests"
+	CodeSyncStateNotFound  = "SyncStateNotFound"
+)
+
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code       string     `json:"code"`
+	Message    string     `json:"message"`
+	InnerError innerError `json:"innerError"`
+}
+
+type innerError struct {
+	Date            string `json:"date"`
+	RequestID       string `json:"request-id"`
+	ClientRequestID string `json:"client-request-id"`
+}
+
+// writeError writes a Graph error envelope
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	envelope := errorEnvelope{
+		Error: errorBody{
+			Code:    code,
+			Message: message,
+			InnerError: innerError{
+				Date:            s.now().UTC().Format(time.RFC3339),
+				RequestID:       s.nextRequestID(),
+				ClientRequestID: r.Header.Get("client-request-id"),
+			},
+		},
+	}
+
+	writeJSON(w, status, envelope)
+}
+
+// writeThrottled writes a 429 response with a Retry-After header
+func (s *Server) writeThrottled(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	s.writeError(w, r, http.StatusTooManyRequests, CodeTooManyRequests, "Too many requests have been sent. Retry after the delay in the Retry-After header.")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package graphfake
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// DefaultListID is the ID of the default "Tasks" list
+func (s *Server) DefaultListID() string {
+	return s.defaultListID
+}
+
+// AddList adds a list, returning its ID
+func (s *Server) AddList(displayName string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.create(&s.st.lists, listKind, Object{"displayName": displayName}).id
+}
+
+// AddTask adds a task to a list, returning its ID. fields uses the Graph JSON
+// property names, e.g. "title" and "dueDateTime".
+func (s *Server) AddTask(listId string, fields Object) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coll := s.st.taskCollection(listId)
+	if coll == nil {
+		return "", fmt.Errorf("list '%s' not found", listId)
+	}
+
+	if msg := validate(taskKind, fields, true); msg != "" {
+		return "", fmt.Errorf("%s", msg)
+	}
+
+	return s.create(coll, taskKind, fields).id, nil
+}
+
+// AddChecklistItem adds a checklist item to a task, returning its ID
+func (s *Server) AddChecklistItem(listId, taskId string, fields Object) (string, error) {
+	return s.addChild(checklistItemKind, listId, taskId, fields)
+}
+
+// AddLinkedResource adds a linked resource to a task, returning its ID
+func (s *Server) AddLinkedResource(listId, taskId string, fields Object) (string, error) {
+	return s.addChild(linkedResourceKind, listId, taskId, fields)
+}
+
+// AddAttachment adds a file attachment to a task, returning its ID
+func (s *Server) AddAttachment(listId, taskId, name, contentType string, content []byte) (string, error) {
+	return s.addChild(attachmentKind, listId, taskId, Object{
+		"name":         name,
+		"contentType":  contentType,
+		"contentBytes": base64.StdEncoding.EncodeToString(content),
+	})
+}
+
+func (s *Server) addChild(kind entityKind, listId, taskId string, fields Object) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coll := s.st.childCollection(s.st.children(kind), listId, taskId)
+	if coll == nil {
+		return "", fmt.Errorf("task '%s' not found in list '%s'", taskId, listId)
+	}
+
+	if msg := validate(kind, fields, true); msg != "" {
+		return "", fmt.Errorf("%s", msg)
+	}
+
+	id := s.create(coll, kind, fields).id
+	s.childrenChanged(&target{coll: coll, kind: kind, parent: s.st.taskCollection(listId).get(taskId)})
+	return id, nil
+}
+
+// Lists returns a snapshot of the lists, as they would be returned by Graph
+func (s *Server) Lists() []Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return snapshot(&s.st.lists)
+}
+
+// Tasks returns a snapshot of the tasks in a list, as they would be returned by
+// Graph
+func (s *Server) Tasks(listId string) []Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return snapshot(s.st.taskCollection(listId))
+}
+
+func snapshot(c *collection) []Object {
+	res := []Object{}
+	if c == nil {
+		return res
+	}
+
+	for _, e := range c.live() {
+		res = append(res, e.json())
+	}
+	return res
+}
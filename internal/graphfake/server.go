@@ -0,0 +1,581 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package graphfake is an in-memory fake of the Microsoft Graph To Do API, for
+// running the api and cmd packages offline in tests.
+//
+// It implements lists, tasks, checklist items, linked resources, attachments,
+// delta queries and JSON batching, with paging, ETags, throttling and Graph
+// error envelopes.
+package graphfake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const basePath = "/v1.0"
+
+// DefaultPageSize is the page size used when $top isn't specified
+const DefaultPageSize = 10
+
+const graphTimeLayout = "2006-01-02T15:04:05.0000000"
+
+// Server is a fake Microsoft Graph server. Its exported fields should be set
+// before any requests are made.
+type Server struct {
+	// PageSize is the page size used when $top isn't specified
+	PageSize int
+
+	// Now is the server clock, used for timestamps
+	Now func() time.Time
+
+	// AccessToken, if not empty, is the only bearer token accepted
+	AccessToken string
+
+	// MailboxTimeZone is the time zone in the mailbox settings, like "Pacific
+	// Standard Time". If empty, DefaultMailboxTimeZone is used.
+	MailboxTimeZone string
+
+	mu            sync.Mutex
+	st            *store
+	defaultListID string
+	throttled     int
+	retryAfter    time.Duration
+	requests      int
+	requestID     int
+	ts            *httptest.Server
+}
+
+// New creates a server which isn't listening. It can be used directly as an
+// http.Handler.
+func New() *Server {
+	s := &Server{
+		PageSize: DefaultPageSize,
+		Now:      time.Now,
+		st:       newStore(),
+	}
+
+	// Every mailbox has the default "Tasks" list
+	list := s.st.add(&s.st.lists, listKind, newListFields("Tasks"))
+	list.fields["wellknownListName"] = "defaultList"
+	s.defaultListID = list.id
+
+	return s
+}
+
+// NewServer creates and starts a server listening on a loopback address.
+// The caller should call Close when finished.
+func NewServer() *Server {
+	s := New()
+	s.ts = httptest.NewServer(s)
+	return s
+}
+
+// URL is the Graph base URL of a started server, including the version
+func (s *Server) URL() string {
+	return s.ts.URL + basePath
+}
+
+// Close shuts down a started server
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// Throttle makes the next n requests fail with 429 Too Many Requests and the
+// given Retry-After delay. Requests inside a batch are throttled individually.
+func (s *Server) Throttle(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttled = n
+	s.retryAfter = retryAfter
+}
+
+// RequestCount is the number of HTTP requests received, where a batch counts as
+// one request
+func (s *Server) RequestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) now() time.Time {
+	return s.Now()
+}
+
+func (s *Server) nextRequestID() string {
+	s.requestID++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", s.requestID)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	if !s.authorized(r) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.writeError(w, r, http.StatusUnauthorized, CodeInvalidAuthToken, "Access token is empty or invalid.")
+		return
+	}
+
+	if r.URL.Path == basePath+"/$batch" {
+		s.serveBatch(w, r)
+		return
+	}
+
+	s.route(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return false
+	}
+	return s.AccessToken == "" || token == s.AccessToken
+}
+
+// target is the resource addressed by a request path
+type target struct {
+	coll   *collection
+	kind   entityKind
+	entity *entity
+	delta  bool
+
+	// parent is the task of checklist items, linked resources and attachments
+	parent *entity
+}
+
+// resolve finds the target for the path segments after /me/todo/lists
+func (s *Server) resolve(segs []string) *target {
+	t := &target{coll: &s.st.lists, kind: listKind}
+
+	levels := []struct {
+		names []string
+		kinds []entityKind
+	}{
+		{},
+		{names: []string{"tasks"}, kinds: []entityKind{taskKind}},
+		{names: []string{"checklistItems", "linkedResources", "attachments"}, kinds: []entityKind{checklistItemKind, linkedResourceKind, attachmentKind}},
+	}
+
+	var listId string
+	for level := 0; ; level++ {
+		if len(segs) == 0 {
+			return t
+		}
+
+		// delta is supported for lists and tasks
+		if segs[0] == "delta" && len(segs) == 1 && (t.kind == listKind || t.kind == taskKind) {
+			t.delta = true
+			return t
+		}
+
+		e := t.coll.get(segs[0])
+		if e == nil {
+			return nil
+		}
+		if len(segs) == 1 {
+			t.entity = e
+			return t
+		}
+
+		if level+1 >= len(levels) {
+			return nil
+		}
+
+		next := levels[level+1]
+		idx := indexOf(next.names, segs[1])
+		if idx == -1 {
+			return nil
+		}
+
+		kind := next.kinds[idx]
+		if kind == taskKind {
+			listId = e.id
+			t = &target{coll: s.st.taskCollection(e.id), kind: kind}
+		} else {
+			t = &target{coll: s.st.childCollection(s.st.children(kind), listId, e.id), kind: kind, parent: e}
+		}
+		segs = segs[2:]
+	}
+}
+
+func indexOf(items []string, s string) int {
+	for i, v := range items {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.throttled > 0 {
+		s.throttled--
+		s.writeThrottled(w, r, s.retryAfter)
+		return
+	}
+
+	if path := strings.TrimPrefix(r.URL.Path, basePath+"/me/mailboxSettings"); path != r.URL.Path {
+		s.serveMailboxSettings(w, r, path)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, basePath+"/me/todo/lists")
+	if path == r.URL.Path {
+		s.writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("Resource not found for the segment '%s'.", r.URL.Path))
+		return
+	}
+
+	segs := []string{}
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+
+	t := s.resolve(segs)
+	if t == nil {
+		s.writeError(w, r, http.StatusNotFound, CodeItemNotFound, "The specified object was not found in the store.")
+		return
+	}
+
+	switch {
+	case t.delta && r.Method == http.MethodGet:
+		s.serveDelta(w, r, t)
+	case t.entity == nil && !t.delta && r.Method == http.MethodGet:
+		s.writePage(w, r, t.kind, t.coll.live(), nil)
+	case t.entity == nil && !t.delta && r.Method == http.MethodPost:
+		s.serveCreate(w, r, t)
+	case t.entity != nil && r.Method == http.MethodGet:
+		s.writeEntity(w, r, http.StatusOK, t.entity)
+	case t.entity != nil && r.Method == http.MethodPatch && t.kind != attachmentKind:
+		s.serveUpdate(w, r, t)
+	case t.entity != nil && r.Method == http.MethodDelete:
+		s.serveDelete(w, r, t)
+	default:
+		s.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, fmt.Sprintf("The method %s is not allowed for the resource.", r.Method))
+	}
+}
+
+func (s *Server) writeEntity(w http.ResponseWriter, r *http.Request, status int, e *entity) {
+	w.Header().Set("ETag", e.etag())
+	writeJSON(w, status, inTimeZone(r, e.json()))
+}
+
+func (s *Server) serveCreate(w http.ResponseWriter, r *http.Request, t *target) {
+	fields, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	if msg := validate(t.kind, fields, true); msg != "" {
+		s.writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, msg)
+		return
+	}
+
+	e := s.create(t.coll, t.kind, fields)
+	s.childrenChanged(t)
+	w.Header().Set("Location", requestURL(r)+"/"+e.id)
+	s.writeEntity(w, r, http.StatusCreated, e)
+}
+
+// create adds a new entity to coll, applying the defaults for kind
+func (s *Server) create(coll *collection, kind entityKind, fields Object) *entity {
+	now := s.now().UTC().Format(time.RFC3339Nano)
+
+	defaults := Object{}
+	switch kind {
+	case listKind:
+		defaults = newListFields("")
+	case taskKind:
+		defaults = Object{
+			"importance":           "normal",
+			"isReminderOn":         false,
+			"status":               "notStarted",
+			"hasAttachments":       false,
+			"categories":           []interface{}{},
+			"body":                 Object{"content": "", "contentType": "text"},
+			"createdDateTime":      now,
+			"lastModifiedDateTime": now,
+		}
+	case checklistItemKind:
+		defaults = Object{"isChecked": false, "createdDateTime": now}
+	case linkedResourceKind:
+		defaults = Object{"applicationName": "", "displayName": "", "externalId": "", "webUrl": ""}
+	case attachmentKind:
+		content, _ := base64.StdEncoding.DecodeString(fields["contentBytes"].(string))
+		defaults = Object{"contentType": "application/octet-stream", "size": len(content), "lastModifiedDateTime": now}
+	}
+
+	for k, v := range fields {
+		if k != "id" && !strings.HasPrefix(k, "@odata") {
+			defaults[k] = v
+		}
+	}
+
+	e := s.st.add(coll, kind, defaults)
+	s.applyRules(e)
+	return e
+}
+
+func (s *Server) serveUpdate(w http.ResponseWriter, r *http.Request, t *target) {
+	if !s.checkIfMatch(w, r, t.entity) {
+		return
+	}
+
+	fields, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	if msg := validate(t.kind, fields, false); msg != "" {
+		s.writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, msg)
+		return
+	}
+
+	s.st.update(t.entity, fields)
+	if t.kind == taskKind {
+		t.entity.fields["lastModifiedDateTime"] = s.now().UTC().Format(time.RFC3339Nano)
+	}
+	s.applyRules(t.entity)
+	s.writeEntity(w, r, http.StatusOK, t.entity)
+}
+
+func (s *Server) serveDelete(w http.ResponseWriter, r *http.Request, t *target) {
+	if !s.checkIfMatch(w, r, t.entity) {
+		return
+	}
+
+	s.st.remove(t.entity)
+	s.childrenChanged(t)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// childrenChanged keeps the hasAttachments of the task in sync, after an
+// attachment is created or deleted
+func (s *Server) childrenChanged(t *target) {
+	if t.kind == attachmentKind && t.parent != nil {
+		t.parent.fields["hasAttachments"] = len(t.coll.live()) > 0
+	}
+}
+
+func (s *Server) checkIfMatch(w http.ResponseWriter, r *http.Request, e *entity) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" || ifMatch == e.etag() {
+		return true
+	}
+
+	s.writeError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "The ETag value in the If-Match header does not match the current version of the item.")
+	return false
+}
+
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) (Object, bool) {
+	fields := Object{}
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, CodeBadRequest, "Unable to read JSON request payload: "+err.Error())
+		return nil, false
+	}
+	return fields, true
+}
+
+// applyRules keeps derived fields in sync, like Graph does
+func (s *Server) applyRules(e *entity) {
+	now := s.now().UTC()
+
+	switch e.kind {
+	case taskKind:
+		if e.fields["status"] == "completed" {
+			if _, ok := e.fields["completedDateTime"]; !ok {
+				e.fields["completedDateTime"] = Object{"dateTime": now.Format(graphTimeLayout), "timeZone": "UTC"}
+			}
+		} else {
+			delete(e.fields, "completedDateTime")
+		}
+	case checklistItemKind:
+		if e.fields["isChecked"] == true {
+			if _, ok := e.fields["checkedDateTime"]; !ok {
+				e.fields["checkedDateTime"] = now.Format(time.RFC3339Nano)
+			}
+		} else {
+			delete(e.fields, "checkedDateTime")
+		}
+	}
+}
+
+var (
+	validStatuses    = []string{"notStarted", "inProgress", "completed", "waitingOnOthers", "deferred"}
+	validImportances = []string{"low", "normal", "high"}
+)
+
+// validate returns a message describing why fields aren't valid for kind, or
+// an empty string
+func validate(kind entityKind, fields Object, creating bool) string {
+	required := map[entityKind]string{
+		listKind:          "displayName",
+		taskKind:          "title",
+		checklistItemKind: "displayName",
+		attachmentKind:    "name",
+	}
+
+	if name, ok := required[kind]; ok {
+		v, present := fields[name]
+		if s, isString := v.(string); (creating || present) && (!isString || s == "") {
+			return fmt.Sprintf("Property '%s' is required and must be a non-empty string.", name)
+		}
+	}
+
+	if kind == attachmentKind {
+		v, _ := fields["contentBytes"].(string)
+		content, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || v == "" {
+			return "Property 'contentBytes' is required and must be base64."
+		}
+		if len(content) > MaxAttachmentSize {
+			return "The attachment is too big. Attachments bigger than 3 MB need an upload session."
+		}
+	}
+
+	if kind == taskKind {
+		enums := map[string][]string{"status": validStatuses, "importance": validImportances}
+		for name, choices := range enums {
+			v, present := fields[name]
+			if !present {
+				continue
+			}
+			if s, ok := v.(string); !ok || indexOf(choices, s) == -1 {
+				return fmt.Sprintf("Requested value '%v' was not found for property '%s'.", v, name)
+			}
+		}
+	}
+
+	return ""
+}
+
+func newListFields(displayName string) Object {
+	return Object{
+		"displayName":       displayName,
+		"isOwner":           true,
+		"isShared":          false,
+		"wellknownListName": "none",
+	}
+}
+
+// requestURL is the absolute URL of r, without the query
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.Path)
+}
+
+// writePage writes a page of items, with an @odata.nextLink if there are more.
+// If deltaToken isn't nil, the last page has an @odata.deltaLink instead.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, kind entityKind, items []*entity, deltaToken *int) {
+	query := r.URL.Query()
+
+	top := s.PageSize
+	if v := query.Get("$top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.writeError(w, r, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid $top value '%s'.", v))
+			return
+		}
+		top = n
+	}
+
+	skip := 0
+	if v := query.Get("$skiptoken"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.writeError(w, r, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid $skiptoken value '%s'.", v))
+			return
+		}
+		skip = n
+	}
+
+	if skip > len(items) {
+		skip = len(items)
+	}
+	end := skip + top
+	if end > len(items) {
+		end = len(items)
+	}
+
+	values := []Object{}
+	for _, e := range items[skip:end] {
+		value := e.json()
+
+		// The content of attachments is only returned when getting one
+		if kind == attachmentKind {
+			delete(value, "contentBytes")
+		}
+		values = append(values, inTimeZone(r, value))
+	}
+
+	page := Object{
+		"@odata.context": fmt.Sprintf("%s://%s%s/$metadata#%ss", "http", r.Host, basePath, kind),
+		"value":          values,
+	}
+
+	if end < len(items) {
+		next := url.Values{}
+		for k, v := range query {
+			next[k] = v
+		}
+		next.Set("$skiptoken", strconv.Itoa(end))
+		page["@odata.nextLink"] = requestURL(r) + "?" + next.Encode()
+	} else if deltaToken != nil {
+		page["@odata.deltaLink"] = requestURL(r) + "?" + url.Values{"$deltatoken": {strconv.Itoa(*deltaToken)}}.Encode()
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// serveDelta returns every live entity on the first round, and the changes
+// since the $deltatoken on later rounds
+func (s *Server) serveDelta(w http.ResponseWriter, r *http.Request, t *target) {
+	items := t.coll.live()
+
+	if v := r.URL.Query().Get("$deltatoken"); v != "" {
+		seq, err := strconv.Atoi(v)
+		if err != nil || seq < 0 || seq > s.st.seq {
+			s.writeError(w, r, http.StatusGone, CodeSyncStateNotFound, "The sync state generation is not valid.")
+			return
+		}
+		items = t.coll.changedSince(seq)
+	}
+
+	seq := s.st.seq
+	s.writePage(w, r, t.kind, items, &seq)
+}
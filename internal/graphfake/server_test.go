@@ -0,0 +1,398 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package graphfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// do sends a request to s and decodes the JSON response into out
+func do(t *testing.T, s *Server, method, url string, body interface{}, headers map[string]string, out interface{}) *http.Response {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	if len(url) == 0 || url[0] == '/' {
+		url = s.URL() + url
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp
+}
+
+type page struct {
+	Value     []Object `json:"value"`
+	NextLink  string   `json:"@odata.nextLink"`
+	DeltaLink string   `json:"@odata.deltaLink"`
+}
+
+type envelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func TestServer_paging(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.PageSize = 2
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		s.AddList(name)
+	}
+
+	names := []string{}
+	url := "/me/todo/lists"
+	pages := 0
+	for url != "" {
+		p := page{}
+		do(t, s, http.MethodGet, url, nil, nil, &p)
+		for _, v := range p.Value {
+			names = append(names, v["displayName"].(string))
+		}
+		url = p.NextLink
+		pages++
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %v, want %v", pages, 3)
+	}
+	if len(names) != 5 || names[0] != "Tasks" || names[4] != "d" {
+		t.Errorf("names = %v, want [Tasks a b c d]", names)
+	}
+
+	p := page{}
+	do(t, s, http.MethodGet, "/me/todo/lists?$top=10", nil, nil, &p)
+	if len(p.Value) != 5 || p.NextLink != "" {
+		t.Errorf("$top=10 returned %v lists and nextLink %q", len(p.Value), p.NextLink)
+	}
+}
+
+func TestServer_tasks(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Now = func() time.Time { return time.Date(2021, 7, 7, 10, 0, 0, 0, time.UTC) }
+
+	listURL := "/me/todo/lists/" + s.DefaultListID() + "/tasks"
+
+	created := Object{}
+	resp := do(t, s, http.MethodPost, listURL, Object{"title": "Pay rent", "importance": "high"}, nil, &created)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %v, want %v", resp.StatusCode, http.StatusCreated)
+	}
+	if created["status"] != "notStarted" || created["importance"] != "high" || created["createdDateTime"] != "2021-07-07T10:00:00Z" {
+		t.Errorf("created = %v", created)
+	}
+
+	taskURL := listURL + "/" + created["id"].(string)
+	etag := resp.Header.Get("ETag")
+
+	// Stale ETag
+	e := envelope{}
+	resp = do(t, s, http.MethodPatch, taskURL, Object{"status": "completed"}, map[string]string{"If-Match": `W/"T0"`}, &e)
+	if resp.StatusCode != http.StatusPreconditionFailed || e.Error.Code != CodePreconditionFailed {
+		t.Errorf("stale etag status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+
+	// Current ETag
+	updated := Object{}
+	resp = do(t, s, http.MethodPatch, taskURL, Object{"status": "completed"}, map[string]string{"If-Match": etag}, &updated)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+	if updated["completedDateTime"] == nil || updated["@odata.etag"] == created["@odata.etag"] {
+		t.Errorf("updated = %v", updated)
+	}
+
+	// Invalid enum
+	e = envelope{}
+	resp = do(t, s, http.MethodPatch, taskURL, Object{"importance": "urgent"}, nil, &e)
+	if resp.StatusCode != http.StatusBadRequest || e.Error.Code != CodeInvalidRequest {
+		t.Errorf("invalid importance status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+
+	// Checklist items
+	item := Object{}
+	resp = do(t, s, http.MethodPost, taskURL+"/checklistItems", Object{"displayName": "Transfer"}, nil, &item)
+	if resp.StatusCode != http.StatusCreated || item["isChecked"] != false {
+		t.Errorf("checklist item status = %v, item = %v", resp.StatusCode, item)
+	}
+
+	// Delete
+	resp = do(t, s, http.MethodDelete, taskURL, nil, nil, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete status = %v, want %v", resp.StatusCode, http.StatusNoContent)
+	}
+
+	e = envelope{}
+	resp = do(t, s, http.MethodGet, taskURL+"/checklistItems", nil, nil, &e)
+	if resp.StatusCode != http.StatusNotFound || e.Error.Code != CodeItemNotFound {
+		t.Errorf("deleted task status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+}
+
+func TestServer_attachments(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	taskId, err := s.AddTask(s.DefaultListID(), Object{"title": "Pay rent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskURL := "/me/todo/lists/" + s.DefaultListID() + "/tasks/" + taskId
+
+	created := Object{}
+	body := Object{"@odata.type": "#microsoft.graph.taskFileAttachment", "name": "lease.txt", "contentType": "text/plain", "contentBytes": "bGVhc2U="}
+	resp := do(t, s, http.MethodPost, taskURL+"/attachments", body, nil, &created)
+	if resp.StatusCode != http.StatusCreated || created["size"] != float64(5) {
+		t.Fatalf("create status = %v, created = %v", resp.StatusCode, created)
+	}
+
+	task := Object{}
+	do(t, s, http.MethodGet, taskURL, nil, nil, &task)
+	if task["hasAttachments"] != true {
+		t.Errorf("hasAttachments = %v, want true", task["hasAttachments"])
+	}
+
+	// The content is only returned when getting one attachment
+	p := page{}
+	do(t, s, http.MethodGet, taskURL+"/attachments", nil, nil, &p)
+	if len(p.Value) != 1 || p.Value[0]["contentBytes"] != nil || p.Value[0]["name"] != "lease.txt" {
+		t.Errorf("attachments = %v", p.Value)
+	}
+
+	got := Object{}
+	attachmentURL := taskURL + "/attachments/" + created["id"].(string)
+	do(t, s, http.MethodGet, attachmentURL, nil, nil, &got)
+	if got["contentBytes"] != "bGVhc2U=" {
+		t.Errorf("attachment = %v", got)
+	}
+
+	e := envelope{}
+	resp = do(t, s, http.MethodPatch, attachmentURL, Object{"name": "rent.txt"}, nil, &e)
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("update status = %v, want %v", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	e = envelope{}
+	resp = do(t, s, http.MethodPost, taskURL+"/attachments", Object{"name": "empty.txt"}, nil, &e)
+	if resp.StatusCode != http.StatusBadRequest || e.Error.Code != CodeInvalidRequest {
+		t.Errorf("missing content status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+
+	resp = do(t, s, http.MethodDelete, attachmentURL, nil, nil, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete status = %v, want %v", resp.StatusCode, http.StatusNoContent)
+	}
+	task = Object{}
+	do(t, s, http.MethodGet, taskURL, nil, nil, &task)
+	if task["hasAttachments"] != false {
+		t.Errorf("hasAttachments after delete = %v, want false", task["hasAttachments"])
+	}
+}
+
+func TestServer_delta(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	keep, _ := s.AddTask(listId, Object{"title": "keep"})
+	gone, _ := s.AddTask(listId, Object{"title": "gone"})
+
+	deltaURL := "/me/todo/lists/" + listId + "/tasks/delta"
+
+	p := page{}
+	do(t, s, http.MethodGet, deltaURL, nil, nil, &p)
+	if len(p.Value) != 2 || p.DeltaLink == "" {
+		t.Fatalf("initial delta = %v", p)
+	}
+
+	do(t, s, http.MethodPatch, "/me/todo/lists/"+listId+"/tasks/"+keep, Object{"title": "kept"}, nil, nil)
+	do(t, s, http.MethodDelete, "/me/todo/lists/"+listId+"/tasks/"+gone, nil, nil, nil)
+
+	next := page{}
+	do(t, s, http.MethodGet, p.DeltaLink, nil, nil, &next)
+	if len(next.Value) != 2 {
+		t.Fatalf("delta = %v, want 2 changes", next.Value)
+	}
+	if next.Value[0]["title"] != "kept" || next.Value[1]["@removed"] == nil {
+		t.Errorf("delta = %v", next.Value)
+	}
+
+	e := envelope{}
+	resp := do(t, s, http.MethodGet, deltaURL+"?$deltatoken=garbage", nil, nil, &e)
+	if resp.StatusCode != http.StatusGone || e.Error.Code != CodeSyncStateNotFound {
+		t.Errorf("invalid delta token status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+}
+
+func TestServer_batch(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	tasksURL := "/me/todo/lists/" + listId + "/tasks"
+
+	batch := Object{"requests": []Object{
+		{"id": "1", "method": "POST", "url": tasksURL, "body": Object{"title": "one"}, "headers": Object{"Content-Type": "application/json"}},
+		{"id": "2", "method": "POST", "url": tasksURL, "body": Object{"title": ""}, "headers": Object{"Content-Type": "application/json"}},
+		{"id": "3", "method": "GET", "url": tasksURL, "dependsOn": []string{"2"}},
+		{"id": "4", "method": "GET", "url": tasksURL, "dependsOn": []string{"1"}},
+	}}
+
+	var out struct {
+		Responses []struct {
+			ID     string `json:"id"`
+			Status int    `json:"status"`
+			Body   page   `json:"body"`
+		} `json:"responses"`
+	}
+	resp := do(t, s, http.MethodPost, "/$batch", batch, nil, &out)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch status = %v", resp.StatusCode)
+	}
+
+	want := []int{http.StatusCreated, http.StatusBadRequest, http.StatusFailedDependency, http.StatusOK}
+	for i, r := range out.Responses {
+		if r.Status != want[i] {
+			t.Errorf("response %v status = %v, want %v", r.ID, r.Status, want[i])
+		}
+	}
+	if len(out.Responses[3].Body.Value) != 1 {
+		t.Errorf("response 4 = %v, want 1 task", out.Responses[3].Body.Value)
+	}
+
+	// Too many requests
+	requests := []Object{}
+	for i := 0; i <= MaxBatchSize; i++ {
+		requests = append(requests, Object{"id": string(rune('a' + i)), "method": "GET", "url": tasksURL})
+	}
+	e := envelope{}
+	resp = do(t, s, http.MethodPost, "/$batch", Object{"requests": requests}, nil, &e)
+	if resp.StatusCode != http.StatusBadRequest || e.Error.Code != CodeBadRequest {
+		t.Errorf("oversized batch status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+}
+
+func TestServer_throttle(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.Throttle(1, 3*time.Second)
+
+	e := envelope{}
+	resp := do(t, s, http.MethodGet, "/me/todo/lists", nil, nil, &e)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "3" || e.Error.Code != CodeTooManyRequests {
+		t.Errorf("throttled status = %v, Retry-After = %v, code = %v", resp.StatusCode, resp.Header.Get("Retry-After"), e.Error.Code)
+	}
+
+	resp = do(t, s, http.MethodGet, "/me/todo/lists", nil, nil, &page{})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status after throttling = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	if got := s.RequestCount(); got != 2 {
+		t.Errorf("RequestCount() = %v, want %v", got, 2)
+	}
+}
+
+func TestServer_unauthorized(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AccessToken = "expected"
+
+	e := envelope{}
+	resp := do(t, s, http.MethodGet, "/me/todo/lists", nil, nil, &e)
+	if resp.StatusCode != http.StatusUnauthorized || e.Error.Code != CodeInvalidAuthToken {
+		t.Errorf("status = %v, code = %v", resp.StatusCode, e.Error.Code)
+	}
+}
+
+func TestServer_timeZones(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.MailboxTimeZone = "Pacific Standard Time"
+
+	var setting Object
+	do(t, s, http.MethodGet, "/me/mailboxSettings/timeZone", nil, nil, &setting)
+	if setting["value"] != "Pacific Standard Time" {
+		t.Errorf("mailbox time zone = %v", setting["value"])
+	}
+
+	id, err := s.AddTask(s.DefaultListID(), Object{
+		"title":            "call",
+		"reminderDateTime": Object{"dateTime": "2021-07-07T09:00:00.0000000", "timeZone": "New Zealand Standard Time"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		prefer string
+		want   Object
+	}{
+		{name: "utc", prefer: "", want: Object{"dateTime": "2021-07-06T21:00:00.0000000", "timeZone": "UTC"}},
+		{name: "windows zone", prefer: `outlook.timezone="Pacific Standard Time"`, want: Object{"dateTime": "2021-07-06T14:00:00.0000000", "timeZone": "Pacific Standard Time"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var task Object
+			do(t, s, http.MethodGet, "/me/todo/lists/"+s.DefaultListID()+"/tasks/"+id, nil, map[string]string{"Prefer": tt.prefer}, &task)
+
+			got, _ := task["reminderDateTime"].(map[string]interface{})
+			if got["dateTime"] != tt.want["dateTime"] || got["timeZone"] != tt.want["timeZone"] {
+				t.Errorf("reminderDateTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package graphfake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Object is a JSON object, as sent to and returned from the server
+type Object map[string]interface{}
+
+type entityKind string
+
+const (
+	listKind           entityKind = "todoTaskList"
+	taskKind           entityKind = "todoTask"
+	checklistItemKind  entityKind = "checklistItem"
+	linkedResourceKind entityKind = "linkedResource"
+	attachmentKind     entityKind = "taskFileAttachment"
+)
+
+// MaxAttachmentSize is the size of the biggest attachment which can be created
+// without an upload session
+const MaxAttachmentSize = 3 * 1024 * 1024
+
+// entity is a stored list, task, checklist item, linked resource or
+// attachment. Deleted entities are kept as tombstones, so that they can be
+// reported by delta queries.
+type entity struct {
+	id      string
+	kind    entityKind
+	version int
+	seq     int
+	removed bool
+	fields  Object
+}
+
+func (e *entity) etag() string {
+	return fmt.Sprintf(`W/"%s%d"`, strings.ToUpper(string(e.kind[:1])), e.version)
+}
+
+// json returns the representation of e returned by the server
+func (e *entity) json() Object {
+	if e.removed {
+		return Object{"id": e.id, "@removed": Object{"reason": "deleted"}}
+	}
+
+	obj := Object{}
+	for k, v := range e.fields {
+		obj[k] = v
+	}
+	obj["id"] = e.id
+	obj["@odata.etag"] = e.etag()
+	if e.kind == attachmentKind {
+		obj["@odata.type"] = "#microsoft.graph.taskFileAttachment"
+	}
+	return obj
+}
+
+// collection is an ordered set of entities
+type collection struct {
+	entities []*entity
+}
+
+func (c *collection) live() []*entity {
+	res := []*entity{}
+	for _, e := range c.entities {
+		if !e.removed {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// changedSince returns the entities, including tombstones, changed after seq
+func (c *collection) changedSince(seq int) []*entity {
+	res := []*entity{}
+	for _, e := range c.entities {
+		if e.seq > seq {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func (c *collection) get(id string) *entity {
+	for _, e := range c.entities {
+		if e.id == id && !e.removed {
+			return e
+		}
+	}
+	return nil
+}
+
+// store holds all of the entities. The caller must hold Server.mu.
+type store struct {
+	nextID int
+	seq    int
+
+	lists           collection
+	tasks           map[string]*collection
+	checklistItems  map[string]*collection
+	linkedResources map[string]*collection
+	attachments     map[string]*collection
+}
+
+func newStore() *store {
+	return &store{
+		tasks:           map[string]*collection{},
+		checklistItems:  map[string]*collection{},
+		linkedResources: map[string]*collection{},
+		attachments:     map[string]*collection{},
+	}
+}
+
+func (st *store) newID(kind entityKind) string {
+	st.nextID++
+	return fmt.Sprintf("AAMk%s%s", strings.ToUpper(string(kind[:1])), strconv.Itoa(100000+st.nextID))
+}
+
+func (st *store) nextSeq() int {
+	st.seq++
+	return st.seq
+}
+
+func (st *store) add(c *collection, kind entityKind, fields Object) *entity {
+	e := &entity{
+		id:      st.newID(kind),
+		kind:    kind,
+		version: 1,
+		seq:     st.nextSeq(),
+		fields:  fields,
+	}
+	c.entities = append(c.entities, e)
+	return e
+}
+
+func (st *store) update(e *entity, fields Object) {
+	for k, v := range fields {
+		if k == "id" || strings.HasPrefix(k, "@odata") {
+			continue
+		}
+		e.fields[k] = v
+	}
+	e.version++
+	e.seq = st.nextSeq()
+}
+
+func (st *store) remove(e *entity) {
+	e.removed = true
+	e.version++
+	e.seq = st.nextSeq()
+
+	switch e.kind {
+	case listKind:
+		if tasks, ok := st.tasks[e.id]; ok {
+			for _, t := range tasks.live() {
+				st.remove(t)
+			}
+		}
+	case taskKind:
+		for _, children := range []map[string]*collection{st.checklistItems, st.linkedResources, st.attachments} {
+			if c, ok := children[e.id]; ok {
+				for _, child := range c.live() {
+					st.remove(child)
+				}
+			}
+		}
+	}
+}
+
+func (st *store) taskCollection(listId string) *collection {
+	if st.lists.get(listId) == nil {
+		return nil
+	}
+
+	c, ok := st.tasks[listId]
+	if !ok {
+		c = &collection{}
+		st.tasks[listId] = c
+	}
+	return c
+}
+
+// children returns the children of tasks which are of kind
+func (st *store) children(kind entityKind) map[string]*collection {
+	switch kind {
+	case linkedResourceKind:
+		return st.linkedResources
+	case attachmentKind:
+		return st.attachments
+	}
+	return st.checklistItems
+}
+
+func (st *store) childCollection(children map[string]*collection, listId, taskId string) *collection {
+	tasks := st.taskCollection(listId)
+	if tasks == nil || tasks.get(taskId) == nil {
+		return nil
+	}
+
+	c, ok := children[taskId]
+	if !ok {
+		c = &collection{}
+		children[taskId] = c
+	}
+	return c
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package graphfake
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/dalyisaac/mstodo/datetime"
+)
+
+// DefaultMailboxTimeZone is the mailbox time zone used when MailboxTimeZone
+// isn't set
+const DefaultMailboxTimeZone = "UTC"
+
+// dateTimeTimeZoneFields are the task fields of type dateTimeTimeZone
+var dateTimeTimeZoneFields = []string{"reminderDateTime", "dueDateTime", "completedDateTime", "startDateTime"}
+
+var preferTimeZoneRegexp = regexp.MustCompile(`outlook\.timezone="([^"]*)"`)
+
+// serveMailboxSettings serves the mailbox time zone
+func (s *Server) serveMailboxSettings(w http.ResponseWriter, r *http.Request, path string) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "The method "+r.Method+" is not allowed for the resource.")
+		return
+	}
+
+	timeZone := s.MailboxTimeZone
+	if timeZone == "" {
+		timeZone = DefaultMailboxTimeZone
+	}
+
+	if path == "/timeZone" {
+		writeJSON(w, http.StatusOK, Object{"value": timeZone})
+		return
+	}
+	writeJSON(w, http.StatusOK, Object{"timeZone": timeZone})
+}
+
+// preferredTimeZone returns the time zone requested by the Prefer:
+// outlook.timezone header, or UTC
+func preferredTimeZone(r *http.Request) (*time.Location, string) {
+	if match := preferTimeZoneRegexp.FindStringSubmatch(r.Header.Get("Prefer")); match != nil {
+		if loc, err := datetime.LoadLocation(match[1]); err == nil {
+			return loc, match[1]
+		}
+	}
+	return time.UTC, "UTC"
+}
+
+// inTimeZone converts the dateTimeTimeZone fields of obj to the time zone
+// requested by r, like Graph does
+func inTimeZone(r *http.Request, obj Object) Object {
+	loc, name := preferredTimeZone(r)
+
+	for _, field := range dateTimeTimeZoneFields {
+		var value map[string]interface{}
+		switch v := obj[field].(type) {
+		case Object:
+			value = v
+		case map[string]interface{}:
+			value = v
+		default:
+			continue
+		}
+
+		dateTime, _ := value["dateTime"].(string)
+		timeZone, _ := value["timeZone"].(string)
+
+		from, err := datetime.LoadLocation(timeZone)
+		if err != nil {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01-02T15:04:05.9999999", dateTime, from)
+		if err != nil {
+			continue
+		}
+
+		obj[field] = Object{"dateTime": t.In(loc).Format(graphTimeLayout), "timeZone": name}
+	}
+
+	return obj
+}
@@ -0,0 +1,230 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package lineedit reads lines from the terminal, with history and tab
+// completion
+package lineedit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dalyisaac/mstodo/internal/term"
+)
+
+// ErrInterrupted is returned by ReadLine when ctrl+c is pressed
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns the completions of the word which ends at pos in line, and
+// the index where the word starts
+type Completer func(line []rune, pos int) (completions []string, start int)
+
+// Editor reads lines, which are added to its history
+type Editor struct {
+	// History are the lines which were read, oldest first
+	History []string
+
+	// MaxHistory is the number of lines kept in History, or 0 to keep them all
+	MaxHistory int
+
+	// Complete completes the word at the cursor when tab is pressed, and can
+	// be nil
+	Complete Completer
+}
+
+// ReadLine prints the prompt and reads a line from the terminal. It returns
+// io.EOF when ctrl+d is pressed on an empty line.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	t, err := term.Open()
+	if err != nil {
+		return "", err
+	}
+	defer t.Restore()
+
+	return e.readLine(t.ReadKeys, t.Out, prompt)
+}
+
+// line is the state of the line being edited
+type line struct {
+	buf []rune
+	pos int
+}
+
+func (l *line) insert(s string) {
+	r := []rune(s)
+	l.buf = append(l.buf[:l.pos], append(r, l.buf[l.pos:]...)...)
+	l.pos += len(r)
+}
+
+func (l *line) set(s string) {
+	l.buf = []rune(s)
+	l.pos = len(l.buf)
+}
+
+func (e *Editor) readLine(readKeys func() ([]string, error), out io.Writer, prompt string) (string, error) {
+	l := &line{}
+
+	// history is a copy of the history with the new line at the end, so that
+	// edits to old lines aren't kept
+	history := append(append([]string{}, e.History...), "")
+	index := len(history) - 1
+
+	redraw := func() {
+		fmt.Fprintf(out, "\r%s%s\x1b[K", prompt, string(l.buf))
+		if back := len(l.buf) - l.pos; back > 0 {
+			fmt.Fprintf(out, "\x1b[%dD", back)
+		}
+	}
+	redraw()
+
+	for {
+		keys, err := readKeys()
+		if err != nil {
+			return "", err
+		}
+
+		for _, key := range keys {
+			switch key {
+			case "enter":
+				fmt.Fprint(out, "\r\n")
+				s := string(l.buf)
+				e.addHistory(s)
+				return s, nil
+			case "ctrl+c":
+				fmt.Fprint(out, "^C\r\n")
+				return "", ErrInterrupted
+			case "ctrl+d":
+				if len(l.buf) == 0 {
+					fmt.Fprint(out, "\r\n")
+					return "", io.EOF
+				}
+			case "backspace":
+				if l.pos > 0 {
+					l.buf = append(l.buf[:l.pos-1], l.buf[l.pos:]...)
+					l.pos--
+				}
+			case "delete":
+				if l.pos < len(l.buf) {
+					l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+				}
+			case "left":
+				if l.pos > 0 {
+					l.pos--
+				}
+			case "right":
+				if l.pos < len(l.buf) {
+					l.pos++
+				}
+			case "home":
+				l.pos = 0
+			case "end":
+				l.pos = len(l.buf)
+			case "ctrl+u":
+				l.buf = l.buf[l.pos:]
+				l.pos = 0
+			case "ctrl+w":
+				start := l.pos
+				for start > 0 && l.buf[start-1] == ' ' {
+					start--
+				}
+				for start > 0 && l.buf[start-1] != ' ' {
+					start--
+				}
+				l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+				l.pos = start
+			case "up", "down":
+				history[index] = string(l.buf)
+				if key == "up" && index > 0 {
+					index--
+				} else if key == "down" && index < len(history)-1 {
+					index++
+				}
+				l.set(history[index])
+			case "tab":
+				e.complete(l, out, prompt)
+			default:
+				if len([]rune(key)) == 1 {
+					l.insert(key)
+				}
+			}
+		}
+
+		redraw()
+	}
+}
+
+// complete replaces the word at the cursor with its completion. If there are
+// several completions, the word is replaced by their common prefix, or else
+// they're printed.
+func (e *Editor) complete(l *line, out io.Writer, prompt string) {
+	if e.Complete == nil {
+		return
+	}
+
+	completions, start := e.Complete(l.buf, l.pos)
+	if len(completions) == 0 || start < 0 || start > l.pos {
+		return
+	}
+
+	replace := func(s string) {
+		l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+		l.pos = start
+		l.insert(s)
+	}
+
+	if len(completions) == 1 {
+		replace(completions[0] + " ")
+		return
+	}
+
+	word := string(l.buf[start:l.pos])
+	if prefix := commonPrefix(completions); len([]rune(prefix)) > len([]rune(word)) {
+		replace(prefix)
+		return
+	}
+
+	fmt.Fprintf(out, "\r\n%s\r\n", strings.Join(completions, "  "))
+}
+
+// addHistory adds s to the history, unless it's empty or the same as the last
+// line
+func (e *Editor) addHistory(s string) {
+	if strings.TrimSpace(s) == "" || (len(e.History) > 0 && e.History[len(e.History)-1] == s) {
+		return
+	}
+
+	e.History = append(e.History, s)
+	if e.MaxHistory > 0 && len(e.History) > e.MaxHistory {
+		e.History = e.History[len(e.History)-e.MaxHistory:]
+	}
+}
+
+// commonPrefix returns the longest prefix of all of items
+func commonPrefix(items []string) string {
+	prefix := []rune(items[0])
+	for _, item := range items[1:] {
+		r := []rune(item)
+		n := 0
+		for n < len(prefix) && n < len(r) && prefix[n] == r[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package lineedit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// keyReader returns each of keys in turn
+func keyReader(keys ...string) func() ([]string, error) {
+	return func() ([]string, error) {
+		if len(keys) == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		key := keys[0]
+		keys = keys[1:]
+		return []string{key}, nil
+	}
+}
+
+func typed(s string, keys ...string) []string {
+	typed := []string{}
+	for _, r := range s {
+		typed = append(typed, string(r))
+	}
+	return append(typed, keys...)
+}
+
+func TestEditor_readLine(t *testing.T) {
+	complete := func(line []rune, pos int) ([]string, int) {
+		start := strings.LastIndex(string(line[:pos]), " ") + 1
+		word := string(line[start:pos])
+		completions := []string{}
+		for _, c := range []string{"Work", "Workout", "Home"} {
+			if strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+				completions = append(completions, c)
+			}
+		}
+		return completions, start
+	}
+
+	tests := []struct {
+		name    string
+		history []string
+		keys    []string
+		want    string
+		wantErr error
+	}{
+		{name: "typing", keys: typed("use Work", "enter"), want: "use Work"},
+		{name: "editing", keys: typed("ls x", "backspace", "left", "left", "delete", "home", "end", "enter"), want: "l "},
+		{name: "insert", keys: typed("ac", "left", "b", "enter"), want: "abc"},
+		{name: "clear", keys: typed("abc", "ctrl+u", "d", "enter"), want: "d"},
+		{name: "delete word", keys: typed("use Work", "ctrl+w", "enter"), want: "use "},
+		{name: "history", history: []string{"ls", "use Work"}, keys: []string{"up", "up", "down", "enter"}, want: "use Work"},
+		{name: "history back to the new line", history: []string{"ls"}, keys: typed("a", "up", "down", "enter"), want: "a"},
+		{name: "complete one", keys: typed("use h", "tab", "enter"), want: "use Home "},
+		{name: "complete common prefix", keys: typed("use w", "tab", "enter"), want: "use Work"},
+		{name: "ctrl+c", keys: typed("abc", "ctrl+c"), wantErr: ErrInterrupted},
+		{name: "ctrl+d", keys: []string{"ctrl+d"}, wantErr: io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Editor{History: tt.history, Complete: complete}
+			got, err := e.readLine(keyReader(tt.keys...), &bytes.Buffer{}, "> ")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Editor.readLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Editor.readLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_addHistory(t *testing.T) {
+	e := &Editor{MaxHistory: 2}
+	for _, line := range []string{"a", "", "b", "b", "c"} {
+		e.addHistory(line)
+	}
+
+	if want := []string{"b", "c"}; !reflect.DeepEqual(e.History, want) {
+		t.Errorf("Editor.History = %v, want %v", e.History, want)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package picker
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxStartPenalty is the most that a match loses for starting later in s
+const maxStartPenalty = 10
+
+// Match reports whether the characters of each word of query are in s in
+// order, ignoring case, like fzf. The score is higher for better matches:
+// consecutive characters, characters at the start of words, and matches near
+// the start of s. An empty query matches everything with a score of 0.
+func Match(query string, s string) (int, bool) {
+	r := []rune(strings.ToLower(s))
+
+	total := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		score, ok := matchWord([]rune(word), r)
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+// matchWord matches the characters of q in r, in order
+func matchWord(q []rune, r []rune) (int, bool) {
+	score, qi, last := 0, 0, -1
+	for i := 0; i < len(r) && qi < len(q); i++ {
+		if r[i] != q[qi] {
+			continue
+		}
+
+		score++
+		if last >= 0 && last == i-1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]) {
+			score += 3
+		}
+		if qi == 0 && i < maxStartPenalty {
+			score -= i
+		} else if qi == 0 {
+			score -= maxStartPenalty
+		}
+
+		last = i
+		qi++
+	}
+
+	return score, qi == len(q)
+}
@@ -0,0 +1,288 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package picker lets the user choose one or more items in the terminal,
+// typing to filter them with fuzzy matching, like fzf
+package picker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dalyisaac/mstodo/internal/term"
+)
+
+// ErrCancelled is returned by Pick when esc or ctrl+c is pressed
+var ErrCancelled = errors.New("cancelled")
+
+const (
+	enterScreen = "\x1b[?1049h"
+	leaveScreen = "\x1b[?1049l"
+	cursorHome  = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearScreen = "\x1b[J"
+)
+
+// Pick shows the items in the terminal, and returns the indexes of the chosen
+// ones. With multi, several items can be chosen with tab, otherwise the item
+// under the cursor is chosen by enter.
+func Pick(prompt string, items []string, multi bool) ([]int, error) {
+	if len(items) == 0 {
+		return nil, errors.New("there's nothing to choose from")
+	}
+
+	t, err := term.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Restore()
+
+	out := bufio.NewWriter(t.Out)
+	out.WriteString(enterScreen)
+	defer func() {
+		out.WriteString(leaveScreen)
+		out.Flush()
+	}()
+
+	size := func() (int, int) {
+		width, height, err := t.Size()
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	}
+
+	return pick(t.ReadKeys, out, size, newPicker(prompt, items, multi))
+}
+
+// pick draws p and handles the keys until an item is chosen
+func pick(readKeys func() ([]string, error), out *bufio.Writer, size func() (int, int), p *picker) ([]int, error) {
+	for {
+		width, height := size()
+		lines := p.render(width, height)
+		fmt.Fprintf(out, "%s%s%s%s\x1b[1;%dH", cursorHome, strings.Join(lines, clearLine+"\r\n"), clearLine, clearScreen, p.cursorColumn()+1)
+		if err := out.Flush(); err != nil {
+			return nil, err
+		}
+
+		keys, err := readKeys()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if chosen, err := p.update(key, height); chosen != nil || err != nil {
+				return chosen, err
+			}
+		}
+	}
+}
+
+// picker is the state of the picker
+type picker struct {
+	prompt string
+	items  []string
+	multi  bool
+
+	query []rune
+
+	// matches are the indexes of the items which match the query, best first
+	matches []int
+
+	// cursor is the index of the match under the cursor, and offset is the
+	// index of the first match shown
+	cursor, offset int
+
+	// selected are the indexes of the items chosen with tab
+	selected map[int]bool
+}
+
+func newPicker(prompt string, items []string, multi bool) *picker {
+	p := &picker{prompt: prompt, items: items, multi: multi, selected: map[int]bool{}}
+	p.filter()
+	return p
+}
+
+// filter finds the items which match the query, and moves the cursor to the
+// best one
+func (p *picker) filter() {
+	query := string(p.query)
+
+	type match struct{ index, score int }
+	matches := []match{}
+	for i, item := range p.items {
+		if score, ok := Match(query, item); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	p.matches = make([]int, len(matches))
+	for i, m := range matches {
+		p.matches[i] = m.index
+	}
+	p.cursor, p.offset = 0, 0
+}
+
+// update handles a key, returning the chosen items when enter is pressed
+func (p *picker) update(key string, height int) ([]int, error) {
+	switch key {
+	case "enter":
+		return p.chosen(), nil
+	case "esc", "ctrl+c", "ctrl+d":
+		return nil, ErrCancelled
+	case "up", "ctrl+p":
+		p.move(-1)
+	case "down", "ctrl+n":
+		p.move(1)
+	case "pgup":
+		p.move(-p.rows(height))
+	case "pgdown":
+		p.move(p.rows(height))
+	case "tab", "shift+tab":
+		if p.multi && len(p.matches) > 0 {
+			index := p.matches[p.cursor]
+			if p.selected[index] {
+				delete(p.selected, index)
+			} else {
+				p.selected[index] = true
+			}
+			if key == "tab" {
+				p.move(1)
+			} else {
+				p.move(-1)
+			}
+		}
+	case "backspace":
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case "ctrl+u":
+		p.query = nil
+		p.filter()
+	case "ctrl+w":
+		end := len(p.query)
+		for end > 0 && p.query[end-1] == ' ' {
+			end--
+		}
+		for end > 0 && p.query[end-1] != ' ' {
+			end--
+		}
+		p.query = p.query[:end]
+		p.filter()
+	default:
+		if len([]rune(key)) == 1 {
+			p.query = append(p.query, []rune(key)...)
+			p.filter()
+		}
+	}
+	return nil, nil
+}
+
+// chosen returns the selected items in their order, or else the item under
+// the cursor. It's nil if nothing matches.
+func (p *picker) chosen() []int {
+	chosen := []int{}
+	for i := range p.items {
+		if p.selected[i] {
+			chosen = append(chosen, i)
+		}
+	}
+
+	if len(chosen) == 0 {
+		if len(p.matches) == 0 {
+			return nil
+		}
+		chosen = append(chosen, p.matches[p.cursor])
+	}
+	return chosen
+}
+
+// move moves the cursor by n matches
+func (p *picker) move(n int) {
+	p.cursor += n
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// rows returns the number of items shown in a terminal of height lines, below
+// the query and the counter
+func (p *picker) rows(height int) int {
+	if rows := height - 2; rows > 0 {
+		return rows
+	}
+	return 1
+}
+
+// render returns the lines to draw: the query, the number of matches and the
+// matching items
+func (p *picker) render(width, height int) []string {
+	lines := []string{fit(p.prompt+"> "+string(p.query), width)}
+
+	counter := fmt.Sprintf("  %d/%d", len(p.matches), len(p.items))
+	if p.multi {
+		counter += fmt.Sprintf(" (%d selected, tab to select)", len(p.selected))
+	}
+	lines = append(lines, fit(counter, width))
+
+	rows := p.rows(height)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	for i := p.offset; i < len(p.matches) && i < p.offset+rows; i++ {
+		index := p.matches[i]
+
+		line := "  "
+		if i == p.cursor {
+			line = "> "
+		}
+		if p.multi {
+			if p.selected[index] {
+				line += "+ "
+			} else {
+				line += "  "
+			}
+		}
+		lines = append(lines, fit(line+p.items[index], width))
+	}
+
+	return lines
+}
+
+// cursorColumn returns the column of the cursor, at the end of the query
+func (p *picker) cursorColumn() int {
+	return len([]rune(p.prompt)) + 2 + len(p.query)
+}
+
+// fit cuts s to width runes
+func fit(s string, width int) string {
+	if r := []rune(s); width > 0 && len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
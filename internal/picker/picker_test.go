@@ -0,0 +1,142 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package picker
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		s      string
+		wantOk bool
+	}{
+		{name: "empty", query: "", s: "Write report", wantOk: true},
+		{name: "subsequence", query: "wrrp", s: "Write report", wantOk: true},
+		{name: "case", query: "WR", s: "write report", wantOk: true},
+		{name: "words", query: "rep wr", s: "Write report", wantOk: true},
+		{name: "order", query: "rw", s: "Write", wantOk: false},
+		{name: "missing word", query: "wr pay", s: "Write report", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Match(tt.query, tt.s); ok != tt.wantOk {
+				t.Errorf("Match() ok = %v, want %v", ok, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestMatch_score(t *testing.T) {
+	better, _ := Match("rep", "Write report")
+	worse, _ := Match("rep", "Buy a crepe")
+	if better <= worse {
+		t.Errorf("Match() score of a word start = %d, want more than %d", better, worse)
+	}
+
+	better, _ = Match("pay", "Pay rent")
+	worse, _ = Match("pay", "Plan a year")
+	if better <= worse {
+		t.Errorf("Match() score of consecutive characters = %d, want more than %d", better, worse)
+	}
+}
+
+// keyReader returns each of keys in turn
+func keyReader(keys ...string) func() ([]string, error) {
+	return func() ([]string, error) {
+		if len(keys) == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		key := keys[0]
+		keys = keys[1:]
+		return []string{key}, nil
+	}
+}
+
+func typed(s string, keys ...string) []string {
+	typed := []string{}
+	for _, r := range s {
+		typed = append(typed, string(r))
+	}
+	return append(typed, keys...)
+}
+
+func Test_pick(t *testing.T) {
+	items := []string{"Write report", "Pay rent", "Buy a crepe", "Call Sam"}
+
+	tests := []struct {
+		name    string
+		multi   bool
+		keys    []string
+		want    []int
+		wantErr error
+	}{
+		{name: "first", keys: []string{"enter"}, want: []int{0}},
+		{name: "move", keys: []string{"down", "down", "up", "enter"}, want: []int{1}},
+		{name: "past the end", keys: []string{"pgdown", "down", "enter"}, want: []int{3}},
+		{name: "filter", keys: typed("cal", "enter"), want: []int{3}},
+		{name: "best match first", keys: typed("rep", "enter"), want: []int{0}},
+		{name: "backspace", keys: typed("calx", "backspace", "enter"), want: []int{3}},
+		{name: "clear", keys: typed("cal", "ctrl+u", "enter"), want: []int{0}},
+		{name: "no match", keys: typed("zzz", "enter", "ctrl+u", "enter"), want: []int{0}},
+		{name: "tab without multi", keys: []string{"tab", "enter"}, want: []int{0}},
+		{name: "multi", multi: true, keys: []string{"tab", "down", "tab", "enter"}, want: []int{0, 2}},
+		{name: "multi unselect", multi: true, keys: []string{"tab", "shift+tab", "tab", "enter"}, want: []int{1}},
+		{name: "multi in item order", multi: true, keys: typed("sam", "tab", "ctrl+u", "tab", "enter"), want: []int{0, 3}},
+		{name: "multi without selection", multi: true, keys: []string{"down", "enter"}, want: []int{1}},
+		{name: "esc", keys: []string{"esc"}, wantErr: ErrCancelled},
+		{name: "ctrl+c", keys: typed("pay", "ctrl+c"), wantErr: ErrCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bufio.NewWriter(ioutil.Discard)
+			size := func() (int, int) { return 40, 4 }
+
+			got, err := pick(keyReader(tt.keys...), out, size, newPicker("Task", items, tt.multi))
+			if err != tt.wantErr {
+				t.Fatalf("pick() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pick() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_picker_render(t *testing.T) {
+	p := newPicker("List", []string{"Work", "Home", "Groceries", "Garden"}, true)
+	for _, key := range []string{"down", "tab", "up"} {
+		p.update(key, 4)
+	}
+
+	want := []string{
+		"List> ",
+		"  4/4 (1 selected, tab to select)",
+		"    Work",
+		"> + Home",
+	}
+	if got := p.render(40, 4); !reflect.DeepEqual(got, want) {
+		t.Errorf("render() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package term
+
+import "unicode/utf8"
+
+// escapeKeys are the escape sequences of the special keys
+var escapeKeys = map[string]string{
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[C":  "right",
+	"\x1b[D":  "left",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+	"\x1bOC":  "right",
+	"\x1bOD":  "left",
+	"\x1b[H":  "home",
+	"\x1b[F":  "end",
+	"\x1bOH":  "home",
+	"\x1bOF":  "end",
+	"\x1b[1~": "home",
+	"\x1b[4~": "end",
+	"\x1b[3~": "delete",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdown",
+	"\x1b[Z":  "shift+tab",
+}
+
+// controlKeys are the names of the control characters
+var controlKeys = map[byte]string{
+	'\r': "enter",
+	'\n': "enter",
+	'\t': "tab",
+	0x7f: "backspace",
+	0x08: "backspace",
+	0x03: "ctrl+c",
+	0x15: "ctrl+u",
+	0x17: "ctrl+w",
+	0x04: "ctrl+d",
+	0x01: "home",
+	0x05: "end",
+	0x0c: "ctrl+l",
+	0x0e: "ctrl+n",
+	0x10: "ctrl+p",
+	0x1b: "esc",
+}
+
+// ParseKeys splits the bytes read from the terminal into keys, which are
+// either the names of special keys, like "up" or "ctrl+c", or a single
+// character, like "a"
+func ParseKeys(b []byte) []string {
+	keys := []string{}
+
+	for len(b) > 0 {
+		if b[0] == 0x1b && len(b) > 1 {
+			if key, n := parseEscape(b); n > 0 {
+				if key != "" {
+					keys = append(keys, key)
+				}
+				b = b[n:]
+				continue
+			}
+		}
+
+		if name, ok := controlKeys[b[0]]; ok {
+			keys = append(keys, name)
+			b = b[1:]
+			continue
+		}
+
+		r, n := utf8.DecodeRune(b)
+		if r != utf8.RuneError && r >= ' ' {
+			keys = append(keys, string(r))
+		}
+		b = b[n:]
+	}
+
+	return keys
+}
+
+// parseEscape returns the key of the escape sequence at the start of b, and
+// its length, or 0 if it isn't one. The key of unknown sequences is empty.
+func parseEscape(b []byte) (string, int) {
+	for seq, key := range escapeKeys {
+		if len(b) >= len(seq) && string(b[:len(seq)]) == seq {
+			return key, len(seq)
+		}
+	}
+
+	if b[1] == '[' || b[1] == 'O' {
+		// Skip an unknown CSI sequence, which ends with a letter or ~
+		for i := 2; i < len(b); i++ {
+			if (b[i] >= 'A' && b[i] <= 'Z') || (b[i] >= 'a' && b[i] <= 'z') || b[i] == '~' {
+				return "", i + 1
+			}
+		}
+		return "", len(b)
+	}
+
+	return "", 0
+}
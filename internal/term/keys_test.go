@@ -0,0 +1,46 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package term
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		b    string
+		want []string
+	}{
+		{name: "characters", b: "aD/", want: []string{"a", "D", "/"}},
+		{name: "unicode", b: "é", want: []string{"é"}},
+		{name: "arrows", b: "\x1b[A\x1b[B\x1bOC", want: []string{"up", "down", "right"}},
+		{name: "control", b: "\r\t\x7f\x03", want: []string{"enter", "tab", "backspace", "ctrl+c"}},
+		{name: "escape", b: "\x1b", want: []string{"esc"}},
+		{name: "delete", b: "\x1b[3~x", want: []string{"delete", "x"}},
+		{name: "unknown sequence", b: "\x1b[1;5Ax", want: []string{"x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKeys([]byte(tt.b)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
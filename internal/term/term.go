@@ -0,0 +1,46 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package term reads keys from the terminal in raw mode, for the tui and the
+// shell
+package term
+
+import (
+	"errors"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// ErrRestored is returned by ReadKeys once the terminal has been restored
+var ErrRestored = errors.New("the terminal has been restored")
+
+// ReadKeys waits for keys to be pressed, and returns them. Once Restore is
+// called, it returns ErrRestored without reading any more keys.
+func (t *Terminal) ReadKeys() ([]string, error) {
+	buf := make([]byte, 256)
+	n, err := t.read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(buf[:n]), nil
+}
@@ -0,0 +1,46 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package term
+
+import (
+	"errors"
+	"os"
+)
+
+var ResizeSignals = []os.Signal{}
+
+type Terminal struct {
+	In, Out *os.File
+}
+
+func Open() (*Terminal, error) {
+	return nil, errors.New("terminals aren't supported on this platform")
+}
+
+func (t *Terminal) Restore() {}
+
+func (t *Terminal) read(buf []byte) (int, error) {
+	return t.In.Read(buf)
+}
+
+func (t *Terminal) Size() (int, int, error) {
+	return 0, 0, errors.New("terminals aren't supported on this platform")
+}
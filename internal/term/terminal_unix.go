@@ -0,0 +1,116 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package term
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// ResizeSignals are sent when the terminal is resized
+var ResizeSignals = []os.Signal{unix.SIGWINCH}
+
+// readPollTimeout is how often, in milliseconds, read checks whether the
+// terminal has been restored while it waits for keys
+const readPollTimeout = 100
+
+// Terminal is the controlling terminal, in raw mode
+type Terminal struct {
+	In, Out *os.File
+	old     unix.Termios
+
+	// mu stops Restore while read reads, and restored stops read reading after
+	// Restore, so that a goroutine waiting for keys doesn't take the input of
+	// whatever reads the terminal next
+	mu       sync.Mutex
+	restored bool
+}
+
+// Open puts stdin into raw mode, so that keys are read as they're pressed and
+// aren't echoed. Newlines written to Out need a carriage return.
+func Open() (*Terminal, error) {
+	fd := int(os.Stdin.Fd())
+
+	old, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("stdin isn't a terminal: %w", err)
+	}
+
+	raw := *old
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return &Terminal{In: os.Stdin, Out: os.Stdout, old: *old}, nil
+}
+
+// Restore takes the terminal out of raw mode, and stops ReadKeys
+func (t *Terminal) Restore() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.restored = true
+	unix.IoctlSetTermios(int(t.In.Fd()), ioctlSetTermios, &t.old)
+}
+
+// read waits until In can be read, and reads it into buf, or returns
+// ErrRestored once Restore has been called
+func (t *Terminal) read(buf []byte) (int, error) {
+	fds := []unix.PollFd{{Fd: int32(t.In.Fd()), Events: unix.POLLIN}}
+
+	for {
+		n, err := unix.Poll(fds, readPollTimeout)
+		if err != nil && err != unix.EINTR {
+			return 0, err
+		}
+
+		t.mu.Lock()
+		if t.restored {
+			t.mu.Unlock()
+			return 0, ErrRestored
+		}
+		if n > 0 {
+			defer t.mu.Unlock()
+			return t.In.Read(buf)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Size returns the width and height of the terminal
+func (t *Terminal) Size() (int, int, error) {
+	ws, err := unix.IoctlGetWinsize(int(t.Out.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
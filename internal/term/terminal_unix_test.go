@@ -0,0 +1,69 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package term
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTerminal_ReadKeys_restore(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	term := &Terminal{In: r, Out: w}
+	w.WriteString("a")
+	if got, err := term.ReadKeys(); err != nil || len(got) != 1 || got[0] != "a" {
+		t.Fatalf("Terminal.ReadKeys() = %q, %v, want [a]", got, err)
+	}
+
+	// A reader which is waiting stops when the terminal is restored, without
+	// reading the keys which come after
+	errs := make(chan error)
+	go func() {
+		_, err := term.ReadKeys()
+		errs <- err
+	}()
+
+	time.Sleep(2 * readPollTimeout * time.Millisecond)
+	term.Restore()
+	w.WriteString("q")
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrRestored) {
+			t.Errorf("Terminal.ReadKeys() error = %v, want ErrRestored", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Terminal.ReadKeys() didn't return after Restore")
+	}
+
+	buf := make([]byte, 8)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "q" {
+		t.Errorf("after Restore, read %q, %v, want the key to be left unread", buf[:n], err)
+	}
+}
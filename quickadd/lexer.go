@@ -0,0 +1,109 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package quickadd
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// token is a word of the input
+type token struct {
+	// text is the word, without quotes and escapes
+	text string
+
+	// meta is true if the word can be metadata, i.e. its first character
+	// wasn't escaped or quoted, like "#finance"
+	meta bool
+
+	// literal is true if part of the word was escaped or quoted, so it's
+	// always part of the title
+	literal bool
+}
+
+// lex splits input into words. A backslash escapes the next character, and
+// double quotes group words, e.g. `\#1 "The Day After Tomorrow"`.
+func lex(input string) ([]token, error) {
+	tokens := []token{}
+
+	var current *token
+	var text strings.Builder
+	quoted := false
+	escaped := false
+
+	flush := func() {
+		if current != nil {
+			current.text = text.String()
+			tokens = append(tokens, *current)
+		}
+		current = nil
+		text.Reset()
+	}
+
+	start := func(meta bool) {
+		if current == nil {
+			current = &token{meta: meta}
+		}
+	}
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			start(false)
+			current.literal = true
+			text.WriteRune(r)
+			escaped = false
+
+		case r == '\\':
+			escaped = true
+
+		case r == '"':
+			// A quote directly after a metadata character keeps the token as
+			// metadata, like @"Work stuff"
+			if current != nil && !quoted && current.meta && text.Len() == 1 {
+				quoted = true
+				continue
+			}
+
+			start(false)
+			if !current.meta {
+				current.literal = true
+			}
+			quoted = !quoted
+
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+
+		default:
+			start(true)
+			text.WriteRune(r)
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("the title ends with an unfinished escape '\\'")
+	}
+
+	if quoted {
+		return nil, errors.New("the title has an unclosed quote")
+	}
+
+	flush()
+	return tokens, nil
+}
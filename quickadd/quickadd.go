@@ -0,0 +1,303 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package quickadd extracts the metadata from a natural-language task title,
+// like "Pay rent tomorrow 9am !high #finance @home every month".
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/utils"
+)
+
+// Importances are the values of the !importance syntax
+var Importances = []string{"low", "normal", "high"}
+
+// dateConnectors are dropped from the title when they come before a date
+var dateConnectors = map[string]bool{"on": true, "at": true, "by": true, "due": true}
+
+// standaloneDateWords are the words which are a date on their own, without a
+// date connector before them
+var standaloneDateWords = map[string]bool{
+	"today": true, "tod": true, "tomorrow": true, "tmr": true, "tmrw": true, "yesterday": true,
+	"eod": true, "eow": true, "eom": true, "eoq": true, "eoy": true,
+}
+
+// standaloneDateRegexp matches the numeric dates and times which are a date on
+// their own, like "17/10", "2021-10-17", "15:00" or "9am"
+var standaloneDateRegexp = regexp.MustCompile(`^(\d+([/.:-]\d+)+|\d{1,2}(:\d{2})?(am|pm))$`)
+
+// Result is the metadata extracted from a title. Fields which weren't given
+// are empty.
+type Result struct {
+	// Title is the title without the metadata
+	Title string
+
+	// Importance is "low", "normal" or "high", from "!high"
+	Importance string
+
+	// Categories are from "#finance"
+	Categories []string
+
+	// List is the list name, from "@home" or @"Work stuff"
+	List string
+
+	// Reminder is from a date time, like "tomorrow 9am"
+	Reminder *time.Time
+
+	// DueDate is from a date, like "next friday"
+	DueDate *time.Time
+
+	// Date is the words which the reminder or due date was parsed from
+	Date string
+
+	// Recurrence is from "every month", "every 2 weeks" or "every monday"
+	Recurrence *Recurrence
+}
+
+// Apply adds the reminder, due date, categories and recurrence to task. The
+// reminder and due date aren't replaced when reminderSet or dueDateSet are
+// true, like when they were given as flags.
+func (res *Result) Apply(task *api.TodoTask, reminderSet, dueDateSet bool) error {
+	if res.Reminder != nil && !reminderSet {
+		task.IsReminderOn = true
+		task.ReminderDateTime = (*datetime.GraphTime)(res.Reminder)
+	}
+
+	if res.DueDate != nil && !dueDateSet {
+		task.DueDateTime = (*datetime.GraphTime)(res.DueDate)
+	}
+
+	task.Categories = res.Categories
+
+	if res.Recurrence == nil {
+		return nil
+	}
+
+	// Recurring tasks need a due date, which is when they start repeating
+	if task.DueDateTime == nil {
+		start, err := datetime.DateParser("today")
+		if err != nil {
+			return err
+		}
+
+		if task.ReminderDateTime != nil {
+			reminder := time.Time(*task.ReminderDateTime)
+			*start = time.Date(reminder.Year(), reminder.Month(), reminder.Day(), 0, 0, 0, 0, reminder.Location())
+		}
+		task.DueDateTime = (*datetime.GraphTime)(start)
+	}
+
+	task.Recurrence = res.Recurrence.Graph(time.Time(*task.DueDateTime))
+	return nil
+}
+
+// Parser extracts the metadata from titles
+type Parser struct {
+	// ParseDateTime parses date times, for reminders
+	ParseDateTime func(input string) (*time.Time, error)
+
+	// ParseDate parses dates, for due dates
+	ParseDate func(input string) (*time.Time, error)
+
+	// IsDateWord reports whether a word can be part of a date
+	IsDateWord func(word string) bool
+}
+
+// DefaultParser uses the datetime package, with its configured locale and time
+// zone
+var DefaultParser = &Parser{
+	ParseDateTime: datetime.DateTimeParser,
+	ParseDate:     datetime.DateParser,
+	IsDateWord:    datetime.IsDateWord,
+}
+
+// Parse extracts the metadata from title using DefaultParser
+func Parse(title string) (*Result, error) {
+	return DefaultParser.Parse(title)
+}
+
+// Parse extracts the metadata from title. The syntax is:
+//
+//	!high, !normal, !low     the importance
+//	#category                a category, which can be repeated
+//	@list or @"my list"      the list
+//	every [n] day|week|...   the recurrence, or every monday [and friday]
+//	a date time or date      the reminder or due date, like "tomorrow 9am"
+//
+// A single word like a weekday, "now" or "3d" is only a date after "on", "at",
+// "by" or "due", like "Call Sam on fri".
+//
+// A backslash escapes the next character, like \#1, and quoted words are kept
+// in the title as they are, like "The Day After Tomorrow".
+func (p *Parser) Parse(title string) (*Result, error) {
+	tokens, err := lex(title)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+
+	tokens, err = res.extractMetadata(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err = res.extractRecurrence(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err = p.extractDate(res, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.text
+	}
+	res.Title = strings.Join(words, " ")
+
+	return res, nil
+}
+
+// extractMetadata extracts the !importance, #category and @list tokens
+func (res *Result) extractMetadata(tokens []token) ([]token, error) {
+	remaining := []token{}
+
+	for _, t := range tokens {
+		if !t.meta || len(t.text) < 2 {
+			remaining = append(remaining, t)
+			continue
+		}
+
+		value := t.text[1:]
+		switch t.text[0] {
+		case '!':
+			importance := strings.ToLower(value)
+			if !utils.ContainsString(Importances, importance) {
+				return nil, fmt.Errorf("'%s' is not an importance - choices: [!%s]. Use \\! for a literal '!'", t.text, strings.Join(Importances, ", !"))
+			}
+			if res.Importance != "" && res.Importance != importance {
+				return nil, fmt.Errorf("the importance is given twice, as !%s and !%s", res.Importance, importance)
+			}
+			res.Importance = importance
+
+		case '#':
+			if !utils.ContainsString(res.Categories, value) {
+				res.Categories = append(res.Categories, value)
+			}
+
+		case '@':
+			if res.List != "" && res.List != value {
+				return nil, fmt.Errorf("the list is given twice, as @%s and @%s", res.List, value)
+			}
+			res.List = value
+
+		default:
+			remaining = append(remaining, t)
+		}
+	}
+
+	return remaining, nil
+}
+
+// extractDate extracts the longest run of words which is a date time, for the
+// reminder, or a date, for the due date
+func (p *Parser) extractDate(res *Result, tokens []token) ([]token, error) {
+	var ambiguous error
+
+	// Longest runs first, then the earliest
+	for length := len(tokens); length > 0; length-- {
+		for start := 0; start+length <= len(tokens); start++ {
+			run := tokens[start : start+length]
+			connected := start > 0 && !tokens[start-1].literal && dateConnectors[strings.ToLower(tokens[start-1].text)]
+			if !p.isDateRun(run, connected) {
+				continue
+			}
+
+			words := make([]string, len(run))
+			for i, t := range run {
+				words[i] = t.text
+			}
+			input := strings.Join(words, " ")
+
+			if t, err := p.ParseDateTime(input); err == nil {
+				res.Reminder = t
+				res.Date = input
+			} else if t, err := p.ParseDate(input); err == nil {
+				res.DueDate = t
+				res.Date = input
+			} else {
+				var ambiguousErr *datetime.AmbiguousDateError
+				if ambiguous == nil && errors.As(err, &ambiguousErr) {
+					ambiguous = err
+				}
+				continue
+			}
+
+			// Drop the word joining the date to the title, like "Meeting on"
+			titleEnd := start
+			if connected {
+				titleEnd--
+			}
+
+			remaining := append([]token{}, tokens[:titleEnd]...)
+			return append(remaining, tokens[start+length:]...), nil
+		}
+	}
+
+	// An ambiguous date like 02/01 is an error rather than part of the title
+	if ambiguous != nil {
+		return nil, ambiguous
+	}
+	return tokens, nil
+}
+
+// isDateRun reports whether run can be a date. connected is whether it comes
+// after a date connector, like "on" or "by".
+func (p *Parser) isDateRun(run []token, connected bool) bool {
+	if len(run) == 1 {
+		// A number on its own is part of the title, like "Buy 2 apples"
+		if _, err := strconv.Atoi(run[0].text); err == nil {
+			return false
+		}
+
+		// Other words, like a weekday, "now" or an offset like "3d", are only a
+		// date after a connector, so that "Book 3d printer" keeps its title
+		word := strings.ToLower(strings.Trim(run[0].text, ",."))
+		if !connected && !standaloneDateWords[word] && !standaloneDateRegexp.MatchString(word) {
+			return false
+		}
+	}
+
+	for _, t := range run {
+		if t.literal || !p.IsDateWord(t.text) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,263 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+)
+
+var (
+	tomorrow9am = time.Date(2021, 7, 8, 9, 0, 0, 0, time.UTC)
+	tomorrow    = time.Date(2021, 7, 8, 0, 0, 0, 0, time.UTC)
+	nextFriday  = time.Date(2021, 7, 16, 0, 0, 0, 0, time.UTC)
+)
+
+func p(t time.Time) *time.Time {
+	return &t
+}
+
+// fakeParser parses a few fixed dates
+func fakeParser() *Parser {
+	lookup := func(dates map[string]time.Time) func(string) (*time.Time, error) {
+		return func(input string) (*time.Time, error) {
+			if input == "02/01" {
+				return nil, &datetime.AmbiguousDateError{
+					Input:  input,
+					Dates:  []time.Time{time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+					Orders: []datetime.DateOrder{datetime.DayMonthYear, datetime.MonthDayYear},
+				}
+			}
+			if d, ok := dates[input]; ok {
+				return &d, nil
+			}
+			return nil, fmt.Errorf("could not parse '%s'", input)
+		}
+	}
+
+	return &Parser{
+		ParseDateTime: lookup(map[string]time.Time{"tomorrow 9am": tomorrow9am, "tomorrow at 9am": tomorrow9am}),
+		ParseDate:     lookup(map[string]time.Time{"tomorrow": tomorrow, "next friday": nextFriday, "next fri": nextFriday}),
+		IsDateWord:    datetime.IsDateWord,
+	}
+}
+
+func TestParser_Parse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    *Result
+		wantErr bool
+	}{
+		{input: "Buy milk", want: &Result{Title: "Buy milk"}},
+		{
+			input: "Pay rent tomorrow 9am !high #finance @home every month",
+			want: &Result{
+				Title:      "Pay rent",
+				Importance: "high",
+				Categories: []string{"finance"},
+				List:       "home",
+				Reminder:   p(tomorrow9am),
+				Date:       "tomorrow 9am",
+				Recurrence: &Recurrence{Unit: Monthly, Interval: 1},
+			},
+		},
+		{input: "Call mum tomorrow at 9am", want: &Result{Title: "Call mum", Reminder: p(tomorrow9am), Date: "tomorrow at 9am"}},
+		{input: "Submit report by next friday", want: &Result{Title: "Submit report", DueDate: p(nextFriday), Date: "next friday"}},
+		{input: `Submit report \by next friday`, want: &Result{Title: "Submit report by", DueDate: p(nextFriday), Date: "next friday"}},
+		{input: "Submit report next friday", want: &Result{Title: "Submit report", DueDate: p(nextFriday), Date: "next friday"}},
+		{input: "Submit report next fri #work #urgent #work", want: &Result{Title: "Submit report", DueDate: p(nextFriday), Date: "next fri", Categories: []string{"work", "urgent"}}},
+		{input: `Plan trip @"Work stuff" !LOW`, want: &Result{Title: "Plan trip", List: "Work stuff", Importance: "low"}},
+		{input: `Fix bug \#1 \!important`, want: &Result{Title: "Fix bug #1 !important"}},
+		{input: `Watch "The Day After Tomorrow"`, want: &Result{Title: "Watch The Day After Tomorrow"}},
+		{input: `Watch \tomorrow`, want: &Result{Title: "Watch tomorrow"}},
+		{input: "Buy sunscreen tomorrow", want: &Result{Title: "Buy sunscreen", DueDate: p(tomorrow), Date: "tomorrow"}},
+		{input: "Water plants every 2 days", want: &Result{Title: "Water plants", Recurrence: &Recurrence{Unit: Daily, Interval: 2}}},
+		{input: "Clean every other week", want: &Result{Title: "Clean", Recurrence: &Recurrence{Unit: Weekly, Interval: 2}}},
+		{input: "Stand-up every weekday", want: &Result{Title: "Stand-up", Recurrence: &Recurrence{Unit: Weekly, Interval: 1, Weekdays: workWeek}}},
+		{input: "Gym every mon, wed and fri", want: &Result{Title: "Gym", Recurrence: &Recurrence{Unit: Weekly, Interval: 1, Weekdays: []time.Weekday{time.Monday, time.Wednesday, time.Friday}}}},
+		{input: "Gym every tue/thu", want: &Result{Title: "Gym", Recurrence: &Recurrence{Unit: Weekly, Interval: 1, Weekdays: []time.Weekday{time.Tuesday, time.Thursday}}}},
+		{input: "Thank everyone every year", want: &Result{Title: "Thank everyone", Recurrence: &Recurrence{Unit: Yearly, Interval: 1}}},
+		{input: "Buy 2 apples", want: &Result{Title: "Buy 2 apples"}},
+		{input: "Read every book", want: &Result{Title: "Read every book"}},
+		{input: "Buy ! and #", want: &Result{Title: "Buy ! and #"}},
+		{input: "Party 02/01", wantErr: true},
+		{input: "Party !urgent", wantErr: true},
+		{input: "Party !high !low", wantErr: true},
+		{input: "Party @home @work", wantErr: true},
+		{input: "Party every day every week", wantErr: true},
+		{input: `Party "tonight`, wantErr: true},
+		{input: `Party \`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := fakeParser().Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_Parse_ambiguous(t *testing.T) {
+	_, err := fakeParser().Parse("Party 02/01")
+
+	var ambiguous *datetime.AmbiguousDateError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Parser.Parse() error = %v, want an *AmbiguousDateError", err)
+	}
+}
+
+func TestParser_Parse_singleWords(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantTitle string
+		wantDate  string
+	}{
+		{input: "Book 3d printer", wantTitle: "Book 3d printer"},
+		{input: "Call Sam now", wantTitle: "Call Sam now"},
+		{input: "sat exam prep", wantTitle: "sat exam prep"},
+		{input: "Email Fri team", wantTitle: "Email Fri team"},
+		{input: "Call Sam on sat", wantTitle: "Call Sam", wantDate: "on sat"},
+		{input: "Renew due 3d", wantTitle: "Renew", wantDate: "3d"},
+		{input: "Send invoice tomorrow", wantTitle: "Send invoice", wantDate: "tomorrow"},
+		{input: "Stand-up 9am", wantTitle: "Stand-up", wantDate: "9am"},
+		{input: "Call Sam next fri", wantTitle: "Call Sam", wantDate: "next fri"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := DefaultParser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parser.Parse() error = %v", err)
+			}
+			if got.Title != tt.wantTitle || got.Date != tt.wantDate {
+				t.Errorf("Parser.Parse() = %q with the date %q, want %q with the date %q", got.Title, got.Date, tt.wantTitle, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestRecurrence_String(t *testing.T) {
+	tests := []struct {
+		recurrence Recurrence
+		want       string
+	}{
+		{recurrence: Recurrence{Unit: Daily, Interval: 1}, want: "every day"},
+		{recurrence: Recurrence{Unit: Monthly, Interval: 3}, want: "every 3 months"},
+		{recurrence: Recurrence{Unit: Weekly, Interval: 1, Weekdays: workWeek}, want: "every weekday"},
+		{recurrence: Recurrence{Unit: Weekly, Interval: 1, Weekdays: []time.Weekday{time.Monday, time.Thursday}}, want: "every Monday, Thursday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.recurrence.String(); got != tt.want {
+				t.Errorf("Recurrence.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrence_Graph(t *testing.T) {
+	start := time.Date(2021, 7, 8, 0, 0, 0, 0, time.UTC)
+	noEnd := api.RecurrenceRange{Type: "noEnd", StartDate: "2021-07-08"}
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		want       *api.PatternedRecurrence
+	}{
+		{
+			name:       "daily",
+			recurrence: Recurrence{Unit: Daily, Interval: 2},
+			want:       &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: api.DailyRecurrence, Interval: 2}, Range: noEnd},
+		},
+		{
+			name:       "weekly on the start's weekday",
+			recurrence: Recurrence{Unit: Weekly, Interval: 1},
+			want:       &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: api.WeeklyRecurrence, Interval: 1, DaysOfWeek: []string{"thursday"}, FirstDayOfWeek: "sunday"}, Range: noEnd},
+		},
+		{
+			name:       "weekly on weekdays",
+			recurrence: Recurrence{Unit: Weekly, Interval: 1, Weekdays: []time.Weekday{time.Monday, time.Friday}},
+			want:       &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: api.WeeklyRecurrence, Interval: 1, DaysOfWeek: []string{"monday", "friday"}, FirstDayOfWeek: "sunday"}, Range: noEnd},
+		},
+		{
+			name:       "monthly",
+			recurrence: Recurrence{Unit: Monthly, Interval: 1},
+			want:       &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: api.AbsoluteMonthlyRecurrence, Interval: 1, DayOfMonth: 8}, Range: noEnd},
+		},
+		{
+			name:       "yearly",
+			recurrence: Recurrence{Unit: Yearly, Interval: 1},
+			want:       &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: api.AbsoluteYearlyRecurrence, Interval: 1, DayOfMonth: 8, Month: 7}, Range: noEnd},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.recurrence.Graph(start); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Recurrence.Graph() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResult_Apply(t *testing.T) {
+	monthly := &Recurrence{Unit: Monthly, Interval: 1}
+	flagTime := datetime.GraphTime(nextFriday)
+
+	tests := []struct {
+		name        string
+		result      Result
+		task        api.TodoTask
+		reminderSet bool
+		dueDateSet  bool
+		wantDue     *time.Time
+		wantDay     int
+	}{
+		{name: "reminder and due date", result: Result{Reminder: p(tomorrow9am), DueDate: p(nextFriday)}, wantDue: p(nextFriday)},
+		{name: "flags are kept", result: Result{DueDate: p(tomorrow)}, task: api.TodoTask{DueDateTime: &flagTime}, dueDateSet: true, wantDue: p(nextFriday)},
+		{name: "recurrence starts on the due date", result: Result{DueDate: p(nextFriday), Recurrence: monthly}, wantDue: p(nextFriday), wantDay: 16},
+		{name: "recurrence starts on the reminder's day", result: Result{Reminder: p(tomorrow9am), Recurrence: monthly}, wantDue: p(tomorrow), wantDay: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			if err := tt.result.Apply(&task, tt.reminderSet, tt.dueDateSet); err != nil {
+				t.Fatalf("Result.Apply() error = %v", err)
+			}
+
+			if tt.result.Reminder != nil && !task.IsReminderOn {
+				t.Errorf("Result.Apply() didn't turn the reminder on")
+			}
+			if task.DueDateTime == nil || !time.Time(*task.DueDateTime).Equal(*tt.wantDue) {
+				t.Errorf("Result.Apply() due date = %v, want %v", task.DueDateTime, tt.wantDue)
+			}
+			if tt.wantDay != 0 && (task.Recurrence == nil || task.Recurrence.Pattern.DayOfMonth != tt.wantDay) {
+				t.Errorf("Result.Apply() recurrence = %+v, want day %d", task.Recurrence, tt.wantDay)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package quickadd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+)
+
+// Recurrence units
+const (
+	Daily   = "day"
+	Weekly  = "week"
+	Monthly = "month"
+	Yearly  = "year"
+)
+
+var recurrenceTypes = map[string]string{
+	Daily:   api.DailyRecurrence,
+	Weekly:  api.WeeklyRecurrence,
+	Monthly: api.AbsoluteMonthlyRecurrence,
+	Yearly:  api.AbsoluteYearlyRecurrence,
+}
+
+var workWeek = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Recurrence is how often a task repeats, like "every 2 weeks"
+type Recurrence struct {
+	// Unit is Daily, Weekly, Monthly or Yearly
+	Unit string
+
+	// Interval is the number of units between occurrences
+	Interval int
+
+	// Weekdays are the days of weekly recurrences. If empty, the task repeats
+	// on the weekday it starts.
+	Weekdays []time.Weekday
+}
+
+func (r *Recurrence) String() string {
+	if len(r.Weekdays) > 0 && r.Interval == 1 {
+		if len(r.Weekdays) == len(workWeek) && r.Weekdays[0] == time.Monday && r.Weekdays[4] == time.Friday {
+			return "every weekday"
+		}
+
+		names := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			names[i] = day.String()
+		}
+		return "every " + strings.Join(names, ", ")
+	}
+
+	if r.Interval == 1 {
+		return "every " + r.Unit
+	}
+	return fmt.Sprintf("every %d %ss", r.Interval, r.Unit)
+}
+
+// Graph converts the recurrence to a Graph recurrence, which starts on start
+// and doesn't end
+func (r *Recurrence) Graph(start time.Time) *api.PatternedRecurrence {
+	pattern := api.RecurrencePattern{
+		Type:     recurrenceTypes[r.Unit],
+		Interval: r.Interval,
+	}
+
+	switch r.Unit {
+	case Weekly:
+		weekdays := r.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+		for _, day := range weekdays {
+			pattern.DaysOfWeek = append(pattern.DaysOfWeek, strings.ToLower(day.String()))
+		}
+		// Graph requires the first day of the week for weekly patterns
+		pattern.FirstDayOfWeek = "sunday"
+
+	case Monthly:
+		pattern.DayOfMonth = start.Day()
+
+	case Yearly:
+		pattern.DayOfMonth = start.Day()
+		pattern.Month = int(start.Month())
+	}
+
+	return &api.PatternedRecurrence{
+		Pattern: pattern,
+		Range: api.RecurrenceRange{
+			Type:      "noEnd",
+			StartDate: start.Format("2006-01-02"),
+		},
+	}
+}
+
+// extractRecurrence extracts "every [n|other] day|week|month|year[s]",
+// "every weekday" and "every monday [and thursday]"
+func (res *Result) extractRecurrence(tokens []token) ([]token, error) {
+	for i, t := range tokens {
+		if t.literal || strings.ToLower(t.text) != "every" {
+			continue
+		}
+
+		recurrence, n := parseRecurrence(tokens[i+1:])
+		if recurrence == nil {
+			continue
+		}
+
+		if res.Recurrence != nil {
+			return nil, fmt.Errorf("the recurrence is given twice, as '%s' and '%s'", res.Recurrence, recurrence)
+		}
+		res.Recurrence = recurrence
+
+		remaining := append([]token{}, tokens[:i]...)
+		remaining = append(remaining, tokens[i+1+n:]...)
+		return res.extractRecurrence(remaining)
+	}
+
+	return tokens, nil
+}
+
+// parseRecurrence parses the words after "every", returning the recurrence
+// and the number of words used, or nil if the words aren't a recurrence
+func parseRecurrence(tokens []token) (*Recurrence, int) {
+	words := []string{}
+	for _, t := range tokens {
+		if t.literal {
+			break
+		}
+		words = append(words, strings.ToLower(t.text))
+	}
+
+	if len(words) == 0 {
+		return nil, 0
+	}
+
+	if words[0] == "weekday" || words[0] == "weekdays" {
+		return &Recurrence{Unit: Weekly, Interval: 1, Weekdays: workWeek}, 1
+	}
+
+	if weekdays, n := parseWeekdays(words); n > 0 {
+		return &Recurrence{Unit: Weekly, Interval: 1, Weekdays: weekdays}, n
+	}
+
+	interval, n := 1, 0
+	if words[0] == "other" {
+		interval, n = 2, 1
+	} else if i, err := strconv.Atoi(words[0]); err == nil && i > 0 {
+		interval, n = i, 1
+	}
+
+	if n >= len(words) {
+		return nil, 0
+	}
+
+	unit := strings.TrimSuffix(words[n], "s")
+	if _, ok := recurrenceTypes[unit]; !ok {
+		return nil, 0
+	}
+
+	return &Recurrence{Unit: unit, Interval: interval}, n + 1
+}
+
+// parseWeekdays parses weekday names separated by commas, slashes or "and",
+// like "mon, wed and fri", returning the number of words used
+func parseWeekdays(words []string) ([]time.Weekday, int) {
+	weekdays := []time.Weekday{}
+	used := 0
+
+	for i, word := range words {
+		if word == "and" || word == "," {
+			continue
+		}
+
+		var days []time.Weekday
+		for _, part := range strings.FieldsFunc(word, func(r rune) bool { return r == ',' || r == '/' }) {
+			day, ok := parseWeekday(part)
+			if !ok {
+				days = nil
+				break
+			}
+			days = append(days, day)
+		}
+
+		if len(days) == 0 {
+			break
+		}
+
+		weekdays = append(weekdays, days...)
+		used = i + 1
+	}
+
+	return weekdays, used
+}
+
+// parseWeekday parses an English weekday name or its abbreviation, like "mon"
+// or "thurs"
+func parseWeekday(word string) (time.Weekday, bool) {
+	word = strings.TrimSuffix(word, "s")
+	if len(word) < 3 {
+		return 0, false
+	}
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if strings.HasPrefix(name, word) {
+			return day, true
+		}
+	}
+	return 0, false
+}
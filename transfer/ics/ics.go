@@ -0,0 +1,489 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package ics reads and writes tasks as the VTODO components of an iCalendar
+// file, from RFC 5545:
+//
+//	BEGIN:VTODO
+//	UID:AAMk...
+//	SUMMARY:Write report
+//	DUE;VALUE=DATE:20211001
+//	PRIORITY:1
+//	CATEGORIES:Work,Red
+//	RRULE:FREQ=WEEKLY;BYDAY=FR;WKST=SU
+//	X-MSTODO-LIST:Work
+//	END:VTODO
+//
+// The UID is the task's ID, so that importing the file again updates the tasks
+// instead of adding them. The reminder is a VALARM, and the notes are the
+// DESCRIPTION.
+package ics
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/dalyisaac/mstodo/utils"
+)
+
+// Fields are the fields which iCalendar keeps
+const Fields = transfer.Title | transfer.Importance | transfer.Status | transfer.DueDate | transfer.Completed |
+	transfer.Categories | transfer.Reminder | transfer.Recurrence | transfer.StartDate | transfer.Body
+
+const (
+	dateLayout    = "20060102"
+	timeLayout    = "20060102T150405"
+	utcTimeLayout = "20060102T150405Z"
+
+	// defaultCalendarName is the name of calendars with the tasks of several
+	// lists
+	defaultCalendarName = "Microsoft To Do"
+
+	// listProperty is the property with the name of the task's list
+	listProperty = "X-MSTODO-LIST"
+
+	// statusProperty is the property with the statuses which VTODOs don't have
+	statusProperty = "X-MSTODO-STATUS"
+)
+
+// statuses are the VTODO statuses of Graph's. The others are written as
+// NEEDS-ACTION with an X-MSTODO-STATUS.
+var statuses = map[api.GraphStatus]string{
+	"not started": "NEEDS-ACTION",
+	"in progress": "IN-PROCESS",
+	"completed":   "COMPLETED",
+}
+
+// priorities are the PRIORITY values of the importances. Normal importance has
+// no priority.
+var priorities = map[string]int{"high": 1, "low": 9}
+
+// Encode writes the tasks as an iCalendar file. The dates are written in the
+// time zone of the tasks' times. The calendar is named after the tasks' list,
+// if they're all in the same one.
+func Encode(w io.Writer, tasks []transfer.Task) error {
+	return encode(w, tasks, time.Now())
+}
+
+func encode(w io.Writer, tasks []transfer.Task, now time.Time) error {
+	lw := &lineWriter{w: w}
+
+	lw.write("BEGIN", "VCALENDAR")
+	lw.write("VERSION", "2.0")
+	lw.write("PRODID", "-//mstodo//mstodo//EN")
+	lw.write("CALSCALE", "GREGORIAN")
+	lw.write("X-WR-CALNAME", escapeText(calendarName(tasks)))
+	for _, task := range tasks {
+		encodeTask(lw, task, now)
+	}
+	lw.write("END", "VCALENDAR")
+
+	return lw.err
+}
+
+// encodeTask writes the VTODO of the task
+func encodeTask(lw *lineWriter, task transfer.Task, now time.Time) {
+	lw.write("BEGIN", "VTODO")
+
+	// UIDs are required, so tasks without an ID get one from their title
+	uid := task.Id
+	if uid == "" {
+		uid = fmt.Sprintf("%x@mstodo", sha1.Sum([]byte(task.List+"\n"+task.Title)))
+	}
+	lw.write("UID", escapeText(uid))
+	lw.write("DTSTAMP", now.UTC().Format(utcTimeLayout))
+	if !task.CreatedDateTime.IsZero() {
+		lw.write("CREATED", task.CreatedDateTime.UTC().Format(utcTimeLayout))
+	}
+	if !task.LastModifiedDateTime.IsZero() {
+		lw.write("LAST-MODIFIED", task.LastModifiedDateTime.UTC().Format(utcTimeLayout))
+	}
+
+	lw.write("SUMMARY", escapeText(task.Title))
+	if body := transfer.BodyText(task.Body); body != "" {
+		lw.write("DESCRIPTION", escapeText(body))
+	}
+	if p, ok := priorities[task.Importance]; ok {
+		lw.write("PRIORITY", strconv.Itoa(p))
+	}
+
+	if status, ok := statuses[task.Status]; ok {
+		lw.write("STATUS", status)
+	} else if task.Status != "" {
+		lw.write("STATUS", statuses["not started"])
+		lw.write(statusProperty, strings.ReplaceAll(string(task.Status), " ", "-"))
+	}
+	if task.Status == "completed" && task.Completed != nil {
+		lw.write("COMPLETED", time.Time(*task.Completed).UTC().Format(utcTimeLayout))
+	}
+
+	if task.StartDateTime != nil {
+		lw.write("DTSTART;VALUE=DATE", time.Time(*task.StartDateTime).Format(dateLayout))
+	}
+	if task.DueDateTime != nil {
+		lw.write("DUE;VALUE=DATE", time.Time(*task.DueDateTime).Format(dateLayout))
+	}
+
+	if len(task.Categories) > 0 {
+		categories := []string{}
+		for _, category := range task.Categories {
+			categories = append(categories, escapeText(category))
+		}
+		lw.write("CATEGORIES", strings.Join(categories, ","))
+	}
+	if task.List != "" {
+		lw.write(listProperty, escapeText(task.List))
+	}
+	if task.Recurrence != nil {
+		if rule, ok := encodeRecurrence(task.Recurrence); ok {
+			lw.write("RRULE", rule)
+		}
+	}
+
+	if task.IsReminderOn && task.ReminderDateTime != nil {
+		lw.write("BEGIN", "VALARM")
+		lw.write("ACTION", "DISPLAY")
+		lw.write("DESCRIPTION", escapeText(task.Title))
+		lw.write("TRIGGER;VALUE=DATE-TIME", time.Time(*task.ReminderDateTime).UTC().Format(utcTimeLayout))
+		lw.write("END", "VALARM")
+	}
+
+	lw.write("END", "VTODO")
+}
+
+// calendarName returns the list of the tasks if they're all in the same one
+func calendarName(tasks []transfer.Task) string {
+	if len(tasks) == 0 || tasks[0].List == "" {
+		return defaultCalendarName
+	}
+	for _, task := range tasks {
+		if task.List != tasks[0].List {
+			return defaultCalendarName
+		}
+	}
+	return tasks[0].List
+}
+
+// component is a VTODO's properties and the properties of its VALARMs
+type component struct {
+	properties []property
+	alarms     [][]property
+}
+
+// Decode reads the tasks from the VTODOs of an iCalendar file. The other
+// components, like VEVENTs, are skipped. Dates and times without a time zone
+// are in loc, and so are the times in time zones which aren't known, which are
+// counted in report.
+func Decode(r io.Reader, loc *time.Location, report transfer.Report) ([]transfer.Task, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 && !strings.EqualFold(lines[0].text, "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("the file isn't an iCalendar file, which starts with BEGIN:VCALENDAR")
+	}
+
+	tasks := []transfer.Task{}
+	var todo *component
+	var alarm []property
+	for _, line := range lines {
+		p, err := parseProperty(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.n, err)
+		}
+
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VTODO"):
+			todo = &component{}
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VALARM") && todo != nil:
+			alarm = []property{}
+		case p.name == "END" && strings.EqualFold(p.value, "VALARM") && alarm != nil:
+			todo.alarms = append(todo.alarms, alarm)
+			alarm = nil
+		case p.name == "END" && strings.EqualFold(p.value, "VTODO") && todo != nil:
+			task, err := decodeTask(todo, loc, report)
+			if err != nil {
+				return nil, fmt.Errorf("the VTODO ending on line %d: %w", line.n, err)
+			}
+			tasks = append(tasks, *task)
+			todo = nil
+		case alarm != nil:
+			alarm = append(alarm, p)
+		case todo != nil:
+			todo.properties = append(todo.properties, p)
+		}
+	}
+
+	return tasks, nil
+}
+
+// decodeTask returns the task of a VTODO. Dates and times without a time zone
+// are in loc, and the time zones which aren't known are counted in report.
+func decodeTask(todo *component, loc *time.Location, report transfer.Report) (*transfer.Task, error) {
+	task := &transfer.Task{TodoTask: api.TodoTask{Status: "not started", Importance: "normal"}}
+
+	// The unknown time zones are counted once for each task
+	unknownZones := map[string]bool{}
+	defer func() {
+		for tzid := range unknownZones {
+			report.Add(fmt.Sprintf("time zone %q, read as %s", tzid, loc))
+		}
+	}()
+
+	var start, due *time.Time
+	var rule *property
+	var status, otherStatus string
+	for i, p := range todo.properties {
+		switch p.name {
+		case "UID":
+			task.Id = unescapeText(p.value)
+		case "SUMMARY":
+			task.Title = strings.TrimSpace(unescapeText(p.value))
+		case "DESCRIPTION":
+			if text := strings.TrimSpace(unescapeText(p.value)); text != "" {
+				task.Body = &api.ItemBody{Content: text, ContentType: "text"}
+			}
+		case "PRIORITY":
+			n, err := strconv.Atoi(p.value)
+			if err != nil || n < 0 || n > 9 {
+				return nil, fmt.Errorf("PRIORITY:%s isn't a number from 0 to 9", p.value)
+			}
+			task.Importance = importance(n)
+		case "STATUS":
+			status = strings.ToUpper(p.value)
+		case statusProperty:
+			otherStatus = strings.ReplaceAll(p.value, "-", " ")
+		case "COMPLETED":
+			t, err := parseTime(p, loc, unknownZones)
+			if err != nil {
+				return nil, err
+			}
+			task.Completed = (*datetime.GraphTime)(&t)
+		case "DTSTART", "DUE":
+			t, err := parseTime(p, loc, unknownZones)
+			if err != nil {
+				return nil, err
+			}
+			if p.name == "DTSTART" {
+				start = &t
+				task.StartDateTime = day(t, loc)
+			} else {
+				due = &t
+				task.DueDateTime = day(t, loc)
+			}
+		case "CATEGORIES":
+			for _, category := range splitList(p.value) {
+				if category = strings.TrimSpace(category); category != "" {
+					task.Categories = append(task.Categories, category)
+				}
+			}
+		case listProperty:
+			task.List = strings.TrimSpace(unescapeText(p.value))
+		case "RRULE":
+			rule = &todo.properties[i]
+		}
+	}
+
+	if task.Title == "" {
+		return nil, fmt.Errorf("the task has no SUMMARY")
+	}
+
+	switch status {
+	case "COMPLETED":
+		task.Status = "completed"
+	case "IN-PROCESS":
+		task.Status = "in progress"
+	case "":
+		// Tasks with a completion time are done, even without a status
+		if task.Completed != nil {
+			task.Status = "completed"
+		}
+	}
+	if otherStatus != "" && task.Status == "not started" {
+		if !utils.ContainsString(api.GraphStatusOptions, otherStatus) {
+			return nil, fmt.Errorf("'%s' is not a valid %s - choices: [%s]", otherStatus, statusProperty, strings.Join(api.GraphStatusOptions, ", "))
+		}
+		task.Status = api.GraphStatus(otherStatus)
+	}
+	if task.Status != "completed" {
+		task.Completed = nil
+	}
+
+	if rule != nil {
+		first := start
+		if first == nil {
+			first = due
+		}
+		if first == nil {
+			return nil, fmt.Errorf("RRULE needs a DTSTART or DUE date to start from")
+		}
+
+		recurrence, err := decodeRecurrence(rule.value, first.In(loc))
+		if err != nil {
+			return nil, fmt.Errorf("RRULE: %w", err)
+		}
+		task.Recurrence = recurrence
+	}
+
+	for _, alarm := range todo.alarms {
+		reminder, ok, err := decodeAlarm(alarm, start, due, loc, unknownZones)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			task.ReminderDateTime = (*datetime.GraphTime)(&reminder)
+			task.IsReminderOn = true
+			break
+		}
+	}
+
+	return task, nil
+}
+
+// decodeAlarm returns the time of a VALARM. Its TRIGGER is either a time, or a
+// duration before or after the task's start or due time. It's false if the
+// alarm has no time. The time zones which aren't known are added to
+// unknownZones.
+func decodeAlarm(alarm []property, start *time.Time, due *time.Time, loc *time.Location, unknownZones map[string]bool) (time.Time, bool, error) {
+	for _, p := range alarm {
+		if p.name != "TRIGGER" {
+			continue
+		}
+
+		if p.param("VALUE") == "DATE-TIME" {
+			t, err := parseTime(p, loc, unknownZones)
+			return t, err == nil, err
+		}
+
+		d, err := parseDuration(p.value)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		// Triggers are relative to the start unless they say otherwise
+		related := start
+		if p.param("RELATED") == "END" || related == nil {
+			related = due
+		}
+		if related == nil {
+			return time.Time{}, false, nil
+		}
+		return related.Add(d), true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
+// importance returns the importance of a PRIORITY. 1 is the highest, 9 is the
+// lowest, and 0 is no priority.
+func importance(priority int) string {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return "high"
+	case priority >= 6:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// parseTime parses a DATE or DATE-TIME value. Times without a time zone, and
+// times in unknown time zones, are in loc. TZIDs can be IANA or Windows names,
+// like "Europe/Paris" or "Pacific Standard Time", and the unknown ones are
+// added to unknownZones.
+func parseTime(p property, loc *time.Location, unknownZones map[string]bool) (time.Time, error) {
+	var t time.Time
+	var err error
+	switch {
+	case p.param("VALUE") == "DATE" || len(p.value) == len(dateLayout):
+		t, err = time.ParseInLocation(dateLayout, p.value, loc)
+	case strings.HasSuffix(strings.ToUpper(p.value), "Z"):
+		t, err = time.Parse(utcTimeLayout, strings.ToUpper(p.value))
+	default:
+		tz := loc
+		if tzid := p.params["TZID"]; tzid != "" {
+			if l, err := datetime.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+				tz = l
+			} else {
+				unknownZones[tzid] = true
+			}
+		}
+		t, err = time.ParseInLocation(timeLayout, p.value, tz)
+	}
+
+	if err != nil {
+		return t, fmt.Errorf("%s:%s isn't a date like 20211001 or a time like 20211001T090000Z", p.name, p.value)
+	}
+	return t, nil
+}
+
+// day returns midnight in loc of the day of t in loc
+func day(t time.Time, loc *time.Location) *datetime.GraphTime {
+	y, m, d := t.In(loc).Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	return (*datetime.GraphTime)(&date)
+}
+
+// parseDuration parses a DURATION value, like -PT15M or P1D
+func parseDuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("'%s' isn't a duration like -PT15M", s)
+
+	value := strings.ToUpper(s)
+	sign := time.Duration(1)
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+	}
+	value = strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(value, "P") || len(value) == 1 {
+		return 0, invalid
+	}
+
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	timeUnits := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+
+	var d time.Duration
+	number := ""
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T':
+			units = timeUnits
+		default:
+			unit, ok := units[c]
+			n, err := strconv.Atoi(number)
+			if !ok || err != nil {
+				return 0, invalid
+			}
+			d += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	if number != "" {
+		return 0, invalid
+	}
+
+	return sign * d, nil
+}
@@ -0,0 +1,309 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package ics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+func graphTime(layout string, s string) *datetime.GraphTime {
+	return graphTimeIn(layout, s, time.UTC)
+}
+
+func graphTimeIn(layout string, s string, loc *time.Location) *datetime.GraphTime {
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return (*datetime.GraphTime)(&t)
+}
+
+// calendar returns an iCalendar file with the lines
+func calendar(lines ...string) string {
+	return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n") + "\r\n"
+}
+
+func TestEncode(t *testing.T) {
+	now, _ := time.Parse(utcTimeLayout, "20211005T120000Z")
+	tasks := []transfer.Task{
+		{List: "Work", TodoTask: api.TodoTask{
+			Id:               "AAMk",
+			Title:            "Write report; draft, then final",
+			Importance:       "high",
+			Status:           "not started",
+			DueDateTime:      graphTime(dateLayout, "20211001"),
+			StartDateTime:    graphTime(dateLayout, "20210927"),
+			ReminderDateTime: graphTime(timeLayout, "20210930T090000"),
+			IsReminderOn:     true,
+			Categories:       []string{"Red", "Office"},
+			Body:             &api.ItemBody{Content: "<p>Ask Sam</p><p>Send to Kim</p>", ContentType: "html"},
+			Recurrence: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "weekly", Interval: 2, DaysOfWeek: []string{"monday", "friday"}, FirstDayOfWeek: "sunday"},
+				Range:   api.RecurrenceRange{Type: "endDate", StartDate: "2021-09-27", EndDate: "2021-12-31"},
+			},
+		}},
+		{List: "Work", TodoTask: api.TodoTask{
+			Id:        "AAMl",
+			Title:     "Pay rent",
+			Status:    "completed",
+			Completed: graphTime(timeLayout, "20211002T000000"),
+		}},
+		{List: "Work", TodoTask: api.TodoTask{Id: "AAMm", Title: "Book flights", Importance: "low", Status: "waiting on others"}},
+	}
+
+	want := calendar(
+		"PRODID:-//mstodo//mstodo//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Work",
+		"BEGIN:VTODO",
+		"UID:AAMk",
+		"DTSTAMP:20211005T120000Z",
+		`SUMMARY:Write report\; draft\, then final`,
+		`DESCRIPTION:Ask Sam\nSend to Kim`,
+		"PRIORITY:1",
+		"STATUS:NEEDS-ACTION",
+		"DTSTART;VALUE=DATE:20210927",
+		"DUE;VALUE=DATE:20211001",
+		"CATEGORIES:Red,Office",
+		"X-MSTODO-LIST:Work",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;WKST=SU;UNTIL=20211231",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		`DESCRIPTION:Write report\; draft\, then final`,
+		"TRIGGER;VALUE=DATE-TIME:20210930T090000Z",
+		"END:VALARM",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:AAMl",
+		"DTSTAMP:20211005T120000Z",
+		"SUMMARY:Pay rent",
+		"STATUS:COMPLETED",
+		"COMPLETED:20211002T000000Z",
+		"X-MSTODO-LIST:Work",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:AAMm",
+		"DTSTAMP:20211005T120000Z",
+		"SUMMARY:Book flights",
+		"PRIORITY:9",
+		"STATUS:NEEDS-ACTION",
+		"X-MSTODO-STATUS:waiting-on-others",
+		"X-MSTODO-LIST:Work",
+		"END:VTODO",
+	)
+
+	var b strings.Builder
+	if err := encode(&b, tasks, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("encode() = %v, want %v", got, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		in         string
+		want       []transfer.Task
+		wantReport transfer.Report
+		wantErr    string
+	}{
+		{
+			name: "task",
+			in: calendar(
+				"BEGIN:VTODO",
+				"UID:AAMk",
+				"SUMMARY:Write report\\, then",
+				"  send it",
+				"DESCRIPTION:Ask Sam\\nSend to Kim",
+				"PRIORITY:2",
+				"STATUS:IN-PROCESS",
+				"DTSTART:20210927T100000",
+				"DUE;TZID=America/New_York:20211001T230000",
+				"CATEGORIES:Red,Office",
+				"CATEGORIES:Big\\, urgent",
+				"X-MSTODO-LIST:Work",
+				"RRULE:FREQ=DAILY;INTERVAL=3;COUNT=5",
+				"BEGIN:VALARM",
+				"TRIGGER;RELATED=END:-PT1H30M",
+				"END:VALARM",
+				"END:VTODO",
+			),
+			want: []transfer.Task{{List: "Work", TodoTask: api.TodoTask{
+				Id:               "AAMk",
+				Title:            "Write report, then send it",
+				Body:             &api.ItemBody{Content: "Ask Sam\nSend to Kim", ContentType: "text"},
+				Importance:       "high",
+				Status:           "in progress",
+				StartDateTime:    graphTimeIn(dateLayout, "20210927", paris),
+				DueDateTime:      graphTimeIn(dateLayout, "20211002", paris),
+				ReminderDateTime: graphTime(timeLayout, "20211002T013000"),
+				IsReminderOn:     true,
+				Categories:       []string{"Red", "Office", "Big, urgent"},
+				Recurrence: &api.PatternedRecurrence{
+					Pattern: api.RecurrencePattern{Type: "daily", Interval: 3},
+					Range:   api.RecurrenceRange{Type: "numbered", StartDate: "2021-09-27", NumberOfOccurrences: 5},
+				},
+			}}},
+		},
+		{
+			name: "completed without a status, and events are skipped",
+			in: calendar(
+				"BEGIN:VEVENT",
+				"SUMMARY:Meeting",
+				"END:VEVENT",
+				"BEGIN:VTODO",
+				"SUMMARY:Pay rent",
+				"PRIORITY:7",
+				"COMPLETED:20211002T080000Z",
+				"X-MSTODO-STATUS:deferred",
+				"END:VTODO",
+			),
+			want: []transfer.Task{{TodoTask: api.TodoTask{
+				Title:      "Pay rent",
+				Importance: "low",
+				Status:     "completed",
+				Completed:  graphTime(utcTimeLayout, "20211002T080000Z"),
+			}}},
+		},
+		{
+			name: "other status",
+			in:   calendar("BEGIN:VTODO", "SUMMARY:Book flights", "X-MSTODO-STATUS:waiting-on-others", "END:VTODO"),
+			want: []transfer.Task{{TodoTask: api.TodoTask{Title: "Book flights", Importance: "normal", Status: "waiting on others"}}},
+		},
+		{
+			name: "windows and unknown time zones",
+			in: calendar(
+				"BEGIN:VTODO",
+				"SUMMARY:Call Sam",
+				"DTSTART;TZID=Nowhere/Nothing:20210927T100000",
+				"DUE;TZID=Pacific Standard Time:20211001T230000",
+				"BEGIN:VALARM",
+				"TRIGGER;VALUE=DATE-TIME;TZID=Pacific Standard Time:20211001T090000",
+				"END:VALARM",
+				"END:VTODO",
+			),
+			want: []transfer.Task{{TodoTask: api.TodoTask{
+				Title:            "Call Sam",
+				Importance:       "normal",
+				Status:           "not started",
+				StartDateTime:    graphTimeIn(dateLayout, "20210927", paris),
+				DueDateTime:      graphTimeIn(dateLayout, "20211002", paris),
+				ReminderDateTime: graphTime(utcTimeLayout, "20211001T160000Z"),
+				IsReminderOn:     true,
+			}}},
+			wantReport: transfer.Report{`time zone "Nowhere/Nothing", read as Europe/Paris`: 1},
+		},
+		{name: "empty", in: "", want: []transfer.Task{}},
+		{name: "not a calendar", in: "Pay rent\n", wantErr: "the file isn't an iCalendar file"},
+		{name: "no summary", in: calendar("BEGIN:VTODO", "UID:AAMk", "END:VTODO"), wantErr: "the VTODO ending on line 5: the task has no SUMMARY"},
+		{name: "invalid due date", in: calendar("BEGIN:VTODO", "SUMMARY:Pay rent", "DUE:tomorrow", "END:VTODO"), wantErr: "DUE:tomorrow isn't a date"},
+		{name: "invalid status", in: calendar("BEGIN:VTODO", "SUMMARY:Pay rent", "X-MSTODO-STATUS:done", "END:VTODO"), wantErr: "'done' is not a valid X-MSTODO-STATUS"},
+		{name: "rule without a date", in: calendar("BEGIN:VTODO", "SUMMARY:Pay rent", "RRULE:FREQ=DAILY", "END:VTODO"), wantErr: "RRULE needs a DTSTART or DUE date"},
+		{name: "invalid line", in: calendar("BEGIN:VTODO", "Pay rent", "END:VTODO"), wantErr: "line 4: 'Pay rent' isn't a content line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := transfer.Report{}
+			got, err := Decode(strings.NewReader(tt.in), paris, report)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Decode() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !sameTask(got[i], tt.want[i]) {
+					t.Errorf("Decode()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+			if tt.wantReport == nil {
+				tt.wantReport = transfer.Report{}
+			}
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("Decode() report = %v, want %v", report, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	task := transfer.Task{List: "Home", TodoTask: api.TodoTask{
+		Id:            "AAMk",
+		Title:         strings.Repeat("Water the plants ", 8),
+		Importance:    "normal",
+		Status:        "not started",
+		DueDateTime:   graphTime(dateLayout, "20211001"),
+		StartDateTime: graphTime(dateLayout, "20211001"),
+		Categories:    []string{"Garden"},
+		Recurrence: &api.PatternedRecurrence{
+			Pattern: api.RecurrencePattern{Type: "absoluteYearly", Interval: 1, DayOfMonth: 1, Month: 10},
+			Range:   api.RecurrenceRange{Type: "noEnd", StartDate: "2021-10-01"},
+		},
+	}}
+
+	var b strings.Builder
+	if err := Encode(&b, []transfer.Task{task}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(strings.NewReader(b.String()), time.UTC, transfer.Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.Title = strings.TrimSpace(task.Title)
+	if len(got) != 1 || !sameTask(got[0], task) {
+		t.Errorf("Decode(Encode()) = %+v, want %+v", got, task)
+	}
+}
+
+// sameTask reports whether a and b are the same, with their times at the same
+// instants
+func sameTask(a transfer.Task, b transfer.Task) bool {
+	times := func(task *transfer.Task) []*datetime.GraphTime {
+		ts := []*datetime.GraphTime{task.DueDateTime, task.StartDateTime, task.ReminderDateTime, task.Completed}
+		task.DueDateTime, task.StartDateTime, task.ReminderDateTime, task.Completed = nil, nil, nil, nil
+		return ts
+	}
+
+	at, bt := times(&a), times(&b)
+	for i := range at {
+		if (at[i] == nil) != (bt[i] == nil) || at[i] != nil && !time.Time(*at[i]).Equal(time.Time(*bt[i])) {
+			return false
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineLength is the most octets of a content line, without the CRLF
+const maxLineLength = 75
+
+// property is a content line, like DUE;VALUE=DATE:20211001
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// param returns the parameter called name, upper-cased
+func (p property) param(name string) string {
+	return strings.ToUpper(p.params[name])
+}
+
+// lineWriter writes content lines, folding the long ones
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+// write writes the content line name:value. value must already be escaped if
+// it's text.
+func (lw *lineWriter) write(name string, value string) {
+	if lw.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+	var b strings.Builder
+	for n := 0; len(line) > 0; n++ {
+		// Continuation lines start with a space, which counts as an octet
+		size := maxLineLength
+		if n > 0 {
+			b.WriteString(" ")
+			size--
+		}
+
+		end := len(line)
+		if end > size {
+			end = size
+			for end > 0 && !utf8.RuneStart(line[end]) {
+				end--
+			}
+		}
+		b.WriteString(line[:end])
+		b.WriteString("\r\n")
+		line = line[end:]
+	}
+
+	_, lw.err = io.WriteString(lw.w, b.String())
+}
+
+// contentLine is an unfolded content line, and the number of its first line
+type contentLine struct {
+	n    int
+	text string
+}
+
+// readLines returns the unfolded content lines of r
+func readLines(r io.Reader) ([]contentLine, error) {
+	lines := []contentLine{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if n == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, contentLine{n: n, text: text})
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty parses a content line. Parameter values can be quoted, so that
+// they can have ';', ':' and ','.
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+
+	// The name ends at the first ';' or ':'
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return p, fmt.Errorf("'%s' isn't a content line like NAME:value", line)
+	}
+	p.name = strings.ToUpper(line[:end])
+	rest := line[end:]
+
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			return p, fmt.Errorf("the parameter of %s has no value", p.name)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quote := strings.Index(rest[1:], `"`)
+			if quote < 0 {
+				return p, fmt.Errorf("the %s parameter of %s has no closing quote", name, p.name)
+			}
+			value = rest[1 : quote+1]
+			rest = rest[quote+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return p, fmt.Errorf("%s has no value", p.name)
+			}
+			value = rest[:end]
+			rest = rest[end:]
+		}
+		p.params[name] = value
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return p, fmt.Errorf("%s has no value", p.name)
+	}
+	p.value = rest[1:]
+	return p, nil
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// unescapeText unescapes a TEXT value
+func unescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitList splits a list of TEXT values at the unescaped commas, and unescapes
+// them
+func splitList(s string) []string {
+	values := []string{}
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, unescapeText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescapeText(s[start:]))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package ics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLineWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "short", value: "Pay rent"},
+		{name: "long", value: strings.Repeat("abcdefghij", 20)},
+		{name: "multi-byte", value: strings.Repeat("Zahlung für Miete ✓ ", 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			lw := &lineWriter{w: &b}
+			lw.write("SUMMARY", tt.value)
+			if lw.err != nil {
+				t.Fatal(lw.err)
+			}
+
+			out := b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Errorf("write() = %q, want a CRLF at the end", out)
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+				if len(line) > maxLineLength || !utf8.ValidString(line) {
+					t.Errorf("write() line %q has %d octets", line, len(line))
+				}
+			}
+
+			lines, err := readLines(strings.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 1 || lines[0].text != "SUMMARY:"+tt.value {
+				t.Errorf("readLines(write()) = %q, want %q", lines, "SUMMARY:"+tt.value)
+			}
+		})
+	}
+}
+
+func TestParseProperty(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    property
+		wantErr bool
+	}{
+		{
+			name: "value",
+			line: "summary:Pay rent: today",
+			want: property{name: "SUMMARY", params: map[string]string{}, value: "Pay rent: today"},
+		},
+		{
+			name: "params",
+			line: `DUE;TZID="GMT+1:00; Paris";VALUE=DATE-TIME:20211001T090000`,
+			want: property{name: "DUE", params: map[string]string{"TZID": "GMT+1:00; Paris", "VALUE": "DATE-TIME"}, value: "20211001T090000"},
+		},
+		{name: "no value", line: "SUMMARY", wantErr: true},
+		{name: "param without a value", line: "DUE;VALUE:20211001", wantErr: true},
+		{name: "unclosed quote", line: `DUE;TZID="Paris:20211001`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProperty(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProperty() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProperty() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	s := "Call Sam; Kim, then\nwrite C:\\notes"
+	escaped := `Call Sam\; Kim\, then\nwrite C:\\notes`
+
+	if got := escapeText(s); got != escaped {
+		t.Errorf("escapeText() = %q, want %q", got, escaped)
+	}
+	if got := unescapeText(escaped); got != s {
+		t.Errorf("unescapeText() = %q, want %q", got, s)
+	}
+	if got, want := splitList(`Red,Big\, urgent,C:\\`), []string{"Red", "Big, urgent", `C:\`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitList() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package ics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+)
+
+// weekdays are the RRULE weekdays of Graph's
+var weekdays = map[string]string{
+	"sunday":    "SU",
+	"monday":    "MO",
+	"tuesday":   "TU",
+	"wednesday": "WE",
+	"thursday":  "TH",
+	"friday":    "FR",
+	"saturday":  "SA",
+}
+
+// encodeRecurrence returns the RRULE value of r. It's false if r's pattern
+// can't be written, like the relative monthly patterns.
+func encodeRecurrence(r *api.PatternedRecurrence) (string, bool) {
+	parts := []string{}
+
+	switch r.Pattern.Type {
+	case api.DailyRecurrence:
+		parts = append(parts, "FREQ=DAILY")
+	case api.WeeklyRecurrence:
+		parts = append(parts, "FREQ=WEEKLY")
+	case api.AbsoluteMonthlyRecurrence:
+		parts = append(parts, "FREQ=MONTHLY")
+	case api.AbsoluteYearlyRecurrence:
+		parts = append(parts, "FREQ=YEARLY")
+	default:
+		return "", false
+	}
+
+	if r.Pattern.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Pattern.Interval))
+	}
+
+	switch r.Pattern.Type {
+	case api.WeeklyRecurrence:
+		days := []string{}
+		for _, day := range r.Pattern.DaysOfWeek {
+			if d, ok := weekdays[strings.ToLower(day)]; ok {
+				days = append(days, d)
+			}
+		}
+		if len(days) > 0 {
+			parts = append(parts, "BYDAY="+strings.Join(days, ","))
+		}
+		if d, ok := weekdays[strings.ToLower(r.Pattern.FirstDayOfWeek)]; ok {
+			parts = append(parts, "WKST="+d)
+		}
+	case api.AbsoluteMonthlyRecurrence:
+		if r.Pattern.DayOfMonth > 0 {
+			parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.Pattern.DayOfMonth))
+		}
+	case api.AbsoluteYearlyRecurrence:
+		if r.Pattern.Month > 0 {
+			parts = append(parts, "BYMONTH="+strconv.Itoa(r.Pattern.Month))
+		}
+		if r.Pattern.DayOfMonth > 0 {
+			parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.Pattern.DayOfMonth))
+		}
+	}
+
+	switch r.Range.Type {
+	case "endDate":
+		if r.Range.EndDate != "" {
+			parts = append(parts, "UNTIL="+strings.ReplaceAll(r.Range.EndDate, "-", ""))
+		}
+	case "numbered":
+		if r.Range.NumberOfOccurrences > 0 {
+			parts = append(parts, "COUNT="+strconv.Itoa(r.Range.NumberOfOccurrences))
+		}
+	}
+
+	return strings.Join(parts, ";"), true
+}
+
+// decodeRecurrence returns the recurrence of an RRULE value, which starts on
+// the day of start
+func decodeRecurrence(value string, start time.Time) (*api.PatternedRecurrence, error) {
+	r := &api.PatternedRecurrence{
+		Pattern: api.RecurrencePattern{Interval: 1},
+		Range:   api.RecurrenceRange{Type: "noEnd", StartDate: start.Format("2006-01-02")},
+	}
+
+	rule := map[string]string{}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("'%s' isn't a rule part like FREQ=DAILY", part)
+		}
+		rule[strings.ToUpper(kv[0])] = strings.ToUpper(kv[1])
+	}
+
+	var days []string
+	if byDay, ok := rule["BYDAY"]; ok {
+		for _, day := range strings.Split(byDay, ",") {
+			d, err := weekday(day)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, d)
+		}
+	}
+
+	switch rule["FREQ"] {
+	case "DAILY":
+		// Daily on some days, like weekdays, is weekly on those days
+		r.Pattern.Type = api.DailyRecurrence
+		if days != nil {
+			r.Pattern.Type = api.WeeklyRecurrence
+		}
+	case "WEEKLY":
+		r.Pattern.Type = api.WeeklyRecurrence
+		if days == nil {
+			days = []string{strings.ToLower(start.Weekday().String())}
+		}
+	case "MONTHLY":
+		r.Pattern.Type = api.AbsoluteMonthlyRecurrence
+	case "YEARLY":
+		r.Pattern.Type = api.AbsoluteYearlyRecurrence
+	case "":
+		return nil, fmt.Errorf("the rule has no FREQ")
+	default:
+		return nil, fmt.Errorf("FREQ=%s can't be a Microsoft To Do recurrence", rule["FREQ"])
+	}
+
+	for key, v := range rule {
+		var err error
+		switch key {
+		case "FREQ", "BYDAY":
+		case "INTERVAL":
+			r.Pattern.Interval, err = positive(key, v)
+		case "BYMONTHDAY":
+			r.Pattern.DayOfMonth, err = positive(key, v)
+		case "BYMONTH":
+			r.Pattern.Month, err = positive(key, v)
+		case "WKST":
+			r.Pattern.FirstDayOfWeek, err = weekday(v)
+		case "UNTIL":
+			// UNTIL can be a date or a date-time, and only the day is kept
+			until, parseErr := time.Parse("20060102", strings.SplitN(v, "T", 2)[0])
+			if parseErr != nil {
+				err = fmt.Errorf("UNTIL=%s isn't a date like 20211231", v)
+			}
+			r.Range.Type = "endDate"
+			r.Range.EndDate = until.Format("2006-01-02")
+		case "COUNT":
+			r.Range.Type = "numbered"
+			r.Range.NumberOfOccurrences, err = positive(key, v)
+		default:
+			err = fmt.Errorf("%s can't be in a Microsoft To Do recurrence", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch r.Pattern.Type {
+	case api.DailyRecurrence:
+		if r.Pattern.DayOfMonth != 0 || r.Pattern.Month != 0 {
+			return nil, fmt.Errorf("BYMONTHDAY and BYMONTH can't be in daily Microsoft To Do recurrences")
+		}
+	case api.WeeklyRecurrence:
+		r.Pattern.DaysOfWeek = days
+		if r.Pattern.FirstDayOfWeek == "" {
+			r.Pattern.FirstDayOfWeek = "sunday"
+		}
+	case api.AbsoluteMonthlyRecurrence, api.AbsoluteYearlyRecurrence:
+		if days != nil {
+			return nil, fmt.Errorf("BYDAY can't be in monthly and yearly Microsoft To Do recurrences")
+		}
+		if r.Pattern.DayOfMonth == 0 {
+			r.Pattern.DayOfMonth = start.Day()
+		}
+		if r.Pattern.Type == api.AbsoluteYearlyRecurrence && r.Pattern.Month == 0 {
+			r.Pattern.Month = int(start.Month())
+		}
+	}
+
+	return r, nil
+}
+
+// weekday returns Graph's weekday of an RRULE weekday, like MO
+func weekday(day string) (string, error) {
+	for name, d := range weekdays {
+		if d == strings.ToUpper(day) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("'%s' isn't a weekday like MO - Microsoft To Do recurrences can't be on the nth weekday", day)
+}
+
+// positive parses the positive integer value of the rule part key
+func positive(key string, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s=%s isn't a positive number", key, value)
+	}
+	return n, nil
+}
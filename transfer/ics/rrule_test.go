@@ -0,0 +1,126 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package ics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+)
+
+func TestEncodeRecurrence(t *testing.T) {
+	tests := []struct {
+		name   string
+		r      api.PatternedRecurrence
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "daily",
+			r:      api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "daily", Interval: 1}, Range: api.RecurrenceRange{Type: "noEnd"}},
+			want:   "FREQ=DAILY",
+			wantOk: true,
+		},
+		{
+			name:   "monthly",
+			r:      api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "absoluteMonthly", Interval: 3, DayOfMonth: 15}, Range: api.RecurrenceRange{Type: "numbered", NumberOfOccurrences: 4}},
+			want:   "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=15;COUNT=4",
+			wantOk: true,
+		},
+		{
+			name:   "yearly",
+			r:      api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "absoluteYearly", Interval: 1, DayOfMonth: 29, Month: 2}, Range: api.RecurrenceRange{Type: "endDate", EndDate: "2030-01-01"}},
+			want:   "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;UNTIL=20300101",
+			wantOk: true,
+		},
+		{
+			name: "relative monthly",
+			r:    api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "relativeMonthly", Interval: 1, DaysOfWeek: []string{"monday"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := encodeRecurrence(&tt.r)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("encodeRecurrence() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestDecodeRecurrence(t *testing.T) {
+	// A Wednesday
+	start := time.Date(2021, 9, 29, 0, 0, 0, 0, time.UTC)
+	noEnd := api.RecurrenceRange{Type: "noEnd", StartDate: "2021-09-29"}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    *api.PatternedRecurrence
+		wantErr bool
+	}{
+		{
+			name:  "weekdays",
+			value: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;WKST=MO",
+			want: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday", "tuesday", "wednesday", "thursday", "friday"}, FirstDayOfWeek: "monday"},
+				Range:   noEnd,
+			},
+		},
+		{
+			name:  "weekly on the start's day",
+			value: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20211231T235959Z",
+			want: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "weekly", Interval: 2, DaysOfWeek: []string{"wednesday"}, FirstDayOfWeek: "sunday"},
+				Range:   api.RecurrenceRange{Type: "endDate", StartDate: "2021-09-29", EndDate: "2021-12-31"},
+			},
+		},
+		{
+			name:  "monthly on the start's day",
+			value: "FREQ=MONTHLY",
+			want:  &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "absoluteMonthly", Interval: 1, DayOfMonth: 29}, Range: noEnd},
+		},
+		{
+			name:  "yearly",
+			value: "freq=yearly;bymonth=2;count=3",
+			want: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "absoluteYearly", Interval: 1, DayOfMonth: 29, Month: 2},
+				Range:   api.RecurrenceRange{Type: "numbered", StartDate: "2021-09-29", NumberOfOccurrences: 3},
+			},
+		},
+		{name: "no frequency", value: "INTERVAL=2", wantErr: true},
+		{name: "hourly", value: "FREQ=HOURLY", wantErr: true},
+		{name: "nth weekday", value: "FREQ=MONTHLY;BYDAY=2MO", wantErr: true},
+		{name: "several days of the month", value: "FREQ=MONTHLY;BYMONTHDAY=1,15", wantErr: true},
+		{name: "unsupported part", value: "FREQ=YEARLY;BYWEEKNO=20", wantErr: true},
+		{name: "invalid interval", value: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRecurrence(tt.value, start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeRecurrence() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
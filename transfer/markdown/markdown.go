@@ -0,0 +1,237 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package markdown reads and writes tasks as a Markdown checklist, with a
+// heading for each list:
+//
+//	# Work
+//
+//	- [ ] Write report due: 2021-10-01 <!-- mstodo:AAMk... -->
+//	  - [x] Outline
+//	  - [ ] Draft
+//	- [x] Book flights
+//
+// The nested bullets are the task's checklist items. The comment has the
+// task's ID, so that importing the file again updates the tasks instead of
+// adding them, and it isn't shown by Markdown viewers. The due date can be any
+// date which mstodo parses, like "due: next friday".
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+// Fields are the fields which Markdown keeps
+const Fields = transfer.Title | transfer.Done | transfer.DueDate | transfer.Checklist
+
+const dateLayout = "2006-01-02"
+
+var (
+	headingPattern = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)(\s+#+)?\s*$`)
+	itemPattern    = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(?:\[([ xX])\]\s+)?(.*)$`)
+	idPattern      = regexp.MustCompile(`\s*<!--\s*mstodo:(\S+?)\s*-->\s*`)
+	duePattern     = regexp.MustCompile(`(?i)(^|\s)due:\s*(.*)$`)
+
+	// escapedDuePattern matches "due:" in titles, which is escaped as "due\:"
+	escapedDuePattern = regexp.MustCompile(`(?i)\bdue\\:`)
+	titleDuePattern   = regexp.MustCompile(`(?i)\bdue:`)
+)
+
+// Encode writes the tasks as a Markdown checklist, with a heading for each
+// list. The dates are written in the time zone of the tasks' times.
+func Encode(w io.Writer, tasks []transfer.Task) error {
+	// The lists are written in the order of their first task
+	lists := []string{}
+	byList := map[string][]transfer.Task{}
+	for _, task := range tasks {
+		if _, ok := byList[task.List]; !ok {
+			lists = append(lists, task.List)
+		}
+		byList[task.List] = append(byList[task.List], task)
+	}
+
+	var b strings.Builder
+	for i, list := range lists {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if list != "" {
+			fmt.Fprintf(&b, "# %s\n\n", list)
+		}
+		for _, task := range byList[list] {
+			b.WriteString(EncodeTask(task))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// EncodeTask returns the checklist lines of the task and its checklist items
+func EncodeTask(task transfer.Task) string {
+	line := checkbox(task.Status == "completed") + " " + titleDuePattern.ReplaceAllStringFunc(oneLine(task.Title), func(due string) string {
+		return due[:3] + `\:`
+	})
+	if task.DueDateTime != nil {
+		line += " due: " + time.Time(*task.DueDateTime).Format(dateLayout)
+	}
+	if task.Id != "" {
+		line += " <!-- mstodo:" + task.Id + " -->"
+	}
+
+	lines := []string{line}
+	for _, item := range task.Checklist {
+		lines = append(lines, "  "+checkbox(item.IsChecked)+" "+oneLine(item.DisplayName))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "- [x]"
+	}
+	return "- [ ]"
+}
+
+// oneLine replaces the line breaks of s, which would end the item
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Decode reads the tasks from the Markdown checklist. A heading is the list
+// of the tasks under it, and the bullets nested in a task are its checklist
+// items. The other lines, like paragraphs and bullets without a checkbox, are
+// skipped. Dates are in loc.
+func Decode(r io.Reader, loc *time.Location) ([]transfer.Task, error) {
+	tasks := []transfer.Task{}
+
+	list := ""
+	task, taskIndent := (*transfer.Task)(nil), 0
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			list = strings.TrimSpace(match[1])
+			task = nil
+			continue
+		}
+
+		match := itemPattern.FindStringSubmatch(line)
+		if match == nil {
+			task = nil
+			continue
+		}
+		indent, box, text := len(match[1]), match[2], strings.TrimSpace(match[3])
+
+		// Nested bullets, with or without a checkbox, are checklist items
+		if task != nil && indent > taskIndent {
+			if text != "" {
+				task.Checklist = append(task.Checklist, api.ChecklistItem{DisplayName: text, IsChecked: box == "x" || box == "X"})
+			}
+			continue
+		}
+
+		task = nil
+		if box == "" {
+			continue
+		}
+
+		decoded, err := decodeTask(text, box != " ", loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		decoded.List = list
+		tasks = append(tasks, *decoded)
+		task, taskIndent = &tasks[len(tasks)-1], indent
+	}
+
+	return tasks, scanner.Err()
+}
+
+// decodeTask returns the task of the text of a checklist line. Dates are in
+// loc.
+func decodeTask(text string, checked bool, loc *time.Location) (*transfer.Task, error) {
+	task := &transfer.Task{
+		TodoTask:  api.TodoTask{Status: "not started", Importance: "normal"},
+		Checklist: []api.ChecklistItem{},
+	}
+	if checked {
+		task.Status = "completed"
+	}
+
+	if match := idPattern.FindStringSubmatch(text); match != nil {
+		task.Id = match[1]
+		text = idPattern.ReplaceAllString(text, " ")
+	}
+
+	if match := duePattern.FindStringSubmatchIndex(text); match != nil {
+		due, rest, err := parseDue(text[match[4]:match[5]])
+		if err != nil {
+			return nil, err
+		}
+		task.DueDateTime = day(due, loc)
+		text = text[:match[0]] + " " + rest
+	}
+
+	task.Title = oneLine(escapedDuePattern.ReplaceAllStringFunc(text, func(due string) string {
+		return due[:3] + ":"
+	}))
+	if task.Title == "" {
+		return nil, fmt.Errorf("the task has no title")
+	}
+	return task, nil
+}
+
+// parseDue parses the date at the start of s, returning the words after it.
+// The longest date is used, so that "due: oct 10 2027" keeps its year. The
+// parser skips the words after a date, so the date only has the words which
+// can be part of one, and "due: next friday call Sam" is due next Friday.
+func parseDue(s string) (time.Time, string, error) {
+	words := strings.Fields(s)
+
+	longest := 1
+	for longest < len(words) && datetime.IsDateWord(words[longest]) {
+		longest++
+	}
+
+	for n := longest; n > 0; n-- {
+		if due, err := datetime.DateParser(strings.Join(words[:n], " ")); err == nil {
+			return *due, strings.Join(words[n:], " "), nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("due: '%s' isn't a date", s)
+}
+
+// day returns midnight in loc of the day of t
+func day(t time.Time, loc *time.Location) *datetime.GraphTime {
+	y, m, d := t.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	return (*datetime.GraphTime)(&date)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+func date(s string) *datetime.GraphTime {
+	d, err := time.ParseInLocation(dateLayout, s, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return (*datetime.GraphTime)(&d)
+}
+
+func TestEncodeTask(t *testing.T) {
+	tests := []struct {
+		name string
+		task transfer.Task
+		want string
+	}{
+		{
+			name: "open",
+			task: transfer.Task{TodoTask: api.TodoTask{Id: "AAMk", Title: "Write report", Status: "not started", DueDateTime: date("2021-10-01")}},
+			want: "- [ ] Write report due: 2021-10-01 <!-- mstodo:AAMk -->\n",
+		},
+		{
+			name: "completed with a checklist",
+			task: transfer.Task{TodoTask: api.TodoTask{Title: "Pack", Status: "completed"}, Checklist: []api.ChecklistItem{{DisplayName: "Socks", IsChecked: true}, {DisplayName: "Hat\nand scarf"}}},
+			want: "- [x] Pack\n  - [x] Socks\n  - [ ] Hat and scarf\n",
+		},
+		{
+			name: "due in the title",
+			task: transfer.Task{TodoTask: api.TodoTask{Title: "Pay rent Due: monday", Status: "in progress"}},
+			want: "- [ ] Pay rent Due\\: monday\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeTask(tt.task); got != tt.want {
+				t.Errorf("EncodeTask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []transfer.Task
+		wantErr string
+	}{
+		{
+			name: "lists and checklists",
+			in: `Notes which are skipped.
+
+# Work
+
+- [ ] Write report due: 2021-10-01 <!-- mstodo:AAMk -->
+  - [x] Outline
+  * Draft
+    - [ ] Nested
+- Not a task
+  - Not an item
+
+## Home ##
+1. [X] Pay rent
+`,
+			want: []transfer.Task{
+				{List: "Work", TodoTask: api.TodoTask{Id: "AAMk", Title: "Write report", Status: "not started", Importance: "normal", DueDateTime: date("2021-10-01")}, Checklist: []api.ChecklistItem{{DisplayName: "Outline", IsChecked: true}, {DisplayName: "Draft"}, {DisplayName: "Nested"}}},
+				{List: "Home", TodoTask: api.TodoTask{Title: "Pay rent", Status: "completed", Importance: "normal"}, Checklist: []api.ChecklistItem{}},
+			},
+		},
+		{
+			name: "words after the due date",
+			in:   "- [ ] Call due: 2021-10-01 about the invoice\n",
+			want: []transfer.Task{
+				{TodoTask: api.TodoTask{Title: "Call about the invoice", Status: "not started", Importance: "normal", DueDateTime: date("2021-10-01")}, Checklist: []api.ChecklistItem{}},
+			},
+		},
+		{
+			name: "due dates with a year",
+			in:   "- [ ] Renew passport due: oct 10 2027\n- [ ] Renew licence due: 20 October 2027 at the office\n",
+			want: []transfer.Task{
+				{TodoTask: api.TodoTask{Title: "Renew passport", Status: "not started", Importance: "normal", DueDateTime: date("2027-10-10")}, Checklist: []api.ChecklistItem{}},
+				{TodoTask: api.TodoTask{Title: "Renew licence at the office", Status: "not started", Importance: "normal", DueDateTime: date("2027-10-20")}, Checklist: []api.ChecklistItem{}},
+			},
+		},
+		{
+			name: "escaped due",
+			in:   "- [ ] Pay rent due\\: monday\n",
+			want: []transfer.Task{
+				{TodoTask: api.TodoTask{Title: "Pay rent due: monday", Status: "not started", Importance: "normal"}, Checklist: []api.ChecklistItem{}},
+			},
+		},
+		{
+			name: "a paragraph ends the task",
+			in:   "- [ ] Pack\nSome notes\n  - Socks\n",
+			want: []transfer.Task{
+				{TodoTask: api.TodoTask{Title: "Pack", Status: "not started", Importance: "normal"}, Checklist: []api.ChecklistItem{}},
+			},
+		},
+		{
+			name:    "not a date",
+			in:      "# Work\n\n- [ ] Pay rent due: whenever\n",
+			wantErr: "line 3: due: 'whenever' isn't a date",
+		},
+		{
+			name:    "no title",
+			in:      "- [ ] <!-- mstodo:AAMk -->\n",
+			wantErr: "line 1: the task has no title",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(tt.in), time.UTC)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := `# Work
+
+- [ ] Write report due: 2021-10-01 <!-- mstodo:AAMk -->
+  - [x] Outline
+  - [ ] Draft
+- [x] Pay rent due\: monday
+
+# Home
+
+- [ ] Book flights
+`
+
+	tasks, err := Decode(strings.NewReader(in), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := Encode(&b, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != in {
+		t.Errorf("Encode(Decode()) = %q, want %q", b.String(), in)
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package spreadsheet reads and writes tasks as CSV files, like the ones
+// which spreadsheets export. The columns are mapped to the fields of the tasks
+// by a Mapping, and the columns which it doesn't map are found by the names in
+// the header row, like "Deadline" for the due date.
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+// Fields are the fields which CSV files keep
+const Fields = transfer.Title | transfer.Importance | transfer.Status | transfer.DueDate | transfer.Reminder | transfer.Categories | transfer.Body
+
+// Field is a field of a task which a column can be mapped to
+type Field string
+
+// The fields
+const (
+	TitleField      Field = "title"
+	ListField       Field = "list"
+	DueField        Field = "due"
+	ReminderField   Field = "reminder"
+	ImportanceField Field = "importance"
+	StatusField     Field = "status"
+	CategoriesField Field = "categories"
+	NotesField      Field = "notes"
+)
+
+// FieldNames are the names of the fields, in the order of the columns written
+// by Encode
+var FieldNames = []string{"title", "list", "importance", "status", "due", "reminder", "categories", "notes"}
+
+// headerNames are the normalized column names which each field is found by
+var headerNames = map[Field][]string{
+	TitleField:      {"title", "task", "taskname", "name", "subject", "summary"},
+	ListField:       {"list", "listname", "project"},
+	DueField:        {"due", "duedate", "deadline", "dueby"},
+	ReminderField:   {"reminder", "remind", "reminderdate", "remindme"},
+	ImportanceField: {"importance", "priority", "prio"},
+	StatusField:     {"status", "state"},
+	CategoriesField: {"categories", "category", "tags", "labels"},
+	NotesField:      {"notes", "note", "description", "details", "body"},
+}
+
+// ErrNoTitle is returned when no column is the title
+var ErrNoTitle = errors.New("no column is mapped to title")
+
+// Column is a column mapped to a field
+type Column struct {
+	// Name is the name of the column in the header row, or empty if it's
+	// given by its number
+	Name string
+
+	// Number is the number of the column, from 1
+	Number int
+
+	Field Field
+}
+
+// Mapping maps columns to fields
+type Mapping []Column
+
+// ParseMapping parses a mapping like "Title=title,Deadline=due,3=importance",
+// where the columns are given by their name in the header row or by their
+// number
+func ParseMapping(s string) (Mapping, error) {
+	mapping := Mapping{}
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+
+	mapped := map[Field]string{}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("'%s' isn't a column and a field, like Deadline=due", strings.TrimSpace(pair))
+		}
+
+		name := strings.TrimSpace(parts[0])
+		field := Field(strings.ToLower(strings.TrimSpace(parts[1])))
+		if _, ok := headerNames[field]; !ok {
+			return nil, fmt.Errorf("'%s' isn't a field - choices: [%s]", strings.TrimSpace(parts[1]), strings.Join(FieldNames, ", "))
+		}
+		if other, ok := mapped[field]; ok {
+			return nil, fmt.Errorf("'%s' and '%s' are both mapped to %s", other, name, field)
+		}
+		mapped[field] = name
+
+		column := Column{Name: name, Field: field}
+		if n, err := strconv.Atoi(name); err == nil {
+			if n < 1 {
+				return nil, fmt.Errorf("'%s' isn't a column number, which start at 1", name)
+			}
+			column = Column{Number: n, Field: field}
+		}
+		mapping = append(mapping, column)
+	}
+	return mapping, nil
+}
+
+// Sheet is the rows of a CSV file, with its columns mapped to fields
+type Sheet struct {
+	// Header is the header row, or nil if the file has none
+	Header []string
+
+	// Comma is the delimiter of the file, which is found from the first row
+	Comma rune
+
+	// Columns are the indexes of the columns of the fields
+	Columns map[Field]int
+
+	Rows []Row
+}
+
+// Row is a row of the file
+type Row struct {
+	// Number is the number of the row in the file, from 1, as shown by
+	// spreadsheets
+	Number int
+
+	Record []string
+}
+
+// Value returns the trimmed value of the field in the row, or an empty string
+// if no column is mapped to it
+func (s *Sheet) Value(row Row, field Field) string {
+	i, ok := s.Columns[field]
+	if !ok || i >= len(row.Record) {
+		return ""
+	}
+	return strings.TrimSpace(row.Record[i])
+}
+
+// Decode reads the rows of a CSV file. The delimiter can be a comma, semicolon
+// or tab. The first row is the header if it has the name of a column of the
+// mapping, or the name of a field, like "Title". The columns which the mapping
+// doesn't map are found by their names in the header.
+func Decode(r io.Reader, mapping Mapping) (*Sheet, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Spreadsheets often start UTF-8 files with a BOM
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	sheet := &Sheet{Comma: sniffComma(b), Columns: map[Field]int{}, Rows: []Row{}}
+	reader := csv.NewReader(bytes.NewReader(b))
+	reader.Comma = sheet.Comma
+	reader.FieldsPerRecord = -1
+
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if isBlank(record) {
+			continue
+		}
+		if n == 1 && isHeader(record, mapping) {
+			sheet.Header = record
+			continue
+		}
+		sheet.Rows = append(sheet.Rows, Row{Number: n, Record: record})
+	}
+
+	if err := sheet.mapColumns(mapping); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// mapColumns finds the columns of the fields
+func (s *Sheet) mapColumns(mapping Mapping) error {
+	used := map[int]bool{}
+	for _, column := range mapping {
+		if column.Name == "" {
+			s.Columns[column.Field] = column.Number - 1
+			used[column.Number-1] = true
+			continue
+		}
+
+		i := s.indexOf(column.Name)
+		if i == -1 {
+			if s.Header == nil {
+				return fmt.Errorf("there's no header row with the column '%s'", column.Name)
+			}
+			return fmt.Errorf("there's no column '%s' - columns: [%s]", column.Name, strings.Join(s.Header, ", "))
+		}
+		s.Columns[column.Field] = i
+		used[i] = true
+	}
+
+	// The other fields are found by the names of the columns
+	for i, name := range s.Header {
+		if used[i] {
+			continue
+		}
+		for _, field := range FieldNames {
+			if _, ok := s.Columns[Field(field)]; !ok && isFieldName(Field(field), name) {
+				s.Columns[Field(field)] = i
+				break
+			}
+		}
+	}
+
+	if _, ok := s.Columns[TitleField]; !ok {
+		return ErrNoTitle
+	}
+	return nil
+}
+
+// indexOf returns the index of the column called name in the header, or -1
+func (s *Sheet) indexOf(name string) int {
+	for i, column := range s.Header {
+		if strings.EqualFold(strings.TrimSpace(column), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isHeader reports whether the row is a header row
+func isHeader(record []string, mapping Mapping) bool {
+	for _, cell := range record {
+		for _, column := range mapping {
+			if column.Name != "" && strings.EqualFold(strings.TrimSpace(cell), column.Name) {
+				return true
+			}
+		}
+		for _, field := range FieldNames {
+			if isFieldName(Field(field), cell) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isFieldName reports whether a column called name is the field, ignoring
+// case, spaces and punctuation, so that "Due date" is the due date
+func isFieldName(field Field, name string) bool {
+	normalized := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+
+	for _, alias := range headerNames[field] {
+		if normalized == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffComma returns the delimiter which is most common in the first line of
+// the file, ignoring the quoted text
+func sniffComma(b []byte) rune {
+	line, _ := bufio.NewReader(bytes.NewReader(b)).ReadString('\n')
+
+	counts := map[rune]int{}
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ',' || r == ';' || r == '\t'):
+			counts[r]++
+		}
+	}
+
+	comma := ','
+	for _, r := range []rune{';', '\t'} {
+		if counts[r] > counts[comma] {
+			comma = r
+		}
+	}
+	return comma
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Categories splits the categories of a cell, which are separated by commas
+// or semicolons
+func Categories(value string) []string {
+	categories := []string{}
+	for _, category := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// Encode writes the tasks as CSV, with a header row. The dates are written in
+// the time zone of the tasks' times.
+func Encode(w io.Writer, tasks []transfer.Task) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"Title", "List", "Importance", "Status", "Due", "Reminder", "Categories", "Notes"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		due, reminder := "", ""
+		if task.DueDateTime != nil {
+			due = time.Time(*task.DueDateTime).Format("2006-01-02")
+		}
+		if task.ReminderDateTime != nil {
+			reminder = time.Time(*task.ReminderDateTime).Format("2006-01-02 15:04")
+		}
+
+		record := []string{task.Title, task.List, task.Importance, string(task.Status), due, reminder, strings.Join(task.Categories, ", "), transfer.BodyText(task.Body)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package spreadsheet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+func TestParseMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Mapping
+		wantErr string
+	}{
+		{name: "empty", s: "", want: Mapping{}},
+		{
+			name: "names and numbers",
+			s:    "Title=title, Deadline = Due,3=importance",
+			want: Mapping{{Name: "Title", Field: TitleField}, {Name: "Deadline", Field: DueField}, {Number: 3, Field: ImportanceField}},
+		},
+		{name: "no field", s: "Title", wantErr: "'Title' isn't a column and a field, like Deadline=due"},
+		{name: "unknown field", s: "Prio=priority", wantErr: "'priority' isn't a field - choices: [title, list, importance, status, due, reminder, categories, notes]"},
+		{name: "mapped twice", s: "Task=title,Name=title", wantErr: "'Task' and 'Name' are both mapped to title"},
+		{name: "column 0", s: "0=title", wantErr: "'0' isn't a column number, which start at 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMapping(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseMapping() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMapping() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		mapping     string
+		wantHeader  bool
+		wantComma   rune
+		wantColumns map[Field]int
+		wantRows    []Row
+		wantErr     string
+	}{
+		{
+			name:        "header names",
+			in:          "\xef\xbb\xbfTask,Due date,Priority,Tags\nPay rent,2021-10-01,high,\"Home, Money\"\n,,,\nCall Sam\n",
+			wantHeader:  true,
+			wantComma:   ',',
+			wantColumns: map[Field]int{TitleField: 0, DueField: 1, ImportanceField: 2, CategoriesField: 3},
+			wantRows:    []Row{{Number: 2, Record: []string{"Pay rent", "2021-10-01", "high", "Home, Money"}}, {Number: 4, Record: []string{"Call Sam"}}},
+		},
+		{
+			name:        "mapping",
+			in:          "Summary;Deadline;Prio\nPay rent;2021-10-01;high\n",
+			mapping:     "Deadline=due,Prio=importance",
+			wantHeader:  true,
+			wantComma:   ';',
+			wantColumns: map[Field]int{TitleField: 0, DueField: 1, ImportanceField: 2},
+			wantRows:    []Row{{Number: 2, Record: []string{"Pay rent", "2021-10-01", "high"}}},
+		},
+		{
+			name:        "mapping takes precedence",
+			in:          "Title,Heading\nPay,Pay rent\n",
+			mapping:     "Heading=title",
+			wantHeader:  true,
+			wantComma:   ',',
+			wantColumns: map[Field]int{TitleField: 1},
+			wantRows:    []Row{{Number: 2, Record: []string{"Pay", "Pay rent"}}},
+		},
+		{
+			name:        "no header",
+			in:          "2021-10-01\tPay rent\n",
+			mapping:     "2=title,1=due",
+			wantComma:   '\t',
+			wantColumns: map[Field]int{TitleField: 1, DueField: 0},
+			wantRows:    []Row{{Number: 1, Record: []string{"2021-10-01", "Pay rent"}}},
+		},
+		{name: "no title", in: "Due,Priority\n2021-10-01,high\n", wantErr: ErrNoTitle.Error()},
+		{name: "missing column", in: "Task,Due\nPay rent,\n", mapping: "Prio=importance", wantErr: "there's no column 'Prio' - columns: [Task, Due]"},
+		{name: "no header for the mapping", in: "Pay rent,high\n", mapping: "Prio=importance", wantErr: "there's no header row with the column 'Prio'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := ParseMapping(tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Decode(strings.NewReader(tt.in), mapping)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			if (got.Header != nil) != tt.wantHeader || got.Comma != tt.wantComma {
+				t.Errorf("Decode() header = %q, comma = %q", got.Header, got.Comma)
+			}
+			if !reflect.DeepEqual(got.Columns, tt.wantColumns) {
+				t.Errorf("Decode() columns = %v, want %v", got.Columns, tt.wantColumns)
+			}
+			if !reflect.DeepEqual(got.Rows, tt.wantRows) {
+				t.Errorf("Decode() rows = %q, want %q", got.Rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestCategories(t *testing.T) {
+	want := []string{"Home", "Money", "Red category"}
+	if got := Categories(" Home, Money;;Red category "); !reflect.DeepEqual(got, want) {
+		t.Errorf("Categories() = %q, want %q", got, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	due := datetime.GraphTime(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	reminder := datetime.GraphTime(time.Date(2021, 9, 30, 9, 30, 0, 0, time.UTC))
+	tasks := []transfer.Task{
+		{List: "Work", TodoTask: api.TodoTask{Title: "Write report, v2", Importance: "high", Status: "not started", DueDateTime: &due, ReminderDateTime: &reminder, Categories: []string{"Office", "Red category"}, Body: &api.ItemBody{Content: "Notes", ContentType: "text"}}},
+		{List: "Tasks", TodoTask: api.TodoTask{Title: "Pay rent", Importance: "normal", Status: "completed"}},
+	}
+
+	var b strings.Builder
+	if err := Encode(&b, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `Title,List,Importance,Status,Due,Reminder,Categories,Notes
+"Write report, v2",Work,high,not started,2021-10-01,2021-09-30 09:30,"Office, Red category",Notes
+Pay rent,Tasks,normal,completed,,,,
+`
+	if b.String() != want {
+		t.Errorf("Encode() = %q, want %q", b.String(), want)
+	}
+
+	// The header maps every column
+	sheet, err := Decode(strings.NewReader(b.String()), Mapping{})
+	if err != nil || len(sheet.Columns) != len(FieldNames) || sheet.Value(sheet.Rows[0], CategoriesField) != "Office, Red category" {
+		t.Errorf("Decode(Encode()) = %+v, %v", sheet, err)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package taskwarrior
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+)
+
+// recurPattern matches the recur durations which Microsoft To Do can repeat
+// on, like "weekly" or "2wk"
+var recurPattern = regexp.MustCompile(`^(\d*)\s*([a-z]+)$`)
+
+// recurUnit returns the pattern type and the interval of a recur unit, like
+// "wk". It's false if Microsoft To Do can't repeat on the unit, like hours.
+func recurUnit(unit string) (string, int, bool) {
+	switch unit {
+	case "d", "day", "days", "daily":
+		return api.DailyRecurrence, 1, true
+	case "w", "wk", "wks", "week", "weeks", "weekly":
+		return api.WeeklyRecurrence, 1, true
+	case "biweekly", "fortnight":
+		return api.WeeklyRecurrence, 2, true
+	case "mo", "mos", "month", "months", "monthly":
+		return api.AbsoluteMonthlyRecurrence, 1, true
+	case "bimonthly":
+		return api.AbsoluteMonthlyRecurrence, 2, true
+	case "q", "qtr", "qtrs", "quarter", "quarters", "quarterly":
+		return api.AbsoluteMonthlyRecurrence, 3, true
+	case "semiannual", "biannual":
+		return api.AbsoluteMonthlyRecurrence, 6, true
+	case "y", "yr", "yrs", "year", "years", "yearly", "annual":
+		return api.AbsoluteYearlyRecurrence, 1, true
+	default:
+		return "", 0, false
+	}
+}
+
+// workWeek are the days of "weekdays"
+var workWeek = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+
+// decodeRecurrence returns the recurrence of a recur duration, repeating from
+// due until until, which can be nil. Dates are in loc.
+func decodeRecurrence(recur string, due time.Time, until *time.Time, loc *time.Location) (*api.PatternedRecurrence, error) {
+	r := &api.PatternedRecurrence{
+		Range: api.RecurrenceRange{Type: "noEnd", StartDate: due.Format("2006-01-02")},
+	}
+	if until != nil {
+		r.Range.Type = "endDate"
+		r.Range.EndDate = until.In(loc).Format("2006-01-02")
+	}
+
+	recur = strings.ToLower(strings.TrimSpace(recur))
+	if recur == "weekdays" {
+		r.Pattern = api.RecurrencePattern{Type: api.WeeklyRecurrence, Interval: 1, DaysOfWeek: append([]string{}, workWeek...), FirstDayOfWeek: "sunday"}
+		return r, nil
+	}
+
+	match := recurPattern.FindStringSubmatch(recur)
+	if match == nil {
+		return nil, fmt.Errorf("recur %q", recur)
+	}
+	patternType, interval, ok := recurUnit(match[2])
+	if !ok {
+		return nil, fmt.Errorf("recur %q", recur)
+	}
+
+	n := 1
+	if match[1] != "" {
+		var err error
+		if n, err = strconv.Atoi(match[1]); err != nil || n <= 0 {
+			return nil, fmt.Errorf("recur %q", recur)
+		}
+	}
+
+	r.Pattern = api.RecurrencePattern{Type: patternType, Interval: n * interval}
+	switch patternType {
+	case api.WeeklyRecurrence:
+		r.Pattern.DaysOfWeek = []string{strings.ToLower(due.Weekday().String())}
+		r.Pattern.FirstDayOfWeek = "sunday"
+	case api.AbsoluteMonthlyRecurrence:
+		r.Pattern.DayOfMonth = due.Day()
+	case api.AbsoluteYearlyRecurrence:
+		r.Pattern.DayOfMonth = due.Day()
+		r.Pattern.Month = int(due.Month())
+	}
+
+	return r, nil
+}
+
+// encodeRecurrence returns the recur duration and the until date of r, which
+// repeats from due. The error describes why r can't be written.
+func encodeRecurrence(r *api.PatternedRecurrence, due *datetime.GraphTime) (string, string, error) {
+	if due == nil {
+		return "", "", fmt.Errorf("recurrence without a due date")
+	}
+
+	var until string
+	switch r.Range.Type {
+	case "endDate":
+		loc := time.Time(*due).Location()
+		end, err := time.ParseInLocation("2006-01-02", r.Range.EndDate, loc)
+		if err != nil {
+			return "", "", fmt.Errorf("recurrence end date %q", r.Range.EndDate)
+		}
+		until = end.UTC().Format(timeLayout)
+	case "numbered":
+		return "", "", fmt.Errorf("recurrence with a number of occurrences")
+	}
+
+	interval := r.Pattern.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+	every := func(one string, unit string) string {
+		if interval == 1 {
+			return one
+		}
+		return strconv.Itoa(interval) + unit
+	}
+
+	switch r.Pattern.Type {
+	case api.DailyRecurrence:
+		return every("daily", "d"), until, nil
+	case api.WeeklyRecurrence:
+		days := map[string]bool{}
+		for _, day := range r.Pattern.DaysOfWeek {
+			days[strings.ToLower(day)] = true
+		}
+		if len(days) <= 1 {
+			return every("weekly", "wk"), until, nil
+		}
+
+		workDays := map[string]bool{}
+		for _, day := range workWeek {
+			workDays[day] = true
+		}
+		if interval == 1 && reflect.DeepEqual(days, workDays) {
+			return "weekdays", until, nil
+		}
+		return "", "", fmt.Errorf("recurrence on several days of the week")
+	case api.AbsoluteMonthlyRecurrence:
+		return every("monthly", "mo"), until, nil
+	case api.AbsoluteYearlyRecurrence:
+		return every("yearly", "y"), until, nil
+	default:
+		return "", "", fmt.Errorf("recurrence %q", r.Pattern.Type)
+	}
+}
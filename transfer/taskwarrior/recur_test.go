@@ -0,0 +1,107 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package taskwarrior
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+)
+
+func TestDecodeRecurrence(t *testing.T) {
+	// A Wednesday
+	due := time.Date(2021, 9, 29, 0, 0, 0, 0, time.UTC)
+	noEnd := api.RecurrenceRange{Type: "noEnd", StartDate: "2021-09-29"}
+
+	tests := []struct {
+		name    string
+		recur   string
+		want    *api.PatternedRecurrence
+		wantErr bool
+	}{
+		{name: "daily", recur: "daily", want: &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "daily", Interval: 1}, Range: noEnd}},
+		{name: "days", recur: "3d", want: &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "daily", Interval: 3}, Range: noEnd}},
+		{
+			name:  "weekdays",
+			recur: "weekdays",
+			want:  &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: workWeek, FirstDayOfWeek: "sunday"}, Range: noEnd},
+		},
+		{
+			name:  "fortnight",
+			recur: "fortnight",
+			want:  &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "weekly", Interval: 2, DaysOfWeek: []string{"wednesday"}, FirstDayOfWeek: "sunday"}, Range: noEnd},
+		},
+		{name: "quarterly", recur: "Quarterly", want: &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "absoluteMonthly", Interval: 3, DayOfMonth: 29}, Range: noEnd}},
+		{name: "years", recur: "2y", want: &api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "absoluteYearly", Interval: 2, DayOfMonth: 29, Month: 9}, Range: noEnd}},
+		{name: "hours", recur: "4h", wantErr: true},
+		{name: "not a duration", recur: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRecurrence(tt.recur, due, nil, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeRecurrence() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRecurrence(t *testing.T) {
+	due := graphTime("2021-09-29 00:00", time.UTC)
+
+	tests := []struct {
+		name      string
+		r         api.PatternedRecurrence
+		want      string
+		wantUntil string
+		wantErr   bool
+	}{
+		{name: "daily", r: api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "daily", Interval: 2}}, want: "2d"},
+		{name: "weekly", r: api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"wednesday"}}}, want: "weekly"},
+		{
+			name: "weekdays",
+			r:    api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"friday", "monday", "tuesday", "wednesday", "thursday"}}},
+			want: "weekdays",
+		},
+		{
+			name:      "yearly until",
+			r:         api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "absoluteYearly", Interval: 1}, Range: api.RecurrenceRange{Type: "endDate", EndDate: "2030-09-29"}},
+			want:      "yearly",
+			wantUntil: "20300929T000000Z",
+		},
+		{name: "several days", r: api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday", "friday"}}}, wantErr: true},
+		{name: "numbered", r: api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "daily", Interval: 1}, Range: api.RecurrenceRange{Type: "numbered", NumberOfOccurrences: 3}}, wantErr: true},
+		{name: "relative", r: api.PatternedRecurrence{Pattern: api.RecurrencePattern{Type: "relativeMonthly", Interval: 1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, until, err := encodeRecurrence(&tt.r, due)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encodeRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || until != tt.wantUntil {
+				t.Errorf("encodeRecurrence() = %q, %q, want %q, %q", got, until, tt.want, tt.wantUntil)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package taskwarrior reads the JSON of Taskwarrior's task export, and writes
+// JSON which task import reads, from
+// https://taskwarrior.org/docs/design/task.html:
+//
+//	[
+//	{"description":"Write report","status":"pending","project":"Work","tags":["Office"],"priority":"H","due":"20211001T000000Z","mstodoid":"AAMk..."}
+//	]
+//
+// The project is the list, the tags are the categories, and the annotations are
+// the notes. Scheduled is the start date, and wait, when the task shows again,
+// is the reminder. Started tasks are in progress. The mstodoid attribute is
+// the task's ID, so that importing the file again updates the tasks instead of
+// adding them.
+//
+// Taskwarrior and Microsoft To Do don't have the same fields, so encoding and
+// decoding count what they can't represent in a report, like Taskwarrior's
+// depends.
+package taskwarrior
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+// Fields are the fields which Taskwarrior keeps
+const Fields = transfer.Title | transfer.Importance | transfer.Status | transfer.DueDate | transfer.Completed |
+	transfer.Categories | transfer.Reminder | transfer.Recurrence | transfer.StartDate | transfer.Body
+
+// timeLayout is the layout of Taskwarrior's dates, which are in UTC
+const timeLayout = "20060102T150405Z"
+
+// task is a Taskwarrior task
+type task struct {
+	Description string       `json:"description"`
+	Status      string       `json:"status"`
+	UUID        string       `json:"uuid,omitempty"`
+	Entry       string       `json:"entry,omitempty"`
+	Modified    string       `json:"modified,omitempty"`
+	Start       string       `json:"start,omitempty"`
+	End         string       `json:"end,omitempty"`
+	Project     string       `json:"project,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Priority    string       `json:"priority,omitempty"`
+	Due         string       `json:"due,omitempty"`
+	Scheduled   string       `json:"scheduled,omitempty"`
+	Wait        string       `json:"wait,omitempty"`
+	Recur       string       `json:"recur,omitempty"`
+	Until       string       `json:"until,omitempty"`
+	Parent      string       `json:"parent,omitempty"`
+	Annotations []annotation `json:"annotations,omitempty"`
+
+	// MSTodoID is the task's ID in Microsoft To Do, which Taskwarrior keeps as
+	// an orphaned user defined attribute
+	MSTodoID string `json:"mstodoid,omitempty"`
+}
+
+// annotation is a note of a task
+type annotation struct {
+	Entry       string `json:"entry,omitempty"`
+	Description string `json:"description"`
+}
+
+// knownAttributes are the attributes which are decoded, or which don't need to
+// be, like the urgency which Taskwarrior works out
+var knownAttributes = map[string]bool{
+	"description": true, "status": true, "uuid": true, "entry": true, "modified": true,
+	"start": true, "end": true, "project": true, "tags": true, "priority": true, "due": true,
+	"scheduled": true, "wait": true, "recur": true, "until": true, "parent": true,
+	"annotations": true, "mstodoid": true, "id": true, "urgency": true, "mask": true,
+	"imask": true,
+}
+
+// priorities are the Taskwarrior priorities of the importances. Normal
+// importance has no priority.
+var priorities = map[string]string{"high": "H", "low": "L"}
+
+// Encode writes the tasks as the JSON of task export, with a task on each line.
+// What Taskwarrior can't represent is counted in report.
+func Encode(w io.Writer, tasks []transfer.Task, report transfer.Report) error {
+	lines := []string{}
+	for _, t := range tasks {
+		b, err := json.Marshal(encodeTask(t, report))
+		if err != nil {
+			return err
+		}
+		lines = append(lines, string(b))
+	}
+
+	_, err := fmt.Fprintf(w, "[\n%s\n]\n", strings.Join(lines, ",\n"))
+	return err
+}
+
+// encodeTask returns the Taskwarrior task of t
+func encodeTask(t transfer.Task, report transfer.Report) task {
+	tw := task{
+		Description: t.Title,
+		Status:      "pending",
+		Project:     t.List,
+		Priority:    priorities[t.Importance],
+		Due:         formatTime(t.DueDateTime),
+		Scheduled:   formatTime(t.StartDateTime),
+		MSTodoID:    t.Id,
+	}
+	if !t.CreatedDateTime.IsZero() {
+		tw.Entry = t.CreatedDateTime.UTC().Format(timeLayout)
+	}
+	if !t.LastModifiedDateTime.IsZero() {
+		tw.Modified = t.LastModifiedDateTime.UTC().Format(timeLayout)
+	}
+
+	switch t.Status {
+	case "completed":
+		tw.Status = "completed"
+		tw.End = formatTime(t.Completed)
+		if tw.End == "" {
+			tw.End = tw.Modified
+		}
+	case "in progress":
+		// Started tasks are active, from when they were last changed
+		tw.Start = tw.Modified
+		if tw.Start == "" {
+			tw.Start = time.Now().UTC().Format(timeLayout)
+		}
+	case "not started", "":
+	default:
+		report.Add(fmt.Sprintf("status %q, written as pending", t.Status))
+	}
+
+	for _, category := range t.Categories {
+		tw.Tags = append(tw.Tags, strings.Join(strings.Fields(category), "_"))
+	}
+	if t.IsReminderOn {
+		tw.Wait = formatTime(t.ReminderDateTime)
+	}
+
+	if body := transfer.BodyText(t.Body); body != "" {
+		for _, line := range strings.Split(body, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				tw.Annotations = append(tw.Annotations, annotation{Entry: tw.Modified, Description: line})
+			}
+		}
+	}
+
+	if t.Recurrence != nil {
+		recur, until, err := encodeRecurrence(t.Recurrence, t.DueDateTime)
+		if err != nil {
+			report.Add(err.Error())
+		} else {
+			tw.Recur = recur
+			tw.Until = until
+			if tw.Status == "pending" {
+				tw.Status = "recurring"
+			}
+		}
+	}
+
+	return tw
+}
+
+// Decode reads the tasks from the JSON of task export, which is an array, or
+// a task on each line for old versions. Deleted tasks are skipped, as are the
+// instances of recurring tasks which are in the JSON too. What Microsoft To Do
+// can't represent is counted in report. Dates are in loc.
+func Decode(r io.Reader, loc *time.Location, report transfer.Report) ([]transfer.Task, error) {
+	raw, err := readTasks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The recurring tasks, whose instances are skipped
+	decoded := make([]task, len(raw))
+	recurring := map[string]bool{}
+	for i, b := range raw {
+		if err := json.Unmarshal(b, &decoded[i]); err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		if decoded[i].Recur != "" && decoded[i].Parent == "" && decoded[i].UUID != "" {
+			recurring[decoded[i].UUID] = true
+		}
+	}
+
+	tasks := []transfer.Task{}
+	for i, tw := range decoded {
+		switch {
+		case tw.Status == "deleted":
+			report.Add("deleted tasks, which were skipped")
+			continue
+		case tw.Parent != "" && recurring[tw.Parent]:
+			report.Add("instances of recurring tasks, which were skipped for the recurring task")
+			continue
+		}
+
+		attributes := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw[i], &attributes); err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		for name := range attributes {
+			if !knownAttributes[name] {
+				report.Add(name)
+			}
+		}
+
+		t, err := decodeTask(tw, loc, report)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		tasks = append(tasks, *t)
+	}
+
+	return tasks, nil
+}
+
+// readTasks returns the JSON of each task
+func readTasks(r io.Reader) ([]json.RawMessage, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := []json.RawMessage{}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return nil, fmt.Errorf("the file isn't the JSON of task export: %w", err)
+		}
+		return raw, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var task json.RawMessage
+		err := decoder.Decode(&task)
+		if err == io.EOF {
+			return raw, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the file isn't the JSON of task export: %w", err)
+		}
+		raw = append(raw, task)
+	}
+}
+
+// decodeTask returns the task of tw. Dates are in loc.
+func decodeTask(tw task, loc *time.Location, report transfer.Report) (*transfer.Task, error) {
+	t := &transfer.Task{
+		List:     strings.TrimSpace(tw.Project),
+		TodoTask: api.TodoTask{Id: tw.MSTodoID, Title: strings.TrimSpace(tw.Description), Status: "not started", Importance: "normal"},
+	}
+	if t.Title == "" {
+		return nil, fmt.Errorf("the task has no description")
+	}
+
+	times := map[string]*time.Time{}
+	for name, value := range map[string]string{"end": tw.End, "due": tw.Due, "scheduled": tw.Scheduled, "wait": tw.Wait, "until": tw.Until} {
+		if value == "" {
+			continue
+		}
+		tm, err := parseTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		times[name] = &tm
+	}
+
+	switch tw.Status {
+	case "completed":
+		t.Status = "completed"
+		if end := times["end"]; end != nil {
+			t.Completed = (*datetime.GraphTime)(end)
+		}
+	case "pending", "waiting", "recurring", "":
+		if tw.Start != "" {
+			t.Status = "in progress"
+		}
+	default:
+		report.Add(fmt.Sprintf("status %q", tw.Status))
+	}
+
+	switch tw.Priority {
+	case "H":
+		t.Importance = "high"
+	case "M", "":
+	case "L":
+		t.Importance = "low"
+	default:
+		report.Add(fmt.Sprintf("priority %q", tw.Priority))
+	}
+
+	for _, tag := range tw.Tags {
+		t.Categories = append(t.Categories, strings.ReplaceAll(tag, "_", " "))
+	}
+
+	if due := times["due"]; due != nil {
+		t.DueDateTime = day(*due, loc)
+	}
+	if scheduled := times["scheduled"]; scheduled != nil {
+		t.StartDateTime = day(*scheduled, loc)
+	}
+
+	// Waiting until "someday" is the end of time, which isn't a reminder
+	if wait := times["wait"]; wait != nil && wait.Year() < 9999 {
+		t.ReminderDateTime = (*datetime.GraphTime)(wait)
+		t.IsReminderOn = true
+	} else if wait != nil {
+		report.Add("wait someday")
+	}
+
+	notes := []string{}
+	for _, a := range tw.Annotations {
+		if text := strings.TrimSpace(a.Description); text != "" {
+			notes = append(notes, text)
+		}
+	}
+	if len(notes) > 0 {
+		t.Body = &api.ItemBody{Content: strings.Join(notes, "\n"), ContentType: "text"}
+	}
+
+	switch {
+	case tw.Recur != "" && times["due"] == nil:
+		report.Add("recur without a due date")
+	case tw.Recur != "":
+		recurrence, err := decodeRecurrence(tw.Recur, times["due"].In(loc), times["until"], loc)
+		if err != nil {
+			report.Add(err.Error())
+		}
+		t.Recurrence = recurrence
+	case times["until"] != nil:
+		report.Add("until, without recur")
+	}
+
+	return t, nil
+}
+
+// parseTime parses a Taskwarrior date, like 20211001T090000Z
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		return t, fmt.Errorf("'%s' isn't a date like 20211001T090000Z", s)
+	}
+	return t, nil
+}
+
+func formatTime(g *datetime.GraphTime) string {
+	if g == nil {
+		return ""
+	}
+	return time.Time(*g).UTC().Format(timeLayout)
+}
+
+// day returns midnight in loc of the day of t in loc
+func day(t time.Time, loc *time.Location) *datetime.GraphTime {
+	y, m, d := t.In(loc).Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	return (*datetime.GraphTime)(&date)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package taskwarrior
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+func graphTime(s string, loc *time.Location) *datetime.GraphTime {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return (*datetime.GraphTime)(&t)
+}
+
+func TestEncode(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2021, 9, 30, 12, 0, 0, 0, time.UTC)
+
+	tasks := []transfer.Task{
+		{List: "Work", TodoTask: api.TodoTask{
+			Id:                   "AAMk",
+			Title:                "Write report",
+			Importance:           "high",
+			Status:               "in progress",
+			DueDateTime:          graphTime("2021-10-01 00:00", paris),
+			StartDateTime:        graphTime("2021-09-27 00:00", paris),
+			ReminderDateTime:     graphTime("2021-09-30 09:00", paris),
+			IsReminderOn:         true,
+			Categories:           []string{"Red category"},
+			Body:                 &api.ItemBody{Content: "<p>Ask Sam</p><p>Send to Kim</p>", ContentType: "html"},
+			LastModifiedDateTime: modified,
+		}},
+		{TodoTask: api.TodoTask{
+			Title:       "Pay rent",
+			Importance:  "normal",
+			Status:      "not started",
+			DueDateTime: graphTime("2021-10-01 00:00", paris),
+			Recurrence: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "absoluteMonthly", Interval: 1, DayOfMonth: 1},
+				Range:   api.RecurrenceRange{Type: "endDate", StartDate: "2021-10-01", EndDate: "2022-06-01"},
+			},
+		}},
+		{TodoTask: api.TodoTask{Title: "Book flights", Importance: "low", Status: "waiting on others"}},
+		{TodoTask: api.TodoTask{
+			Title:  "Water plants",
+			Status: "completed",
+			Recurrence: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday", "friday"}},
+				Range:   api.RecurrenceRange{Type: "noEnd"},
+			},
+			Completed: graphTime("2021-10-02 10:00", time.UTC),
+		}},
+	}
+
+	want := `[
+{"description":"Write report","status":"pending","modified":"20210930T120000Z","start":"20210930T120000Z","project":"Work","tags":["Red_category"],"priority":"H","due":"20210930T220000Z","scheduled":"20210926T220000Z","wait":"20210930T070000Z","annotations":[{"entry":"20210930T120000Z","description":"Ask Sam"},{"entry":"20210930T120000Z","description":"Send to Kim"}],"mstodoid":"AAMk"},
+{"description":"Pay rent","status":"recurring","due":"20210930T220000Z","recur":"monthly","until":"20220531T220000Z"},
+{"description":"Book flights","status":"pending","priority":"L"},
+{"description":"Water plants","status":"completed","end":"20211002T100000Z"}
+]
+`
+	report := transfer.Report{}
+	var b strings.Builder
+	if err := Encode(&b, tasks, report); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+
+	wantReport := transfer.Report{
+		`status "waiting on others", written as pending`: 1,
+		"recurrence without a due date":                  1,
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("Encode() report = %v, want %v", report, wantReport)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := `[
+{"id":1,"description":"Write report","status":"pending","uuid":"u1","entry":"20210925T120000Z","start":"20210930T120000Z","project":"Work","tags":["Red_category"],"priority":"H","due":"20210930T220000Z","scheduled":"20210926T220000Z","wait":"20210930T070000Z","annotations":[{"entry":"20210930T120000Z","description":"Ask Sam"},{"entry":"20210930T130000Z","description":"Send to Kim"}],"depends":"u2","urgency":9.2,"mstodoid":"AAMk"},
+{"id":0,"description":"Pay rent","status":"recurring","uuid":"u3","due":"20210930T220000Z","recur":"2wk","until":"20220531T220000Z","mask":"-"},
+{"id":2,"description":"Pay rent","status":"pending","uuid":"u4","parent":"u3","due":"20211014T220000Z","recur":"2wk","imask":0},
+{"id":0,"description":"Old task","status":"deleted","uuid":"u5"},
+{"id":0,"description":"Feed cat","status":"completed","uuid":"u6","end":"20211002T100000Z","priority":"L","recur":"hourly","due":"20211002T080000Z","depends":"u1","estimate":"PT1H"}
+]`
+
+	want := []transfer.Task{
+		{List: "Work", TodoTask: api.TodoTask{
+			Id:               "AAMk",
+			Title:            "Write report",
+			Importance:       "high",
+			Status:           "in progress",
+			DueDateTime:      graphTime("2021-10-01 00:00", paris),
+			StartDateTime:    graphTime("2021-09-27 00:00", paris),
+			ReminderDateTime: graphTime("2021-09-30 07:00", time.UTC),
+			IsReminderOn:     true,
+			Categories:       []string{"Red category"},
+			Body:             &api.ItemBody{Content: "Ask Sam\nSend to Kim", ContentType: "text"},
+		}},
+		{TodoTask: api.TodoTask{
+			Title:       "Pay rent",
+			Importance:  "normal",
+			Status:      "not started",
+			DueDateTime: graphTime("2021-10-01 00:00", paris),
+			Recurrence: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "weekly", Interval: 2, DaysOfWeek: []string{"friday"}, FirstDayOfWeek: "sunday"},
+				Range:   api.RecurrenceRange{Type: "endDate", StartDate: "2021-10-01", EndDate: "2022-06-01"},
+			},
+		}},
+		{TodoTask: api.TodoTask{
+			Title:       "Feed cat",
+			Importance:  "low",
+			Status:      "completed",
+			DueDateTime: graphTime("2021-10-02 00:00", paris),
+			Completed:   graphTime("2021-10-02 10:00", time.UTC),
+		}},
+	}
+	wantReport := transfer.Report{
+		"depends":                           2,
+		"estimate":                          1,
+		`recur "hourly"`:                    1,
+		"deleted tasks, which were skipped": 1,
+		"instances of recurring tasks, which were skipped for the recurring task": 1,
+	}
+
+	report := transfer.Report{}
+	got, err := Decode(strings.NewReader(in), paris, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Decode() = %+v, want %+v", got, want)
+	}
+	for i := range got {
+		if !sameTask(got[i], want[i]) {
+			t.Errorf("Decode()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("Decode() report = %v, want %v", report, wantReport)
+	}
+}
+
+func TestDecode_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{name: "not JSON", in: "Pay rent", wantErr: "the file isn't the JSON of task export"},
+		{name: "no description", in: `[{"status":"pending"}]`, wantErr: "task 1: the task has no description"},
+		{name: "invalid date", in: `{"description":"Pay rent","due":"tomorrow"}`, wantErr: "task 1: due: 'tomorrow' isn't a date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.in), time.UTC, transfer.Report{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecode_lines(t *testing.T) {
+	// Old versions of task export write a task on each line
+	in := "{\"description\":\"Pay rent\",\"status\":\"pending\"}\n{\"description\":\"Feed cat\",\"status\":\"waiting\",\"wait\":\"99991230T000000Z\"}\n"
+
+	report := transfer.Report{}
+	got, err := Decode(strings.NewReader(in), time.UTC, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Title != "Feed cat" || got[1].ReminderDateTime != nil || report["wait someday"] != 1 {
+		t.Errorf("Decode() = %+v, report %v", got, report)
+	}
+}
+
+// sameTask reports whether a and b are the same, with their times at the same
+// instants
+func sameTask(a transfer.Task, b transfer.Task) bool {
+	times := func(task *transfer.Task) []*datetime.GraphTime {
+		ts := []*datetime.GraphTime{task.DueDateTime, task.StartDateTime, task.ReminderDateTime, task.Completed}
+		task.DueDateTime, task.StartDateTime, task.ReminderDateTime, task.Completed = nil, nil, nil, nil
+		return ts
+	}
+
+	at, bt := times(&a), times(&b)
+	for i := range at {
+		if (at[i] == nil) != (bt[i] == nil) || at[i] != nil && !time.Time(*at[i]).Equal(time.Time(*bt[i])) {
+			return false
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
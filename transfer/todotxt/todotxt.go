@@ -0,0 +1,247 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package todotxt reads and writes tasks in the todo.txt format, from
+// https://github.com/todotxt/todo.txt:
+//
+//	x 2021-10-02 2021-09-30 Write report +Work @Office due:2021-10-01 mstodo:AAMk
+//
+// The priority is the importance, +project is the list, @context are the
+// categories, and due: is the due date. The mstodo: tag is the task's ID, so
+// that importing the file again updates the tasks instead of adding them. The
+// spaces of lists and categories are written as underscores.
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+	"github.com/dalyisaac/mstodo/utils"
+)
+
+// Fields are the fields which todo.txt keeps
+const Fields = transfer.Title | transfer.Importance | transfer.Status | transfer.DueDate | transfer.Completed | transfer.Categories
+
+const (
+	dateLayout = "2006-01-02"
+
+	// idKey is the key of the tag with the task's ID
+	idKey = "mstodo"
+)
+
+var priorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+// priorities are the priorities of the importances. Normal importance has no
+// priority.
+var priorities = map[string]string{"high": "A", "low": "C"}
+
+// Encode writes the tasks as todo.txt lines. The dates are written in the
+// time zone of the tasks' times.
+func Encode(w io.Writer, tasks []transfer.Task) error {
+	for _, task := range tasks {
+		if _, err := fmt.Fprintln(w, EncodeTask(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeTask returns the todo.txt line of the task
+func EncodeTask(task transfer.Task) string {
+	words := []string{}
+
+	completed := task.Status == "completed"
+	if completed {
+		words = append(words, "x")
+		if task.Completed != nil {
+			words = append(words, formatDate(task.Completed))
+		}
+	} else if p, ok := priorities[task.Importance]; ok {
+		words = append(words, "("+p+")")
+	}
+
+	// The creation date can only follow the completion date
+	if !task.CreatedDateTime.IsZero() && (!completed || task.Completed != nil) {
+		words = append(words, task.CreatedDateTime.Format(dateLayout))
+	}
+
+	words = append(words, strings.Fields(task.Title)...)
+
+	if task.List != "" {
+		words = append(words, "+"+tagValue(task.List))
+	}
+	for _, category := range task.Categories {
+		words = append(words, "@"+tagValue(category))
+	}
+	if task.DueDateTime != nil {
+		words = append(words, "due:"+formatDate(task.DueDateTime))
+	}
+	if p, ok := priorities[task.Importance]; ok && completed {
+		words = append(words, "pri:"+p)
+	}
+	if task.Status != "" && task.Status != "not started" && !completed {
+		words = append(words, "status:"+strings.ReplaceAll(string(task.Status), " ", "-"))
+	}
+	if task.Id != "" {
+		words = append(words, idKey+":"+task.Id)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Decode reads the tasks from todo.txt lines. Dates are in loc.
+func Decode(r io.Reader, loc *time.Location) ([]transfer.Task, error) {
+	tasks := []transfer.Task{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		task, err := DecodeTask(scanner.Text(), loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, scanner.Err()
+}
+
+// DecodeTask reads a task from a todo.txt line. Dates are in loc. The last
+// +project is the list, and the others are kept in the title.
+func DecodeTask(line string, loc *time.Location) (*transfer.Task, error) {
+	words := strings.Fields(line)
+	task := &transfer.Task{TodoTask: api.TodoTask{Status: "not started", Importance: "normal"}}
+
+	// Completion, priority and dates
+	if len(words) > 0 && words[0] == "x" {
+		task.Status = "completed"
+		words = words[1:]
+		if len(words) > 0 {
+			if date, err := parseDate(words[0], loc); err == nil {
+				task.Completed = date
+				words = words[1:]
+			}
+		}
+	} else if len(words) > 0 && priorityPattern.MatchString(words[0]) {
+		task.Importance = importance(words[0][1:2])
+		words = words[1:]
+	}
+
+	// The creation date is read-only in Microsoft To Do
+	if len(words) > 0 {
+		if _, err := parseDate(words[0], loc); err == nil {
+			words = words[1:]
+		}
+	}
+
+	project := -1
+	for i, word := range words {
+		if isProject(word) {
+			project = i
+		}
+	}
+
+	title := []string{}
+	for i, word := range words {
+		key, value := splitTag(word)
+
+		switch {
+		case i == project:
+			task.List = strings.ReplaceAll(word[1:], "_", " ")
+		case len(word) > 1 && word[0] == '@':
+			task.Categories = append(task.Categories, strings.ReplaceAll(word[1:], "_", " "))
+		case key == "due":
+			date, err := parseDate(value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("due: %w", err)
+			}
+			task.DueDateTime = date
+		case key == "pri" && len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z':
+			task.Importance = importance(value)
+		case key == "status" && task.Status != "completed":
+			status := strings.ReplaceAll(value, "-", " ")
+			if !utils.ContainsString(api.GraphStatusOptions, status) {
+				return nil, fmt.Errorf("'%s' is not a valid status - choices: [%s]", value, strings.Join(api.GraphStatusOptions, ", "))
+			}
+			task.Status = api.GraphStatus(status)
+		case key == idKey:
+			task.Id = value
+		default:
+			title = append(title, word)
+		}
+	}
+
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		return nil, fmt.Errorf("the task has no title")
+	}
+
+	return task, nil
+}
+
+// importance returns the importance of a priority. A is high, B is normal, and
+// the others are low.
+func importance(priority string) string {
+	switch priority {
+	case "A":
+		return "high"
+	case "B":
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+func isProject(word string) bool {
+	return len(word) > 1 && word[0] == '+'
+}
+
+// splitTag splits a key:value tag. The key is empty if word isn't a tag.
+func splitTag(word string) (string, string) {
+	parts := strings.SplitN(word, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.Contains(parts[1], "/") {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// tagValue replaces the spaces of s, which end a project or context
+func tagValue(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
+
+func formatDate(g *datetime.GraphTime) string {
+	return time.Time(*g).Format(dateLayout)
+}
+
+func parseDate(s string, loc *time.Location) (*datetime.GraphTime, error) {
+	date, err := time.ParseInLocation(dateLayout, s, loc)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' isn't a date like 2021-10-01", s)
+	}
+	return (*datetime.GraphTime)(&date), nil
+}
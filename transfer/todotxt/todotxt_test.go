@@ -0,0 +1,150 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package todotxt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/transfer"
+)
+
+func date(s string) *datetime.GraphTime {
+	d, err := time.ParseInLocation(dateLayout, s, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return (*datetime.GraphTime)(&d)
+}
+
+func TestEncodeTask(t *testing.T) {
+	created, _ := time.Parse(dateLayout, "2021-09-30")
+
+	tests := []struct {
+		name string
+		task transfer.Task
+		want string
+	}{
+		{
+			name: "open",
+			task: transfer.Task{List: "Work", TodoTask: api.TodoTask{Id: "AAMk", Title: "Write  report", Importance: "high", Status: "not started", DueDateTime: date("2021-10-01"), Categories: []string{"Red category", "Office"}, CreatedDateTime: created}},
+			want: "(A) 2021-09-30 Write report +Work @Red_category @Office due:2021-10-01 mstodo:AAMk",
+		},
+		{
+			name: "completed",
+			task: transfer.Task{List: "My Tasks", TodoTask: api.TodoTask{Title: "Pay rent", Importance: "low", Status: "completed", Completed: date("2021-10-02"), CreatedDateTime: created}},
+			want: "x 2021-10-02 2021-09-30 Pay rent +My_Tasks pri:C",
+		},
+		{
+			name: "completed without a date",
+			task: transfer.Task{TodoTask: api.TodoTask{Title: "Pay rent", Importance: "normal", Status: "completed", CreatedDateTime: created}},
+			want: "x Pay rent",
+		},
+		{
+			name: "in progress",
+			task: transfer.Task{TodoTask: api.TodoTask{Title: "Pay rent", Importance: "normal", Status: "waiting on others"}},
+			want: "Pay rent status:waiting-on-others",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeTask(tt.task); got != tt.want {
+				t.Errorf("EncodeTask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    transfer.Task
+		wantErr bool
+	}{
+		{
+			name: "open",
+			line: "(A) 2021-09-30 Write report +Work @Red_category @Office due:2021-10-01 mstodo:AAMk",
+			want: transfer.Task{List: "Work", TodoTask: api.TodoTask{Id: "AAMk", Title: "Write report", Importance: "high", Status: "not started", DueDateTime: date("2021-10-01"), Categories: []string{"Red category", "Office"}}},
+		},
+		{
+			name: "completed",
+			line: "x 2021-10-02 2021-09-30 Pay rent +My_Tasks pri:C",
+			want: transfer.Task{List: "My Tasks", TodoTask: api.TodoTask{Title: "Pay rent", Importance: "low", Status: "completed", Completed: date("2021-10-02")}},
+		},
+		{
+			name: "plain",
+			line: "Call Sam about http://example.com",
+			want: transfer.Task{TodoTask: api.TodoTask{Title: "Call Sam about http://example.com", Importance: "normal", Status: "not started"}},
+		},
+		{
+			name: "last project is the list",
+			line: "(B) Plan +Launch party +Home",
+			want: transfer.Task{List: "Home", TodoTask: api.TodoTask{Title: "Plan +Launch party", Importance: "normal", Status: "not started"}},
+		},
+		{
+			name: "low priority and status",
+			line: "(D) Book flights status:in-progress",
+			want: transfer.Task{TodoTask: api.TodoTask{Title: "Book flights", Importance: "low", Status: "in progress"}},
+		},
+		{name: "invalid due date", line: "Pay rent due:tomorrow", wantErr: true},
+		{name: "invalid status", line: "Pay rent status:done", wantErr: true},
+		{name: "no title", line: "x 2021-10-02 +Work", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeTask(tt.line, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("DecodeTask() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	in := "Write report +Work\n\nx Pay rent\nPay rent due:soon\n"
+
+	_, err := Decode(strings.NewReader(in), time.UTC)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4: due:") {
+		t.Errorf("Decode() error = %v, want an error on line 4", err)
+	}
+
+	tasks, err := Decode(strings.NewReader(in[:strings.Index(in, "Pay rent due")]), time.UTC)
+	if err != nil || len(tasks) != 2 {
+		t.Errorf("Decode() = %v, %v, want 2 tasks", tasks, err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	line := "(A) Write report +Work @Office due:2021-10-01 mstodo:AAMk"
+
+	task, err := DecodeTask(line, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EncodeTask(*task); got != line {
+		t.Errorf("EncodeTask(DecodeTask()) = %q, want %q", got, line)
+	}
+}
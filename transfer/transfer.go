@@ -0,0 +1,419 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package transfer converts tasks to and from the formats of other tools, for
+// mstodo import and export. Each format is in a sub-package.
+package transfer
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+)
+
+// Task is a task with the name of its list
+type Task struct {
+	api.TodoTask
+
+	// List is the name of the task's list, or empty for the default list
+	List string
+
+	// Checklist are the task's checklist items, for formats which keep them
+	Checklist []api.ChecklistItem
+}
+
+// Fields are the fields of a task which a format keeps, so that importing only
+// changes those fields
+type Fields int
+
+const (
+	Title Fields = 1 << iota
+	Importance
+	Status
+	DueDate
+	Completed
+	Categories
+	Reminder
+	Recurrence
+	StartDate
+	Body
+
+	// Done is whether the task is completed, for formats which only have a
+	// checkbox instead of the status
+	Done
+
+	// Checklist is the checklist items, which are merged by MergeChecklist
+	Checklist
+)
+
+// Has reports whether f has all of fields
+func (f Fields) Has(fields Fields) bool {
+	return f&fields == fields
+}
+
+// Report counts what a format couldn't represent, like the fields of another
+// tool which Microsoft To Do doesn't have, by its description
+type Report map[string]int
+
+// Add counts what for one task
+func (r Report) Add(what string) {
+	r[what]++
+}
+
+// Lines returns the counts, like "depends: 2 tasks", sorted by description
+func (r Report) Lines() []string {
+	lines := []string{}
+	for what, n := range r {
+		tasks := "tasks"
+		if n == 1 {
+			tasks = "task"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d %s", what, n, tasks))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// Merge returns existing with the fields of imported, and whether any of them
+// changed. Dates are compared by their day in loc, as formats like todo.txt
+// only keep the day.
+func Merge(existing api.TodoTask, imported api.TodoTask, fields Fields, loc *time.Location) (api.TodoTask, bool) {
+	merged := existing
+
+	if fields.Has(Title) {
+		merged.Title = imported.Title
+	}
+	if fields.Has(Importance) && imported.Importance != "" {
+		merged.Importance = imported.Importance
+	}
+	if fields.Has(Status) && imported.Status != "" {
+		merged.Status = imported.Status
+	}
+	if fields.Has(Done) && !fields.Has(Status) && (imported.Status == "completed") != (existing.Status == "completed") {
+		merged.Status = imported.Status
+	}
+	if fields.Has(DueDate) && !sameDay(existing.DueDateTime, imported.DueDateTime, loc) {
+		merged.DueDateTime = imported.DueDateTime
+	}
+	if fields.Has(Completed) && !sameDay(existing.Completed, imported.Completed, loc) && imported.Completed != nil {
+		merged.Completed = imported.Completed
+	}
+	if merged.Status != "completed" {
+		merged.Completed = nil
+	}
+	if fields.Has(Categories) {
+		merged.Categories = imported.Categories
+	}
+	if fields.Has(Reminder) && !sameTime(existing.ReminderDateTime, imported.ReminderDateTime) {
+		merged.ReminderDateTime = imported.ReminderDateTime
+		merged.IsReminderOn = imported.ReminderDateTime != nil
+	}
+	if fields.Has(Recurrence) && !sameRecurrence(existing.Recurrence, imported.Recurrence) {
+		merged.Recurrence = imported.Recurrence
+	}
+	if fields.Has(StartDate) && !sameDay(existing.StartDateTime, imported.StartDateTime, loc) {
+		merged.StartDateTime = imported.StartDateTime
+	}
+	if fields.Has(Body) && BodyText(existing.Body) != BodyText(imported.Body) {
+		merged.Body = imported.Body
+		if merged.Body == nil {
+			merged.Body = &api.ItemBody{ContentType: "text"}
+		}
+	}
+
+	return merged, len(Changes(existing, merged)) > 0
+}
+
+// Changes returns the names of the fields which are different in merged, which
+// was returned by Merge
+func Changes(existing api.TodoTask, merged api.TodoTask) []string {
+	changes := []string{}
+	add := func(changed bool, name string) {
+		if changed {
+			changes = append(changes, name)
+		}
+	}
+
+	add(existing.Title != merged.Title, "title")
+	add(existing.Importance != merged.Importance, "importance")
+	add(existing.Status != merged.Status, "status")
+	add(existing.DueDateTime != merged.DueDateTime, "due date")
+	add(existing.Completed != merged.Completed, "completed date")
+	add(!sameStrings(existing.Categories, merged.Categories), "categories")
+	add(existing.ReminderDateTime != merged.ReminderDateTime, "reminder")
+	add(!reflect.DeepEqual(existing.Recurrence, merged.Recurrence), "recurrence")
+	add(existing.StartDateTime != merged.StartDateTime, "start date")
+	add(existing.Body != merged.Body, "notes")
+	return changes
+}
+
+// Diff returns what changed in merged, which was returned by Merge, like
+// `title: "Write reprt" -> "Write report"`. Times are shown in loc.
+func Diff(existing api.TodoTask, merged api.TodoTask, loc *time.Location) []string {
+	diff := []string{}
+	for _, name := range Changes(existing, merged) {
+		diff = append(diff, fmt.Sprintf("%s: %s -> %s", name, describe(existing, name, loc), describe(merged, name, loc)))
+	}
+	return diff
+}
+
+// describe returns the value of the field of task, which is called name by
+// Changes
+func describe(task api.TodoTask, name string, loc *time.Location) string {
+	date := func(g *datetime.GraphTime, layout string) string {
+		if g == nil {
+			return "none"
+		}
+		return time.Time(*g).In(loc).Format(layout)
+	}
+
+	switch name {
+	case "title":
+		return fmt.Sprintf("%q", task.Title)
+	case "importance":
+		return task.Importance
+	case "status":
+		return string(task.Status)
+	case "due date":
+		return date(task.DueDateTime, "2006-01-02")
+	case "completed date":
+		return date(task.Completed, "2006-01-02")
+	case "start date":
+		return date(task.StartDateTime, "2006-01-02")
+	case "reminder":
+		return date(task.ReminderDateTime, "2006-01-02 15:04")
+	case "categories":
+		if len(task.Categories) == 0 {
+			return "none"
+		}
+		return strings.Join(task.Categories, ", ")
+	case "recurrence":
+		return describeRecurrence(task.Recurrence)
+	case "notes":
+		text := strings.Join(strings.Fields(BodyText(task.Body)), " ")
+		if len([]rune(text)) > 40 {
+			text = string([]rune(text)[:39]) + "…"
+		}
+		return fmt.Sprintf("%q", text)
+	default:
+		return ""
+	}
+}
+
+// describeRecurrence returns how often r repeats, like "every 2 weeks on
+// monday, friday"
+func describeRecurrence(r *api.PatternedRecurrence) string {
+	if r == nil {
+		return "none"
+	}
+
+	every := func(unit string) string {
+		if r.Pattern.Interval <= 1 {
+			return "every " + unit
+		}
+		return fmt.Sprintf("every %d %ss", r.Pattern.Interval, unit)
+	}
+
+	var description string
+	switch r.Pattern.Type {
+	case api.DailyRecurrence:
+		description = every("day")
+	case api.WeeklyRecurrence:
+		description = every("week") + " on " + strings.Join(r.Pattern.DaysOfWeek, ", ")
+	case api.AbsoluteMonthlyRecurrence:
+		description = every("month") + fmt.Sprintf(" on day %d", r.Pattern.DayOfMonth)
+	case api.AbsoluteYearlyRecurrence:
+		description = every("year") + fmt.Sprintf(" on %s %d", time.Month(r.Pattern.Month), r.Pattern.DayOfMonth)
+	default:
+		description = r.Pattern.Type
+	}
+
+	switch r.Range.Type {
+	case "endDate":
+		description += " until " + r.Range.EndDate
+	case "numbered":
+		description += fmt.Sprintf(", %d times", r.Range.NumberOfOccurrences)
+	}
+	return description
+}
+
+// ChecklistChanges are the changes which make a task's checklist items the
+// imported ones
+type ChecklistChanges struct {
+	Create []api.ChecklistItem
+
+	// Update are the existing items, with the imported checked state
+	Update []api.ChecklistItem
+
+	Delete []api.ChecklistItem
+}
+
+// MergeChecklist returns the changes which make the existing checklist items
+// the imported ones. The items are matched by their names.
+func MergeChecklist(existing []api.ChecklistItem, imported []api.ChecklistItem) ChecklistChanges {
+	changes := ChecklistChanges{}
+	matched := make([]bool, len(existing))
+
+	for _, item := range imported {
+		found := false
+		for i, e := range existing {
+			if matched[i] || strings.TrimSpace(e.DisplayName) != strings.TrimSpace(item.DisplayName) {
+				continue
+			}
+
+			matched[i], found = true, true
+			if e.IsChecked != item.IsChecked {
+				e.IsChecked = item.IsChecked
+				changes.Update = append(changes.Update, e)
+			}
+			break
+		}
+		if !found {
+			changes.Create = append(changes.Create, item)
+		}
+	}
+
+	for i, e := range existing {
+		if !matched[i] {
+			changes.Delete = append(changes.Delete, e)
+		}
+	}
+	return changes
+}
+
+// Empty reports whether there are no changes
+func (c ChecklistChanges) Empty() bool {
+	return len(c.Create) == 0 && len(c.Update) == 0 && len(c.Delete) == 0
+}
+
+// Lines describes the changes, like `+ "Book hotel"`
+func (c ChecklistChanges) Lines() []string {
+	lines := []string{}
+	for _, item := range c.Create {
+		lines = append(lines, fmt.Sprintf("+ %q", item.DisplayName))
+	}
+	for _, item := range c.Update {
+		if item.IsChecked {
+			lines = append(lines, fmt.Sprintf("checked %q", item.DisplayName))
+		} else {
+			lines = append(lines, fmt.Sprintf("unchecked %q", item.DisplayName))
+		}
+	}
+	for _, item := range c.Delete {
+		lines = append(lines, fmt.Sprintf("- %q", item.DisplayName))
+	}
+	return lines
+}
+
+var (
+	htmlBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6])>`)
+	htmlTags   = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+// BodyText returns the notes of body as text, without HTML. It's empty if
+// body is nil.
+func BodyText(body *api.ItemBody) string {
+	if body == nil {
+		return ""
+	}
+	if !strings.EqualFold(body.ContentType, "html") {
+		return strings.TrimSpace(body.Content)
+	}
+
+	text := htmlBreaks.ReplaceAllString(body.Content, "\n")
+	text = html.UnescapeString(htmlTags.ReplaceAllString(text, ""))
+	text = blankLines.ReplaceAllString(strings.ReplaceAll(text, "\r", ""), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// sameDay reports whether a and b are on the same day in loc, or both nil
+func sameDay(a *datetime.GraphTime, b *datetime.GraphTime, loc *time.Location) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	ay, am, ad := time.Time(*a).In(loc).Date()
+	by, bm, bd := time.Time(*b).In(loc).Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// sameTime reports whether a and b are the same instant, or both nil
+func sameTime(a *datetime.GraphTime, b *datetime.GraphTime) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return time.Time(*a).Equal(time.Time(*b))
+}
+
+// sameRecurrence reports whether a and b repeat in the same way, or are both
+// nil. Graph fills in the fields which don't apply to a pattern's type, and
+// moves the start of the range as the task repeats, so those are ignored.
+func sameRecurrence(a *api.PatternedRecurrence, b *api.PatternedRecurrence) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(recurrenceKey(a), recurrenceKey(b))
+}
+
+// recurrenceKey returns the fields of r which apply to its pattern and range
+func recurrenceKey(r *api.PatternedRecurrence) api.PatternedRecurrence {
+	key := api.PatternedRecurrence{
+		Pattern: api.RecurrencePattern{Type: r.Pattern.Type, Interval: r.Pattern.Interval},
+		Range:   api.RecurrenceRange{Type: r.Range.Type},
+	}
+
+	switch r.Pattern.Type {
+	case api.WeeklyRecurrence:
+		key.Pattern.DaysOfWeek = append([]string{}, r.Pattern.DaysOfWeek...)
+		sort.Strings(key.Pattern.DaysOfWeek)
+		key.Pattern.FirstDayOfWeek = r.Pattern.FirstDayOfWeek
+	case api.AbsoluteMonthlyRecurrence:
+		key.Pattern.DayOfMonth = r.Pattern.DayOfMonth
+	case api.AbsoluteYearlyRecurrence:
+		key.Pattern.DayOfMonth = r.Pattern.DayOfMonth
+		key.Pattern.Month = r.Pattern.Month
+	}
+
+	switch r.Range.Type {
+	case "endDate":
+		key.Range.EndDate = r.Range.EndDate
+	case "numbered":
+		key.Range.NumberOfOccurrences = r.Range.NumberOfOccurrences
+	}
+	return key
+}
+
+// sameStrings reports whether a and b have the same strings, in any order
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,243 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package transfer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+)
+
+func graphTime(s string, loc *time.Location) *datetime.GraphTime {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return (*datetime.GraphTime)(&t)
+}
+
+func TestBodyText(t *testing.T) {
+	tests := []struct {
+		name string
+		body *api.ItemBody
+		want string
+	}{
+		{name: "nil", body: nil, want: ""},
+		{name: "text", body: &api.ItemBody{Content: " Call <Sam> \n", ContentType: "text"}, want: "Call <Sam>"},
+		{name: "html", body: &api.ItemBody{Content: "<html><body><p>Call Sam &amp; Kim</p><p>at 9<br>or 10</p></body></html>", ContentType: "html"}, want: "Call Sam & Kim\nat 9\nor 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BodyText(tt.body); got != tt.want {
+				t.Errorf("BodyText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := api.TodoTask{
+		Id:               "AAMk",
+		Title:            "Write report",
+		Importance:       "normal",
+		Status:           "not started",
+		DueDateTime:      graphTime("2021-10-01 00:00", paris),
+		ReminderDateTime: graphTime("2021-09-30 09:00", paris),
+		IsReminderOn:     true,
+		Categories:       []string{"Work", "Red"},
+		Recurrence: &api.PatternedRecurrence{
+			Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"monday", "friday"}, FirstDayOfWeek: "sunday"},
+			Range:   api.RecurrenceRange{Type: "noEnd", StartDate: "2021-09-27"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		imported    api.TodoTask
+		fields      Fields
+		wantChanged bool
+		check       func(merged api.TodoTask) bool
+	}{
+		{
+			name:     "same",
+			imported: api.TodoTask{Title: "Write report", Importance: "normal", Status: "not started", DueDateTime: graphTime("2021-09-30 22:00", time.UTC), Categories: []string{"Red", "Work"}},
+			fields:   Title | Importance | Status | DueDate | Categories,
+		},
+		{
+			name:        "other fields are kept",
+			imported:    api.TodoTask{Title: "Write the report", Importance: "high", Status: "not started"},
+			fields:      Title | Importance,
+			wantChanged: true,
+			check: func(m api.TodoTask) bool {
+				return m.Title == "Write the report" && m.Importance == "high" && m.DueDateTime != nil && m.IsReminderOn && len(m.Categories) == 2
+			},
+		},
+		{
+			name:        "due date removed",
+			imported:    api.TodoTask{Title: "Write report", Importance: "normal", Status: "not started", Categories: []string{"Work", "Red"}},
+			fields:      Title | Importance | Status | DueDate | Categories,
+			wantChanged: true,
+			check:       func(m api.TodoTask) bool { return m.DueDateTime == nil },
+		},
+		{
+			name:        "completed",
+			imported:    api.TodoTask{Title: "Write report", Status: "completed", Completed: graphTime("2021-10-02 00:00", paris)},
+			fields:      Title | Status | Completed,
+			wantChanged: true,
+			check:       func(m api.TodoTask) bool { return m.Status == "completed" && m.Completed != nil },
+		},
+		{
+			name:        "reminder removed",
+			imported:    api.TodoTask{Title: "Write report"},
+			fields:      Reminder,
+			wantChanged: true,
+			check:       func(m api.TodoTask) bool { return m.ReminderDateTime == nil && !m.IsReminderOn },
+		},
+		{
+			name: "same recurrence",
+			imported: api.TodoTask{Recurrence: &api.PatternedRecurrence{
+				Pattern: api.RecurrencePattern{Type: "weekly", Interval: 1, DaysOfWeek: []string{"friday", "monday"}, FirstDayOfWeek: "sunday"},
+				Range:   api.RecurrenceRange{Type: "noEnd", StartDate: "2021-10-01"},
+			}},
+			fields: Recurrence,
+			check:  func(m api.TodoTask) bool { return m.Recurrence == existing.Recurrence },
+		},
+		{
+			name:        "recurrence removed",
+			imported:    api.TodoTask{},
+			fields:      Recurrence,
+			wantChanged: true,
+			check:       func(m api.TodoTask) bool { return m.Recurrence == nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, changed := Merge(existing, tt.imported, tt.fields, paris)
+			if changed != tt.wantChanged {
+				t.Errorf("Merge() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if got := len(Changes(existing, merged)) > 0; got != changed {
+				t.Errorf("Changes() = %v, but Merge() changed = %v", Changes(existing, merged), changed)
+			}
+			if merged.Id != existing.Id {
+				t.Errorf("Merge() id = %v, want %v", merged.Id, existing.Id)
+			}
+			if tt.check != nil && !tt.check(merged) {
+				t.Errorf("Merge() = %+v", merged)
+			}
+		})
+	}
+}
+
+func TestReport_Lines(t *testing.T) {
+	report := Report{}
+	report.Add("wait")
+	report.Add("depends")
+	report.Add("depends")
+
+	want := []string{"depends: 2 tasks", "wait: 1 task"}
+	if got := report.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %v, want %v", got, want)
+	}
+}
+
+func TestMerge_done(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   api.GraphStatus
+		imported   api.GraphStatus
+		wantStatus api.GraphStatus
+	}{
+		{name: "checked", existing: "in progress", imported: "completed", wantStatus: "completed"},
+		{name: "unchecked", existing: "completed", imported: "not started", wantStatus: "not started"},
+		{name: "still open", existing: "in progress", imported: "not started", wantStatus: "in progress"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := api.TodoTask{Title: "Pay rent", Status: tt.existing}
+			merged, _ := Merge(existing, api.TodoTask{Title: "Pay rent", Status: tt.imported}, Title|Done, time.UTC)
+			if merged.Status != tt.wantStatus {
+				t.Errorf("Merge() status = %v, want %v", merged.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	existing := api.TodoTask{
+		Title:       "Write reprt",
+		Importance:  "normal",
+		DueDateTime: graphTime("2021-10-01 00:00", time.UTC),
+		Recurrence: &api.PatternedRecurrence{
+			Pattern: api.RecurrencePattern{Type: "weekly", Interval: 2, DaysOfWeek: []string{"monday", "friday"}},
+			Range:   api.RecurrenceRange{Type: "endDate", EndDate: "2021-12-31"},
+		},
+	}
+	merged := existing
+	merged.Title = "Write report"
+	merged.DueDateTime = nil
+	merged.Recurrence = nil
+
+	want := []string{
+		`title: "Write reprt" -> "Write report"`,
+		"due date: 2021-10-01 -> none",
+		"recurrence: every 2 weeks on monday, friday until 2021-12-31 -> none",
+	}
+	if got := Diff(existing, merged, time.UTC); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeChecklist(t *testing.T) {
+	existing := []api.ChecklistItem{
+		{Id: "1", DisplayName: "Passport", IsChecked: false},
+		{Id: "2", DisplayName: "Tickets", IsChecked: true},
+		{Id: "3", DisplayName: "Charger"},
+	}
+	imported := []api.ChecklistItem{
+		{DisplayName: "Passport", IsChecked: true},
+		{DisplayName: " Tickets ", IsChecked: true},
+		{DisplayName: "Sunscreen"},
+	}
+
+	changes := MergeChecklist(existing, imported)
+	want := ChecklistChanges{
+		Create: []api.ChecklistItem{{DisplayName: "Sunscreen"}},
+		Update: []api.ChecklistItem{{Id: "1", DisplayName: "Passport", IsChecked: true}},
+		Delete: []api.ChecklistItem{{Id: "3", DisplayName: "Charger"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("MergeChecklist() = %+v, want %+v", changes, want)
+	}
+
+	wantLines := []string{`+ "Sunscreen"`, `checked "Passport"`, `- "Charger"`}
+	if got := changes.Lines(); !reflect.DeepEqual(got, wantLines) {
+		t.Errorf("Lines() = %q, want %q", got, wantLines)
+	}
+	if changes.Empty() || !MergeChecklist(existing, existing).Empty() {
+		t.Errorf("Empty() is wrong")
+	}
+}
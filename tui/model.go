@@ -0,0 +1,576 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/datetime"
+	"github.com/dalyisaac/mstodo/quickadd"
+)
+
+// Backend is the part of the Graph API which the TUI uses. It's implemented by
+// *api.Client.
+type Backend interface {
+	GetLists(ctx context.Context) (*api.TodoTaskListList, error)
+	GetTasks(ctx context.Context, listId string) (*api.TodoTaskList, error)
+	CreateTask(ctx context.Context, listId string, task *api.TodoTask) error
+	UpdateTask(ctx context.Context, listId string, taskId string, task *api.TodoTask) error
+	DeleteTask(ctx context.Context, listId string, taskId string) error
+
+	// The checklist items, linked resources and attachments are kept when a
+	// task is deleted, so that undo can recreate them
+	GetChecklistItems(ctx context.Context, listId string, taskId string) ([]api.ChecklistItem, error)
+	CreateChecklistItem(ctx context.Context, listId string, taskId string, item *api.ChecklistItem) error
+	GetLinkedResources(ctx context.Context, listId string, taskId string) ([]api.LinkedResource, error)
+	CreateLinkedResource(ctx context.Context, listId string, taskId string, resource *api.LinkedResource) error
+	GetAttachments(ctx context.Context, listId string, taskId string) ([]api.Attachment, error)
+	GetAttachment(ctx context.Context, listId string, taskId string, attachmentId string) (*api.Attachment, error)
+	CreateAttachment(ctx context.Context, listId string, taskId string, attachment *api.Attachment) error
+}
+
+type pane int
+
+const (
+	listsPane pane = iota
+	tasksPane
+)
+
+// prompt is the line where text is entered, like the title of a new task
+type prompt struct {
+	label  string
+	input  []rune
+	submit func(ctx context.Context, m *model, value string) error
+}
+
+// deletedTask is a task which can be restored by undo, with its checklist
+// items, linked resources and attachments
+type deletedTask struct {
+	listId      string
+	task        api.TodoTask
+	checklist   []api.ChecklistItem
+	resources   []api.LinkedResource
+	attachments []api.Attachment
+}
+
+// model is the state of the TUI, which is changed by keys and drawn by render
+type model struct {
+	backend  Backend
+	location *time.Location
+
+	lists api.TodoTaskListList
+	tasks api.TodoTaskList
+
+	listIndex int
+	taskIndex int
+	focus     pane
+
+	// search filters the tasks by title, ignoring case
+	search string
+
+	prompt  *prompt
+	deleted []deletedTask
+
+	// status is the message shown above the help, like an error
+	status string
+
+	showHelp bool
+	quit     bool
+}
+
+func newModel(backend Backend, location *time.Location) *model {
+	return &model{backend: backend, location: location, focus: tasksPane}
+}
+
+// currentList returns the selected list, or nil if there are no lists
+func (m *model) currentList() *api.TodoTaskListItem {
+	if m.listIndex < 0 || m.listIndex >= len(m.lists) {
+		return nil
+	}
+	return &m.lists[m.listIndex]
+}
+
+// visibleTasks returns the tasks which match the search
+func (m *model) visibleTasks() api.TodoTaskList {
+	if m.search == "" {
+		return m.tasks
+	}
+
+	search := strings.ToLower(m.search)
+	tasks := api.TodoTaskList{}
+	for _, task := range m.tasks {
+		if strings.Contains(strings.ToLower(task.Title), search) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// currentTask returns the selected task, or nil if there are no tasks
+func (m *model) currentTask() *api.TodoTask {
+	tasks := m.visibleTasks()
+	if m.taskIndex < 0 || m.taskIndex >= len(tasks) {
+		return nil
+	}
+	return &tasks[m.taskIndex]
+}
+
+// refresh reloads the lists and the tasks of the selected list, keeping the
+// selection
+func (m *model) refresh(ctx context.Context) error {
+	listId := ""
+	if list := m.currentList(); list != nil {
+		listId = list.Id
+	}
+
+	lists, err := m.backend.GetLists(ctx)
+	if err != nil {
+		return err
+	}
+	m.lists = *lists
+
+	m.listIndex = 0
+	for i, list := range m.lists {
+		if list.Id == listId {
+			m.listIndex = i
+		}
+	}
+
+	return m.loadTasks(ctx)
+}
+
+// loadTasks reloads the tasks of the selected list, keeping the selected task
+func (m *model) loadTasks(ctx context.Context) error {
+	taskId := ""
+	if task := m.currentTask(); task != nil {
+		taskId = task.Id
+	}
+
+	list := m.currentList()
+	if list == nil {
+		m.tasks = nil
+		return nil
+	}
+
+	tasks, err := m.backend.GetTasks(ctx, list.Id)
+	if err != nil {
+		return err
+	}
+
+	m.tasks = api.TodoTaskList{}
+	for _, task := range *tasks {
+		m.tasks = append(m.tasks, task.In(m.location))
+	}
+
+	// Open tasks are shown before completed tasks
+	sort.SliceStable(m.tasks, func(i, j int) bool {
+		return m.tasks[i].Status != "completed" && m.tasks[j].Status == "completed"
+	})
+
+	m.selectTask(taskId)
+	return nil
+}
+
+// selectTask selects the task with the id, or keeps the index in range if it's
+// gone
+func (m *model) selectTask(taskId string) {
+	tasks := m.visibleTasks()
+	for i, task := range tasks {
+		if task.Id == taskId {
+			m.taskIndex = i
+			return
+		}
+	}
+
+	if m.taskIndex >= len(tasks) {
+		m.taskIndex = len(tasks) - 1
+	}
+	if m.taskIndex < 0 {
+		m.taskIndex = 0
+	}
+}
+
+// update handles a key
+func (m *model) update(ctx context.Context, key string) {
+	if m.prompt != nil {
+		m.updatePrompt(ctx, key)
+		return
+	}
+
+	m.status = ""
+	if err := m.handleKey(ctx, key); err != nil {
+		m.status = "Error: " + err.Error()
+	}
+}
+
+func (m *model) handleKey(ctx context.Context, key string) error {
+	switch key {
+	case "q", "ctrl+c":
+		m.quit = true
+	case "?":
+		m.showHelp = !m.showHelp
+	case "tab", "shift+tab":
+		if m.focus == listsPane {
+			m.focus = tasksPane
+		} else {
+			m.focus = listsPane
+		}
+	case "left", "h":
+		m.focus = listsPane
+	case "right", "l":
+		m.focus = tasksPane
+	case "enter":
+		if m.focus == listsPane {
+			m.focus = tasksPane
+		}
+	case "up", "k":
+		return m.move(ctx, -1)
+	case "down", "j":
+		return m.move(ctx, 1)
+	case "pgup":
+		return m.move(ctx, -10)
+	case "pgdown":
+		return m.move(ctx, 10)
+	case "home", "g":
+		return m.move(ctx, -1<<30)
+	case "end", "G":
+		return m.move(ctx, 1<<30)
+	case "r", "ctrl+l":
+		if err := m.refresh(ctx); err != nil {
+			return err
+		}
+		m.status = "Refreshed"
+	case "/":
+		m.startPrompt("Search: ", m.search, func(ctx context.Context, m *model, value string) error {
+			m.search = value
+			m.taskIndex = 0
+			return nil
+		})
+	case "esc":
+		if m.search != "" {
+			m.search = ""
+			m.taskIndex = 0
+		}
+	case "a":
+		m.startPrompt("Add: ", "", addTask)
+	case "u":
+		return m.undo(ctx)
+	default:
+		if m.currentTask() != nil {
+			return m.handleTaskKey(ctx, key)
+		}
+	}
+
+	return nil
+}
+
+// handleTaskKey handles the keys which change the selected task
+func (m *model) handleTaskKey(ctx context.Context, key string) error {
+	task := *m.currentTask()
+
+	switch key {
+	case " ", "x":
+		if task.Status == "completed" {
+			task.Status = "not started"
+		} else {
+			task.Status = "completed"
+		}
+		return m.updateTask(ctx, &task)
+	case "i":
+		task.Importance = nextImportance(task.Importance)
+		return m.updateTask(ctx, &task)
+	case "e":
+		m.startPrompt("Title: ", task.Title, func(ctx context.Context, m *model, value string) error {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return fmt.Errorf("the title is empty")
+			}
+			task.Title = value
+			return m.updateTask(ctx, &task)
+		})
+	case "d":
+		due := ""
+		if task.DueDateTime != nil {
+			due = time.Time(*task.DueDateTime).Format("2006-01-02")
+		}
+		m.startPrompt("Due date (empty to remove): ", due, func(ctx context.Context, m *model, value string) error {
+			task.DueDateTime = nil
+			if value = strings.TrimSpace(value); value != "" {
+				due, err := datetime.DateParser(value)
+				if err != nil {
+					return err
+				}
+				task.DueDateTime = (*datetime.GraphTime)(due)
+			}
+			return m.updateTask(ctx, &task)
+		})
+	case "D", "delete":
+		return m.deleteTask(ctx, task)
+	}
+
+	return nil
+}
+
+// move moves the selection of the focused pane by delta, within its bounds
+func (m *model) move(ctx context.Context, delta int) error {
+	clamp := func(i, n int) int {
+		if i >= n {
+			i = n - 1
+		}
+		if i < 0 {
+			i = 0
+		}
+		return i
+	}
+
+	if m.focus == tasksPane {
+		m.taskIndex = clamp(m.taskIndex+delta, len(m.visibleTasks()))
+		return nil
+	}
+
+	listIndex := clamp(m.listIndex+delta, len(m.lists))
+	if listIndex == m.listIndex {
+		return nil
+	}
+
+	m.listIndex = listIndex
+	m.taskIndex = 0
+	m.search = ""
+	return m.loadTasks(ctx)
+}
+
+func (m *model) startPrompt(label string, value string, submit func(ctx context.Context, m *model, value string) error) {
+	m.prompt = &prompt{label: label, input: []rune(value), submit: submit}
+}
+
+// updatePrompt handles a key while text is being entered
+func (m *model) updatePrompt(ctx context.Context, key string) {
+	p := m.prompt
+
+	switch key {
+	case "enter":
+		m.prompt = nil
+		if err := p.submit(ctx, m, string(p.input)); err != nil {
+			m.status = "Error: " + err.Error()
+		}
+	case "esc", "ctrl+c":
+		m.prompt = nil
+	case "backspace":
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	case "ctrl+u":
+		p.input = nil
+	default:
+		if len([]rune(key)) == 1 {
+			p.input = append(p.input, []rune(key)...)
+		}
+	}
+}
+
+// updateTask saves the changes to task, and reloads the tasks
+func (m *model) updateTask(ctx context.Context, task *api.TodoTask) error {
+	list := m.currentList()
+	if err := m.backend.UpdateTask(ctx, list.Id, task.Id, task); err != nil {
+		return err
+	}
+
+	if err := m.loadTasks(ctx); err != nil {
+		return err
+	}
+	m.selectTask(task.Id)
+	return nil
+}
+
+// deleteTask deletes task, which can be restored with undo
+func (m *model) deleteTask(ctx context.Context, task api.TodoTask) error {
+	list := m.currentList()
+	deleted, err := m.snapshotTask(ctx, list.Id, task)
+	if err != nil {
+		return err
+	}
+
+	if err := m.backend.DeleteTask(ctx, list.Id, task.Id); err != nil {
+		return err
+	}
+
+	m.deleted = append(m.deleted, *deleted)
+	if err := m.loadTasks(ctx); err != nil {
+		return err
+	}
+
+	m.status = fmt.Sprintf("Deleted %q - press u to undo", task.Title)
+	return nil
+}
+
+// snapshotTask gets what undo needs to recreate task. Attachments which are
+// too big to be created again can't be undone, so the task isn't deleted.
+func (m *model) snapshotTask(ctx context.Context, listId string, task api.TodoTask) (*deletedTask, error) {
+	deleted := &deletedTask{listId: listId, task: task}
+
+	checklist, err := m.backend.GetChecklistItems(ctx, listId, task.Id)
+	if err != nil {
+		return nil, err
+	}
+	deleted.checklist = checklist
+
+	resources, err := m.backend.GetLinkedResources(ctx, listId, task.Id)
+	if err != nil {
+		return nil, err
+	}
+	deleted.resources = resources
+
+	if !task.HasAttachments {
+		return deleted, nil
+	}
+
+	attachments, err := m.backend.GetAttachments(ctx, listId, task.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		if a.Size > api.MaxAttachmentSize {
+			return nil, fmt.Errorf("the attachment '%s' is bigger than 3 MB, so the delete couldn't be undone - delete the task in To Do instead", a.Name)
+		}
+
+		attachment, err := m.backend.GetAttachment(ctx, listId, task.Id, a.Id)
+		if err != nil {
+			return nil, err
+		}
+		deleted.attachments = append(deleted.attachments, *attachment)
+	}
+
+	return deleted, nil
+}
+
+// undo restores the last deleted task with its checklist items, linked
+// resources and attachments. It's recreated, so it has a new id.
+func (m *model) undo(ctx context.Context) error {
+	if len(m.deleted) == 0 {
+		m.status = "Nothing to undo"
+		return nil
+	}
+
+	last := m.deleted[len(m.deleted)-1]
+	if err := m.backend.CreateTask(ctx, last.listId, &last.task); err != nil {
+		return err
+	}
+	m.deleted = m.deleted[:len(m.deleted)-1]
+
+	// The task is restored, so a failure here is shown rather than undone again
+	if err := m.restoreChildren(ctx, last); err != nil {
+		m.loadTasks(ctx)
+		return fmt.Errorf("restored %q without all of its items: %w", last.task.Title, err)
+	}
+
+	if err := m.loadTasks(ctx); err != nil {
+		return err
+	}
+
+	m.status = fmt.Sprintf("Restored %q", last.task.Title)
+	return nil
+}
+
+// restoreChildren creates the checklist items, linked resources and
+// attachments of deleted on its recreated task
+func (m *model) restoreChildren(ctx context.Context, deleted deletedTask) error {
+	for i := range deleted.checklist {
+		if err := m.backend.CreateChecklistItem(ctx, deleted.listId, deleted.task.Id, &deleted.checklist[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := range deleted.resources {
+		if err := m.backend.CreateLinkedResource(ctx, deleted.listId, deleted.task.Id, &deleted.resources[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := range deleted.attachments {
+		if err := m.backend.CreateAttachment(ctx, deleted.listId, deleted.task.Id, &deleted.attachments[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addTask creates a task from the title, which can contain metadata like
+// "Pay rent tomorrow !high"
+func addTask(ctx context.Context, m *model, value string) error {
+	quick, err := quickadd.Parse(value)
+	if err != nil {
+		return err
+	}
+	if quick.Title == "" {
+		return fmt.Errorf("the title is empty")
+	}
+
+	list := m.currentList()
+	if quick.List != "" {
+		id, err := m.lists.GetListId(quick.List)
+		if err != nil {
+			return err
+		}
+		for i := range m.lists {
+			if m.lists[i].Id == id {
+				list = &m.lists[i]
+			}
+		}
+	}
+	if list == nil {
+		return fmt.Errorf("there are no lists")
+	}
+
+	task := api.TodoTask{
+		Title:      quick.Title,
+		Importance: "normal",
+		Status:     "not started",
+	}
+	if quick.Importance != "" {
+		task.Importance = quick.Importance
+	}
+	if err := quick.Apply(&task, false, false); err != nil {
+		return err
+	}
+
+	if err := m.backend.CreateTask(ctx, list.Id, &task); err != nil {
+		return err
+	}
+
+	m.status = fmt.Sprintf("Added %q to %s", task.Title, list.DisplayName)
+	return m.loadTasks(ctx)
+}
+
+// nextImportance cycles through the importances
+func nextImportance(importance string) string {
+	switch importance {
+	case "low":
+		return "normal"
+	case "normal":
+		return "high"
+	default:
+		return "low"
+	}
+}
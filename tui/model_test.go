@@ -0,0 +1,260 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/internal/graphfake"
+	"golang.org/x/oauth2"
+)
+
+// newTestModel returns a model of s, with the Work list selected
+func newTestModel(t *testing.T, s *graphfake.Server) *model {
+	t.Helper()
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})
+	client := api.NewClientWithTokenSource(context.Background(), s.URL(), ts)
+
+	m := newModel(client, time.UTC)
+	if err := m.refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for m.currentList().DisplayName != "Work" {
+		m.focus = listsPane
+		press(m, "down")
+	}
+	m.focus = tasksPane
+	return m
+}
+
+// press sends the keys to m
+func press(m *model, keys ...string) {
+	for _, key := range keys {
+		m.update(context.Background(), key)
+	}
+}
+
+// typeText sends the characters of s to m
+func typeText(m *model, s string) {
+	for _, r := range s {
+		press(m, string(r))
+	}
+}
+
+func titles(tasks api.TodoTaskList) []string {
+	titles := []string{}
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func Test_model(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	for _, task := range []graphfake.Object{
+		{"title": "Write report"},
+		{"title": "Book flights"},
+	} {
+		if _, err := s.AddTask(listId, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestModel(t, s)
+	if got := strings.Join(titles(m.tasks), ","); got != "Write report,Book flights" {
+		t.Fatalf("tasks = %v", got)
+	}
+
+	// Toggle complete, which moves the task after the open tasks
+	press(m, " ")
+	if m.status != "" {
+		t.Fatalf("toggle complete status = %v", m.status)
+	}
+	if got := strings.Join(titles(m.tasks), ","); got != "Book flights,Write report" || m.tasks[1].Status != "completed" {
+		t.Errorf("tasks after toggle complete = %v", m.tasks)
+	}
+	if m.currentTask().Title != "Write report" {
+		t.Errorf("toggle complete should keep the task selected, got %v", m.currentTask().Title)
+	}
+
+	// Edit the title
+	press(m, "e", "ctrl+u")
+	typeText(m, "Write the report")
+	press(m, "enter")
+	if m.currentTask().Title != "Write the report" {
+		t.Errorf("edited title = %v", m.currentTask().Title)
+	}
+
+	// Edit the due date and the importance
+	press(m, "k", "d")
+	typeText(m, "2021-07-09")
+	press(m, "enter", "i", "i")
+	task := m.currentTask()
+	if task.Title != "Book flights" || task.DueDateTime == nil || time.Time(*task.DueDateTime).Day() != 9 || task.Importance != "low" {
+		t.Errorf("edited task = %+v, status %v", task, m.status)
+	}
+
+	press(m, "d")
+	typeText(m, "not a date")
+	press(m, "enter")
+	if !strings.HasPrefix(m.status, "Error:") {
+		t.Errorf("an invalid due date should show an error, got %q", m.status)
+	}
+
+	// Add, with the quick add metadata
+	press(m, "a")
+	typeText(m, "Pay rent !high #finance")
+	press(m, "enter")
+	if len(m.tasks) != 3 {
+		t.Fatalf("tasks after add = %v, status %v", titles(m.tasks), m.status)
+	}
+	for _, task := range m.tasks {
+		if task.Title == "Pay rent" && (task.Importance != "high" || len(task.Categories) != 1) {
+			t.Errorf("added task = %+v", task)
+		}
+	}
+
+	// Search
+	press(m, "/")
+	typeText(m, "RENT")
+	press(m, "enter")
+	if got := titles(m.visibleTasks()); len(got) != 1 || got[0] != "Pay rent" {
+		t.Errorf("tasks after search = %v", got)
+	}
+
+	// Delete and undo
+	press(m, "D")
+	if len(m.tasks) != 2 || len(m.visibleTasks()) != 0 {
+		t.Errorf("tasks after delete = %v", titles(m.tasks))
+	}
+	press(m, "esc", "u")
+	if len(m.tasks) != 3 || !strings.HasPrefix(m.status, "Restored") {
+		t.Errorf("tasks after undo = %v, status %v", titles(m.tasks), m.status)
+	}
+	press(m, "u")
+	if m.status != "Nothing to undo" {
+		t.Errorf("status after the last undo = %v", m.status)
+	}
+
+	// Changes made elsewhere are shown after a refresh
+	if _, err := s.AddTask(listId, graphfake.Object{"title": "Water plants"}); err != nil {
+		t.Fatal(err)
+	}
+	press(m, "r")
+	if len(m.tasks) != 4 {
+		t.Errorf("tasks after refresh = %v", titles(m.tasks))
+	}
+
+	press(m, "q")
+	if !m.quit {
+		t.Errorf("q should quit")
+	}
+}
+
+func Test_model_undo(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	taskId, err := s.AddTask(listId, graphfake.Object{"title": "Write report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddChecklistItem(listId, taskId, graphfake.Object{"displayName": "Outline", "isChecked": true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddLinkedResource(listId, taskId, graphfake.Object{"webUrl": "https://example.com/report", "applicationName": "Docs", "displayName": "Report"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddAttachment(listId, taskId, "notes.txt", "text/plain", []byte("notes")); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t, s)
+	press(m, "D", "u")
+	if len(m.tasks) != 1 || !strings.HasPrefix(m.status, "Restored") {
+		t.Fatalf("tasks after undo = %v, status %v", titles(m.tasks), m.status)
+	}
+
+	ctx := context.Background()
+	client := m.backend
+	restoredId := m.tasks[0].Id
+
+	checklist, err := client.GetChecklistItems(ctx, listId, restoredId)
+	if err != nil || len(checklist) != 1 || checklist[0].DisplayName != "Outline" || !checklist[0].IsChecked {
+		t.Errorf("restored checklist = %+v, %v", checklist, err)
+	}
+
+	resources, err := client.GetLinkedResources(ctx, listId, restoredId)
+	if err != nil || len(resources) != 1 || resources[0].WebUrl != "https://example.com/report" {
+		t.Errorf("restored linked resources = %+v, %v", resources, err)
+	}
+
+	attachments, err := client.GetAttachments(ctx, listId, restoredId)
+	if err != nil || len(attachments) != 1 {
+		t.Fatalf("restored attachments = %+v, %v", attachments, err)
+	}
+	if attachment, err := client.GetAttachment(ctx, listId, restoredId, attachments[0].Id); err != nil || string(attachment.ContentBytes) != "notes" {
+		t.Errorf("restored attachment = %+v, %v", attachment, err)
+	}
+}
+
+func Test_model_render(t *testing.T) {
+	s := graphfake.NewServer()
+	defer s.Close()
+
+	listId := s.AddList("Work")
+	if _, err := s.AddTask(listId, graphfake.Object{
+		"title":       "Write report",
+		"importance":  "high",
+		"dueDateTime": graphfake.Object{"dateTime": "2021-07-09T00:00:00.0000000", "timeZone": "UTC"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t, s)
+	lines := m.render(80, 10, nil, time.Now())
+
+	if len(lines) != 10 {
+		t.Fatalf("render() returned %v lines, want 10", len(lines))
+	}
+	out := strings.Join(lines, "\n")
+	for _, want := range []string{"mstodo - Work", "Tasks", "[ ] ! Write report", "due Fri 9 Jul", "? help"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render() doesn't contain %q:\n%s", want, out)
+		}
+	}
+
+	press(m, "/")
+	typeText(m, "xyz")
+	if lines := m.render(80, 10, nil, time.Now()); !strings.Contains(lines[8], "Search: xyz") {
+		t.Errorf("render() prompt = %q", lines[8])
+	}
+	press(m, "enter")
+	if out := strings.Join(m.render(80, 10, nil, time.Now()), "\n"); !strings.Contains(out, "No tasks match the search") {
+		t.Errorf("render() with no matching tasks:\n%s", out)
+	}
+}
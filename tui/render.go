@@ -0,0 +1,204 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/dalyisaac/mstodo/utils"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+const (
+	shortHelp = "? help  tab switch pane  j/k move  space done  a add  / search  q quit"
+	longHelp  = "j/k move  g/G first/last  tab switch pane  space done  e edit title  d due date  i importance  a add  D delete  u undo  / search  esc clear search  r refresh  q quit"
+)
+
+var (
+	selectedColors = text.Colors{text.ReverseVideo}
+	titleColors    = text.Colors{text.Bold}
+)
+
+// render draws the model into lines of the width and height. styles colour
+// the tasks, and can be nil.
+func (m *model) render(width, height int, styles *utils.TaskStyles, now time.Time) []string {
+	if width < 20 || height < 5 {
+		return []string{fit("Too small", width)}
+	}
+
+	lines := []string{m.titleLine(width)}
+
+	listsWidth := width / 4
+	if listsWidth > 30 {
+		listsWidth = 30
+	}
+	tasksWidth := width - listsWidth - 3
+
+	bodyHeight := height - 3
+	listLines := m.renderLists(listsWidth, bodyHeight)
+	taskLines := m.renderTasks(tasksWidth, bodyHeight, styles, now)
+	for i := 0; i < bodyHeight; i++ {
+		lines = append(lines, listLines[i]+" │ "+taskLines[i])
+	}
+
+	lines = append(lines, m.statusLine(width))
+	lines = append(lines, m.helpLine(width))
+	return lines
+}
+
+func (m *model) titleLine(width int) string {
+	title := "mstodo"
+	if list := m.currentList(); list != nil {
+		title += " - " + list.DisplayName
+	}
+	if m.search != "" {
+		title += fmt.Sprintf(" - search: %q", m.search)
+	}
+	return titleColors.Sprint(fit(title, width))
+}
+
+func (m *model) statusLine(width int) string {
+	if m.prompt != nil {
+		return fit(m.prompt.label+string(m.prompt.input)+"█", width)
+	}
+	return fit(m.status, width)
+}
+
+func (m *model) helpLine(width int) string {
+	if m.showHelp {
+		return text.Faint.Sprint(fit(longHelp, width))
+	}
+	return text.Faint.Sprint(fit(shortHelp, width))
+}
+
+func (m *model) renderLists(width, height int) []string {
+	lines := []string{}
+	first := scrollOffset(m.listIndex, len(m.lists), height)
+
+	for i := first; i < len(m.lists) && len(lines) < height; i++ {
+		line := fit(" "+m.lists[i].DisplayName, width)
+		if i == m.listIndex {
+			if m.focus == listsPane {
+				line = selectedColors.Sprint(line)
+			} else {
+				line = text.Bold.Sprint(line)
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, fit("", width))
+	}
+	return lines
+}
+
+func (m *model) renderTasks(width, height int, styles *utils.TaskStyles, now time.Time) []string {
+	lines := []string{}
+	tasks := m.visibleTasks()
+	first := scrollOffset(m.taskIndex, len(tasks), height)
+
+	if len(tasks) == 0 {
+		if m.search != "" {
+			lines = append(lines, text.Faint.Sprint(fit(" No tasks match the search", width)))
+		} else {
+			lines = append(lines, text.Faint.Sprint(fit(" No tasks - press a to add one", width)))
+		}
+	}
+
+	for i := first; i < len(tasks) && len(lines) < height; i++ {
+		line := fit(taskLine(tasks[i], width), width)
+
+		colors := text.Colors{}
+		if styles != nil {
+			colors = append(colors, styles.Colors(tasks[i], now)...)
+		}
+		if i == m.taskIndex && m.focus == tasksPane {
+			colors = append(colors, selectedColors...)
+		}
+		lines = append(lines, colors.Sprint(line))
+	}
+
+	for len(lines) < height {
+		lines = append(lines, fit("", width))
+	}
+	return lines
+}
+
+// taskLine is a task's checkbox, title, importance and due date
+func taskLine(task api.TodoTask, width int) string {
+	check := "[ ]"
+	if task.Status == "completed" {
+		check = "[x]"
+	}
+
+	importance := " "
+	switch task.Importance {
+	case "high":
+		importance = "!"
+	case "low":
+		importance = "↓"
+	}
+
+	details := []string{}
+	if task.DueDateTime != nil {
+		details = append(details, "due "+time.Time(*task.DueDateTime).Format("Mon 2 Jan"))
+	}
+	if task.Recurrence != nil {
+		details = append(details, "↻")
+	}
+	for _, category := range task.Categories {
+		details = append(details, "#"+category)
+	}
+
+	left := fmt.Sprintf(" %s %s %s", check, importance, task.Title)
+	right := strings.Join(details, " ")
+	if right == "" {
+		return left
+	}
+
+	// The details are aligned to the right, and the title is shortened to fit
+	titleWidth := width - text.RuneCount(right) - 1
+	if titleWidth < text.RuneCount(left) {
+		left = text.Snip(left, titleWidth, "…")
+	}
+	return text.Pad(left, titleWidth, ' ') + " " + right
+}
+
+// scrollOffset returns the first of n items which is shown, so that the
+// selected item is within the height
+func scrollOffset(selected, n, height int) int {
+	if n <= height || selected < height/2 {
+		return 0
+	}
+	if selected > n-height/2 {
+		return n - height
+	}
+	return selected - height/2
+}
+
+// fit pads or shortens s to the width
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return text.Pad(text.Snip(s, width, "…"), width, ' ')
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package tui is a full-screen terminal UI for browsing and editing tasks, with
+// the lists on the left and the tasks of the selected list on the right.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/internal/term"
+	"github.com/dalyisaac/mstodo/utils"
+)
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	cursorHome  = "\x1b[H"
+	clearLine   = "\x1b[K"
+)
+
+// Options configure the TUI
+type Options struct {
+	// Location is the time zone which the dates are shown in
+	Location *time.Location
+
+	// Styles colour the tasks, and can be nil
+	Styles *utils.TaskStyles
+
+	// Refresh is how often the lists and tasks are reloaded, or 0 to only
+	// reload them with r
+	Refresh time.Duration
+
+	// Timeout is the maximum duration of the requests made by each key and
+	// refresh, or 0 for no timeout
+	Timeout time.Duration
+}
+
+// Run shows the TUI until it's quit or ctx is done
+func Run(ctx context.Context, backend Backend, opts Options) error {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	m := newModel(backend, opts.Location)
+
+	// requestContext is the context of the requests made by each key and
+	// refresh
+	requestContext := func() (context.Context, context.CancelFunc) {
+		if opts.Timeout > 0 {
+			return context.WithTimeout(ctx, opts.Timeout)
+		}
+		return context.WithCancel(ctx)
+	}
+
+	reqCtx, cancel := requestContext()
+	err := m.refresh(reqCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	t, err := term.Open()
+	if err != nil {
+		return err
+	}
+	defer t.Restore()
+
+	out := bufio.NewWriter(t.Out)
+	out.WriteString(enterScreen)
+	defer func() {
+		out.WriteString(leaveScreen)
+		out.Flush()
+	}()
+
+	// The reader stops when Run returns, before the next command reads the
+	// terminal
+	keys := make(chan []string)
+	done := make(chan struct{})
+	defer close(done)
+	go readKeys(t, keys, done)
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, term.ResizeSignals...)
+	defer signal.Stop(resize)
+
+	var refresh <-chan time.Time
+	if opts.Refresh > 0 {
+		ticker := time.NewTicker(opts.Refresh)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for !m.quit {
+		width, height, err := t.Size()
+		if err != nil {
+			return err
+		}
+
+		lines := m.render(width, height, opts.Styles, time.Now().In(opts.Location))
+		out.WriteString(cursorHome + strings.Join(lines, clearLine+"\r\n") + clearLine)
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case ks, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			reqCtx, cancel := requestContext()
+			for _, key := range ks {
+				m.update(reqCtx, key)
+			}
+			cancel()
+		case <-resize:
+			out.WriteString("\x1b[2J")
+		case <-refresh:
+			// Don't reload under the user while they're typing
+			if m.prompt == nil {
+				reqCtx, cancel := requestContext()
+				if err := m.refresh(reqCtx); err != nil {
+					m.status = "Error: " + err.Error()
+				}
+				cancel()
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// readKeys sends the keys read from t, until it fails, t is restored or done
+// is closed
+func readKeys(t *term.Terminal, keys chan<- []string, done <-chan struct{}) {
+	defer close(keys)
+
+	for {
+		ks, err := t.ReadKeys()
+		if err != nil {
+			return
+		}
+
+		select {
+		case keys <- ks:
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const ignoredColChars = " [{}]"
+
+// Column is a table column, which gets its cell from each item in the table
+type Column struct {
+	table.ColumnConfig
+
+	// Aliases are the other names of the column, like "due" for "Due Date"
+	Aliases []string
+
+	// Value returns the cell of the item in the row
+	Value func(item interface{}) interface{}
+}
+
+// Columns is the registry of the columns which a command can show, in their
+// default order
+type Columns []Column
+
+// Find returns the column with the name or alias. The names are compared
+// without case, spaces, dashes and underscores, so "due-date" is "Due Date".
+func (columns Columns) Find(name string) (*Column, error) {
+	key := columnKey(name)
+
+	for i, c := range columns {
+		if columnKey(c.Name) == key {
+			return &columns[i], nil
+		}
+		for _, alias := range c.Aliases {
+			if columnKey(alias) == key {
+				return &columns[i], nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("'%s' is not a column - choices: [%s]", strings.Trim(name, ignoredColChars), strings.Join(columns.Names(), ", "))
+}
+
+// Select returns the columns in include, in that order, without the columns in
+// exclude. Both are comma-separated names or aliases, like "title,due". If
+// include is empty, all the columns are included.
+func (columns Columns) Select(include string, exclude string) (Columns, error) {
+	selected := Columns{}
+
+	if names := splitColumnNames(include); len(names) == 0 {
+		selected = append(selected, columns...)
+	} else {
+		for _, name := range names {
+			c, err := columns.Find(name)
+			if err != nil {
+				return nil, err
+			}
+			if !selected.contains(c.Name) {
+				selected = append(selected, *c)
+			}
+		}
+	}
+
+	excluded := []string{}
+	for _, name := range splitColumnNames(exclude) {
+		c, err := columns.Find(name)
+		if err != nil {
+			return nil, err
+		}
+		excluded = append(excluded, c.Name)
+	}
+
+	allowed := Columns{}
+	for _, c := range selected {
+		if !ContainsString(excluded, c.Name) {
+			allowed = append(allowed, c)
+		}
+	}
+
+	return allowed, nil
+}
+
+// Prepend returns the columns with the named column first, unless it's already
+// included
+func (columns Columns) Prepend(registry Columns, name string) (Columns, error) {
+	c, err := registry.Find(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if columns.contains(c.Name) {
+		return columns, nil
+	}
+	return append(Columns{*c}, columns...), nil
+}
+
+// Names returns the names of the columns
+func (columns Columns) Names() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Configs returns the table configs of the columns
+func (columns Columns) Configs() []table.ColumnConfig {
+	configs := make([]table.ColumnConfig, len(columns))
+	for i, c := range columns {
+		configs[i] = c.ColumnConfig
+	}
+	return configs
+}
+
+// Header returns the header row of the columns
+func (columns Columns) Header() table.Row {
+	header := table.Row{}
+	for _, c := range columns {
+		header = append(header, c.Name)
+	}
+	return header
+}
+
+// Row returns the cells of item in the columns
+func (columns Columns) Row(item interface{}) table.Row {
+	row := table.Row{}
+	for _, c := range columns {
+		row = append(row, c.Value(item))
+	}
+	return row
+}
+
+// SortBy parses the columns to sort by, like "[title:dsc,due]", which can use
+// the column aliases
+func (columns Columns) SortBy(s string) ([]table.SortBy, error) {
+	parts := []string{}
+	for _, part := range strings.Split(strings.Trim(s, sortByCutset), ",") {
+		part = strings.Trim(part, sortByCutset)
+		name := strings.Split(part, ":")[0]
+
+		// Unknown columns are ignored, like "none"
+		if c, err := columns.Find(name); err == nil {
+			part = c.Name + strings.TrimPrefix(part, name)
+		}
+		parts = append(parts, part)
+	}
+
+	return GetSortByColumns(strings.Join(parts, ","), columns.Configs())
+}
+
+func (columns Columns) contains(name string) bool {
+	for _, c := range columns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// columnKey normalizes a column name for comparisons
+func columnKey(name string) string {
+	name = strings.ToLower(strings.Trim(name, ignoredColChars))
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name)
+}
+
+func splitColumnNames(s string) []string {
+	names := []string{}
+	for _, name := range strings.Split(strings.Trim(s, ignoredColChars), ",") {
+		if name = strings.Trim(name, ignoredColChars); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Checks to see if the []ColumnConfig cols contains the name.
+// c.Name and name are compared with lowercase.
+func columnsContainName(cols []table.ColumnConfig, name string) bool {
+	name = strings.ToLower(name)
+
+	for _, c := range cols {
+		if strings.ToLower(c.Name) == name {
+			return true
+		}
+	}
+	return false
+}
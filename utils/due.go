@@ -0,0 +1,39 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package utils
+
+import (
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+)
+
+// DaysUntilDue returns the number of days from now until the task is due,
+// which is negative when it's overdue. now must be in the time zone which the
+// task's dates are in. ok is false when the task has no due date.
+func DaysUntilDue(task api.TodoTask, now time.Time) (days int, ok bool) {
+	if task.DueDateTime == nil {
+		return 0, false
+	}
+
+	due := time.Time(*task.DueDateTime)
+	dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(dueDay.Sub(today).Hours() / 24), true
+}
@@ -40,9 +40,13 @@ const (
 	NoSort = -1
 )
 
+// SortOptions returns the valid sort flags
+func SortOptions() []string {
+	return []string{asc, dsc, none}
+}
+
 func GetSortOptions() string {
-	options := []string{asc, dsc, none}
-	return fmt.Sprintf("[%s]", strings.Join(options, ", "))
+	return fmt.Sprintf("[%s]", strings.Join(SortOptions(), ", "))
 }
 
 func GetNumericSortOptions() string {
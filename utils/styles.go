@@ -0,0 +1,145 @@
+/*
+Copyright © 2021 Isaac Daly <isaac.daly@outlook.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalyisaac/mstodo/api"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// colorNames are the names used by the styles config
+var colorNames = map[string]text.Color{
+	"bold":        text.Bold,
+	"faint":       text.Faint,
+	"dim":         text.Faint,
+	"italic":      text.Italic,
+	"underline":   text.Underline,
+	"blink":       text.BlinkSlow,
+	"reverse":     text.ReverseVideo,
+	"crossed-out": text.CrossedOut,
+	"strike":      text.CrossedOut,
+}
+
+func init() {
+	for i, name := range []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"} {
+		colorNames[name] = text.FgBlack + text.Color(i)
+		colorNames["hi-"+name] = text.FgHiBlack + text.Color(i)
+		colorNames["bg-"+name] = text.BgBlack + text.Color(i)
+		colorNames["bg-hi-"+name] = text.BgHiBlack + text.Color(i)
+	}
+}
+
+// ParseColors parses colours and attributes separated by commas or spaces,
+// like "red,bold" or "faint crossed-out"
+func ParseColors(s string) (text.Colors, error) {
+	colors := text.Colors{}
+
+	for _, name := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == ',' || r == ' ' }) {
+		c, ok := colorNames[name]
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a colour - choices: [%s]", name, strings.Join(ColorNames(), ", "))
+		}
+		colors = append(colors, c)
+	}
+
+	return colors, nil
+}
+
+// ColorNames returns the names accepted by ParseColors
+func ColorNames() []string {
+	names := []string{}
+	for name := range colorNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TaskStyles are the colours of task rows
+type TaskStyles struct {
+	// Overdue is for tasks due before today
+	Overdue text.Colors
+
+	// DueToday is for tasks due today
+	DueToday text.Colors
+
+	// HighImportance is for tasks with high importance
+	HighImportance text.Colors
+
+	// Completed is for completed tasks, instead of Overdue and DueToday
+	Completed text.Colors
+
+	// Categories are the colours of the category names
+	Categories map[string]text.Colors
+}
+
+// Colors returns the colours of task's row, where now is the current time in
+// the time zone which the task's dates are in
+func (styles *TaskStyles) Colors(task api.TodoTask, now time.Time) text.Colors {
+	colors := text.Colors{}
+
+	if task.Status == "completed" {
+		colors = append(colors, styles.Completed...)
+	} else if days, ok := DaysUntilDue(task, now); ok {
+		switch {
+		case days < 0:
+			colors = append(colors, styles.Overdue...)
+		case days == 0:
+			colors = append(colors, styles.DueToday...)
+		}
+	}
+
+	if task.Importance == "high" {
+		colors = append(colors, styles.HighImportance...)
+	}
+
+	return colors
+}
+
+// Category returns the category name in its colour, or as it is if styles is
+// nil
+func (styles *TaskStyles) Category(category string) string {
+	if styles == nil {
+		return category
+	}
+
+	for name, colors := range styles.Categories {
+		if strings.EqualFold(name, category) {
+			return colors.Sprint(category)
+		}
+	}
+	return category
+}
+
+// StyleRowPainter paints rows with the text.Colors in their last, hidden cell
+var StyleRowPainter = table.RowPainter(func(row table.Row) text.Colors {
+	if len(row) == 0 {
+		return nil
+	}
+
+	if colors, ok := row[len(row)-1].(text.Colors); ok && len(colors) > 0 {
+		return colors
+	}
+	return nil
+})
@@ -18,24 +18,25 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package utils
 
 import (
-	"os"
+	"io"
+	"sort"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/viper"
 )
 
-func CreateBasicTable(header *table.Row) table.Writer {
-	return createTable(header, true, nil)
+func CreateBasicTable(out io.Writer, header *table.Row) table.Writer {
+	return createTable(out, header, true, nil)
 }
 
-func CreateFormattedTable(header *table.Row, columnConfigs *[]table.ColumnConfig) table.Writer {
-	return createTable(header, true, columnConfigs)
+func CreateFormattedTable(out io.Writer, header *table.Row, columnConfigs *[]table.ColumnConfig) table.Writer {
+	return createTable(out, header, true, columnConfigs)
 }
 
-func createTable(header *table.Row, showHeader bool, columnConfigs *[]table.ColumnConfig) table.Writer {
+func createTable(out io.Writer, header *table.Row, showHeader bool, columnConfigs *[]table.ColumnConfig) table.Writer {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(out)
 
 	t.SetStyle(*matchTableStyle(viper.GetString("table-style")))
 
@@ -50,53 +51,48 @@ func createTable(header *table.Row, showHeader bool, columnConfigs *[]table.Colu
 	return t
 }
 
+// tableStyles are the styles for the table-style config
+var tableStyles = map[string]table.Style{
+	"Default":                    table.StyleDefault,
+	"Bold":                       table.StyleBold,
+	"ColoredBright":              table.StyleColoredBright,
+	"ColoredDark":                table.StyleColoredDark,
+	"ColoredBlackOnBlueWhite":    table.StyleColoredBlackOnBlueWhite,
+	"ColoredBlackOnCyanWhite":    table.StyleColoredBlackOnCyanWhite,
+	"ColoredBlackOnGreenWhite":   table.StyleColoredBlackOnGreenWhite,
+	"ColoredBlackOnMagentaWhite": table.StyleColoredBlackOnMagentaWhite,
+	"ColoredBlackOnYellowWhite":  table.StyleColoredBlackOnYellowWhite,
+	"ColoredBlackOnRedWhite":     table.StyleColoredBlackOnRedWhite,
+	"ColoredBlueWhiteOnBlack":    table.StyleColoredBlueWhiteOnBlack,
+	"ColoredCyanWhiteOnBlack":    table.StyleColoredCyanWhiteOnBlack,
+	"ColoredGreenWhiteOnBlack":   table.StyleColoredGreenWhiteOnBlack,
+	"ColoredMagentaWhiteOnBlack": table.StyleColoredMagentaWhiteOnBlack,
+	"ColoredRedWhiteOnBlack":     table.StyleColoredRedWhiteOnBlack,
+	"ColoredYellowWhiteOnBlack":  table.StyleColoredYellowWhiteOnBlack,
+	"Double":                     table.StyleDouble,
+	"Light":                      table.StyleLight,
+	"Rounded":                    table.StyleRounded,
+}
+
 func IsTableStyleValid(style string) bool {
 	return matchTableStyle(style) != nil
 }
 
+// TableStyleNames returns the names of the table styles
+func TableStyleNames() []string {
+	names := []string{}
+	for name := range tableStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func matchTableStyle(style string) *table.Style {
-	switch style {
-	case "Default":
-		return &table.StyleDefault
-	case "Bold":
-		return &table.StyleBold
-	case "ColoredBright":
-		return &table.StyleColoredBright
-	case "ColoredDark":
-		return &table.StyleColoredDark
-	case "ColoredBlackOnBlueWhite":
-		return &table.StyleColoredBlackOnBlueWhite
-	case "ColoredBlackOnCyanWhite":
-		return &table.StyleColoredBlackOnCyanWhite
-	case "ColoredBlackOnGreenWhite":
-		return &table.StyleColoredBlackOnGreenWhite
-	case "ColoredBlackOnMagentaWhite":
-		return &table.StyleColoredBlackOnMagentaWhite
-	case "ColoredBlackOnYellowWhite":
-		return &table.StyleColoredBlackOnYellowWhite
-	case "ColoredBlackOnRedWhite":
-		return &table.StyleColoredBlackOnRedWhite
-	case "ColoredBlueWhiteOnBlack":
-		return &table.StyleColoredBlueWhiteOnBlack
-	case "ColoredCyanWhiteOnBlack":
-		return &table.StyleColoredCyanWhiteOnBlack
-	case "ColoredGreenWhiteOnBlack":
-		return &table.StyleColoredGreenWhiteOnBlack
-	case "ColoredMagentaWhiteOnBlack":
-		return &table.StyleColoredMagentaWhiteOnBlack
-	case "ColoredRedWhiteOnBlack":
-		return &table.StyleColoredRedWhiteOnBlack
-	case "ColoredYellowWhiteOnBlack":
-		return &table.StyleColoredYellowWhiteOnBlack
-	case "Double":
-		return &table.StyleDouble
-	case "Light":
-		return &table.StyleLight
-	case "Rounded":
-		return &table.StyleRounded
-	default:
-		return nil
+	if s, ok := tableStyles[style]; ok {
+		return &s
 	}
+	return nil
 }
 
 func LeftColumn(name string) table.ColumnConfig {